@@ -0,0 +1,146 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// templateArch returns the value of {{.Arch}} for the installer: its
+// architecture, or the alias configured for it in arch_aliases.
+func (i InstallerConfig) templateArch() string {
+	if alias, ok := i.ArchAliases[i.Architecture]; ok {
+		return alias
+	}
+	return i.Architecture
+}
+
+// expandInstallerArchitectures replaces each installer that lists several
+// architectures with one installer per architecture. Indexes in problems refer
+// to the configured installers.
+func expandInstallerArchitectures(installers []InstallerConfig) ([]InstallerConfig, []fieldError) {
+	var expanded []InstallerConfig
+	var problems []fieldError
+	for i, installer := range installers {
+		for _, arch := range slices.Sorted(maps.Keys(installer.ArchAliases)) {
+			if !isValidArchitecture(arch) {
+				problems = append(problems, fieldError{fmt.Sprintf("installers[%d].arch_aliases", i),
+					fmt.Sprintf("alias for unknown architecture %q", arch)})
+			}
+		}
+
+		if len(installer.Architectures) == 0 {
+			expanded = append(expanded, installer)
+			continue
+		}
+		if installer.Architecture != "" {
+			problems = append(problems, fieldError{fmt.Sprintf("installers[%d].architectures", i),
+				"Only one of architecture and architectures may be set"})
+		}
+		for _, arch := range installer.Architectures {
+			copied := installer
+			copied.Architecture = arch
+			copied.Architectures = nil
+			expanded = append(expanded, copied)
+		}
+	}
+	return expanded, problems
+}
+
+// validateArchitectureAliases checks that architecture_aliases maps asset
+// name tokens to winget architectures.
+func validateArchitectureAliases(aliases map[string]string) []fieldError {
+	var problems []fieldError
+	for _, token := range slices.Sorted(maps.Keys(aliases)) {
+		field := "architecture_aliases." + token
+		if strings.TrimSpace(token) == "" {
+			problems = append(problems, fieldError{"architecture_aliases", "alias tokens must not be empty"})
+			continue
+		}
+		if arch := aliases[token]; !isValidArchitecture(arch) {
+			problems = append(problems, fieldError{field, fmt.Sprintf("unknown architecture %q", arch)})
+		}
+	}
+	return problems
+}
+
+// applyArchitectureAliases adds the architecture_aliases tokens to the
+// arch_aliases of every installer, so {{.Arch}} renders as the token of the
+// installer's architecture. Installer arch_aliases take precedence, and the
+// first token in alphabetical order is used for architectures with several.
+func applyArchitectureAliases(installers []InstallerConfig, aliases map[string]string) {
+	for i := range installers {
+		installer := &installers[i]
+		for _, token := range slices.Sorted(maps.Keys(aliases)) {
+			arch := aliases[token]
+			if !isValidArchitecture(arch) || strings.TrimSpace(token) == "" {
+				// Reported by validateArchitectureAliases
+				continue
+			}
+			if _, ok := installer.ArchAliases[arch]; ok {
+				continue
+			}
+			installer.ArchAliases = maps.Clone(installer.ArchAliases)
+			if installer.ArchAliases == nil {
+				installer.ArchAliases = make(map[string]string)
+			}
+			installer.ArchAliases[arch] = token
+		}
+	}
+}
+
+// inferArchitecture returns the winget architecture named in an asset file
+// name, either literally (x64, x86, arm64, arm, or x86_64 for x64) or by an
+// architecture_aliases token, matched case-insensitively between separators.
+// It returns "" if the name contains no architecture and an error if it
+// contains several.
+func inferArchitecture(name string, aliases map[string]string) (string, error) {
+	tokens := map[string]string{"x64": "x64", "x86_64": "x64", "x86": "x86", "arm64": "arm64", "arm": "arm"}
+	for token, arch := range aliases {
+		tokens[strings.ToLower(token)] = arch
+	}
+	// Longer tokens first, so x86_64 is not read as x86
+	ordered := slices.SortedFunc(maps.Keys(tokens), func(a, b string) int {
+		return cmp.Or(len(b)-len(a), strings.Compare(a, b))
+	})
+
+	lower := strings.ToLower(name)
+	found := make(map[string]bool)
+	for _, token := range ordered {
+		for start := 0; ; {
+			i := strings.Index(lower[start:], token)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(token)
+			if isArchSeparator(lower, i-1) && isArchSeparator(lower, end) {
+				found[tokens[token]] = true
+				// Blank the match so shorter tokens do not match inside it
+				lower = lower[:i] + strings.Repeat(" ", len(token)) + lower[end:]
+			}
+			start = end
+		}
+	}
+
+	switch archs := slices.Sorted(maps.Keys(found)); len(archs) {
+	case 0:
+		return "", nil
+	case 1:
+		return archs[0], nil
+	default:
+		return "", fmt.Errorf("%s names several architectures: %s", name, strings.Join(archs, ", "))
+	}
+}
+
+// isArchSeparator reports whether the byte at i of s, or the start or end of
+// s, separates an architecture token from the rest of a file name.
+func isArchSeparator(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !('a' <= c && c <= 'z' || '0' <= c && c <= '9')
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExpandInstallerArchitectures(t *testing.T) {
+	installers, problems := expandInstallerArchitectures([]InstallerConfig{
+		{URL: "https://example.com/app.msi", Architecture: "x86", Type: "msi"},
+		{
+			URL:           "https://example.com/app-{{.Arch}}.zip",
+			Architectures: []string{"x64", "arm64"},
+			ArchAliases:   map[string]string{"x64": "amd64"},
+			Type:          "zip",
+		},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	expected := []struct{ arch, templateArch string }{{"x86", "x86"}, {"x64", "amd64"}, {"arm64", "arm64"}}
+	if len(installers) != len(expected) {
+		t.Fatalf("expected %d installers, got %d", len(expected), len(installers))
+	}
+	for i, e := range expected {
+		if installers[i].Architecture != e.arch || installers[i].templateArch() != e.templateArch {
+			t.Errorf("installer %d: expected %s/%s, got %s/%s", i, e.arch, e.templateArch, installers[i].Architecture, installers[i].templateArch())
+		}
+		if installers[i].Architectures != nil {
+			t.Errorf("installer %d: expected architectures to be cleared", i)
+		}
+	}
+}
+
+func TestExpandInstallerArchitecturesProblems(t *testing.T) {
+	_, problems := expandInstallerArchitectures([]InstallerConfig{
+		{Architecture: "x64", Architectures: []string{"arm64"}},
+		{Architectures: []string{"x64"}, ArchAliases: map[string]string{"amd64": "x64"}},
+	})
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if problems[0].Field != "installers[0].architectures" || problems[1].Field != "installers[1].arch_aliases" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestRenderConfigTemplatesArch(t *testing.T) {
+	cfg, problems := decodePluginConfig(map[string]any{
+		"package_id": "MyOrg.MyApp",
+		"installers": []any{map[string]any{
+			"url":           "https://example.com/{{.Version}}/app-{{.Arch}}.zip",
+			"architectures": []any{"x64", "arm64"},
+			"arch_aliases":  map[string]any{"x64": "amd64"},
+			"type":          "zip",
+		}},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if err := renderConfigTemplates(cfg, sampleTemplateData(cfg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://example.com/1.2.3/app-amd64.zip", "https://example.com/1.2.3/app-arm64.zip"}
+	for i, url := range expected {
+		if cfg.Installers[i].URL != url {
+			t.Errorf("installer %d: expected URL '%s', got '%s'", i, url, cfg.Installers[i].URL)
+		}
+	}
+}
+
+func TestInferArchitecture(t *testing.T) {
+	aliases := map[string]string{"amd64": "x64", "aarch64": "arm64", "win32": "x86"}
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"myapp-1.2.3-x64.msi", "x64", false},
+		{"myapp-1.2.3-amd64.msi", "x64", false},
+		{"MyApp_AArch64_setup.exe", "arm64", false},
+		{"myapp-win32.zip", "x86", false},
+		{"myapp-x86_64.zip", "x64", false},
+		{"myapp-arm64.zip", "arm64", false},
+		{"myapp-arm.zip", "arm", false},
+		{"myapp-armada.zip", "", false},
+		{"myapp-1.2.3.msi", "", false},
+		{"myapp-x64-arm64.zip", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arch, err := inferArchitecture(tt.name, aliases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("inferArchitecture(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if arch != tt.expected {
+				t.Errorf("inferArchitecture(%q) = '%s', expected '%s'", tt.name, arch, tt.expected)
+			}
+		})
+	}
+}
+
+func TestArchitectureAliases(t *testing.T) {
+	cfg, problems := decodePluginConfig(map[string]any{
+		"package_id":           "MyOrg.MyApp",
+		"architecture_aliases": map[string]any{"amd64": "x64", "x86_64": "x64", "aarch64": "arm64"},
+		"installers": []any{
+			map[string]any{
+				"url":           "https://example.com/app-{{.Arch}}.zip",
+				"architectures": []any{"x64", "arm64", "x86"},
+				"type":          "zip",
+			},
+			map[string]any{
+				"url":          "https://example.com/app-{{.Arch}}.msi",
+				"architecture": "x64",
+				"arch_aliases": map[string]any{"x64": "win64"},
+				"type":         "msi",
+			},
+		},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if err := renderConfigTemplates(cfg, sampleTemplateData(cfg)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"https://example.com/app-amd64.zip",
+		"https://example.com/app-aarch64.zip",
+		"https://example.com/app-x86.zip",
+		"https://example.com/app-win64.msi",
+	}
+	for i, url := range expected {
+		if cfg.Installers[i].URL != url {
+			t.Errorf("installer %d: expected '%s', got '%s'", i, url, cfg.Installers[i].URL)
+		}
+	}
+
+	if problems := validateArchitectureAliases(map[string]string{"amd64": "x64", "ia64": "itanium"}); len(problems) != 1 || problems[0].Field != "architecture_aliases.ia64" {
+		t.Errorf("expected a problem on the unknown architecture, got %v", problems)
+	}
+}
+
+func TestValidateAssetInstallerWithoutArchitecture(t *testing.T) {
+	cfg := validTestConfig()
+	cfg["installers"] = []any{
+		map[string]any{"asset": "myapp-*-amd64.msi", "type": "msi"},
+		map[string]any{"url": "https://example.com/app.msi", "type": "msi"},
+	}
+
+	resp, err := (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasValidationError(resp, "installers[0].architecture") {
+		t.Error("expected the architecture of the asset installer to be inferred")
+	}
+	if !hasValidationError(resp, "installers[1].architecture") {
+		t.Error("expected URL installers to require an architecture")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+// hasAssetInstallers reports whether any installer is declared by an asset
+// pattern instead of a URL.
+func hasAssetInstallers(installers []InstallerConfig) bool {
+	for _, installer := range installers {
+		if installer.Asset != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAssetPattern checks that an asset pattern is a valid glob once its
+// template is rendered with sample data.
+func validateAssetPattern(pattern string, data templateData) error {
+	rendered, err := renderTemplate(pattern, data)
+	if err != nil {
+		// Reported by the template check
+		return nil
+	}
+	if strings.Contains(rendered, "/") {
+		return fmt.Errorf("asset pattern %q must match a file name, not a path", pattern)
+	}
+	if _, err := path.Match(rendered, ""); err != nil {
+		return fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// isPatchAsset reports whether a file name matches one of the patch_assets
+// patterns.
+func isPatchAsset(name string, patchPatterns []string) bool {
+	for _, pattern := range patchPatterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatchAssets checks the patch_assets patterns and that no installer
+// URL, rendered with sample data, points to a patch installer.
+func validatePatchAssets(cfg *Config) []fieldError {
+	var problems []fieldError
+	data := sampleTemplateData(cfg)
+	patterns := make([]string, 0, len(cfg.PatchAssets))
+	for i, pattern := range cfg.PatchAssets {
+		if err := validateAssetPattern(pattern, data); err != nil {
+			problems = append(problems, fieldError{fmt.Sprintf("patch_assets[%d]", i), err.Error()})
+			continue
+		}
+		if rendered, err := renderTemplate(pattern, data); err == nil {
+			patterns = append(patterns, rendered)
+		}
+	}
+
+	for i, installer := range cfg.Installers {
+		if installer.URL == "" {
+			continue
+		}
+		data.Arch = installer.templateArch()
+		rendered, err := renderTemplate(installer.URL, data)
+		if err != nil {
+			// Reported by the template check
+			continue
+		}
+		u, err := url.Parse(rendered)
+		if err != nil {
+			continue
+		}
+		if name := path.Base(u.Path); isPatchAsset(name, patterns) {
+			problems = append(problems, fieldError{fmt.Sprintf("installers[%d].url", i),
+				fmt.Sprintf("%s is a patch installer matched by patch_assets; only full installers are published", name)})
+		}
+	}
+	return problems
+}
+
+// assetRules are the settings that apply to release assets matched by asset
+// patterns.
+type assetRules struct {
+	// PatchPatterns are the rendered patch_assets patterns.
+	PatchPatterns []string
+	ArchAliases   map[string]string
+	ScopeRules    []ScopeRule
+}
+
+// assetRulesFor returns the asset rules of a configuration whose templates
+// are rendered.
+func assetRulesFor(cfg *Config) assetRules {
+	return assetRules{
+		PatchPatterns: cfg.PatchAssets,
+		ArchAliases:   cfg.ArchitectureAliases,
+		ScopeRules:    cfg.ScopeRules,
+	}
+}
+
+// resolveInstallerAssets replaces rendered asset patterns with the download URL
+// of the single release asset each pattern matches. Installers with URLs are
+// kept as they are and optional installers without a matching asset are
+// dropped. Assets matching the patch patterns are ignored. Asset installers
+// without an architecture take the one named in the asset file name,
+// directly or through the architecture aliases, and those without a scope
+// take the scope of the first scope rule matching the file name. The SHA256
+// digests GitHub publishes for assets are kept as known checksums.
+func resolveInstallerAssets(installers []InstallerConfig, assets []githubclient.ReleaseAsset, rules assetRules) ([]InstallerConfig, error) {
+	assets = slices.DeleteFunc(slices.Clone(assets), func(asset githubclient.ReleaseAsset) bool {
+		return isPatchAsset(asset.Name, rules.PatchPatterns)
+	})
+
+	resolved := make([]InstallerConfig, 0, len(installers))
+	for i, installer := range installers {
+		if installer.Asset == "" {
+			resolved = append(resolved, installer)
+			continue
+		}
+
+		pattern := installer.Asset
+		var matches []githubclient.ReleaseAsset
+		for _, asset := range assets {
+			if ok, _ := path.Match(pattern, asset.Name); ok {
+				matches = append(matches, asset)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			// Optional installers are dropped when the release lacks them
+			if installer.Optional {
+				continue
+			}
+			return nil, fmt.Errorf("installer %d: no release asset matches %q", i, pattern)
+		case 1:
+			// MSIX bundles take their architectures from the bundle manifest
+			if installer.Architecture == "" && !isMSIXInstaller(installer) {
+				arch, err := inferArchitecture(matches[0].Name, rules.ArchAliases)
+				if err != nil {
+					return nil, fmt.Errorf("installer %d: %w", i, err)
+				}
+				if arch == "" {
+					return nil, fmt.Errorf("installer %d: release asset %s names no architecture; set architecture or add its token to architecture_aliases", i, matches[0].Name)
+				}
+				installer.Architecture = arch
+			}
+			if installer.Scope == "" {
+				installer.Scope = inferScope(matches[0].Name, rules.ScopeRules)
+			}
+			if digest, ok := strings.CutPrefix(matches[0].Digest, "sha256:"); ok {
+				installer.sha256 = strings.ToUpper(digest)
+			}
+			installer.URL = matches[0].URL
+			installer.Asset = ""
+			resolved = append(resolved, installer)
+		default:
+			names := make([]string, len(matches))
+			for j, match := range matches {
+				names[j] = match.Name
+			}
+			return nil, fmt.Errorf("installer %d: %q matches several release assets: %s",
+				i, pattern, strings.Join(names, ", "))
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+func TestValidateAssetPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"*-x64.msi", false},
+		{"myapp-{{.Version}}-arm64.zip", false},
+		{"[-x64.msi", true},
+		{"dist/*.msi", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			err := validateAssetPattern(tt.pattern, sampleTemplateData(&Config{}))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAssetPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveInstallerAssets(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-1.2.3-x64.msi", URL: "https://example.com/myapp-1.2.3-x64.msi"},
+		{Name: "myapp-1.2.3-arm64.zip", URL: "https://example.com/myapp-1.2.3-arm64.zip"},
+		{Name: "myapp-1.2.3-arm64.zip.sha256", URL: "https://example.com/myapp-1.2.3-arm64.zip.sha256"},
+		{Name: "checksums.txt", URL: "https://example.com/checksums.txt"},
+	}
+
+	installers := []InstallerConfig{
+		{Asset: "*-x64.msi", Architecture: "x64", Type: "msi"},
+		{Asset: "myapp-1.2.3-arm64.zip", Architecture: "arm64", Type: "zip"},
+		{URL: "https://example.com/other.exe", Architecture: "x86", Type: "exe"},
+	}
+
+	resolved, err := resolveInstallerAssets(installers, assets, assetRules{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"https://example.com/myapp-1.2.3-x64.msi",
+		"https://example.com/myapp-1.2.3-arm64.zip",
+		"https://example.com/other.exe",
+	}
+	for i, url := range expected {
+		if resolved[i].URL != url {
+			t.Errorf("installer %d: expected URL '%s', got '%s'", i, url, resolved[i].URL)
+		}
+		if resolved[i].Asset != "" {
+			t.Errorf("installer %d: expected asset pattern to be cleared", i)
+		}
+	}
+	if installers[0].URL != "" {
+		t.Error("expected input installers to be left unchanged")
+	}
+}
+
+func TestResolveInstallerAssetsOptional(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{{Name: "myapp-x64.msi", URL: "https://example.com/myapp-x64.msi"}}
+	installers := []InstallerConfig{
+		{Asset: "*-x64.msi", Architecture: "x64", Type: "msi"},
+		{Asset: "*-arm64.msi", Architecture: "arm64", Type: "msi", Optional: true},
+	}
+
+	resolved, err := resolveInstallerAssets(installers, assets, assetRules{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resolved) != 1 || resolved[0].Architecture != "x64" {
+		t.Errorf("expected only the x64 installer, got %v", resolved)
+	}
+}
+
+func TestResolveInstallerAssetsErrors(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-x64.msi", URL: "https://example.com/myapp-x64.msi"},
+		{Name: "myapp-debug-x64.msi", URL: "https://example.com/myapp-debug-x64.msi"},
+	}
+
+	tests := []struct {
+		pattern string
+		message string
+	}{
+		{"*-arm64.msi", "no release asset matches"},
+		{"*-x64.msi", "matches several release assets: myapp-x64.msi, myapp-debug-x64.msi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := resolveInstallerAssets([]InstallerConfig{{Asset: tt.pattern}}, assets, assetRules{})
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing '%s', got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestResolveInstallerAssetsIgnoresPatches(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-1.2.3-x64.msi", URL: "https://example.com/myapp-1.2.3-x64.msi"},
+		{Name: "myapp-1.2.3-x64.msp", URL: "https://example.com/myapp-1.2.3-x64.msp"},
+		{Name: "myapp-1.2.3-x64-delta.exe", URL: "https://example.com/myapp-1.2.3-x64-delta.exe"},
+		{Name: "myapp-1.2.3-x64-setup.exe", URL: "https://example.com/myapp-1.2.3-x64-setup.exe"},
+	}
+	installers := []InstallerConfig{
+		{Asset: "myapp-*-x64.ms?", Architecture: "x64", Type: "msi"},
+		{Asset: "myapp-*-x64-*.exe", Architecture: "x64", Type: "exe"},
+	}
+
+	resolved, err := resolveInstallerAssets(installers, assets, assetRules{PatchPatterns: []string{"*.msp", "*-delta.exe"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resolved) != 2 || resolved[0].URL != "https://example.com/myapp-1.2.3-x64.msi" || resolved[1].URL != "https://example.com/myapp-1.2.3-x64-setup.exe" {
+		t.Errorf("expected only the full installers, got %v", resolved)
+	}
+
+	if _, err := resolveInstallerAssets(installers, assets, assetRules{}); err == nil || !strings.Contains(err.Error(), "matches several release assets") {
+		t.Errorf("expected patches to match without patch_assets, got %v", err)
+	}
+}
+
+func TestValidatePatchAssets(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		patchAssets   []string
+		expectedField string
+	}{
+		{"full installer", "https://example.com/myapp-{{.Version}}.msi", []string{"*.msp"}, ""},
+		{"patch installer", "https://example.com/myapp-{{.Version}}.msp?download=1", []string{"*.msp"}, "installers[0].url"},
+		{"templated pattern", "https://example.com/myapp-{{.Version}}-delta.exe", []string{"*-{{.Version}}-delta.exe"}, "installers[0].url"},
+		{"no patterns", "https://example.com/myapp.msp", nil, ""},
+		{"invalid pattern", "https://example.com/myapp.msi", []string{"[.msp"}, "patch_assets[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Installers:  []InstallerConfig{{URL: tt.url, Architecture: "x64", Type: "msi"}},
+				PatchAssets: tt.patchAssets,
+			}
+			problems := validatePatchAssets(cfg)
+			if tt.expectedField == "" {
+				if len(problems) > 0 {
+					t.Errorf("expected no problems, got %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.expectedField {
+				t.Errorf("expected a problem on %s, got %v", tt.expectedField, problems)
+			}
+		})
+	}
+}
+
+func TestResolveInstallerAssetsInfersArchitecture(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-1.2.3-amd64.msi", URL: "https://example.com/myapp-1.2.3-amd64.msi"},
+		{Name: "myapp-1.2.3-aarch64.msi", URL: "https://example.com/myapp-1.2.3-aarch64.msi"},
+		{Name: "myapp-1.2.3.zip", URL: "https://example.com/myapp-1.2.3.zip"},
+	}
+	aliases := map[string]string{"amd64": "x64", "aarch64": "arm64"}
+
+	resolved, err := resolveInstallerAssets([]InstallerConfig{
+		{Asset: "*-amd64.msi", Type: "msi"},
+		{Asset: "*-aarch64.msi", Type: "msi"},
+		{Asset: "*.zip", Architecture: "neutral", Type: "zip"},
+	}, assets, assetRules{ArchAliases: aliases})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, arch := range []string{"x64", "arm64", "neutral"} {
+		if resolved[i].Architecture != arch {
+			t.Errorf("installer %d: expected architecture '%s', got '%s'", i, arch, resolved[i].Architecture)
+		}
+	}
+
+	_, err = resolveInstallerAssets([]InstallerConfig{{Asset: "*.zip", Type: "zip"}}, assets, assetRules{ArchAliases: aliases})
+	if err == nil || !strings.Contains(err.Error(), "names no architecture") {
+		t.Errorf("expected an architecture error, got %v", err)
+	}
+}
+
+func TestResolveInstallerAssetsDigest(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-x64.msi", URL: "https://example.com/myapp-x64.msi", Digest: "sha256:3a2f0c7d1e5b4a69c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5"},
+		{Name: "myapp-x64.zip", URL: "https://example.com/myapp-x64.zip"},
+	}
+
+	resolved, err := resolveInstallerAssets([]InstallerConfig{
+		{Asset: "*.msi", Architecture: "x64", Type: "msi"},
+		{Asset: "*.zip", Architecture: "x64", Type: "zip"},
+	}, assets, assetRules{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash, source := knownChecksum(resolved[0], nil); hash != "3A2F0C7D1E5B4A69C8D7E6F5A4B3C2D1E0F9A8B7C6D5E4F3A2B1C0D9E8F7A6B5" || source != "release asset digest" {
+		t.Errorf("expected the asset digest as known checksum, got %s from %q", hash, source)
+	}
+	if hash, _ := knownChecksum(resolved[1], nil); hash != "" {
+		t.Errorf("expected no known checksum without a digest, got %s", hash)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+// Attestation verifiers.
+const (
+	attestationVerifierAPI = "api"
+	attestationVerifierGH  = "gh"
+)
+
+// defaultPredicateType is the SLSA build provenance predicate type.
+const defaultPredicateType = "https://slsa.dev/provenance/v1"
+
+// ghCommand is the GitHub CLI executable.
+var ghCommand = "gh"
+
+// AttestationConfig defines how installers are checked against build
+// provenance attestations before their hashes are published.
+type AttestationConfig struct {
+	Enabled bool `json:"enabled"`
+	// Repository is the owner/name repository whose attestations are
+	// checked. It defaults to the release repository.
+	Repository    string `json:"repository"`
+	PredicateType string `json:"predicate_type"`
+	// Verifier is "api" to require a matching attestation from the GitHub
+	// attestations API, or "gh" to also verify its signature with
+	// `gh attestation verify` against the cached installer.
+	Verifier string `json:"verifier"`
+}
+
+// validateAttestation checks the attestation settings.
+func validateAttestation(cfg *Config) []fieldError {
+	att := cfg.Attestation
+	if !att.Enabled {
+		return nil
+	}
+
+	var problems []fieldError
+	if att.Repository != "" {
+		if owner, name, ok := strings.Cut(att.Repository, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			problems = append(problems, fieldError{"attestation.repository", "repository must have the form owner/name"})
+		}
+	}
+	switch att.Verifier {
+	case attestationVerifierAPI:
+	case attestationVerifierGH:
+		if cfg.Download.CacheDir == "" {
+			problems = append(problems, fieldError{"attestation.verifier", "the gh verifier requires download.cache_dir"})
+		}
+	default:
+		problems = append(problems, fieldError{"attestation.verifier", fmt.Sprintf("verifier must be %q or %q", attestationVerifierAPI, attestationVerifierGH)})
+	}
+	return problems
+}
+
+// attestationRepository returns the owner and name of the repository whose
+// attestations are checked.
+func attestationRepository(cfg *Config, owner, name string) (string, string, error) {
+	if cfg.Attestation.Repository != "" {
+		owner, name, _ = strings.Cut(cfg.Attestation.Repository, "/")
+	}
+	if owner == "" || name == "" {
+		return "", "", fmt.Errorf("no repository to check attestations of; set attestation.repository")
+	}
+	return owner, name, nil
+}
+
+// verifyAttestation checks that owner/repo has an attestation of the
+// configured predicate type for an installer hash. With the gh verifier,
+// the attestation signature is verified against the installer file too.
+func verifyAttestation(ctx context.Context, client *githubclient.Client, cfg *Config, owner, repo, hash, file string) error {
+	digest := "sha256:" + strings.ToLower(hash)
+	attestations, err := client.Attestations(ctx, owner, repo, digest)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, attestation := range attestations {
+		if attestationMatches(attestation.Bundle, cfg.Attestation.PredicateType, hash) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no %s attestation for %s in %s/%s", cfg.Attestation.PredicateType, digest, owner, repo)
+	}
+
+	if cfg.Attestation.Verifier == attestationVerifierGH {
+		output, err := ghAttestationVerify(ctx, file, owner+"/"+repo, cfg.Attestation.PredicateType, cfg.GitHubToken)
+		if err != nil {
+			return fmt.Errorf("%w\n%s", err, output)
+		}
+	}
+	return nil
+}
+
+// attestationMatches reports whether a Sigstore bundle holds an in-toto
+// statement of predicateType whose subjects include the hash.
+func attestationMatches(bundle json.RawMessage, predicateType, hash string) bool {
+	var b struct {
+		DSSEEnvelope struct {
+			Payload string `json:"payload"`
+		} `json:"dsseEnvelope"`
+	}
+	if err := json.Unmarshal(bundle, &b); err != nil {
+		return false
+	}
+	payload, err := base64.StdEncoding.DecodeString(b.DSSEEnvelope.Payload)
+	if err != nil {
+		return false
+	}
+
+	var statement struct {
+		PredicateType string `json:"predicateType"`
+		Subject       []struct {
+			Digest map[string]string `json:"digest"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(payload, &statement); err != nil || statement.PredicateType != predicateType {
+		return false
+	}
+	for _, subject := range statement.Subject {
+		if strings.EqualFold(subject.Digest["sha256"], hash) {
+			return true
+		}
+	}
+	return false
+}
+
+// ghAttestationVerify verifies the attestation signature of a file with the
+// GitHub CLI and returns its output.
+func ghAttestationVerify(ctx context.Context, file, repo, predicateType, token string) (string, error) {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, ghCommand, "attestation", "verify", file, "--repo", repo, "--predicate-type", predicateType)
+	// gh reads the token from the environment, which keeps it out of
+	// process listings
+	cmd.Env = append(os.Environ(), "GH_TOKEN="+token)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if err != nil {
+		return out, fmt.Errorf("gh attestation verify failed: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// testAttestationBundle returns a Sigstore bundle whose statement attests
+// a SHA256 hash with a predicate type.
+func testAttestationBundle(t *testing.T, predicateType, hash string) json.RawMessage {
+	t.Helper()
+	statement, err := json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v1",
+		"predicateType": predicateType,
+		"subject":       []any{map[string]any{"name": "app.msi", "digest": map[string]string{"sha256": strings.ToLower(hash)}}},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal statement: %v", err)
+	}
+	bundle, err := json.Marshal(map[string]any{
+		"dsseEnvelope": map[string]string{
+			"payload":     base64.StdEncoding.EncodeToString(statement),
+			"payloadType": "application/vnd.in-toto+json",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal bundle: %v", err)
+	}
+	return bundle
+}
+
+func TestAttestationMatches(t *testing.T) {
+	hash := installerhash.FromBytes([]byte("installer"))
+
+	tests := []struct {
+		name     string
+		bundle   json.RawMessage
+		expected bool
+	}{
+		{"matching", testAttestationBundle(t, defaultPredicateType, hash), true},
+		{"other predicate", testAttestationBundle(t, "https://spdx.dev/Document/v2.3", hash), false},
+		{"other subject", testAttestationBundle(t, defaultPredicateType, strings.Repeat("A", 64)), false},
+		{"invalid payload", json.RawMessage(`{"dsseEnvelope":{"payload":"not base64"}}`), false},
+		{"not a bundle", json.RawMessage(`[]`), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := attestationMatches(tt.bundle, defaultPredicateType, hash); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateAttestation(t *testing.T) {
+	tests := []struct {
+		name        string
+		attestation AttestationConfig
+		cacheDir    string
+		wantField   string
+	}{
+		{"disabled", AttestationConfig{Verifier: "bogus"}, "", ""},
+		{"api", AttestationConfig{Enabled: true, Verifier: attestationVerifierAPI, Repository: "myorg/myapp"}, "", ""},
+		{"gh with cache", AttestationConfig{Enabled: true, Verifier: attestationVerifierGH}, "/tmp/cache", ""},
+		{"gh without cache", AttestationConfig{Enabled: true, Verifier: attestationVerifierGH}, "", "attestation.verifier"},
+		{"unknown verifier", AttestationConfig{Enabled: true, Verifier: "cosign"}, "", "attestation.verifier"},
+		{"invalid repository", AttestationConfig{Enabled: true, Verifier: attestationVerifierAPI, Repository: "myapp"}, "", "attestation.repository"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Attestation: tt.attestation, Download: DownloadConfig{CacheDir: tt.cacheDir}}
+			problems := validateAttestation(cfg)
+			if tt.wantField == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("expected a %s problem, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
+
+func TestExecuteAttestation(t *testing.T) {
+	hash := installerhash.FromBytes([]byte("installer"))
+
+	tests := []struct {
+		name         string
+		attestations string
+		success      bool
+	}{
+		{"attested", `{"attestations":[{"bundle":` + string(testAttestationBundle(t, defaultPredicateType, hash)) + `}]}`, true},
+		{"other binary attested", `{"attestations":[{"bundle":` + string(testAttestationBundle(t, defaultPredicateType, strings.Repeat("B", 64))) + `}]}`, false},
+		{"not attested", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/repos/myorg/myapp/attestations/sha256:"+strings.ToLower(hash):
+					if tt.attestations == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(tt.attestations))
+				case r.URL.Path == "/app.msi":
+					_, _ = w.Write([]byte("installer"))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			originalBase := githubAPIBase
+			githubAPIBase = server.URL
+			defer func() { githubAPIBase = originalBase }()
+
+			cfg := validTestConfig()
+			cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+			cfg["output_dir"] = t.TempDir()
+			cfg["pull_request"] = map[string]any{"enabled": false}
+			cfg["attestation"] = map[string]any{"enabled": true}
+
+			resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3", RepositoryOwner: "myorg", RepositoryName: "myapp"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.success {
+				t.Fatalf("expected success %v, got %v: %s", tt.success, resp.Success, resp.Message)
+			}
+			if !tt.success && resp.Outputs["error_category"] != string(categoryValidation) {
+				t.Errorf("expected validation error category, got %v", resp.Outputs["error_category"])
+			}
+		})
+	}
+}
+
+func TestGHAttestationVerify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake gh script requires a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "gh")
+	script := "#!/bin/sh\necho \"args: $@\"\necho \"token: $GH_TOKEN\"\n[ \"$3\" = /cache/app.msi ]\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake gh: %v", err)
+	}
+	original := ghCommand
+	ghCommand = path
+	defer func() { ghCommand = original }()
+
+	output, err := ghAttestationVerify(context.Background(), "/cache/app.msi", "myorg/myapp", defaultPredicateType, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "args: attestation verify /cache/app.msi --repo myorg/myapp --predicate-type "+defaultPredicateType) {
+		t.Errorf("unexpected arguments: %s", output)
+	}
+	if !strings.Contains(output, "token: secret") {
+		t.Errorf("expected token in the environment: %s", output)
+	}
+
+	if _, err := ghAttestationVerify(context.Background(), "/other.msi", "myorg/myapp", defaultPredicateType, ""); err == nil {
+		t.Error("expected error when verification fails")
+	}
+}
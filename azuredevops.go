@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// azureDevOpsAPIVersion is the Azure DevOps REST API version used.
+const azureDevOpsAPIVersion = "7.1"
+
+// zeroObjectID is the Git object ID of a ref that does not exist.
+const zeroObjectID = "0000000000000000000000000000000000000000"
+
+// AzureDevOpsConfig defines the Azure DevOps Git repository holding a
+// winget-pkgs style manifest tree, used by the azure_devops backend.
+type AzureDevOpsConfig struct {
+	// OrganizationURL is the organization, such as
+	// https://dev.azure.com/contoso.
+	OrganizationURL string `json:"organization_url"`
+	Project         string `json:"project"`
+	Repository      string `json:"repository"`
+	// TargetBranch is the branch pull requests are opened against.
+	TargetBranch string `json:"target_branch"`
+	// Token is a personal access token with Code (Read & Write) access.
+	Token string `json:"token"`
+}
+
+// changeRequest is the pull or merge request opened by the Git hosting
+// backends: the manifests are pushed to SourceBranch and proposed for
+// TargetBranch.
+type changeRequest struct {
+	SourceBranch  string
+	TargetBranch  string
+	Title         string
+	Description   string
+	CommitMessage string
+}
+
+// scmStatusError is returned for Git hosting API responses with an error
+// status.
+type scmStatusError struct {
+	Service    string
+	StatusCode int
+	Body       string
+}
+
+func (e *scmStatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Service, e.StatusCode, e.Body)
+}
+
+// validateAzureDevOps checks the azure_devops backend configuration.
+func validateAzureDevOps(cfg AzureDevOpsConfig, allowInsecure bool) []fieldError {
+	var problems []fieldError
+	if cfg.OrganizationURL == "" {
+		problems = append(problems, fieldError{"azure_devops.organization_url", "organization_url is required"})
+	} else if u, err := url.Parse(cfg.OrganizationURL); err != nil || u.Host == "" {
+		problems = append(problems, fieldError{"azure_devops.organization_url", fmt.Sprintf("invalid organization URL %q", cfg.OrganizationURL)})
+	} else if u.Scheme != "https" && !(allowInsecure && u.Scheme == "http") {
+		problems = append(problems, fieldError{"azure_devops.organization_url", "organization_url must use https"})
+	}
+	for _, f := range []struct{ name, value string }{
+		{"project", cfg.Project},
+		{"repository", cfg.Repository},
+		{"target_branch", cfg.TargetBranch},
+		{"token", cfg.Token},
+	} {
+		if f.value == "" {
+			problems = append(problems, fieldError{"azure_devops." + f.name, f.name + " is required"})
+		}
+	}
+	return problems
+}
+
+// AzureDevOpsClient pushes manifests to an Azure DevOps Git repository and
+// opens pull requests for them.
+type AzureDevOpsClient struct {
+	repoURL string
+	token   string
+	client  *http.Client
+}
+
+// NewAzureDevOpsClient creates a client for a repository of an Azure DevOps
+// project, authenticating with a personal access token.
+func NewAzureDevOpsClient(organizationURL, project, repository, token string) *AzureDevOpsClient {
+	return &AzureDevOpsClient{
+		repoURL: fmt.Sprintf("%s/%s/_apis/git/repositories/%s",
+			strings.TrimSuffix(organizationURL, "/"), url.PathEscape(project), url.PathEscape(repository)),
+		token: token,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// SubmitPullRequest pushes the manifests to the source branch, creating it
+// from the target branch if needed, and opens a pull request. An active pull
+// request for the branch is reused. It returns the pull request web URL.
+func (c *AzureDevOpsClient) SubmitPullRequest(ctx context.Context, m *manifest.Set, cr changeRequest) (string, error) {
+	files, err := m.GetFiles()
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest files: %w", err)
+	}
+
+	// A retried run pushes on top of the branch of the previous attempt
+	oldObjectID, err := c.branchObjectID(ctx, cr.SourceBranch)
+	if err != nil {
+		return "", err
+	}
+	existing := map[string]bool{}
+	if oldObjectID == "" {
+		if oldObjectID, err = c.branchObjectID(ctx, cr.TargetBranch); err != nil {
+			return "", err
+		}
+		if oldObjectID == "" {
+			return "", fmt.Errorf("target branch %s not found", cr.TargetBranch)
+		}
+	}
+	for path := range files {
+		if existing[path], err = c.itemExists(ctx, path, oldObjectID); err != nil {
+			return "", err
+		}
+	}
+
+	changes := make([]map[string]any, 0, len(files))
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		changeType := "add"
+		if existing[path] {
+			changeType = "edit"
+		}
+		changes = append(changes, map[string]any{
+			"changeType": changeType,
+			"item":       map[string]string{"path": "/" + path},
+			"newContent": map[string]string{"content": files[path], "contentType": "rawtext"},
+		})
+	}
+	push := map[string]any{
+		"refUpdates": []map[string]string{{"name": "refs/heads/" + cr.SourceBranch, "oldObjectId": oldObjectID}},
+		"commits":    []map[string]any{{"comment": cr.CommitMessage, "changes": changes}},
+	}
+	if err := c.send(ctx, "POST", "/pushes", push, nil); err != nil {
+		return "", fmt.Errorf("failed to push manifests: %w", err)
+	}
+
+	if prURL, err := c.activePullRequest(ctx, cr); err != nil || prURL != "" {
+		return prURL, err
+	}
+	var pr azureDevOpsPullRequest
+	err = c.send(ctx, "POST", "/pullrequests", map[string]string{
+		"sourceRefName": "refs/heads/" + cr.SourceBranch,
+		"targetRefName": "refs/heads/" + cr.TargetBranch,
+		"title":         cr.Title,
+		"description":   cr.Description,
+	}, &pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return pr.webURL(), nil
+}
+
+// azureDevOpsPullRequest is the subset of an Azure DevOps pull request used
+// by the plugin.
+type azureDevOpsPullRequest struct {
+	PullRequestID int `json:"pullRequestId"`
+	Repository    struct {
+		WebURL string `json:"webUrl"`
+	} `json:"repository"`
+}
+
+// webURL returns the address of the pull request in the web UI.
+func (pr azureDevOpsPullRequest) webURL() string {
+	return fmt.Sprintf("%s/pullrequest/%d", pr.Repository.WebURL, pr.PullRequestID)
+}
+
+// activePullRequest returns the URL of the active pull request of a change
+// request, or "" if there is none.
+func (c *AzureDevOpsClient) activePullRequest(ctx context.Context, cr changeRequest) (string, error) {
+	query := url.Values{
+		"searchCriteria.sourceRefName": {"refs/heads/" + cr.SourceBranch},
+		"searchCriteria.targetRefName": {"refs/heads/" + cr.TargetBranch},
+		"searchCriteria.status":        {"active"},
+	}
+	var result struct {
+		Value []azureDevOpsPullRequest `json:"value"`
+	}
+	if err := c.send(ctx, "GET", "/pullrequests?"+query.Encode(), nil, &result); err != nil {
+		return "", fmt.Errorf("failed to look up pull request: %w", err)
+	}
+	if len(result.Value) == 0 {
+		return "", nil
+	}
+	return result.Value[0].webURL(), nil
+}
+
+// branchObjectID returns the commit a branch points to, or "" if the branch
+// does not exist.
+func (c *AzureDevOpsClient) branchObjectID(ctx context.Context, branch string) (string, error) {
+	var result struct {
+		Value []struct {
+			Name     string `json:"name"`
+			ObjectID string `json:"objectId"`
+		} `json:"value"`
+	}
+	if err := c.send(ctx, "GET", "/refs?filter="+url.QueryEscape("heads/"+branch), nil, &result); err != nil {
+		return "", fmt.Errorf("failed to look up branch %s: %w", branch, err)
+	}
+	// The filter matches ref name prefixes
+	for _, ref := range result.Value {
+		if ref.Name == "refs/heads/"+branch && ref.ObjectID != zeroObjectID {
+			return ref.ObjectID, nil
+		}
+	}
+	return "", nil
+}
+
+// itemExists reports whether a file exists at a commit.
+func (c *AzureDevOpsClient) itemExists(ctx context.Context, path, commit string) (bool, error) {
+	query := url.Values{
+		"path":                          {"/" + path},
+		"versionDescriptor.version":     {commit},
+		"versionDescriptor.versionType": {"commit"},
+	}
+	err := c.send(ctx, "GET", "/items?"+query.Encode(), nil, nil)
+	var statusErr *scmStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// send issues a JSON request against the repository API and decodes the
+// response into result, if not nil.
+func (c *AzureDevOpsClient) send(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.repoURL+path+sep+"api-version="+azureDevOpsAPIVersion, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(":"+c.token)))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// Azure DevOps answers unauthenticated requests with a sign-in page
+	if resp.StatusCode == http.StatusNonAuthoritativeInfo || resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		status := resp.StatusCode
+		if status == http.StatusNonAuthoritativeInfo {
+			status = http.StatusUnauthorized
+		}
+		return &scmStatusError{Service: "Azure DevOps", StatusCode: status, Body: strings.TrimSpace(string(respBody))}
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAzureDevOpsClientSubmitPullRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		branchExists bool
+		prExists     bool
+		changeType   string
+		oldObjectID  string
+		expectCreate bool
+	}{
+		{"new branch", false, false, "add", "base", true},
+		{"existing branch", true, false, "edit", "head", true},
+		{"existing pull request", true, true, "edit", "head", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var push struct {
+				RefUpdates []map[string]string `json:"refUpdates"`
+				Commits    []struct {
+					Comment string `json:"comment"`
+					Changes []struct {
+						ChangeType string            `json:"changeType"`
+						Item       map[string]string `json:"item"`
+					} `json:"changes"`
+				} `json:"commits"`
+			}
+			created := false
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if user, pass, ok := r.BasicAuth(); !ok || user != "" || pass != "pat" {
+					t.Errorf("expected basic auth with the token, got %q", r.Header.Get("Authorization"))
+				}
+				if r.URL.Query().Get("api-version") != azureDevOpsAPIVersion {
+					t.Errorf("expected api-version %s, got %q", azureDevOpsAPIVersion, r.URL.RawQuery)
+				}
+
+				prefix := "/contoso/My%20Project/_apis/git/repositories/manifests"
+				if !strings.HasPrefix(r.URL.EscapedPath(), prefix) {
+					t.Errorf("unexpected path %s", r.URL.EscapedPath())
+				}
+				switch strings.TrimPrefix(r.URL.EscapedPath(), prefix) {
+				case "/refs":
+					switch r.URL.Query().Get("filter") {
+					case "heads/main":
+						_, _ = w.Write([]byte(`{"value":[{"name":"refs/heads/main","objectId":"base"}]}`))
+					case "heads/winget/MyOrg-MyApp/1.0.0":
+						if tt.branchExists {
+							_, _ = w.Write([]byte(`{"value":[{"name":"refs/heads/winget/MyOrg-MyApp/1.0.0","objectId":"head"}]}`))
+							return
+						}
+						_, _ = w.Write([]byte(`{"value":[]}`))
+					}
+				case "/items":
+					if tt.branchExists {
+						_, _ = w.Write([]byte(`{}`))
+						return
+					}
+					w.WriteHeader(http.StatusNotFound)
+				case "/pushes":
+					if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+						t.Errorf("failed to decode push: %v", err)
+					}
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{}`))
+				case "/pullrequests":
+					if r.Method == "GET" {
+						if tt.prExists {
+							_, _ = w.Write([]byte(`{"value":[{"pullRequestId":4,"repository":{"webUrl":"https://dev.azure.com/contoso/p/_git/manifests"}}]}`))
+							return
+						}
+						_, _ = w.Write([]byte(`{"value":[]}`))
+						return
+					}
+					created = true
+					_, _ = w.Write([]byte(`{"pullRequestId":5,"repository":{"webUrl":"https://dev.azure.com/contoso/p/_git/manifests"}}`))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := NewAzureDevOpsClient(server.URL+"/contoso/", "My Project", "manifests", "pat")
+			prURL, err := client.SubmitPullRequest(context.Background(), validTestManifests(t), changeRequest{
+				SourceBranch:  "winget/MyOrg-MyApp/1.0.0",
+				TargetBranch:  "main",
+				Title:         "New version: MyOrg.MyApp version 1.0.0",
+				CommitMessage: "New version: MyOrg.MyApp version 1.0.0",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(push.RefUpdates) != 1 || push.RefUpdates[0]["oldObjectId"] != tt.oldObjectID {
+				t.Errorf("expected a ref update from %s, got %v", tt.oldObjectID, push.RefUpdates)
+			}
+			if len(push.Commits) != 1 || len(push.Commits[0].Changes) != 3 {
+				t.Fatalf("expected one commit of three files, got %+v", push.Commits)
+			}
+			for _, change := range push.Commits[0].Changes {
+				if change.ChangeType != tt.changeType || !strings.HasPrefix(change.Item["path"], "/manifests/m/MyOrg/MyApp/1.0.0/") {
+					t.Errorf("unexpected change %+v", change)
+				}
+			}
+			if created != tt.expectCreate {
+				t.Errorf("expected pull request creation %v, got %v", tt.expectCreate, created)
+			}
+			wantID := "5"
+			if tt.prExists {
+				wantID = "4"
+			}
+			if prURL != "https://dev.azure.com/contoso/p/_git/manifests/pullrequest/"+wantID {
+				t.Errorf("unexpected pull request URL %s", prURL)
+			}
+		})
+	}
+}
+
+func TestAzureDevOpsClientUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Azure DevOps redirects unauthenticated API calls to a sign-in page
+		w.WriteHeader(http.StatusNonAuthoritativeInfo)
+	}))
+	defer server.Close()
+
+	client := NewAzureDevOpsClient(server.URL, "p", "manifests", "pat")
+	_, err := client.SubmitPullRequest(context.Background(), validTestManifests(t), changeRequest{SourceBranch: "b", TargetBranch: "main"})
+	var statusErr *scmStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized status error, got %v", err)
+	}
+	if category := classifyError(err); category != categoryAuth {
+		t.Errorf("expected category %s, got %s", categoryAuth, category)
+	}
+}
+
+func TestValidateAzureDevOps(t *testing.T) {
+	valid := AzureDevOpsConfig{OrganizationURL: "https://dev.azure.com/contoso", Project: "p", Repository: "manifests", TargetBranch: "main", Token: "pat"}
+	if problems := validateAzureDevOps(valid, false); len(problems) != 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+
+	insecure := valid
+	insecure.OrganizationURL = "http://ado.local/contoso"
+	if problems := validateAzureDevOps(insecure, false); len(problems) != 1 || problems[0].Field != "azure_devops.organization_url" {
+		t.Errorf("expected an organization_url problem, got %v", problems)
+	}
+	if problems := validateAzureDevOps(insecure, true); len(problems) != 0 {
+		t.Errorf("unexpected problems with insecure URLs allowed: %v", problems)
+	}
+
+	problems := validateAzureDevOps(AzureDevOpsConfig{}, false)
+	if len(problems) != 5 {
+		t.Errorf("expected five problems for an empty configuration, got %v", problems)
+	}
+}
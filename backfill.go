@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// maxBackfillReleases is the largest number of past releases a backfill
+// submits.
+const maxBackfillReleases = 50
+
+// BackfillConfig defines the past versions submitted by the backfill
+// command, one pull request per version.
+type BackfillConfig struct {
+	// Versions lists the versions to submit.
+	Versions []string `json:"versions"`
+	// Releases submits the latest published GitHub releases of the
+	// repository, instead of Versions.
+	Releases int `json:"releases"`
+	// Interval is the delay between submissions, which keeps a backfill
+	// within the winget-pkgs pull request rate limits.
+	Interval time.Duration `json:"interval"`
+}
+
+// validateBackfill checks the backfill settings.
+func validateBackfill(cfg BackfillConfig) []fieldError {
+	var problems []fieldError
+	if len(cfg.Versions) > 0 && cfg.Releases > 0 {
+		problems = append(problems, fieldError{"backfill.releases", "only one of versions and releases may be set"})
+	}
+	if cfg.Releases < 0 || cfg.Releases > maxBackfillReleases {
+		problems = append(problems, fieldError{"backfill.releases", fmt.Sprintf("releases must be between 0 and %d", maxBackfillReleases)})
+	}
+	seen := make(map[string]bool)
+	for i, version := range cfg.Versions {
+		switch {
+		case strings.TrimSpace(version) == "":
+			problems = append(problems, fieldError{fmt.Sprintf("backfill.versions[%d]", i), "version must not be empty"})
+		case seen[version]:
+			problems = append(problems, fieldError{fmt.Sprintf("backfill.versions[%d]", i), fmt.Sprintf("version %q is duplicated", version)})
+		}
+		seen[version] = true
+	}
+	if cfg.Interval < 0 {
+		problems = append(problems, fieldError{"backfill.interval", "interval must not be negative"})
+	}
+	return problems
+}
+
+// backfillReleases returns the releases a backfill submits, oldest first.
+// Explicit versions are tagged v<version>; releases are read from the
+// repository of the release context.
+func (p *WinGetPlugin) backfillReleases(ctx context.Context, releaseCtx plugin.ReleaseContext, cfg *Config, logger *slog.Logger) ([]plugin.ReleaseContext, error) {
+	var releases []plugin.ReleaseContext
+	if cfg.Backfill.Releases == 0 {
+		for _, version := range cfg.Backfill.Versions {
+			release := releaseCtx
+			release.Version = version
+			release.TagName = "v" + strings.TrimPrefix(version, "v")
+			releases = append(releases, release)
+		}
+		return releases, nil
+	}
+
+	if releaseCtx.RepositoryOwner == "" || releaseCtx.RepositoryName == "" {
+		return nil, fmt.Errorf("backfill.releases requires the repository owner and name")
+	}
+	ghClient := newGitHubClient(ctx, cfg, "", logger)
+	published, err := ghClient.Releases(ctx, releaseCtx.RepositoryOwner, releaseCtx.RepositoryName, cfg.Backfill.Releases)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range published {
+		release := releaseCtx
+		release.Version = strings.TrimPrefix(r.TagName, "v")
+		release.TagName = r.TagName
+		releases = append(releases, release)
+	}
+	slices.Reverse(releases)
+	return releases, nil
+}
+
+// Backfill submits each configured past version as if it had just been
+// released, waiting backfill.interval between submissions. It submits every
+// version even if some fail, and returns the result of each in the backfill
+// output.
+func (p *WinGetPlugin) Backfill(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+	cfg := p.parseConfig(req.Config)
+	cfg.DryRun = cfg.DryRun || req.DryRun
+	redactor := newRedactor(cfg)
+	logger := slog.New(redactor.handler(slog.Default().Handler())).With("plugin", "winget", "mode", "backfill")
+
+	releases, err := p.backfillReleases(ctx, req.Context, cfg, logger)
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to list releases to backfill: %v", err)
+		redactor.executeResponse(resp)
+		return resp, nil
+	}
+	if len(releases) == 0 {
+		return failureResponse(categoryValidation, "Nothing to backfill: set backfill.versions or backfill.releases"), nil
+	}
+
+	var results []any
+	failed := 0
+	category := categoryUnknown
+	for i, release := range releases {
+		if i > 0 && cfg.Backfill.Interval > 0 && !cfg.DryRun {
+			logger.Info("Waiting before the next submission", "interval", cfg.Backfill.Interval)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(cfg.Backfill.Interval):
+			}
+		}
+
+		logger.Info("Backfilling version", "version", release.Version, "tag", release.TagName)
+		versionReq := req
+		versionReq.Hook = plugin.HookPostPublish
+		versionReq.Context = release
+		resp, err := p.Execute(ctx, versionReq)
+		if err != nil {
+			return nil, err
+		}
+
+		result := map[string]any{
+			"version": release.Version,
+			"success": resp.Success,
+			"message": resp.Message,
+		}
+		if prURL, ok := resp.Outputs["pr_url"]; ok {
+			result["pr_url"] = prURL
+		}
+		if !resp.Success {
+			if failed == 0 {
+				if c, ok := resp.Outputs["error_category"].(string); ok {
+					category = errorCategory(c)
+				}
+			}
+			failed++
+			logger.Error("Backfill of version failed", "version", release.Version, "error", resp.Message)
+		}
+		results = append(results, result)
+	}
+
+	message := fmt.Sprintf("Backfilled %d of %d versions of %s", len(releases)-failed, len(releases), cfg.PackageID)
+	if failed > 0 {
+		resp := failureResponse(category, "%s", message)
+		resp.Outputs["backfill"] = results
+		return resp, nil
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: message,
+		Outputs: map[string]any{"backfill": results},
+	}, nil
+}
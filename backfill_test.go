@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateBackfill(t *testing.T) {
+	tests := []struct {
+		name     string
+		backfill BackfillConfig
+		fields   []string
+	}{
+		{"empty", BackfillConfig{}, nil},
+		{"versions", BackfillConfig{Versions: []string{"1.0.0", "1.1.0"}, Interval: time.Minute}, nil},
+		{"releases", BackfillConfig{Releases: 5}, nil},
+		{"both", BackfillConfig{Versions: []string{"1.0.0"}, Releases: 5}, []string{"backfill.releases"}},
+		{"too many releases", BackfillConfig{Releases: maxBackfillReleases + 1}, []string{"backfill.releases"}},
+		{"duplicate version", BackfillConfig{Versions: []string{"1.0.0", "1.0.0", " "}}, []string{"backfill.versions[1]", "backfill.versions[2]"}},
+		{"negative interval", BackfillConfig{Interval: -time.Second}, []string{"backfill.interval"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateBackfill(tt.backfill)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestBackfillReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/myorg/myapp/releases":
+			_, _ = w.Write([]byte(`[{"tag_name":"v1.2.0"},{"tag_name":"v1.1.0"},{"tag_name":"v1.0.0"}]`))
+		default:
+			_, _ = w.Write([]byte("installer"))
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["backfill"] = map[string]any{"releases": float64(2), "interval": "1ms"}
+
+	resp, err := (&WinGetPlugin{}).Backfill(context.Background(), plugin.ExecuteRequest{
+		Config:  cfg,
+		Context: plugin.ReleaseContext{RepositoryOwner: "myorg", RepositoryName: "myapp"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	results, _ := resp.Outputs["backfill"].([]any)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %v", resp.Outputs["backfill"])
+	}
+	// Releases are submitted oldest first
+	for i, version := range []string{"1.1.0", "1.2.0"} {
+		if results[i].(map[string]any)["version"] != version {
+			t.Errorf("expected result %d for %s, got %v", i, version, results[i])
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", version)); err != nil {
+			t.Errorf("expected manifests of %s: %v", version, err)
+		}
+	}
+}
+
+func TestBackfillReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "1.1.0") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["backfill"] = map[string]any{"versions": []any{"1.0.0", "1.1.0", "1.2.0"}, "interval": "0s"}
+
+	resp, err := (&WinGetPlugin{}).Backfill(context.Background(), plugin.ExecuteRequest{Config: cfg})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || resp.Message != "Backfilled 2 of 3 versions of MyOrg.MyApp" {
+		t.Errorf("expected a partial failure, got %v: %s", resp.Success, resp.Message)
+	}
+	if resp.Outputs["error_category"] != string(categoryValidation) {
+		t.Errorf("expected the category of the failed version, got %v", resp.Outputs["error_category"])
+	}
+	results, _ := resp.Outputs["backfill"].([]any)
+	if len(results) != 3 || results[1].(map[string]any)["success"] != false || results[2].(map[string]any)["success"] != true {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
+
+func TestRunCLIBackfill(t *testing.T) {
+	outputDir := t.TempDir()
+	args := []string{"backfill", "--config", writeCLIConfig(t, cliTestConfig), "--versions", "1.0.0, 1.1.0", "--dry-run", "--output-dir", outputDir}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"1.0.0: ", "1.1.0: ", "Backfilled 2 of 2 versions"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in output, got: %s", want, stdout.String())
+		}
+	}
+}
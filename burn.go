@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"debug/pe"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// errNotBurnBundle is returned by readBurnBundle for executables without a
+// Burn section.
+var errNotBurnBundle = errors.New("not a WiX Burn bundle")
+
+// burnTypes are the installer types read for a WiX Burn bundle: exe
+// installers are submitted as burn when they turn out to be one.
+var burnTypes = map[string]bool{
+	"exe":  true,
+	"burn": true,
+}
+
+const (
+	// burnSectionMagic starts the .wixburn section of Burn bundles.
+	burnSectionMagic = 0x00f14300
+	// burnManifestFile is the name of the Burn manifest in the UX container.
+	burnManifestFile = "0"
+)
+
+// burnBundle is the identity of a WiX Burn bundle.
+type burnBundle struct {
+	// BundleCode is the bundle ID, the ProductCode of its Apps & Features
+	// entry.
+	BundleCode string
+	// UpgradeCode is the upgrade code related bundles share across
+	// versions, or "" if the bundle has none.
+	UpgradeCode string
+}
+
+// burnManifest is the part of the Burn manifest read by readBurnBundle.
+// WiX v3 names the related bundle code Id, and WiX v4 Code.
+type burnManifest struct {
+	RelatedBundles []struct {
+		ID     string `xml:"Id,attr"`
+		Code   string `xml:"Code,attr"`
+		Action string `xml:"Action,attr"`
+	} `xml:"RelatedBundle"`
+}
+
+// readBurnBundle reads the bundle code from the .wixburn section of the
+// executable at file, and the upgrade code from the Burn manifest of its
+// attached UX container.
+func readBurnBundle(file string) (*burnBundle, error) {
+	f, err := pe.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotBurnBundle, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	section := f.Section(".wixburn")
+	if section == nil {
+		return nil, errNotBurnBundle
+	}
+	data, err := section.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Burn section: %w", err)
+	}
+	// magic, version, bundle ID, stub size, original checksum, signature
+	// offset and size, format, container count, then the container sizes
+	if len(data) < 52 || binary.LittleEndian.Uint32(data) != burnSectionMagic {
+		return nil, errNotBurnBundle
+	}
+	bundle := &burnBundle{BundleCode: formatGUID(data[8:24])}
+	stubSize := binary.LittleEndian.Uint32(data[24:])
+	if binary.LittleEndian.Uint32(data[44:]) == 0 {
+		return nil, errors.New("bundle has no UX container")
+	}
+	uxSize := binary.LittleEndian.Uint32(data[48:])
+
+	// The UX container is a cabinet attached right after the stub
+	exe, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = exe.Close() }()
+	info, err := exe.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if int64(stubSize)+int64(uxSize) > info.Size() {
+		return nil, errors.New("UX container is out of bounds")
+	}
+	cab := make([]byte, uxSize)
+	if _, err := exe.ReadAt(cab, int64(stubSize)); err != nil {
+		return nil, fmt.Errorf("failed to read UX container: %w", err)
+	}
+	content, err := readCabinetFile(cab, burnManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Burn manifest: %w", err)
+	}
+
+	var m burnManifest
+	if err := xml.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse Burn manifest: %w", err)
+	}
+	for _, related := range m.RelatedBundles {
+		code := related.Code
+		if code == "" {
+			code = related.ID
+		}
+		if strings.EqualFold(related.Action, "upgrade") && code != "" {
+			if bundle.UpgradeCode, err = normalizeGUID(code); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	return bundle, nil
+}
+
+// burnInstaller completes an installer with the codes of its Burn bundle.
+// Configured codes take precedence, and exe installers become burn
+// installers, which winget correlates through the bundle's UpgradeCode.
+func burnInstaller(installer InstallerConfig, bundle *burnBundle, logger *slog.Logger) InstallerConfig {
+	if installer.Type != "burn" {
+		logger.Info("Installer is a WiX Burn bundle, submitting it as burn")
+		installer.Type = "burn"
+	}
+	if installer.ProductCode == "" {
+		installer.ProductCode = bundle.BundleCode
+	}
+	if installer.UpgradeCode == "" {
+		installer.UpgradeCode = bundle.UpgradeCode
+	} else if bundle.UpgradeCode != "" && !strings.EqualFold(installer.UpgradeCode, bundle.UpgradeCode) {
+		logger.Warn("Configured upgrade_code differs from the Burn bundle", "upgrade_code", installer.UpgradeCode, "bundle_upgrade_code", bundle.UpgradeCode)
+	}
+	return installer
+}
+
+// formatGUID formats a GUID in its binary layout (little-endian first three
+// fields) in the braced, uppercase form.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf("{%08X-%04X-%04X-%X-%X}",
+		binary.LittleEndian.Uint32(b), binary.LittleEndian.Uint16(b[4:]), binary.LittleEndian.Uint16(b[6:]), b[8:10], b[10:16])
+}
+
+// Cabinet compression types.
+const (
+	cabCompressNone  = 0
+	cabCompressMSZIP = 1
+)
+
+// readCabinetFile extracts the file named name from an uncompressed or MSZIP
+// compressed cabinet.
+func readCabinetFile(cab []byte, name string) ([]byte, error) {
+	le := binary.LittleEndian
+	if len(cab) < 36 || string(cab[:4]) != "MSCF" {
+		return nil, errors.New("not a cabinet")
+	}
+	filesOffset := int(le.Uint32(cab[16:]))
+	folders, files, flags := int(le.Uint16(cab[26:])), int(le.Uint16(cab[28:])), le.Uint16(cab[30:])
+	if flags&0x3 != 0 {
+		return nil, errors.New("multi-part cabinets are not supported")
+	}
+	offset, folderReserve, dataReserve := 36, 0, 0
+	if flags&0x4 != 0 {
+		if len(cab) < 40 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		headerReserve := int(le.Uint16(cab[36:]))
+		folderReserve, dataReserve = int(cab[38]), int(cab[39])
+		offset = 40 + headerReserve
+	}
+
+	// Find the file, then decompress its folder up to the end of the file
+	for i := 0; i < files; i++ {
+		if filesOffset+16 > len(cab) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		size, start, folder := int(le.Uint32(cab[filesOffset:])), int(le.Uint32(cab[filesOffset+4:])), int(le.Uint16(cab[filesOffset+8:]))
+		end := bytes.IndexByte(cab[filesOffset+16:], 0)
+		if end < 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		fileName := string(cab[filesOffset+16 : filesOffset+16+end])
+		filesOffset += 16 + end + 1
+		if fileName != name {
+			continue
+		}
+		if folder >= folders {
+			return nil, fmt.Errorf("file %s is in a continued folder", name)
+		}
+
+		entry := offset + folder*(8+folderReserve)
+		if entry+8 > len(cab) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		dataOffset, blocks, compression := int(le.Uint32(cab[entry:])), int(le.Uint16(cab[entry+4:])), le.Uint16(cab[entry+6:])&0xf
+		content, err := readCabinetFolder(cab, dataOffset, blocks, dataReserve, compression, start+size)
+		if err != nil {
+			return nil, err
+		}
+		return content[start : start+size], nil
+	}
+	return nil, fmt.Errorf("file %s not found", name)
+}
+
+// readCabinetFolder decompresses the data blocks of a cabinet folder until
+// limit bytes are available.
+func readCabinetFolder(cab []byte, offset, blocks, reserve int, compression uint16, limit int) ([]byte, error) {
+	var out []byte
+	for i := 0; i < blocks && len(out) < limit; i++ {
+		if offset+8+reserve > len(cab) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		compressed, uncompressed := int(binary.LittleEndian.Uint16(cab[offset+4:])), int(binary.LittleEndian.Uint16(cab[offset+6:]))
+		offset += 8 + reserve
+		if offset+compressed > len(cab) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		block := cab[offset : offset+compressed]
+		offset += compressed
+
+		switch compression {
+		case cabCompressNone:
+			out = append(out, block...)
+		case cabCompressMSZIP:
+			// Each block is a deflate stream after a "CK" signature, with
+			// the previous 32KB of output as its dictionary
+			if !bytes.HasPrefix(block, []byte("CK")) {
+				return nil, errors.New("invalid MSZIP block")
+			}
+			dict := out[max(0, len(out)-32*1024):]
+			r := flate.NewReaderDict(bytes.NewReader(block[2:]), dict)
+			decoded, err := io.ReadAll(io.LimitReader(r, int64(uncompressed)))
+			_ = r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("invalid MSZIP block: %w", err)
+			}
+			out = append(out, decoded...)
+		default:
+			return nil, fmt.Errorf("unsupported cabinet compression %d", compression)
+		}
+	}
+	if len(out) < limit {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return out, nil
+}
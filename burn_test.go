@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+const testBurnManifest = `<?xml version="1.0" encoding="utf-8"?>
+<BurnManifest xmlns="http://wixtoolset.org/schemas/v4/2008/Burn">
+  <RelatedBundle Id="{fedcba98-7654-3210-fedc-ba9876543210}" Action="Detect"/>
+  <RelatedBundle Id="{01234567-89ab-cdef-0123-456789abcdef}" Action="Upgrade"/>
+</BurnManifest>`
+
+// testBundleCode is the bundle code of testBurnBundle, in its binary layout.
+var testBundleCode = []byte{0x67, 0x45, 0x23, 0x01, 0xab, 0x89, 0xef, 0xcd, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88}
+
+// testCabinet returns a single-folder cabinet holding files, with MSZIP
+// compression if compress is set.
+func testCabinet(t *testing.T, compress bool, files map[string]string) []byte {
+	t.Helper()
+	var content []byte
+	var entries []byte
+	for name, data := range files {
+		entries = binary.LittleEndian.AppendUint32(entries, uint32(len(data)))
+		entries = binary.LittleEndian.AppendUint32(entries, uint32(len(content)))
+		entries = append(entries, make([]byte, 8)...)
+		entries = append(append(entries, name...), 0)
+		content = append(content, data...)
+	}
+
+	block := content
+	compression := uint16(cabCompressNone)
+	if compress {
+		var buf bytes.Buffer
+		buf.WriteString("CK")
+		w, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write(content)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		block, compression = buf.Bytes(), cabCompressMSZIP
+	}
+
+	filesOffset := 36 + 8
+	dataOffset := filesOffset + len(entries)
+	var cab []byte
+	cab = append(cab, "MSCF"...)
+	cab = append(cab, make([]byte, 12)...)
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(filesOffset))
+	cab = append(cab, make([]byte, 6)...)
+	cab = binary.LittleEndian.AppendUint16(cab, 1)
+	cab = binary.LittleEndian.AppendUint16(cab, uint16(len(files)))
+	cab = append(cab, make([]byte, 6)...)
+	cab = binary.LittleEndian.AppendUint32(cab, uint32(dataOffset))
+	cab = binary.LittleEndian.AppendUint16(cab, 1)
+	cab = binary.LittleEndian.AppendUint16(cab, compression)
+	cab = append(cab, entries...)
+	cab = append(cab, make([]byte, 4)...)
+	cab = binary.LittleEndian.AppendUint16(cab, uint16(len(block)))
+	cab = binary.LittleEndian.AppendUint16(cab, uint16(len(content)))
+	return append(cab, block...)
+}
+
+// testBurnBundle returns a Burn bundle whose UX container holds manifest.
+func testBurnBundle(t *testing.T, manifest string) []byte {
+	t.Helper()
+	cab := testCabinet(t, true, map[string]string{"u0": "ux", burnManifestFile: manifest})
+
+	section := func(stubSize int) []byte {
+		var b []byte
+		b = binary.LittleEndian.AppendUint32(b, burnSectionMagic)
+		b = binary.LittleEndian.AppendUint32(b, 2)
+		b = append(b, testBundleCode...)
+		b = binary.LittleEndian.AppendUint32(b, uint32(stubSize))
+		b = append(b, make([]byte, 16)...)
+		b = binary.LittleEndian.AppendUint32(b, 1)
+		return binary.LittleEndian.AppendUint32(b, uint32(len(cab)))
+	}
+	stub := testPEFile(t, testSection{".text", []byte{0xc3}}, testSection{".wixburn", section(0)})
+	stub = testPEFile(t, testSection{".text", []byte{0xc3}}, testSection{".wixburn", section(len(stub))})
+	return append(stub, cab...)
+}
+
+func TestReadCabinetFile(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		cab := testCabinet(t, compress, map[string]string{"a": "first file", "b": strings.Repeat("second file ", 100)})
+		content, err := readCabinetFile(cab, "b")
+		if err != nil {
+			t.Fatalf("compress=%v: unexpected error: %v", compress, err)
+		}
+		if string(content) != strings.Repeat("second file ", 100) {
+			t.Errorf("compress=%v: unexpected content %q", compress, content)
+		}
+		if _, err := readCabinetFile(cab, "c"); err == nil {
+			t.Errorf("compress=%v: expected an error for a missing file", compress)
+		}
+	}
+	if _, err := readCabinetFile([]byte("not a cabinet"), "a"); err == nil {
+		t.Error("expected an error for a file that is not a cabinet")
+	}
+}
+
+func TestReadBurnBundle(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	bundle, err := readBurnBundle(write("bundle.exe", testBurnBundle(t, testBurnManifest)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := burnBundle{
+		BundleCode:  "{01234567-89AB-CDEF-1122-334455667788}",
+		UpgradeCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}",
+	}
+	if *bundle != want {
+		t.Errorf("got %+v, want %+v", *bundle, want)
+	}
+
+	// WiX v4 manifests name the code Code
+	bundle, err = readBurnBundle(write("v4.exe", testBurnBundle(t, `<BurnManifest><RelatedBundle Code="{01234567-89AB-CDEF-0123-456789ABCDEF}" Action="upgrade"/></BurnManifest>`)))
+	if err != nil || bundle.UpgradeCode != want.UpgradeCode {
+		t.Errorf("expected the WiX v4 upgrade code, got %+v, %v", bundle, err)
+	}
+
+	// A UX container size beyond the end of the file is not allocated
+	truncated := testBurnBundle(t, testBurnManifest)
+	if _, err := readBurnBundle(write("truncated.exe", truncated[:len(truncated)-1])); err == nil || !strings.Contains(err.Error(), "out of bounds") {
+		t.Errorf("expected an out of bounds UX container, got: %v", err)
+	}
+
+	if _, err := readBurnBundle(write("setup.exe", testPEFile(t, testSection{".text", []byte{0xc3}}))); !errors.Is(err, errNotBurnBundle) {
+		t.Errorf("expected errNotBurnBundle for an executable without a Burn section, got: %v", err)
+	}
+	if _, err := readBurnBundle(write("setup.txt", []byte("installer"))); !errors.Is(err, errNotBurnBundle) {
+		t.Errorf("expected errNotBurnBundle for a file that is not an executable, got: %v", err)
+	}
+}
+
+func TestBurnInstaller(t *testing.T) {
+	bundle := &burnBundle{BundleCode: "{BUNDLE}", UpgradeCode: "{UPGRADE}"}
+	logger := slog.New(slog.DiscardHandler)
+
+	got := burnInstaller(InstallerConfig{Type: "exe"}, bundle, logger)
+	if got.Type != "burn" || got.ProductCode != "{BUNDLE}" || got.UpgradeCode != "{UPGRADE}" {
+		t.Errorf("unexpected installer: %+v", got)
+	}
+
+	got = burnInstaller(InstallerConfig{Type: "burn", ProductCode: "{PRODUCT}", UpgradeCode: "{OTHER}"}, bundle, logger)
+	if got.ProductCode != "{PRODUCT}" || got.UpgradeCode != "{OTHER}" {
+		t.Errorf("expected configured codes to take precedence, got: %+v", got)
+	}
+}
+
+func TestExecuteBurnBundle(t *testing.T) {
+	installer := testBurnBundle(t, testBurnManifest)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(installer)
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	installerCfg := cfg["installers"].([]any)[0].(map[string]any)
+	installerCfg["url"] = server.URL + "/bundle.exe"
+	installerCfg["type"] = "exe"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"InstallerType: burn",
+		"ProductCode: '{01234567-89AB-CDEF-1122-334455667788}'",
+		"UpgradeCode: '{01234567-89AB-CDEF-0123-456789ABCDEF}'",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in the installer manifest, got:\n%s", want, content)
+		}
+	}
+}
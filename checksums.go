@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ChecksumsConfig configures installer checksums computed by an earlier
+// pipeline step, which save downloading the installers to hash them.
+type ChecksumsConfig struct {
+	// File is a checksums file in sha256sum format, such as the one
+	// written by GoReleaser, listing installers by file name.
+	File string `json:"file"`
+	// Verify downloads and hashes installers with a known checksum anyway
+	// and fails if the hashes differ.
+	Verify bool `json:"verify"`
+}
+
+// sha256Pattern matches a hex-encoded SHA256.
+var sha256Pattern = regexp.MustCompile(`^[0-9A-Fa-f]{64}$`)
+
+// bsdChecksumLine matches a line of a BSD-style checksums file such as
+// "SHA256 (myapp.msi) = <hash>".
+var bsdChecksumLine = regexp.MustCompile(`^SHA256 \((.+)\) = ([0-9A-Fa-f]{64})$`)
+
+// readChecksumsFile reads a checksums file into a map of file names to
+// uppercase SHA256s. Lines are either "<hash>  <file>", with an optional "*"
+// before binary file names, or BSD-style "SHA256 (<file>) = <hash>"; blank
+// lines and # comments are skipped.
+func readChecksumsFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var hash, name string
+		if match := bsdChecksumLine.FindStringSubmatch(text); match != nil {
+			name, hash = match[1], match[2]
+		} else {
+			var ok bool
+			hash, name, ok = strings.Cut(text, " ")
+			name = strings.TrimPrefix(strings.TrimSpace(name), "*")
+			if !ok || name == "" || !sha256Pattern.MatchString(hash) {
+				return nil, fmt.Errorf("%s line %d is not a SHA256 checksum line", file, line)
+			}
+		}
+		checksums[path.Base(name)] = strings.ToUpper(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums file: %w", err)
+	}
+	return checksums, nil
+}
+
+// knownChecksum returns the checksum of an installer computed before the
+// plugin ran: the digest GitHub published for its release asset, or its
+// entry in the checksums file. It also returns where the checksum comes
+// from, or "" if there is none.
+func knownChecksum(installer InstallerConfig, checksums map[string]string) (hash, source string) {
+	if installer.sha256 != "" {
+		return installer.sha256, "release asset digest"
+	}
+	u, err := url.Parse(installer.URL)
+	if err != nil {
+		return "", ""
+	}
+	if hash, ok := checksums[path.Base(u.Path)]; ok {
+		return hash, "checksums file"
+	}
+	return "", ""
+}
+
+// needsInstallerFile reports whether an installer must be downloaded even
+// if its checksum is known: to read it, to verify its attestation, to scan
+// it or to keep it in the download cache.
+func needsInstallerFile(cfg *Config, installer InstallerConfig) bool {
+	return readsInstallerFile(installer) || cfg.Attestation.Enabled || cfg.Scan.Scanner != "" || cfg.Download.CacheDir != ""
+}
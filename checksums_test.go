@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestReadChecksumsFile(t *testing.T) {
+	const hash = "3a2f0c7d1e5b4a69c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5"
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "sha256sum",
+			content:  "# release checksums\n" + hash + "  myapp-1.2.3-x64.msi\n\n" + hash + " *dist/myapp-1.2.3.zip\n",
+			expected: map[string]string{"myapp-1.2.3-x64.msi": strings.ToUpper(hash), "myapp-1.2.3.zip": strings.ToUpper(hash)},
+		},
+		{
+			name:     "bsd",
+			content:  "SHA256 (myapp setup.exe) = " + hash + "\n",
+			expected: map[string]string{"myapp setup.exe": strings.ToUpper(hash)},
+		},
+		{
+			name:    "md5",
+			content: "d41d8cd98f00b204e9800998ecf8427e  myapp.msi\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing file name",
+			content: hash + "\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "checksums.txt")
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			checksums, err := readChecksumsFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readChecksumsFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(checksums) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, checksums)
+			}
+			for name, expected := range tt.expected {
+				if checksums[name] != expected {
+					t.Errorf("%s: expected '%s', got '%s'", name, expected, checksums[name])
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteReusesChecksums(t *testing.T) {
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	execute := func(checksum string, verify bool) *plugin.ExecuteResponse {
+		t.Helper()
+		file := filepath.Join(t.TempDir(), "checksums.txt")
+		if err := os.WriteFile(file, []byte(checksum+"  app-1.2.3.msi\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := validTestConfig()
+		cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+		cfg["allow_insecure_urls"] = true
+		cfg["output_dir"] = t.TempDir()
+		cfg["pull_request"] = map[string]any{"enabled": false}
+		cfg["checksums"] = map[string]any{"file": file, "verify": verify}
+		resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  cfg,
+			Context: plugin.ReleaseContext{Version: "1.2.3"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	checksum := installerhash.FromBytes([]byte("installer"))
+	resp := execute(strings.ToLower(checksum), false)
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if downloads.Load() != 0 {
+		t.Errorf("expected the installer not to be downloaded, got %d downloads", downloads.Load())
+	}
+	hashes, _ := resp.Outputs["installer_hashes"].(map[string]any)
+	if entry, _ := hashes[server.URL+"/app-1.2.3.msi"].(map[string]any); entry["sha256"] != checksum {
+		t.Errorf("expected the reused checksum in installer_hashes, got %v", resp.Outputs["installer_hashes"])
+	}
+
+	resp = execute(checksum, true)
+	if !resp.Success || downloads.Load() != 1 {
+		t.Fatalf("expected a verified download, got %d downloads: %s", downloads.Load(), resp.Message)
+	}
+
+	resp = execute(strings.Repeat("0", 64), true)
+	if resp.Success || !strings.Contains(resp.Message, "does not match the checksums file checksum") {
+		t.Errorf("expected a checksum mismatch, got: %s", resp.Message)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+	"gopkg.in/yaml.v3"
+)
+
+const cliUsage = `Usage: plugin-winget <command> --config <file> [flags]
+       plugin-winget import [--manifests <dir> | --package <id> [--version <version>]]
+                            [--wingetcreate-settings <file>]
+
+Commands:
+  generate  Generate and validate manifests without submitting them
+  submit    Generate manifests and submit them with the configured backend
+  validate  Validate the configuration
+  track     Report the state of the version's pull request, waiting for
+            it to be merged or closed if track.timeout is set
+  backfill  Submit the past versions listed in backfill.versions or
+            --versions, or the latest backfill.releases GitHub releases
+  status    List the open winget-pkgs pull requests of the package, with
+            their labels and ages
+  verify    Regenerate the manifests of a published version and compare
+            them byte for byte with winget-pkgs, printing any drift
+  reconcile Compare the merged manifests of a version with the
+            configuration, printing the configuration changes that keep
+            moderator edits in later releases
+  preview   Print the manifests and submission plan of a version as JSON
+            without submitting or writing anything
+  import    Print configuration converted from existing manifests, the
+            manifests of a package published in winget-pkgs or
+            wingetcreate settings
+
+Flags:
+`
+
+// cliCommands lists the commands of the standalone CLI. Any other invocation
+// serves the plugin to the Relicta host.
+var cliCommands = map[string]bool{
+	"generate":  true,
+	"submit":    true,
+	"validate":  true,
+	"track":     true,
+	"backfill":  true,
+	"status":    true,
+	"verify":    true,
+	"reconcile": true,
+	"preview":   true,
+	"import":    true,
+}
+
+// isCLICommand reports whether args invoke the standalone CLI.
+func isCLICommand(args []string) bool {
+	return len(args) > 0 && cliCommands[args[0]]
+}
+
+// runCLI runs a standalone CLI command through the same code paths as the
+// plugin host and returns the process exit code.
+func runCLI(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	command := args[0]
+	fs := newCLIFlagSet(command, stderr)
+	if command == "import" {
+		return runImport(ctx, fs, args[1:], stdout, stderr)
+	}
+
+	configPath := fs.String("config", "", "plugin configuration file (YAML or JSON)")
+	version := fs.String("version", "", "version to publish (required for generate, submit, track, verify, reconcile and preview)")
+	tag := fs.String("tag", "", "release tag used to resolve asset installers (default v<version>)")
+	repo := fs.String("repo", "", "repository owner/name used to resolve asset installers")
+	outputDir := fs.String("output-dir", "", "directory to write manifests to, overriding output_dir")
+	versions := fs.String("versions", "", "comma-separated versions to backfill, overriding backfill.versions")
+	dryRun := fs.Bool("dry-run", false, "generate manifests without submitting them")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if *configPath == "" {
+		_, _ = fmt.Fprintln(stderr, "--config is required")
+		return 2
+	}
+	if command != "validate" && command != "backfill" && command != "status" && *version == "" {
+		_, _ = fmt.Fprintln(stderr, "--version is required")
+		return 2
+	}
+
+	config, err := readConfigFile(*configPath)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *outputDir != "" {
+		config["output_dir"] = *outputDir
+	}
+	if *versions != "" {
+		var list []any
+		for _, v := range strings.Split(*versions, ",") {
+			list = append(list, strings.TrimSpace(v))
+		}
+		backfill, _ := config["backfill"].(map[string]any)
+		if backfill == nil {
+			backfill = map[string]any{}
+		}
+		backfill["versions"] = list
+		delete(backfill, "releases")
+		config["backfill"] = backfill
+	}
+	hook := plugin.HookPostPublish
+	if command == "track" {
+		hook = plugin.HookOnSuccess
+		track, _ := config["track"].(map[string]any)
+		if track == nil {
+			track = map[string]any{}
+		}
+		track["enabled"] = true
+		config["track"] = track
+	}
+
+	p := &WinGetPlugin{}
+	validation, err := p.Validate(ctx, config)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, e := range validation.Errors {
+		level := e.Code
+		if level == "" {
+			level = "error"
+		}
+		_, _ = fmt.Fprintf(stderr, "%s: %s: %s\n", level, e.Field, e.Message)
+	}
+	if !validation.Valid {
+		_, _ = fmt.Fprintln(stderr, "Configuration is invalid")
+		return 1
+	}
+	if command == "validate" {
+		_, _ = fmt.Fprintln(stdout, "Configuration is valid")
+		return 0
+	}
+
+	releaseCtx := plugin.ReleaseContext{
+		Version: *version,
+		TagName: *tag,
+	}
+	if releaseCtx.TagName == "" {
+		releaseCtx.TagName = "v" + strings.TrimPrefix(*version, "v")
+	}
+	if *repo != "" {
+		owner, name, ok := strings.Cut(*repo, "/")
+		if !ok || owner == "" || name == "" {
+			_, _ = fmt.Fprintf(stderr, "invalid --repo %q, expected owner/name\n", *repo)
+			return 2
+		}
+		releaseCtx.RepositoryOwner, releaseCtx.RepositoryName = owner, name
+	}
+
+	req := plugin.ExecuteRequest{
+		Hook:    hook,
+		Config:  config,
+		Context: releaseCtx,
+		DryRun:  command == "generate" || *dryRun,
+	}
+	var resp *plugin.ExecuteResponse
+	switch command {
+	case "backfill":
+		resp, err = p.Backfill(ctx, req)
+	case "status":
+		resp, err = p.Status(ctx, req)
+	case "verify":
+		resp, err = p.Verify(ctx, req)
+	case "reconcile":
+		resp, err = p.Reconcile(ctx, req)
+	case "preview":
+		resp, err = p.Preview(ctx, req)
+	default:
+		resp, err = p.Execute(ctx, req)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if results, ok := resp.Outputs["backfill"].([]any); ok {
+		for _, r := range results {
+			result := r.(map[string]any)
+			_, _ = fmt.Fprintf(stdout, "%s: %s\n", result["version"], result["message"])
+		}
+	}
+	if pulls, ok := resp.Outputs["pull_requests"].([]any); ok {
+		for _, pr := range pulls {
+			pull := pr.(map[string]any)
+			_, _ = fmt.Fprintf(stdout, "#%d %s (%s) %v %s\n", pull["number"], pull["title"], pull["age"], pull["labels"], pull["url"])
+		}
+	}
+	if drift, ok := resp.Outputs["manifest_drift"].(string); ok {
+		_, _ = fmt.Fprint(stdout, drift)
+	}
+	if suggestions, ok := resp.Outputs["config_suggestions"].([]any); ok {
+		for _, s := range suggestions {
+			suggestion := s.(map[string]any)
+			_, _ = fmt.Fprintln(stdout, configSuggestion{Field: suggestion["field"].(string), Current: suggestion["current"], Suggested: suggestion["suggested"]})
+		}
+	}
+	if !resp.Success {
+		_, _ = fmt.Fprintln(stderr, resp.Message)
+		return 1
+	}
+	if preview, ok := resp.Outputs["preview"]; ok {
+		data, err := json.MarshalIndent(preview, "", "  ")
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		_, _ = fmt.Fprintln(stdout, string(data))
+		return 0
+	}
+
+	_, _ = fmt.Fprintln(stdout, resp.Message)
+	if dir, ok := resp.Outputs["manifest_dir"].(string); ok {
+		_, _ = fmt.Fprintf(stdout, "Manifests: %s\n", dir)
+	}
+	return 0
+}
+
+// newCLIFlagSet creates the flag set of a CLI command.
+func newCLIFlagSet(command string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("plugin-winget "+command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(stderr, cliUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// runImport prints plugin configuration converted from an existing
+// winget-pkgs manifest directory or published package and/or a wingetcreate
+// settings file.
+func runImport(ctx context.Context, fs *flag.FlagSet, args []string, stdout, stderr io.Writer) int {
+	manifestDir := fs.String("manifests", "", "winget-pkgs version directory to import")
+	packageID := fs.String("package", "", "package identifier whose manifests to import from winget-pkgs")
+	version := fs.String("version", "", "published version to import with --package (default latest)")
+	settingsPath := fs.String("wingetcreate-settings", "", "wingetcreate settings.json file to import")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *manifestDir == "" && *packageID == "" && *settingsPath == "" {
+		_, _ = fmt.Fprintln(stderr, "--manifests, --package or --wingetcreate-settings is required")
+		return 2
+	}
+	if *manifestDir != "" && *packageID != "" {
+		_, _ = fmt.Fprintln(stderr, "only one of --manifests and --package may be set")
+		return 2
+	}
+
+	config := make(map[string]any)
+	if *settingsPath != "" {
+		settings, warnings, err := importWingetcreateSettings(*settingsPath)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		for _, warning := range warnings {
+			_, _ = fmt.Fprintf(stderr, "warning: %s\n", warning)
+		}
+		config = mergeConfig(config, settings)
+	}
+	if *manifestDir != "" {
+		imported, err := importManifestDir(*manifestDir)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		config = mergeConfig(config, imported)
+	}
+	if *packageID != "" {
+		// The GitHub token is optional but raises the API rate limit
+		cfg, _ := decodePluginConfig(map[string]any{})
+		imported, importedVersion, warnings, err := importPublishedPackage(ctx, newGitHubClient(ctx, cfg, "", slog.Default()), *packageID, *version)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		for _, warning := range warnings {
+			_, _ = fmt.Fprintf(stderr, "warning: %s\n", warning)
+		}
+		_, _ = fmt.Fprintf(stderr, "Imported %s version %s from winget-pkgs\n", *packageID, importedVersion)
+		config = mergeConfig(config, imported)
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	_, _ = stdout.Write(out)
+	return 0
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cliTestConfig = `package_id: MyOrg.MyApp
+github_token: test-token
+min_installers: 1
+installers:
+  - url: https://example.com/app-{{.Version}}.msi
+    architecture: x64
+    type: msi
+metadata:
+  publisher: My Org
+  name: My App
+  short_description: A test app
+  license: MIT
+`
+
+func writeCLIConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "winget.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsCLICommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+	}{
+		{nil, false},
+		{[]string{"generate"}, true},
+		{[]string{"submit", "--config", "x"}, true},
+		{[]string{"validate"}, true},
+		{[]string{"track"}, true},
+		{[]string{"backfill"}, true},
+		{[]string{"preview"}, true},
+		{[]string{"serve"}, false},
+	}
+
+	for _, tt := range tests {
+		if result := isCLICommand(tt.args); result != tt.expected {
+			t.Errorf("isCLICommand(%v) = %v, expected %v", tt.args, result, tt.expected)
+		}
+	}
+}
+
+func TestRunCLIUsageErrors(t *testing.T) {
+	config := writeCLIConfig(t, cliTestConfig)
+
+	tests := []struct {
+		name     string
+		args     []string
+		contains string
+	}{
+		{"missing config", []string{"validate"}, "--config is required"},
+		{"missing version", []string{"generate", "--config", config}, "--version is required"},
+		{"invalid repo", []string{"generate", "--config", config, "--version", "1.0.0", "--repo", "myorg"}, "invalid --repo"},
+		{"unknown flag", []string{"validate", "--bogus"}, "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runCLI(context.Background(), tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("expected exit code 2, got %d", code)
+			}
+			if !strings.Contains(stderr.String(), tt.contains) {
+				t.Errorf("expected stderr containing '%s', got '%s'", tt.contains, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunCLIValidate(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runCLI(context.Background(), []string{"validate", "--config", writeCLIConfig(t, cliTestConfig)}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Configuration is valid") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	invalid := writeCLIConfig(t, "package_id: MyApp\n")
+	if code := runCLI(context.Background(), []string{"validate", "--config", invalid}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "error: package_id:") {
+		t.Errorf("expected package_id error, got: %s", stderr.String())
+	}
+}
+
+func TestRunCLIGenerate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	outputDir := t.TempDir()
+	args := []string{"generate", "--config", writeCLIConfig(t, cliTestConfig), "--version", "1.2.3", "--output-dir", outputDir}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	dir := filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3")
+	if !strings.Contains(stdout.String(), "Manifests: "+dir) {
+		t.Errorf("expected manifest directory in output, got: %s", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "MyOrg.MyApp.installer.yaml")); err != nil {
+		t.Errorf("expected installer manifest to be written: %v", err)
+	}
+}
+
+func TestRunCLIPreview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	args := []string{"preview", "--config", writeCLIConfig(t, cliTestConfig), "--version", "1.2.3"}
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	var preview map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &preview); err != nil {
+		t.Fatalf("expected JSON output, got %s: %v", stdout.String(), err)
+	}
+	if preview["version"] != "1.2.3" || len(preview["manifests"].([]any)) != 3 {
+		t.Errorf("unexpected preview %v", preview)
+	}
+}
+
+func TestRunCLIImport(t *testing.T) {
+	dir, err := validTestManifests(t).WriteTo(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"import", "--manifests", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	config, err := readConfigFile(writeCLIConfig(t, stdout.String()))
+	if err != nil {
+		t.Fatalf("failed to read imported config: %v", err)
+	}
+	if config["package_id"] != "MyOrg.MyApp" {
+		t.Errorf("unexpected imported config: %s", stdout.String())
+	}
+
+	stderr.Reset()
+	if code := runCLI(context.Background(), []string{"import"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+	if code := runCLI(context.Background(), []string{"import", "--manifests", dir, "--package", "MyOrg.MyApp"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunCLIImportPackage(t *testing.T) {
+	newWingetPkgsServer(t, "MyOrg.MyApp", map[string]map[string]string{"1.0.0": manifestFiles(t, validTestManifests(t))})
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), []string{"import", "--package", "MyOrg.MyApp"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "Imported MyOrg.MyApp version 1.0.0") {
+		t.Errorf("expected the imported version, got: %s", stderr.String())
+	}
+	config, err := readConfigFile(writeCLIConfig(t, stdout.String()))
+	if err != nil {
+		t.Fatalf("failed to read imported config: %v", err)
+	}
+	if config["package_id"] != "MyOrg.MyApp" {
+		t.Errorf("unexpected imported config: %s", stdout.String())
+	}
+}
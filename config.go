@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// configDecoder decodes raw plugin configuration into typed structs using their
+// json tags. Unknown keys and type mismatches are recorded as problems instead
+// of being silently ignored.
+type configDecoder struct {
+	problems []fieldError
+}
+
+// decodeConfig decodes raw configuration into out, which must be a pointer to
+// a struct pre-populated with defaults. It returns every problem found.
+func decodeConfig(raw map[string]any, out any) []fieldError {
+	d := &configDecoder{}
+	d.decodeStruct("", raw, reflect.ValueOf(out).Elem())
+	return d.problems
+}
+
+func (d *configDecoder) addProblem(path, message string) {
+	d.problems = append(d.problems, fieldError{Field: path, Message: message})
+}
+
+func (d *configDecoder) decode(path string, raw any, v reflect.Value) {
+	if raw == nil {
+		return
+	}
+
+	if v.Type() == durationType {
+		duration, ok := parseDuration(raw)
+		if !ok {
+			d.addProblem(path, fmt.Sprintf("expected a duration such as \"5m\" or a number of seconds, got %s", describeValue(raw)))
+			return
+		}
+		v.SetInt(int64(duration))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			d.addProblem(path, fmt.Sprintf("expected a string, got %s", describeValue(raw)))
+			return
+		}
+		// Empty strings keep the default, matching environment fallbacks
+		if s != "" {
+			v.SetString(s)
+		}
+
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			v.SetBool(b)
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				d.addProblem(path, fmt.Sprintf("expected a boolean, got %s", describeValue(raw)))
+				return
+			}
+			v.SetBool(parsed)
+		default:
+			d.addProblem(path, fmt.Sprintf("expected a boolean, got %s", describeValue(raw)))
+		}
+
+	case reflect.Int, reflect.Int64:
+		switch n := raw.(type) {
+		case float64:
+			if n != float64(int64(n)) {
+				d.addProblem(path, fmt.Sprintf("expected an integer, got %v", n))
+				return
+			}
+			v.SetInt(int64(n))
+		case int:
+			v.SetInt(int64(n))
+		case string:
+			// Values expanded from ${VAR} arrive as strings; empty ones keep
+			// the default
+			if n == "" {
+				return
+			}
+			parsed, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				d.addProblem(path, fmt.Sprintf("expected an integer, got %s", describeValue(raw)))
+				return
+			}
+			v.SetInt(parsed)
+		default:
+			d.addProblem(path, fmt.Sprintf("expected an integer, got %s", describeValue(raw)))
+		}
+
+	case reflect.Slice:
+		items, ok := toSlice(raw)
+		if !ok {
+			d.addProblem(path, fmt.Sprintf("expected a list, got %s", describeValue(raw)))
+			return
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			d.decode(fmt.Sprintf("%s[%d]", path, i), item, slice.Index(i))
+		}
+		v.Set(slice)
+
+	case reflect.Map:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			d.addProblem(path, fmt.Sprintf("expected an object, got %s", describeValue(raw)))
+			return
+		}
+		result := reflect.MakeMapWithSize(v.Type(), len(m))
+		for _, key := range sortedKeys(m) {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			d.decode(joinPath(path, key), m[key], elem)
+			result.SetMapIndex(reflect.ValueOf(key), elem)
+		}
+		v.Set(result)
+
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			d.addProblem(path, fmt.Sprintf("expected an object, got %s", describeValue(raw)))
+			return
+		}
+		d.decodeStruct(path, m, v)
+
+	default:
+		d.addProblem(path, fmt.Sprintf("unsupported configuration type %s", v.Type()))
+	}
+}
+
+func (d *configDecoder) decodeStruct(path string, raw map[string]any, v reflect.Value) {
+	fields := structFields(v.Type())
+
+	for _, key := range sortedKeys(raw) {
+		index, ok := fields[key]
+		if !ok {
+			message := fmt.Sprintf("unknown configuration key %q", key)
+			if suggestion := suggestKey(key, fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			d.addProblem(joinPath(path, key), message)
+			continue
+		}
+		d.decode(joinPath(path, key), raw[key], v.Field(index))
+	}
+}
+
+// normalizeConfigKeys returns a copy of raw configuration for the struct type
+// t in which camelCase keys such as packageId are renamed to their snake_case
+// json tags and dotted keys such as metadata.publisher are expanded into
+// nested objects. Only keys of struct fields are rewritten; map values such as
+// installer switches keep their keys. Keys that resolve to the same setting
+// with different values are reported.
+func normalizeConfigKeys(path string, raw map[string]any, t reflect.Type) (map[string]any, []fieldError) {
+	fields := structFields(t)
+	normalized := make(map[string]any, len(raw))
+	var problems []fieldError
+
+	for _, key := range sortedKeys(raw) {
+		name, value := key, raw[key]
+		if _, ok := fields[name]; !ok {
+			if head, rest, dotted := strings.Cut(key, "."); dotted {
+				if index, ok := fields[fieldKey(head, fields)]; ok && t.Field(index).Type.Kind() == reflect.Struct {
+					name, value = fieldKey(head, fields), map[string]any{rest: value}
+				}
+			} else {
+				name = fieldKey(key, fields)
+			}
+		}
+
+		if index, ok := fields[name]; ok {
+			var fieldProblems []fieldError
+			value, fieldProblems = normalizeFieldKeys(joinPath(path, name), value, t.Field(index).Type)
+			problems = append(problems, fieldProblems...)
+		}
+		problems = append(problems, insertConfigKey(path, normalized, name, value)...)
+	}
+	return normalized, problems
+}
+
+// normalizeFieldKeys normalizes the keys of a raw value decoded into a field
+// of type t.
+func normalizeFieldKeys(path string, raw any, t reflect.Type) (any, []fieldError) {
+	switch {
+	case t.Kind() == reflect.Struct && t != durationType:
+		if m, ok := raw.(map[string]any); ok {
+			return normalizeConfigKeys(path, m, t)
+		}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+		if items, ok := raw.([]any); ok {
+			normalized := make([]any, len(items))
+			var problems []fieldError
+			for i, item := range items {
+				var itemProblems []fieldError
+				normalized[i], itemProblems = normalizeFieldKeys(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())
+				problems = append(problems, itemProblems...)
+			}
+			return normalized, problems
+		}
+	}
+	return raw, nil
+}
+
+// insertConfigKey sets key in a normalized map, merging objects that were
+// given under several spellings and reporting conflicting values.
+func insertConfigKey(path string, m map[string]any, key string, value any) []fieldError {
+	existing, ok := m[key]
+	if !ok {
+		m[key] = value
+		return nil
+	}
+
+	existingMap, existingOK := existing.(map[string]any)
+	valueMap, valueOK := value.(map[string]any)
+	if !existingOK || !valueOK {
+		if reflect.DeepEqual(existing, value) {
+			return nil
+		}
+		return []fieldError{{joinPath(path, key), "key is set more than once with different values"}}
+	}
+
+	merged := make(map[string]any, len(existingMap)+len(valueMap))
+	for k, v := range existingMap {
+		merged[k] = v
+	}
+	var problems []fieldError
+	for _, k := range sortedKeys(valueMap) {
+		problems = append(problems, insertConfigKey(joinPath(path, key), merged, k, valueMap[k])...)
+	}
+	m[key] = merged
+	return problems
+}
+
+// fieldKey returns the json tag matching a camelCase key, or key itself.
+func fieldKey(key string, fields map[string]int) string {
+	if _, ok := fields[key]; ok {
+		return key
+	}
+	if snake := snakeCase(key); snake != key {
+		if _, ok := fields[snake]; ok {
+			return snake
+		}
+	}
+	return key
+}
+
+// snakeCase converts a camelCase key to snake_case, keeping initialisms
+// together: packageId and packageID both become package_id.
+func snakeCase(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// structFields maps json tag names to field indexes.
+func structFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag != "" && tag != "-" {
+			fields[tag] = i
+		}
+	}
+	return fields
+}
+
+// suggestKey returns the known key closest to key, if it is a likely typo.
+func suggestKey(key string, fields map[string]int) string {
+	best, bestDistance := "", -1
+	for candidate := range fields {
+		distance := levenshtein(strings.ToLower(key), candidate)
+		if bestDistance == -1 || distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	// Allow roughly one edit per three characters
+	if bestDistance >= 0 && bestDistance <= max(1, len(key)/3) {
+		return best
+	}
+	return ""
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// toSlice converts list values produced by JSON or YAML decoding.
+func toSlice(raw any) ([]any, bool) {
+	switch items := raw.(type) {
+	case []any:
+		return items, true
+	case []string:
+		result := make([]any, len(items))
+		for i, item := range items {
+			result[i] = item
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+// describeValue describes a raw configuration value for error messages.
+func describeValue(raw any) string {
+	switch v := raw.(type) {
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case float64, int, int64:
+		return fmt.Sprintf("number %v", v)
+	case []any, []string:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("%T", raw)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeConfigUnknownKeys(t *testing.T) {
+	raw := map[string]any{
+		"package_id": "MyOrg.MyApp",
+		"instalers":  []any{},
+		"metdata":    map[string]any{},
+		"metadata": map[string]any{
+			"publsher": "My Org",
+		},
+		"installers": []any{
+			map[string]any{"url": "https://example.com/app.msi", "architcture": "x64"},
+		},
+		"completely_unrelated": true,
+	}
+
+	cfg := defaultConfig()
+	problems := decodeConfig(raw, cfg)
+
+	expected := map[string]string{
+		"instalers":                 `did you mean "installers"?`,
+		"metdata":                   `did you mean "metadata"?`,
+		"metadata.publsher":         `did you mean "publisher"?`,
+		"installers[0].architcture": `did you mean "architecture"?`,
+		"completely_unrelated":      `unknown configuration key "completely_unrelated"`,
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+	for _, problem := range problems {
+		want, ok := expected[problem.Field]
+		if !ok {
+			t.Errorf("unexpected problem for '%s': %s", problem.Field, problem.Message)
+			continue
+		}
+		if !strings.Contains(problem.Message, want) {
+			t.Errorf("expected '%s' for '%s', got '%s'", want, problem.Field, problem.Message)
+		}
+		if problem.Field == "completely_unrelated" && strings.Contains(problem.Message, "did you mean") {
+			t.Error("unrelated key should not get a suggestion")
+		}
+	}
+
+	// Known keys are still decoded
+	if cfg.PackageID != "MyOrg.MyApp" || len(cfg.Installers) != 1 || cfg.Installers[0].URL != "https://example.com/app.msi" {
+		t.Errorf("known keys not decoded: %+v", cfg)
+	}
+}
+
+func TestDecodeConfigWrongTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      map[string]any
+		field    string
+		contains string
+	}{
+		{"string", map[string]any{"package_id": float64(42)}, "package_id", "expected a string, got number 42"},
+		{"boolean", map[string]any{"dry_run": "maybe"}, "dry_run", `expected a boolean, got string "maybe"`},
+		{"list", map[string]any{"installers": "https://example.com/app.msi"}, "installers", "expected a list"},
+		{"object", map[string]any{"metadata": []any{}}, "metadata", "expected an object, got a list"},
+		{"nested", map[string]any{"metadata": map[string]any{"tags": []any{"ok", true}}}, "metadata.tags[1]", "expected a string, got boolean true"},
+		{"map value", map[string]any{"installers": []any{map[string]any{"switches": map[string]any{"Silent": 1.0}}}}, "installers[0].switches.Silent", "expected a string"},
+		{"duration", map[string]any{"timeouts": map[string]any{"github": "soon"}}, "timeouts.github", "expected a duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := decodeConfig(tt.raw, defaultConfig())
+			if len(problems) != 1 {
+				t.Fatalf("expected 1 problem, got %v", problems)
+			}
+			if problems[0].Field != tt.field {
+				t.Errorf("expected field '%s', got '%s'", tt.field, problems[0].Field)
+			}
+			if !strings.Contains(problems[0].Message, tt.contains) {
+				t.Errorf("expected message containing '%s', got '%s'", tt.contains, problems[0].Message)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigDefaults(t *testing.T) {
+	cfg := defaultConfig()
+	problems := decodeConfig(map[string]any{
+		"validate":     "false",
+		"pull_request": map[string]any{"title": ""},
+		"timeouts":     map[string]any{"download": float64(30)},
+	}, cfg)
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	if cfg.Validate {
+		t.Error("expected string boolean to be decoded")
+	}
+	if cfg.PullRequest.Title != defaultConfig().PullRequest.Title {
+		t.Errorf("expected empty string to keep default title, got '%s'", cfg.PullRequest.Title)
+	}
+	if cfg.PullRequest.BaseBranch != "master" {
+		t.Errorf("expected default base branch, got '%s'", cfg.PullRequest.BaseBranch)
+	}
+	if cfg.Timeouts.Download != 30*time.Second {
+		t.Errorf("expected 30s download timeout, got %s", cfg.Timeouts.Download)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"installers", "instalers", 1},
+		{"metadata", "metdata", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if result := levenshtein(tt.a, tt.b); result != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, result, tt.expected)
+		}
+	}
+}
+
+func TestValidateReportsConfigProblems(t *testing.T) {
+	p := &WinGetPlugin{}
+	cfg := validTestConfig()
+	cfg["instalers"] = cfg["installers"]
+	delete(cfg, "installers")
+
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !hasValidationError(resp, "instalers") {
+		t.Errorf("expected unknown key error, got %v", resp.Errors)
+	}
+	for _, e := range resp.Errors {
+		if e.Field == "instalers" && !strings.Contains(e.Message, `did you mean "installers"?`) {
+			t.Errorf("expected suggestion, got '%s'", e.Message)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"packageId":        "package_id",
+		"packageID":        "package_id",
+		"shortDescription": "short_description",
+		"publisherURL":     "publisher_url",
+		"URLCheck":         "url_check",
+		"package_id":       "package_id",
+	}
+	for key, expected := range tests {
+		if result := snakeCase(key); result != expected {
+			t.Errorf("snakeCase(%q) = %q, expected %q", key, result, expected)
+		}
+	}
+}
+
+func TestDecodePluginConfigKeyStyles(t *testing.T) {
+	cfg, problems := decodePluginConfig(map[string]any{
+		"packageId":                 "MyOrg.MyApp",
+		"metadata.publisher":        "My Organization",
+		"metadata.shortDescription": "A useful application",
+		"metadata":                  map[string]any{"licenseUrl": "https://example.com/LICENSE"},
+		"pullRequest.baseBranch":    "main",
+		"installers": []any{map[string]any{
+			"url":          "https://example.com/app.exe",
+			"architecture": "x64",
+			"type":         "exe",
+			"switches":     map[string]any{"Silent": "/S"},
+		}},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	if cfg.PackageID != "MyOrg.MyApp" {
+		t.Errorf("expected package_id 'MyOrg.MyApp', got '%s'", cfg.PackageID)
+	}
+	if cfg.Metadata.Publisher != "My Organization" || cfg.Metadata.ShortDescription != "A useful application" || cfg.Metadata.LicenseURL != "https://example.com/LICENSE" {
+		t.Errorf("unexpected metadata: %+v", cfg.Metadata)
+	}
+	if cfg.PullRequest.BaseBranch != "main" {
+		t.Errorf("expected base branch 'main', got '%s'", cfg.PullRequest.BaseBranch)
+	}
+	if cfg.Installers[0].Switches["Silent"] != "/S" {
+		t.Errorf("expected switch keys to be kept, got %v", cfg.Installers[0].Switches)
+	}
+}
+
+func TestDecodePluginConfigKeyConflicts(t *testing.T) {
+	_, problems := decodePluginConfig(map[string]any{
+		"package_id":         "MyOrg.MyApp",
+		"packageId":          "MyOrg.Other",
+		"metadata.publisher": "My Organization",
+		"metadata":           map[string]any{"publisher": "Someone Else"},
+		"metadata.unknwn":    "value",
+	})
+
+	expected := map[string]bool{"package_id": true, "metadata.publisher": true, "metadata.unknwn": true}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), problems)
+	}
+	for _, problem := range problems {
+		if !expected[problem.Field] {
+			t.Errorf("unexpected problem for '%s': %s", problem.Field, problem.Message)
+		}
+	}
+}
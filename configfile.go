@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileKeys lists the keys a repository config file may set. Credentials
+// and publishing settings stay in the release configuration.
+var configFileKeys = map[string]bool{
+	"package_id": true,
+	"installers": true,
+	"metadata":   true,
+	"locales":    true,
+}
+
+// readConfigFile reads a configuration file. JSON files are read as YAML, of
+// which JSON is a subset.
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var config map[string]any
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if config == nil {
+		config = make(map[string]any)
+	}
+	return config, nil
+}
+
+// applyConfigFile merges the file named by the config_file key under the
+// inline configuration, so inline values take precedence. Values read from the
+// file are used as written, without ${VAR} expansion.
+func applyConfigFile(raw map[string]any) (map[string]any, []fieldError) {
+	path, ok := raw["config_file"].(string)
+	if !ok || path == "" {
+		return raw, nil
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		return raw, []fieldError{{"config_file", err.Error()}}
+	}
+
+	file, problems := normalizeConfigKeys("config_file", file, reflect.TypeOf(Config{}))
+	for _, key := range sortedKeys(file) {
+		if !configFileKeys[key] {
+			problems = append(problems, fieldError{"config_file", fmt.Sprintf(
+				"key %q is not allowed in %s, allowed keys: %s", key, path, strings.Join(allowedConfigFileKeys(), ", "))})
+			delete(file, key)
+		}
+	}
+
+	return mergeConfig(file, raw), problems
+}
+
+// allowedConfigFileKeys returns the keys a config file may set, sorted.
+func allowedConfigFileKeys() []string {
+	keys := make([]string, 0, len(configFileKeys))
+	for key := range configFileKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeConfig returns base with override applied on top. Nested maps are
+// merged key by key; any other override value, including lists, replaces the
+// base value. Empty strings and nulls are treated as unset, matching the
+// decoder. Neither input is modified.
+func mergeConfig(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		if value == nil || value == "" {
+			if _, ok := merged[key]; ok {
+				continue
+			}
+		}
+		baseMap, baseOK := merged[key].(map[string]any)
+		overrideMap, overrideOK := value.(map[string]any)
+		if baseOK && overrideOK {
+			merged[key] = mergeConfig(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "winget.json")
+	if err := os.WriteFile(path, []byte(`{"package_id": "MyOrg.MyApp", "metadata": {"name": "My App"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config["package_id"] != "MyOrg.MyApp" {
+		t.Errorf("unexpected config: %v", config)
+	}
+	if metadata, ok := config["metadata"].(map[string]any); !ok || metadata["name"] != "My App" {
+		t.Errorf("expected nested metadata map, got %v", config["metadata"])
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	base := map[string]any{
+		"package_id": "MyOrg.FromFile",
+		"installers": []any{"file"},
+		"metadata":   map[string]any{"name": "File Name", "publisher": "File Publisher"},
+	}
+	override := map[string]any{
+		"installers": []any{"inline"},
+		"metadata":   map[string]any{"name": "Inline Name", "publisher": ""},
+	}
+
+	merged := mergeConfig(base, override)
+	expected := map[string]any{
+		"package_id": "MyOrg.FromFile",
+		"installers": []any{"inline"},
+		"metadata":   map[string]any{"name": "Inline Name", "publisher": "File Publisher"},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("expected %v, got %v", expected, merged)
+	}
+	if base["metadata"].(map[string]any)["name"] != "File Name" {
+		t.Error("expected base to be left unmodified")
+	}
+}
+
+func TestApplyConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".winget.yaml")
+	content := `package_id: MyOrg.MyApp
+github_token: from-file
+metadata:
+  publisher: My Org
+  name: My App
+  short_description: A test app
+  license: MIT
+installers:
+  - url: https://example.com/app-{{.Version}}.msi
+    architecture: x64
+    type: msi
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, problems := applyConfigFile(map[string]any{
+		"config_file": path,
+		"metadata":    map[string]any{"name": "Inline App"},
+	})
+	if len(problems) != 1 || problems[0].Field != "config_file" || !strings.Contains(problems[0].Message, `"github_token"`) {
+		t.Errorf("expected github_token to be rejected, got %v", problems)
+	}
+	if _, ok := raw["github_token"]; ok {
+		t.Error("expected disallowed key to be dropped")
+	}
+
+	cfg, _ := decodePluginConfig(raw)
+	if cfg.PackageID != "MyOrg.MyApp" || cfg.Metadata.Publisher != "My Org" || len(cfg.Installers) != 1 {
+		t.Errorf("expected file values, got %+v", cfg)
+	}
+	if cfg.Metadata.Name != "Inline App" {
+		t.Errorf("expected inline name to win, got '%s'", cfg.Metadata.Name)
+	}
+}
+
+func TestValidateConfigFileMissing(t *testing.T) {
+	cfg := validTestConfig()
+	cfg["config_file"] = filepath.Join(t.TempDir(), "missing.yaml")
+
+	p := &WinGetPlugin{}
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Valid || !hasValidationError(resp, "config_file") {
+		t.Errorf("expected config_file error, got %v", resp.Errors)
+	}
+}
+
+func TestDecodePluginConfigDoesNotExpandConfigFileEnv(t *testing.T) {
+	t.Setenv("CI_SECRET", "hunter2")
+	t.Setenv("CI_FORK_OWNER", "my-bot")
+
+	path := filepath.Join(t.TempDir(), "winget.yaml")
+	content := "package_id: MyOrg.MyApp\nmetadata:\n  short_description: \"${CI_SECRET}\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, problems := decodePluginConfig(map[string]any{
+		"config_file":  path,
+		"pull_request": map[string]any{"fork_owner": "${CI_FORK_OWNER}"},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if cfg.Metadata.ShortDescription != "${CI_SECRET}" {
+		t.Errorf("expected the config file value to stay unexpanded, got '%s'", cfg.Metadata.ShortDescription)
+	}
+	if cfg.PullRequest.ForkOwner != "my-bot" {
+		t.Errorf("expected the inline value to be expanded, got '%s'", cfg.PullRequest.ForkOwner)
+	}
+}
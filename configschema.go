@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// configSchemaRequired lists the required keys of each configuration object,
+// by path. List items are addressed with "[]". The installer type is not
+// required since a profile may set it.
+var configSchemaRequired = map[string][]string{
+	"":         {"package_id", "installers", "metadata"},
+	"metadata": {"publisher", "name", "short_description", "license"},
+}
+
+// configSchemaEnums returns the allowed values of enumerated configuration
+// keys, by path.
+func configSchemaEnums() map[string][]string {
+	enums, _ := manifest.EnumsFor(manifest.SchemaVersion)
+	return map[string][]string{
+		"backend":                              {backendGitHub, backendREST, backendWingetcreate, backendKomac, backendAzureDevOps, backendGitLab},
+		"dry_run_hash":                         {dryRunHashPlaceholder, dryRunHashReal},
+		"profile":                              slices.Sorted(maps.Keys(configProfiles)),
+		"wingetcreate.mode":                    {wingetcreateModeSubmit, wingetcreateModeUpdate},
+		"pull_request.on_existing_branch":      {branchPolicyReuse, branchPolicyNew},
+		"pull_request.commit_strategy":         {commitStrategyAPI, commitStrategyGit},
+		"pull_request.title_preset":            {prTitleNewVersion, prTitleNewPackage, prTitleUpdate},
+		"attestation.verifier":                 {attestationVerifierAPI, attestationVerifierGH},
+		"scan.scanner":                         {scannerVirusTotal, scannerDefender},
+		"installers[].architecture":            enums.Architectures,
+		"installers[].architectures[]":         enums.Architectures,
+		"installers[].type":                    enums.InstallerTypes,
+		"installers[].scope":                   enums.Scopes,
+		"installers[].scopes[]":                enums.Scopes,
+		"installers[].repair_behavior":         enums.RepairBehaviors,
+		"installers[].unsupported_arguments[]": enums.UnsupportedArguments,
+	}
+}
+
+// configSchema returns the JSON Schema of the plugin configuration, derived
+// from the Config struct so it cannot drift from the decoder.
+var configSchema = sync.OnceValue(func() string {
+	schema := configSchemaFor("", reflect.TypeOf(Config{}), configSchemaEnums())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "winget plugin configuration"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+})
+
+// configSchemaFor returns the schema of a configuration value of type t.
+func configSchemaFor(path string, t reflect.Type, enums map[string][]string) map[string]any {
+	if t == durationType {
+		return map[string]any{"type": []string{"string", "number"}}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema := map[string]any{"type": "string"}
+		if values, ok := enums[path]; ok {
+			schema["enum"] = values
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": configSchemaFor(path+"[]", t.Elem(), enums)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": configSchemaFor(path+".*", t.Elem(), enums)}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for name, index := range structFields(t) {
+			properties[name] = configSchemaFor(strings.TrimPrefix(path+"."+name, "."), t.Field(index).Type, enums)
+		}
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if required, ok := configSchemaRequired[path]; ok {
+			schema["required"] = required
+		}
+		return schema
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// compileConfigSchema compiles the published configuration schema.
+func compileConfigSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(configSchema()))
+	if err != nil {
+		t.Fatalf("config schema is not valid JSON: %v", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("config.json", doc); err != nil {
+		t.Fatalf("failed to add config schema: %v", err)
+	}
+	schema, err := compiler.Compile("config.json")
+	if err != nil {
+		t.Fatalf("failed to compile config schema: %v", err)
+	}
+	return schema
+}
+
+// validateAgainstConfigSchema validates raw configuration after a JSON round
+// trip, as the host would see it.
+func validateAgainstConfigSchema(t *testing.T, schema *jsonschema.Schema, raw map[string]any) error {
+	t.Helper()
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	return schema.Validate(inst)
+}
+
+func TestConfigSchema(t *testing.T) {
+	schema := compileConfigSchema(t)
+
+	cfg := validTestConfig()
+	cfg["timeouts"] = map[string]any{"download": "30m", "github": 300}
+	cfg["installers"].([]any)[0].(map[string]any)["switches"] = map[string]any{"Silent": "/qn"}
+	if err := validateAgainstConfigSchema(t, schema, cfg); err != nil {
+		t.Errorf("expected valid test config to match the schema: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(map[string]any)
+	}{
+		{"unknown backend", func(cfg map[string]any) { cfg["backend"] = "ftp" }},
+		{"unknown architecture", func(cfg map[string]any) {
+			cfg["installers"].([]any)[0].(map[string]any)["architecture"] = "amd64"
+		}},
+		{"missing publisher", func(cfg map[string]any) { delete(cfg["metadata"].(map[string]any), "publisher") }},
+		{"unknown key", func(cfg map[string]any) { cfg["instalers"] = []any{} }},
+		{"wrong type", func(cfg map[string]any) { cfg["min_installers"] = "two" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			tt.modify(cfg)
+			if err := validateAgainstConfigSchema(t, schema, cfg); err == nil {
+				t.Error("expected schema violation")
+			}
+		})
+	}
+}
+
+func TestGetInfoConfigSchema(t *testing.T) {
+	info := (&WinGetPlugin{}).GetInfo()
+	if info.ConfigSchema != configSchema() {
+		t.Error("expected GetInfo to publish the config schema")
+	}
+}
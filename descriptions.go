@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxShortDescriptionLength is the maximum length of a ShortDescription.
+	maxShortDescriptionLength = 256
+	// maxDescriptionLength is the maximum length of a Description.
+	maxDescriptionLength = 10000
+	// minDescriptionLength is the minimum length of a ShortDescription or
+	// Description.
+	minDescriptionLength = 3
+)
+
+// validateDescriptionFiles checks that descriptions are set either inline or
+// from a file. The files are read when the manifests are generated.
+func validateDescriptionFiles(cfg *Config) []fieldError {
+	var problems []fieldError
+	if cfg.Metadata.ShortDescription != "" && cfg.Metadata.ShortDescriptionFile != "" {
+		problems = append(problems, fieldError{"metadata.short_description_file", "only one of short_description and short_description_file may be set"})
+	}
+	for i, locale := range cfg.Locales {
+		if locale.Description != "" && locale.DescriptionFile != "" {
+			problems = append(problems, fieldError{fmt.Sprintf("locales[%d].description_file", i), "only one of description and description_file may be set"})
+		}
+	}
+	return problems
+}
+
+// loadDescriptionFiles reads the descriptions configured as files, relative
+// to the released repository, into cfg.
+func loadDescriptionFiles(cfg *Config) error {
+	if cfg.Metadata.ShortDescriptionFile != "" {
+		description, err := readDescriptionFile(cfg.Metadata.ShortDescriptionFile, maxShortDescriptionLength)
+		if err != nil {
+			return fmt.Errorf("metadata.short_description_file: %w", err)
+		}
+		cfg.Metadata.ShortDescription = description
+	}
+	for i := range cfg.Locales {
+		locale := &cfg.Locales[i]
+		if locale.DescriptionFile == "" {
+			continue
+		}
+		description, err := readDescriptionFile(locale.DescriptionFile, maxDescriptionLength)
+		if err != nil {
+			return fmt.Errorf("locales[%d].description_file: %w", i, err)
+		}
+		locale.Description = description
+	}
+	return nil
+}
+
+// readDescriptionFile reads a description file, trimmed of surrounding
+// whitespace, and checks its length in characters.
+func readDescriptionFile(path string, maxLength int) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read description: %w", err)
+	}
+	description := strings.TrimSpace(string(data))
+	switch length := utf8.RuneCountInString(description); {
+	case length < minDescriptionLength:
+		return "", fmt.Errorf("description in %s must be at least %d characters", path, minDescriptionLength)
+	case length > maxLength:
+		return "", fmt.Errorf("description in %s must be at most %d characters, got %d", path, maxLength, length)
+	}
+	return description, nil
+}
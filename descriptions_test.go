@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDescriptionFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	cfg := &Config{
+		Metadata: MetadataConfig{ShortDescriptionFile: write("short.txt", "  A useful application\n")},
+		Locales: []LocaleConfig{
+			{Locale: "en-US", Description: "Inline description"},
+			{Locale: "de-DE", DescriptionFile: write("de.md", "\nEine nützliche Anwendung.\n\nMit Details.\n")},
+		},
+	}
+	if err := loadDescriptionFiles(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Metadata.ShortDescription != "A useful application" {
+		t.Errorf("unexpected short description %q", cfg.Metadata.ShortDescription)
+	}
+	if cfg.Locales[0].Description != "Inline description" || cfg.Locales[1].Description != "Eine nützliche Anwendung.\n\nMit Details." {
+		t.Errorf("unexpected descriptions %q, %q", cfg.Locales[0].Description, cfg.Locales[1].Description)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		contains string
+	}{
+		{"missing file", &Config{Metadata: MetadataConfig{ShortDescriptionFile: filepath.Join(dir, "missing.txt")}}, "metadata.short_description_file: failed to read"},
+		{"too short", &Config{Metadata: MetadataConfig{ShortDescriptionFile: write("empty.txt", " \n")}}, "at least 3 characters"},
+		{"too long", &Config{Metadata: MetadataConfig{ShortDescriptionFile: write("long.txt", strings.Repeat("a", 257))}}, "at most 256 characters, got 257"},
+		{"locale too long", &Config{Locales: []LocaleConfig{{Locale: "de-DE", DescriptionFile: write("long.md", strings.Repeat("ü", 10001))}}}, "locales[0].description_file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadDescriptionFiles(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got: %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestValidateDescriptionFiles(t *testing.T) {
+	cfg := validTestConfig()
+	metadata := cfg["metadata"].(map[string]any)
+	delete(metadata, "short_description")
+	metadata["short_description_file"] = "docs/short-description.txt"
+
+	resp, err := (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasValidationError(resp, "metadata.short_description") || hasValidationError(resp, "metadata.short_description_file") {
+		t.Errorf("expected short_description_file to stand in for short_description, got: %+v", resp.Errors)
+	}
+
+	metadata["short_description"] = "A useful application"
+	cfg["locales"] = []any{map[string]any{"locale": "en-US", "description": "Inline", "description_file": "docs/description.md"}}
+	resp, err = (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, field := range []string{"metadata.short_description_file", "locales[0].description_file"} {
+		if !hasValidationError(resp, field) {
+			t.Errorf("expected a validation error for %s", field)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+// defaultMinInstallerSize is the default download.min_size: installers are
+// rarely smaller than 100 KB, while error pages rarely are larger.
+const defaultMinInstallerSize = 100 << 10
+
+// checkInstallerDownload fails for downloads that look like an error page
+// served with a 200 status rather than an installer: smaller than
+// download.min_size, or with a Content-Type listed in
+// download.reject_content_types.
+func checkInstallerDownload(cfg DownloadConfig, stats installerhash.Stats) error {
+	if stats.Size < cfg.MinSize {
+		return fmt.Errorf("the download is %d bytes, smaller than download.min_size %d, and is likely an error page rather than an installer", stats.Size, cfg.MinSize)
+	}
+	if stats.ContentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(stats.ContentType)
+	if err != nil {
+		// Malformed content types are common on file servers
+		return nil
+	}
+	for _, rejected := range cfg.RejectContentTypes {
+		if strings.EqualFold(mediaType, rejected) {
+			return fmt.Errorf("the download has Content-Type %s and is likely an error page rather than an installer", mediaType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestCheckInstallerDownload(t *testing.T) {
+	cfg := defaultConfig().Download
+	tests := []struct {
+		name    string
+		stats   installerhash.Stats
+		wantErr bool
+	}{
+		{"installer", installerhash.Stats{Size: 5 << 20, ContentType: "application/octet-stream"}, false},
+		{"no content type", installerhash.Stats{Size: 5 << 20}, false},
+		{"malformed content type", installerhash.Stats{Size: 5 << 20, ContentType: "application/"}, false},
+		{"too small", installerhash.Stats{Size: 2 << 10, ContentType: "application/octet-stream"}, true},
+		{"error page", installerhash.Stats{Size: 5 << 20, ContentType: "text/html; charset=utf-8"}, true},
+		{"json error", installerhash.Stats{Size: 5 << 20, ContentType: "Application/JSON"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkInstallerDownload(cfg, tt.stats); (err != nil) != tt.wantErr {
+				t.Errorf("checkInstallerDownload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExecuteRejectsErrorPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body>Download unavailable</body></html>"))
+	}))
+	defer server.Close()
+
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["allow_insecure_urls"] = true
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "Content-Type text/html") {
+		t.Errorf("expected the error page to be rejected, got: %s", resp.Message)
+	}
+}
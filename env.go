@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+)
+
+// envReference matches ${VAR} references in configuration values.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// EnvConfig restricts which environment variables configuration values may
+// reference. Both lists hold names or glob patterns such as "CI_*"; deny wins
+// over allow, and an empty allow list allows every variable.
+type EnvConfig struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// allows reports whether the named variable may be expanded.
+func (e EnvConfig) allows(name string) bool {
+	if matchesAny(name, e.Deny) {
+		return false
+	}
+	return len(e.Allow) == 0 || matchesAny(name, e.Allow)
+}
+
+// matchesAny reports whether name matches any of the glob patterns.
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// expandConfigEnv returns a copy of the raw configuration with ${VAR}
+// references in string values replaced by environment variables. Unset
+// variables expand to the empty string, so defaults and environment fallbacks
+// still apply. References to variables outside the env allow and deny lists
+// are left unexpanded and reported.
+func expandConfigEnv(raw map[string]any) (map[string]any, []fieldError) {
+	var env EnvConfig
+	if rawEnv, ok := raw["env"].(map[string]any); ok {
+		if problems := decodeConfig(rawEnv, &env); len(problems) > 0 {
+			// Reported by the main decode, which sees the same keys
+			return raw, nil
+		}
+	}
+
+	var problems []fieldError
+	var expand func(path string, value any) any
+	expand = func(path string, value any) any {
+		switch v := value.(type) {
+		case string:
+			return envReference.ReplaceAllStringFunc(v, func(ref string) string {
+				name := envReference.FindStringSubmatch(ref)[1]
+				if !env.allows(name) {
+					problems = append(problems, fieldError{path, fmt.Sprintf("environment variable %s is not allowed by env.allow/env.deny", name)})
+					return ref
+				}
+				return os.Getenv(name)
+			})
+		case map[string]any:
+			expanded := make(map[string]any, len(v))
+			for key, item := range v {
+				if path == "" && key == "env" {
+					expanded[key] = item
+					continue
+				}
+				expanded[key] = expand(joinPath(path, key), item)
+			}
+			return expanded
+		case []any:
+			expanded := make([]any, len(v))
+			for i, item := range v {
+				expanded[i] = expand(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+			return expanded
+		default:
+			return value
+		}
+	}
+
+	expanded := expand("", raw).(map[string]any)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return expanded, problems
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandConfigEnv(t *testing.T) {
+	t.Setenv("CI_PUBLISHER", "My Organization")
+	t.Setenv("CI_HOST", "downloads.example.com")
+
+	raw := map[string]any{
+		"package_id": "MyOrg.MyApp",
+		"installers": []any{map[string]any{"url": "https://${CI_HOST}/app-{{.Version}}.msi"}},
+		"metadata":   map[string]any{"publisher": "${CI_PUBLISHER}", "name": "${CI_UNSET}"},
+	}
+
+	expanded, problems := expandConfigEnv(raw)
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if url := expanded["installers"].([]any)[0].(map[string]any)["url"]; url != "https://downloads.example.com/app-{{.Version}}.msi" {
+		t.Errorf("unexpected url '%v'", url)
+	}
+	metadata := expanded["metadata"].(map[string]any)
+	if metadata["publisher"] != "My Organization" {
+		t.Errorf("unexpected publisher '%v'", metadata["publisher"])
+	}
+	if metadata["name"] != "" {
+		t.Errorf("expected unset variable to expand to empty, got '%v'", metadata["name"])
+	}
+	if raw["metadata"].(map[string]any)["publisher"] != "${CI_PUBLISHER}" {
+		t.Error("expected raw configuration to be left unchanged")
+	}
+}
+
+func TestExpandConfigEnvAllowDeny(t *testing.T) {
+	t.Setenv("CI_PUBLISHER", "My Organization")
+	t.Setenv("CI_SECRET", "hunter2")
+	t.Setenv("HOME", "/home/ci")
+
+	raw := map[string]any{
+		"env": map[string]any{"allow": []any{"CI_*"}, "deny": []any{"*_SECRET"}},
+		"metadata": map[string]any{
+			"publisher": "${CI_PUBLISHER}",
+			"name":      "${CI_SECRET}",
+			"moniker":   "${HOME}",
+		},
+	}
+
+	expanded, problems := expandConfigEnv(raw)
+	metadata := expanded["metadata"].(map[string]any)
+	if metadata["publisher"] != "My Organization" {
+		t.Errorf("unexpected publisher '%v'", metadata["publisher"])
+	}
+	if metadata["name"] != "${CI_SECRET}" || metadata["moniker"] != "${HOME}" {
+		t.Errorf("expected disallowed references to be left unexpanded, got %v", metadata)
+	}
+	if len(problems) != 2 || problems[0].Field != "metadata.moniker" || problems[1].Field != "metadata.name" {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if !strings.Contains(problems[0].Message, "HOME is not allowed") {
+		t.Errorf("unexpected message '%s'", problems[0].Message)
+	}
+}
+
+func TestDecodePluginConfigExpandsEnv(t *testing.T) {
+	t.Setenv("CI_FORK_OWNER", "my-bot")
+
+	cfg, problems := decodePluginConfig(map[string]any{
+		"package_id":   "MyOrg.MyApp",
+		"pull_request": map[string]any{"fork_owner": "${CI_FORK_OWNER}"},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if cfg.PullRequest.ForkOwner != "my-bot" {
+		t.Errorf("expected fork owner 'my-bot', got '%s'", cfg.PullRequest.ForkOwner)
+	}
+}
+
+func TestDecodePluginConfigExpandsEnvIntoInteger(t *testing.T) {
+	t.Setenv("CI_MAX_OPEN_PRS", "5")
+
+	cfg, problems := decodePluginConfig(map[string]any{
+		"package_id": "MyOrg.MyApp",
+		"throttle":   map[string]any{"max_open_prs": "${CI_MAX_OPEN_PRS}"},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	if cfg.Throttle.MaxOpenPRs != 5 {
+		t.Errorf("expected max open PRs 5, got %d", cfg.Throttle.MaxOpenPRs)
+	}
+
+	t.Setenv("CI_MAX_OPEN_PRS", "many")
+	_, problems = decodePluginConfig(map[string]any{
+		"package_id": "MyOrg.MyApp",
+		"throttle":   map[string]any{"max_open_prs": "${CI_MAX_OPEN_PRS}"},
+	})
+	if len(problems) != 1 || problems[0].Field != "throttle.max_open_prs" {
+		t.Fatalf("expected a throttle.max_open_prs problem, got %v", problems)
+	}
+}
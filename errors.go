@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+// errorCategory classifies execution failures so orchestrators can decide
+// whether to retry. It is returned in the error_category output.
+type errorCategory string
+
+const (
+	// categoryTransient covers network failures, timeouts and server errors.
+	categoryTransient errorCategory = "transient"
+	// categoryRateLimit covers rate limit responses.
+	categoryRateLimit errorCategory = "rate_limit"
+	// categoryAuth covers missing or insufficient credentials.
+	categoryAuth errorCategory = "auth"
+	// categoryValidation covers invalid configuration, releases or manifests,
+	// which fail again on retry.
+	categoryValidation errorCategory = "validation"
+	// categoryConflict covers state conflicts such as existing branches or
+	// pull requests.
+	categoryConflict errorCategory = "conflict"
+	// categoryUnknown covers failures that could not be classified.
+	categoryUnknown errorCategory = "unknown"
+)
+
+// retryable reports whether a failure of the category may succeed on retry.
+func (c errorCategory) retryable() bool {
+	return c == categoryTransient || c == categoryRateLimit
+}
+
+// classifyError returns the category of an execution error.
+func classifyError(err error) errorCategory {
+	if errors.Is(err, installerhash.ErrNotFound) || errors.Is(err, installerhash.ErrPayloadMutated) {
+		return categoryValidation
+	}
+	if errors.Is(err, errSubmissionQueueFull) {
+		return categoryRateLimit
+	}
+	if errors.Is(err, githubclient.ErrBranchConflict) || errors.Is(err, githubclient.ErrPullRequestClosed) {
+		return categoryConflict
+	}
+
+	var apiErr *githubclient.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.RateLimited() {
+			return categoryRateLimit
+		}
+		return classifyStatus(apiErr.StatusCode)
+	}
+	var downloadErr *installerhash.StatusError
+	if errors.As(err, &downloadErr) {
+		return classifyStatus(downloadErr.StatusCode)
+	}
+	var restErr *restStatusError
+	if errors.As(err, &restErr) {
+		return classifyStatus(restErr.StatusCode)
+	}
+	var scmErr *scmStatusError
+	if errors.As(err, &scmErr) {
+		return classifyStatus(scmErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return categoryTransient
+	}
+	return categoryUnknown
+}
+
+// classifyStatus returns the category of an HTTP error status.
+func classifyStatus(status int) errorCategory {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return categoryRateLimit
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return categoryAuth
+	case status == http.StatusConflict || status == http.StatusUnprocessableEntity:
+		return categoryConflict
+	case status == http.StatusRequestTimeout || status >= 500:
+		return categoryTransient
+	default:
+		return categoryValidation
+	}
+}
+
+// failureResponse returns a failed execution response of the given category.
+func failureResponse(category errorCategory, format string, args ...any) *plugin.ExecuteResponse {
+	message := fmt.Sprintf(format, args...)
+	return &plugin.ExecuteResponse{
+		Success: false,
+		Message: message,
+		Error:   message,
+		Outputs: map[string]any{
+			"error_category": string(category),
+			"retryable":      category.retryable(),
+		},
+	}
+}
+
+// classifyAuthError classifies an authentication failure, which is an auth
+// failure unless it is known to be transient.
+func classifyAuthError(err error) errorCategory {
+	if category := classifyError(err); category != categoryUnknown && category != categoryValidation {
+		return category
+	}
+	return categoryAuth
+}
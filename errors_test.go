@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected errorCategory
+	}{
+		{"timeout", fmt.Errorf("failed: %w", context.DeadlineExceeded), categoryTransient},
+		{"installer not found", fmt.Errorf("download failed: %w", installerhash.ErrNotFound), categoryValidation},
+		{"installer payload mutated", fmt.Errorf("download failed: %w", installerhash.ErrPayloadMutated), categoryValidation},
+		{"download server error", &installerhash.StatusError{StatusCode: 502}, categoryTransient},
+		{"download forbidden", &installerhash.StatusError{StatusCode: 403}, categoryAuth},
+		{"github unauthorized", &githubclient.APIError{StatusCode: 401}, categoryAuth},
+		{"github secondary rate limit", &githubclient.APIError{StatusCode: 403, Message: "You have exceeded a secondary rate limit"}, categoryRateLimit},
+		{"github too many requests", &githubclient.APIError{StatusCode: 429}, categoryRateLimit},
+		{"github conflict", fmt.Errorf("failed to create branch: %w", &githubclient.APIError{StatusCode: 422}), categoryConflict},
+		{"github not found", &githubclient.APIError{StatusCode: 404}, categoryValidation},
+		{"branch conflict", fmt.Errorf("failed: %w", githubclient.ErrBranchConflict), categoryConflict},
+		{"closed pull request", fmt.Errorf("failed: %w", githubclient.ErrPullRequestClosed), categoryConflict},
+		{"rest server error", &restStatusError{StatusCode: 503}, categoryTransient},
+		{"unclassified", errors.New("something broke"), categoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if category := classifyError(tt.err); category != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, category)
+			}
+		})
+	}
+}
+
+func TestClassifyAuthError(t *testing.T) {
+	if category := classifyAuthError(errors.New("Azure AD token request failed with status 400")); category != categoryAuth {
+		t.Errorf("expected auth, got %s", category)
+	}
+	if category := classifyAuthError(context.DeadlineExceeded); category != categoryTransient {
+		t.Errorf("expected transient, got %s", category)
+	}
+}
+
+func TestExecuteErrorCategory(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		category  errorCategory
+		retryable bool
+	}{
+		{"server error", http.StatusBadGateway, categoryTransient, true},
+		{"not found", http.StatusNotFound, categoryValidation, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			originalBase := githubAPIBase
+			githubAPIBase = server.URL
+			defer func() { githubAPIBase = originalBase }()
+
+			cfg := validTestConfig()
+			cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+
+			resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success {
+				t.Fatal("expected failure")
+			}
+			if resp.Outputs["error_category"] != string(tt.category) || resp.Outputs["retryable"] != tt.retryable {
+				t.Errorf("expected %s (retryable %v), got %v (retryable %v)", tt.category, tt.retryable, resp.Outputs["error_category"], resp.Outputs["retryable"])
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// exeMetadataTypes are the installer types whose Apps & Features entry is
+// filled from the version resource of the installer: Inno Setup and NSIS set
+// it from the AppVersion and VIProductVersion settings that also version the
+// installed program, and NSIS the DisplayName from its product name. The
+// ProductCode and DisplayName of Inno Setup installers are read from their
+// setup header by readInnoApp.
+var exeMetadataTypes = map[string]bool{
+	"inno":     true,
+	"nullsoft": true,
+}
+
+// exeMetadata is the product information of an installer's version resource.
+type exeMetadata struct {
+	ProductName    string
+	ProductVersion string
+}
+
+// PE resource constants.
+const (
+	rtRCData             = 10
+	rtVersion            = 16
+	resourceSubdirectory = 0x80000000
+)
+
+// readExeMetadata reads the ProductName and ProductVersion of the version
+// resource of the PE file at file.
+func readExeMetadata(file string) (*exeMetadata, error) {
+	f, err := pe.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open installer: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// The version resource is the first name of the RT_VERSION type
+	version, err := peResource(f, rtVersion, -1)
+	if err != nil {
+		return nil, fmt.Errorf("installer has no version resource: %w", err)
+	}
+
+	strs := versionStrings(version)
+	meta := &exeMetadata{
+		ProductName:    strs["ProductName"],
+		ProductVersion: strs["ProductVersion"],
+	}
+	if meta.ProductName == "" && meta.ProductVersion == "" {
+		return nil, errors.New("version resource has no ProductName or ProductVersion")
+	}
+	return meta, nil
+}
+
+// peResource returns the data of the first language of the resource of a PE
+// file with the given type and ID, or of the first name of the type if id is
+// negative.
+func peResource(f *pe.File, typ, id int) ([]byte, error) {
+	section := f.Section(".rsrc")
+	if section == nil {
+		return nil, errors.New("installer has no resources")
+	}
+	rsrc, err := section.Data()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resources: %w", err)
+	}
+
+	offset, ok := resourceEntry(rsrc, 0, typ)
+	if ok && offset&resourceSubdirectory != 0 {
+		offset, ok = resourceEntry(rsrc, offset&^resourceSubdirectory, id)
+	}
+	if ok && offset&resourceSubdirectory != 0 {
+		offset, ok = resourceEntry(rsrc, offset&^resourceSubdirectory, -1)
+	}
+	if !ok || offset&resourceSubdirectory != 0 || int(offset)+8 > len(rsrc) {
+		return nil, errors.New("resource not found")
+	}
+	rva := binary.LittleEndian.Uint32(rsrc[offset:])
+	size := binary.LittleEndian.Uint32(rsrc[offset+4:])
+	start := int64(rva) - int64(section.VirtualAddress)
+	if start < 0 || start+int64(size) > int64(len(rsrc)) {
+		return nil, errors.New("resource is out of bounds")
+	}
+	return rsrc[start : start+int64(size)], nil
+}
+
+// resourceEntry returns the offset of the entry of the resource directory at
+// dir with the given ID, or of its first entry if id is negative.
+func resourceEntry(rsrc []byte, dir uint32, id int) (uint32, bool) {
+	if int(dir)+16 > len(rsrc) {
+		return 0, false
+	}
+	named := int(binary.LittleEndian.Uint16(rsrc[dir+12:]))
+	ids := int(binary.LittleEndian.Uint16(rsrc[dir+14:]))
+	for i := range named + ids {
+		entry := int(dir) + 16 + 8*i
+		if entry+8 > len(rsrc) {
+			return 0, false
+		}
+		if id < 0 || (i >= named && binary.LittleEndian.Uint32(rsrc[entry:]) == uint32(id)) {
+			return binary.LittleEndian.Uint32(rsrc[entry+4:]), true
+		}
+	}
+	return 0, false
+}
+
+// versionStrings returns the strings of the first string table of a
+// VS_VERSIONINFO resource.
+func versionStrings(data []byte) map[string]string {
+	strs := make(map[string]string)
+	versionBlocks(data, func(_ string, _, children []byte) {
+		versionBlocks(children, func(key string, _, children []byte) {
+			if key != "StringFileInfo" {
+				return
+			}
+			var first bool
+			versionBlocks(children, func(_ string, _, children []byte) {
+				if first {
+					return
+				}
+				first = true
+				versionBlocks(children, func(key string, value, _ []byte) {
+					strs[key] = strings.TrimSpace(decodeUTF16(value))
+				})
+			})
+		})
+	})
+	return strs
+}
+
+// versionBlocks calls fn with the key, value and children of each of the
+// consecutive version resource blocks in data.
+func versionBlocks(data []byte, fn func(key string, value, children []byte)) {
+	for len(data) >= 6 {
+		length := int(binary.LittleEndian.Uint16(data))
+		valueLength := int(binary.LittleEndian.Uint16(data[2:]))
+		if length < 6 || length > len(data) {
+			return
+		}
+		block := data[:length]
+
+		// The key is a NUL-terminated UTF-16 string, then the value and the
+		// children are 32-bit aligned
+		end := 6
+		for end+1 < len(block) && (block[end] != 0 || block[end+1] != 0) {
+			end += 2
+		}
+		key := decodeUTF16(block[6:end])
+		valueStart := min(align4(end+2), len(block))
+		if binary.LittleEndian.Uint16(data[4:]) == 1 {
+			// Text values are measured in characters
+			valueLength *= 2
+		}
+		valueEnd := min(valueStart+valueLength, len(block))
+		fn(key, block[valueStart:valueEnd], block[min(align4(valueEnd), len(block)):])
+
+		data = data[min(align4(length), len(data)):]
+	}
+}
+
+// align4 rounds n up to a multiple of 4.
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// decodeUTF16 decodes a UTF-16LE string, up to its NUL terminator.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := binary.LittleEndian.Uint16(b[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
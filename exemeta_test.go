@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// utf16z encodes s as a NUL-terminated UTF-16LE string.
+func utf16z(s string) []byte {
+	var b []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return append(b, 0, 0)
+}
+
+// pad4 pads b to a multiple of 4 bytes.
+func pad4(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// versionBlock encodes a VS_VERSIONINFO block.
+func versionBlock(key string, text bool, value []byte, children ...[]byte) []byte {
+	b := pad4(append(make([]byte, 6), utf16z(key)...))
+	b = append(b, value...)
+	for _, child := range children {
+		b = append(pad4(b), child...)
+	}
+	valueLength, valueType := len(value), uint16(0)
+	if text {
+		valueLength, valueType = len(value)/2, 1
+	}
+	binary.LittleEndian.PutUint16(b, uint16(len(b)))
+	binary.LittleEndian.PutUint16(b[2:], uint16(valueLength))
+	binary.LittleEndian.PutUint16(b[4:], valueType)
+	return b
+}
+
+// testPE returns a PE file whose version resource has the given strings.
+func testPE(t *testing.T, strs map[string]string) []byte {
+	t.Helper()
+	var table [][]byte
+	for key, value := range strs {
+		table = append(table, versionBlock(key, true, utf16z(value)))
+	}
+	version := versionBlock("VS_VERSION_INFO", false, make([]byte, 52),
+		versionBlock("StringFileInfo", true, nil, versionBlock("040904b0", true, nil, table...)))
+
+	return testPEFile(t, testResourceSection(rtVersion, 1, version))
+}
+
+// testResourceSection returns the .rsrc section of a PE file built by
+// testPEFile holding a single resource with the given type and ID.
+func testResourceSection(typ, id uint32, data []byte) testSection {
+	const sectionRVA = 0x1000
+
+	// Type, name and language directories leading to the data
+	var rsrc []byte
+	directory := func(id, offset uint32) {
+		rsrc = append(rsrc, make([]byte, 14)...)
+		rsrc = binary.LittleEndian.AppendUint16(rsrc, 1)
+		rsrc = binary.LittleEndian.AppendUint32(rsrc, id)
+		rsrc = binary.LittleEndian.AppendUint32(rsrc, offset)
+	}
+	directory(typ, resourceSubdirectory|24)
+	directory(id, resourceSubdirectory|48)
+	directory(0x409, 72)
+	rsrc = binary.LittleEndian.AppendUint32(rsrc, sectionRVA+88)
+	rsrc = binary.LittleEndian.AppendUint32(rsrc, uint32(len(data)))
+	rsrc = append(rsrc, make([]byte, 8)...)
+	rsrc = append(rsrc, data...)
+	return testSection{".rsrc", rsrc}
+}
+
+// testSection is a section of a PE file built by testPEFile.
+type testSection struct {
+	name string
+	data []byte
+}
+
+// testPEFile returns a PE file with the given sections, the first at RVA
+// 0x1000 and file offset 0x200, each next one 0x1000 further in both.
+func testPEFile(t testing.TB, sections ...testSection) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	header := make([]byte, 0x40)
+	copy(header, "MZ")
+	binary.LittleEndian.PutUint32(header[0x3c:], 0x40)
+	buf.Write(header)
+	buf.WriteString("PE\x00\x00")
+	write := func(v any) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(struct {
+		Machine, NumberOfSections                            uint16
+		TimeDateStamp, PointerToSymbolTable, NumberOfSymbols uint32
+		SizeOfOptionalHeader, Characteristics                uint16
+	}{Machine: 0x14c, NumberOfSections: uint16(len(sections))})
+	for i, section := range sections {
+		var name [8]byte
+		copy(name[:], section.name)
+		write(struct {
+			Name                                                      [8]byte
+			VirtualSize, VirtualAddress, SizeOfRawData                uint32
+			PointerToRawData, PointerToRelocations, PointerToLineNums uint32
+			NumberOfRelocations, NumberOfLineNumbers                  uint16
+			Characteristics                                           uint32
+		}{
+			Name:             name,
+			VirtualSize:      uint32(len(section.data)),
+			VirtualAddress:   uint32(0x1000 * (i + 1)),
+			SizeOfRawData:    uint32(len(section.data)),
+			PointerToRawData: uint32(0x200 + 0x1000*i),
+		})
+	}
+	for i, section := range sections {
+		buf.Write(make([]byte, 0x200+0x1000*i-buf.Len()))
+		buf.Write(section.data)
+	}
+	return buf.Bytes()
+}
+
+func TestReadExeMetadata(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	meta, err := readExeMetadata(write("setup.exe", testPE(t, map[string]string{
+		"CompanyName":    "My Organization",
+		"ProductName":    "My Application",
+		"ProductVersion": "1.2.3.0 ",
+	})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *meta != (exeMetadata{ProductName: "My Application", ProductVersion: "1.2.3.0"}) {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+
+	if _, err := readExeMetadata(write("empty.exe", testPE(t, map[string]string{"CompanyName": "My Organization"}))); err == nil {
+		t.Error("expected an error for a version resource without product information")
+	}
+	if _, err := readExeMetadata(write("setup.txt", []byte("not an installer"))); err == nil {
+		t.Error("expected an error for a file that is not a PE file")
+	}
+}
+
+func TestExecuteNullsoftMetadata(t *testing.T) {
+	installer := testPE(t, map[string]string{
+		"ProductName":    "My Application",
+		"ProductVersion": "1.2.3.0",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(installer)
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	installerCfg := cfg["installers"].([]any)[0].(map[string]any)
+	installerCfg["url"] = server.URL + "/setup.exe"
+	installerCfg["type"] = "nullsoft"
+	installerCfg["product_code"] = "MyApp"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "AppsAndFeaturesEntries:\n        - DisplayName: My Application\n          DisplayVersion: 1.2.3.0\n          ProductCode: MyApp"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("expected the installer metadata in the Apps & Features entry, got:\n%s", content)
+	}
+}
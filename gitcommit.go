@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// pull_request.commit_strategy values.
+const (
+	// commitStrategyAPI commits each manifest file through the GitHub
+	// Contents API.
+	commitStrategyAPI = "api"
+	// commitStrategyGit commits all manifest files at once from a local
+	// clone of the fork.
+	commitStrategyGit = "git"
+)
+
+// gitCommand is the git executable.
+var gitCommand = "git"
+
+// gitCommitter commits manifests from a blobless, sparse, shallow clone of
+// the fork branch and pushes them over HTTPS in a single commit, optionally
+// signed with a GPG key.
+type gitCommitter struct {
+	// host is the base URL of the repositories, such as https://github.com.
+	host  string
+	token string
+	// signingKey is the GPG key ID commits are signed with, if any.
+	signingKey string
+}
+
+// gitHostURL returns the base URL of the Git repositories of a GitHub API
+// base URL: github.com for api.github.com, or the host of a GitHub Enterprise
+// Server API.
+func gitHostURL(apiBase string) string {
+	if apiBase == "https://api.github.com" {
+		return "https://github.com"
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(apiBase, "/"), "/api/v3")
+}
+
+// Commit clones branch, writes the files, commits them and pushes the
+// commit to the branch.
+func (c *gitCommitter) Commit(ctx context.Context, owner, repo, branch string, files map[string]string, message string) error {
+	dir, err := os.MkdirTemp("", "winget-git-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// Only the trees and the manifest directories are downloaded, not the
+	// blobs of the rest of winget-pkgs
+	paths := slices.Sorted(maps.Keys(files))
+	dirs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		dirs = append(dirs, path.Dir(p))
+	}
+	dirs = slices.Compact(dirs)
+
+	repoURL := fmt.Sprintf("%s/%s/%s.git", c.host, owner, repo)
+	if err := c.git(ctx, dir, "clone", "--filter=blob:none", "--depth=1", "--sparse", "--single-branch", "--branch", branch, repoURL, "."); err != nil {
+		return err
+	}
+	if err := c.git(ctx, dir, append([]string{"sparse-checkout", "set"}, dirs...)...); err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", p, err)
+		}
+		if err := os.WriteFile(file, []byte(files[p]), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", p, err)
+		}
+	}
+	if err := c.git(ctx, dir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+
+	sign := "--no-gpg-sign"
+	if c.signingKey != "" {
+		sign = "--gpg-sign=" + c.signingKey
+	}
+	if err := c.gitAs(ctx, dir, owner, "commit", sign, "--message", message); err != nil {
+		return err
+	}
+	return c.git(ctx, dir, "push", "origin", "HEAD:refs/heads/"+branch)
+}
+
+// git runs a git command in dir. The token is passed as an HTTP header
+// through the environment so it appears neither in process listings nor in
+// remote URLs.
+func (c *gitCommitter) git(ctx context.Context, dir string, args ...string) error {
+	return c.gitAs(ctx, dir, "", args...)
+}
+
+// gitAs runs a git command in dir with the GitHub user as the author and
+// committer, if set.
+func (c *gitCommitter) gitAs(ctx context.Context, dir, user string, args ...string) error {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, gitCommand, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	if user != "" {
+		email := user + "@users.noreply.github.com"
+		cmd.Env = append(cmd.Env,
+			"GIT_AUTHOR_NAME="+user, "GIT_AUTHOR_EMAIL="+email,
+			"GIT_COMMITTER_NAME="+user, "GIT_COMMITTER_EMAIL="+email)
+	}
+	cmd.Env = append(cmd.Env,
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic "+base64.StdEncoding.EncodeToString([]byte("x-access-token:"+c.token)),
+	)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitHostURL(t *testing.T) {
+	tests := []struct {
+		apiBase  string
+		expected string
+	}{
+		{"https://api.github.com", "https://github.com"},
+		{"https://ghes.example.com/api/v3", "https://ghes.example.com"},
+		{"https://ghes.example.com/api/v3/", "https://ghes.example.com"},
+	}
+
+	for _, tt := range tests {
+		if result := gitHostURL(tt.apiBase); result != tt.expected {
+			t.Errorf("gitHostURL(%q) = '%s', expected '%s'", tt.apiBase, result, tt.expected)
+		}
+	}
+}
+
+func TestGitCommitterCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	// A bare "myuser/winget-pkgs" fork with an existing manifest and the
+	// branch created from master
+	host := t.TempDir()
+	origin := filepath.Join(host, "myuser", "winget-pkgs.git")
+	work := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run(host, "init", "--bare", "--initial-branch=master", origin)
+	run(origin, "config", "uploadpack.allowFilter", "true")
+	run(work, "init", "--initial-branch=master")
+	if err := os.MkdirAll(filepath.Join(work, "manifests", "o", "Other.App"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(work, "manifests", "o", "Other.App", "Other.App.yaml"), []byte("PackageIdentifier: Other.App\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run(work, "add", ".")
+	run(work, "commit", "--no-gpg-sign", "-m", "initial")
+	run(work, "push", origin, "master", "master:winget/MyOrg-MyApp/1.0.0")
+
+	committer := &gitCommitter{host: "file://" + filepath.ToSlash(host), token: "ghp_token"}
+	files := map[string]string{
+		"manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.yaml":           "PackageIdentifier: MyOrg.MyApp\n",
+		"manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.installer.yaml": "Installers: []\n",
+	}
+	if err := committer.Commit(context.Background(), "myuser", "winget-pkgs", "winget/MyOrg-MyApp/1.0.0", files, "New version: MyOrg.MyApp version 1.0.0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	branch := "winget/MyOrg-MyApp/1.0.0"
+	if count := run(origin, "rev-list", "--count", branch); count != "2" {
+		t.Errorf("expected one commit on top of master, got %s commits", count)
+	}
+	if subject := run(origin, "log", "-1", "--format=%s <%ae>", branch); subject != "New version: MyOrg.MyApp version 1.0.0 <myuser@users.noreply.github.com>" {
+		t.Errorf("unexpected commit %q", subject)
+	}
+	tree := run(origin, "ls-tree", "-r", "--name-only", branch)
+	for _, want := range []string{"manifests/o/Other.App/Other.App.yaml", "manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.installer.yaml"} {
+		if !strings.Contains(tree, want) {
+			t.Errorf("expected %s in the branch, got:\n%s", want, tree)
+		}
+	}
+	if content := run(origin, "show", branch+":manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.yaml"); content != "PackageIdentifier: MyOrg.MyApp" {
+		t.Errorf("unexpected manifest content %q", content)
+	}
+}
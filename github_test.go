@@ -1,213 +0,0 @@
-package main
-
-import (
-	"context"
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
-	"strings"
-	"testing"
-)
-
-func TestNewGitHubClient(t *testing.T) {
-	client := NewGitHubClient("test-token", "myuser")
-
-	if client.token != "test-token" {
-		t.Errorf("expected token 'test-token', got '%s'", client.token)
-	}
-	if client.forkOwner != "myuser" {
-		t.Errorf("expected forkOwner 'myuser', got '%s'", client.forkOwner)
-	}
-}
-
-func TestGitHubClientEnsureForkWithOwner(t *testing.T) {
-	client := NewGitHubClient("test-token", "specified-owner")
-
-	owner, err := client.EnsureFork(context.Background())
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-
-	if owner != "specified-owner" {
-		t.Errorf("expected owner 'specified-owner', got '%s'", owner)
-	}
-}
-
-func TestGitHubClientGetCurrentUser(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/user" {
-			t.Errorf("unexpected path: %s", r.URL.Path)
-		}
-
-		// Check auth header
-		auth := r.Header.Get("Authorization")
-		if !strings.HasPrefix(auth, "Bearer ") {
-			t.Error("missing Bearer token")
-		}
-
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]string{"login": "testuser"})
-	}))
-	defer server.Close()
-
-	// Override API base for testing
-	originalBase := githubAPIBase
-	defer func() {
-		// Note: Can't easily restore in actual code, but this shows the pattern
-		_ = originalBase
-	}()
-
-	client := &GitHubClient{
-		token:  "test-token",
-		client: &http.Client{},
-	}
-
-	// Create a test request to verify auth
-	req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL+"/user", nil)
-	resp, err := client.doRequestRaw(req)
-	if err != nil {
-		t.Fatalf("request failed: %v", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("expected status 200, got %d", resp.StatusCode)
-	}
-}
-
-func TestGitHubClientForkExists(t *testing.T) {
-	tests := []struct {
-		name       string
-		statusCode int
-		expected   bool
-	}{
-		{"exists", http.StatusOK, true},
-		{"not found", http.StatusNotFound, false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				w.WriteHeader(tt.statusCode)
-			}))
-			defer server.Close()
-
-			client := &GitHubClient{
-				token:  "test-token",
-				client: &http.Client{},
-			}
-
-			// Direct test of forkExists is hard without mocking the base URL
-			// This tests the helper indirectly through doRequestRaw
-			req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
-			resp, err := client.doRequestRaw(req)
-			if err != nil {
-				t.Fatalf("request failed: %v", err)
-			}
-			defer func() { _ = resp.Body.Close() }()
-
-			exists := resp.StatusCode == http.StatusOK
-			if exists != tt.expected {
-				t.Errorf("expected %v, got %v", tt.expected, exists)
-			}
-		})
-	}
-}
-
-func TestGitHubClientDoRequestSetsHeaders(t *testing.T) {
-	var receivedHeaders http.Header
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		receivedHeaders = r.Header
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{}`))
-	}))
-	defer server.Close()
-
-	client := &GitHubClient{
-		token:  "my-secret-token",
-		client: &http.Client{},
-	}
-
-	req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
-	_, _ = client.doRequestRaw(req)
-
-	// Check Authorization header
-	if auth := receivedHeaders.Get("Authorization"); auth != "Bearer my-secret-token" {
-		t.Errorf("expected 'Bearer my-secret-token', got '%s'", auth)
-	}
-
-	// Check Accept header
-	if accept := receivedHeaders.Get("Accept"); accept != "application/vnd.github+json" {
-		t.Errorf("expected 'application/vnd.github+json', got '%s'", accept)
-	}
-
-	// Check API version header
-	if version := receivedHeaders.Get("X-GitHub-Api-Version"); version != "2022-11-28" {
-		t.Errorf("expected '2022-11-28', got '%s'", version)
-	}
-}
-
-func TestGitHubClientDoRequestError(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusForbidden)
-		_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
-	}))
-	defer server.Close()
-
-	client := &GitHubClient{
-		token:  "invalid-token",
-		client: &http.Client{},
-	}
-
-	req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
-	var result map[string]any
-	err := client.doRequest(req, &result)
-
-	if err == nil {
-		t.Error("expected error for 403 response")
-	}
-
-	if !strings.Contains(err.Error(), "403") {
-		t.Errorf("error should mention status code: %v", err)
-	}
-}
-
-func TestGitHubClientCreateBranch(t *testing.T) {
-	var receivedBody map[string]string
-
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "POST" {
-			t.Errorf("expected POST, got %s", r.Method)
-		}
-
-		if err := json.NewDecoder(r.Body).Decode(&receivedBody); err != nil {
-			t.Errorf("failed to decode body: %v", err)
-		}
-
-		w.WriteHeader(http.StatusCreated)
-	}))
-	defer server.Close()
-
-	// This is a simplified test - actual createBranch uses fixed GitHub URL
-	// Testing the request body format
-	client := &GitHubClient{
-		token:  "test-token",
-		client: &http.Client{},
-	}
-
-	_ = client // Using client in the pattern shown above
-	_ = server.URL
-
-	// Verify expected body structure
-	expectedRef := "refs/heads/test-branch"
-	expectedSHA := "abc123"
-	body := map[string]string{
-		"ref": expectedRef,
-		"sha": expectedSHA,
-	}
-
-	if body["ref"] != expectedRef {
-		t.Errorf("expected ref '%s', got '%s'", expectedRef, body["ref"])
-	}
-}
@@ -0,0 +1,1840 @@
+// Package githubclient submits winget manifests to microsoft/winget-pkgs
+// through the GitHub REST API.
+package githubclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	neturl "net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const (
+	wingetPkgsOwner = "microsoft"
+	wingetPkgsRepo  = "winget-pkgs"
+)
+
+// DefaultBaseURL is the public GitHub API base URL.
+const DefaultBaseURL = "https://api.github.com"
+
+// Client handles GitHub API operations for winget-pkgs.
+type Client struct {
+	token     string
+	forkOwner string
+	baseURL   string
+	client    *http.Client
+	retry     RetryPolicy
+	rateLimit RateLimitPolicy
+	requests  *atomic.Int64
+	logger    *slog.Logger
+	committer Committer
+	userAgent string
+
+	mu       sync.Mutex
+	identity Identity
+}
+
+// Identity is what the client has learned about the token's GitHub user. It
+// is cached for the lifetime of the client and can be carried across runs
+// with WithIdentity.
+type Identity struct {
+	// User is the login of the authenticated user.
+	User string
+	// ForkExists reports whether the user's winget-pkgs fork is known to
+	// exist.
+	ForkExists bool
+}
+
+// RetryPolicy controls how failed GitHub API requests are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the initial attempt.
+	MaxRetries int
+	// Backoff is the delay before the first retry; it doubles on each retry.
+	Backoff time.Duration
+}
+
+// RateLimitPolicy controls waiting out rate limit responses that tell the
+// client when to retry, such as GitHub's secondary rate limits. Waits are
+// independent of the RetryPolicy.
+type RateLimitPolicy struct {
+	// MaxRetries is the number of rate limited responses waited out per
+	// request. Zero disables waiting.
+	MaxRetries int
+	// MaxWait is the longest single wait. Responses asking for a longer wait
+	// are returned as errors.
+	MaxWait time.Duration
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithBaseURL sets the GitHub API base URL.
+func WithBaseURL(baseURL string) Option {
+	return func(g *Client) {
+		g.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(g *Client) {
+		g.client = client
+	}
+}
+
+// WithRetryPolicy sets the retry policy for transient API failures.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(g *Client) {
+		g.retry = policy
+	}
+}
+
+// WithRateLimitPolicy sets the policy for waiting out rate limit responses.
+func WithRateLimitPolicy(policy RateLimitPolicy) Option {
+	return func(g *Client) {
+		g.rateLimit = policy
+	}
+}
+
+// WithLogger sets the logger of rate limit waits and branch cleanup.
+func WithLogger(logger *slog.Logger) Option {
+	return func(g *Client) {
+		g.logger = logger
+	}
+}
+
+// WithIdentity seeds the client with an identity learned earlier, skipping
+// the current user and fork lookups.
+func WithIdentity(identity Identity) Option {
+	return func(g *Client) {
+		g.identity = identity
+	}
+}
+
+// WithRequestCounter counts every API request attempt, including retries,
+// in counter. Clients may share a counter.
+func WithRequestCounter(counter *atomic.Int64) Option {
+	return func(g *Client) {
+		g.requests = counter
+	}
+}
+
+// Committer commits files to a branch of a repository in a single commit.
+type Committer interface {
+	Commit(ctx context.Context, owner, repo, branch string, files map[string]string, message string) error
+}
+
+// WithUserAgent sets the User-Agent header of API requests, which GitHub
+// uses to identify clients. Without it requests carry Go's default.
+func WithUserAgent(userAgent string) Option {
+	return func(g *Client) {
+		g.userAgent = userAgent
+	}
+}
+
+// WithCommitter commits the manifests of new pull requests with committer
+// instead of one Contents API commit per file.
+func WithCommitter(committer Committer) Option {
+	return func(g *Client) {
+		g.committer = committer
+	}
+}
+
+// New creates a new GitHub client.
+func New(token, forkOwner string, opts ...Option) *Client {
+	g := &Client{
+		token:     token,
+		forkOwner: forkOwner,
+		baseURL:   DefaultBaseURL,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		logger: slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// EnsureFork ensures the user has a fork of winget-pkgs.
+func (g *Client) EnsureFork(ctx context.Context) (string, error) {
+	// If fork owner is specified, use it
+	if g.forkOwner != "" {
+		return g.forkOwner, nil
+	}
+
+	// Get current user
+	user, err := g.currentUser(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	if g.Identity().ForkExists {
+		return user, nil
+	}
+
+	// Check if fork exists
+	exists, err := g.forkExists(ctx, user)
+	if err != nil {
+		return "", fmt.Errorf("failed to check fork: %w", err)
+	}
+
+	if !exists {
+		// Create fork
+		if err := g.createFork(ctx); err != nil {
+			return "", fmt.Errorf("failed to create fork: %w", err)
+		}
+
+		// Wait for fork to be ready
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(forkReadyWait):
+		}
+	}
+
+	g.mu.Lock()
+	g.identity.ForkExists = true
+	g.mu.Unlock()
+
+	return user, nil
+}
+
+// Identity returns what the client has learned about the token's user so
+// far.
+func (g *Client) Identity() Identity {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.identity
+}
+
+// currentUser returns the authenticated user, looking it up on first use.
+func (g *Client) currentUser(ctx context.Context) (string, error) {
+	if user := g.Identity().User; user != "" {
+		return user, nil
+	}
+
+	user, err := g.getCurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	g.identity = Identity{User: user}
+	g.mu.Unlock()
+	return user, nil
+}
+
+// TokenInfo describes the client's token, as reported by CheckToken.
+type TokenInfo struct {
+	// User is the login of the token's user.
+	User string
+	// Scopes are the OAuth scopes of a classic token, or nil for tokens
+	// without scopes, such as fine-grained tokens.
+	Scopes []string
+	// ExpiresAt is the expiry of the token, or zero if it does not expire.
+	ExpiresAt time.Time
+	// RateLimitRemaining is the number of core API requests left until
+	// RateLimitReset.
+	RateLimitRemaining int
+	RateLimitReset     time.Time
+	// ForkExists reports whether the winget-pkgs fork exists, and
+	// ForkWritable whether the token can push to it.
+	ForkExists   bool
+	ForkWritable bool
+}
+
+// tokenExpirationLayouts are the formats of the
+// GitHub-Authentication-Token-Expiration header.
+var tokenExpirationLayouts = []string{"2006-01-02 15:04:05 MST", "2006-01-02 15:04:05 -0700"}
+
+// CheckToken reports the user, scopes, expiry and rate limit of the client's
+// token, and whether it can push to the winget-pkgs fork. An invalid or
+// expired token fails with a 401 APIError.
+func (g *Client) CheckToken(ctx context.Context) (*TokenInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/user", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError(resp.StatusCode, body)
+	}
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	info := &TokenInfo{User: user.Login}
+	if scopes, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, scope := range strings.Split(strings.Join(scopes, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				info.Scopes = append(info.Scopes, scope)
+			}
+		}
+	}
+	if expiration := resp.Header.Get("GitHub-Authentication-Token-Expiration"); expiration != "" {
+		for _, layout := range tokenExpirationLayouts {
+			if t, err := time.Parse(layout, expiration); err == nil {
+				info.ExpiresAt = t
+				break
+			}
+		}
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "GET", g.baseURL+"/rate_limit", nil)
+	if err != nil {
+		return nil, err
+	}
+	var rateLimit struct {
+		Resources struct {
+			Core struct {
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := g.doRequest(req, &rateLimit); err != nil {
+		return nil, fmt.Errorf("failed to get rate limit: %w", err)
+	}
+	info.RateLimitRemaining = rateLimit.Resources.Core.Remaining
+	info.RateLimitReset = time.Unix(rateLimit.Resources.Core.Reset, 0)
+
+	forkOwner := g.forkOwner
+	if forkOwner == "" {
+		forkOwner = user.Login
+	}
+	req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/repos/%s/%s", g.baseURL, forkOwner, wingetPkgsRepo), nil)
+	if err != nil {
+		return nil, err
+	}
+	var fork struct {
+		Permissions struct {
+			Push bool `json:"push"`
+		} `json:"permissions"`
+	}
+	if err := g.doRequest(req, &fork); err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("failed to look up fork: %w", err)
+	} else if err == nil {
+		info.ForkExists = true
+		info.ForkWritable = fork.Permissions.Push
+	}
+	return info, nil
+}
+
+// PullRequestOptions configures the pull request opened by CreatePR.
+type PullRequestOptions struct {
+	// BaseBranch is the winget-pkgs branch the pull request targets.
+	BaseBranch string
+	// Title is the pull request title.
+	Title string
+	// Body is the pull request description.
+	Body string
+	// Branch is the fork branch to push to. It defaults to BranchName.
+	Branch string
+	// BranchAttempts is the number of branches tried while the branch
+	// exists with different manifests: Branch, then AttemptBranch suffixes
+	// -2, -3 and so on. Zero or one only tries Branch, failing with
+	// ErrBranchConflict. A branch with the same manifests and a closed or
+	// merged pull request fails with ErrPullRequestClosed instead.
+	BranchAttempts int
+}
+
+// PullRequestResult is the pull request opened or resumed by CreatePR.
+type PullRequestResult struct {
+	URL string
+	// Branch is the fork branch of the pull request, and Attempt its
+	// attempt number, 1 for PullRequestOptions.Branch.
+	Branch  string
+	Attempt int
+	// Resumed reports whether the branch was pushed by an earlier run, so
+	// the pull request was not submitted by this one.
+	Resumed bool
+}
+
+// AttemptBranch returns the fork branch of an attempt: branch itself for the
+// first attempt, and branch with a -2, -3, ... suffix for the next ones.
+func AttemptBranch(branch string, attempt int) string {
+	if attempt <= 1 {
+		return branch
+	}
+	return fmt.Sprintf("%s-%d", branch, attempt)
+}
+
+// CreatePR creates a pull request with the manifests.
+func (g *Client) CreatePR(ctx context.Context, manifests *manifest.Set, opts PullRequestOptions) (*PullRequestResult, error) {
+	forkOwner := g.forkOwner
+	if forkOwner == "" {
+		user, err := g.currentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		forkOwner = user
+	}
+
+	// Get base branch SHA
+	baseSHA, err := g.getBranchSHA(ctx, wingetPkgsOwner, wingetPkgsRepo, opts.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base branch SHA: %w", err)
+	}
+
+	baseBranch := opts.Branch
+	if baseBranch == "" {
+		baseBranch = BranchName(manifests.Version.PackageIdentifier, manifests.Version.PackageVersion)
+	}
+
+	// Get files to commit
+	files, err := manifests.GetFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest files: %w", err)
+	}
+
+	// A retried run finds the branch of the previous attempt, which is
+	// resumed if it holds the same manifests
+	attempt, branchName := 1, baseBranch
+	for {
+		_, err := g.getBranchSHA(ctx, forkOwner, wingetPkgsRepo, branchName)
+		if isNotFound(err) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up branch: %w", err)
+		}
+		prURL, err := g.resumePR(ctx, forkOwner, branchName, manifests.Path, files, opts)
+		if errors.Is(err, ErrBranchConflict) && attempt < opts.BranchAttempts {
+			attempt++
+			branchName = AttemptBranch(baseBranch, attempt)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &PullRequestResult{URL: prURL, Branch: branchName, Attempt: attempt, Resumed: true}, nil
+	}
+
+	// Create branch in fork
+	if err := g.createBranch(ctx, forkOwner, branchName, baseSHA); err != nil {
+		return nil, fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	// Commit files
+	commitMessage := fmt.Sprintf("New version: %s version %s",
+		manifests.Version.PackageIdentifier, manifests.Version.PackageVersion)
+
+	if g.committer != nil {
+		err = g.committer.Commit(ctx, forkOwner, wingetPkgsRepo, branchName, files, commitMessage)
+	} else {
+		err = g.commitFiles(ctx, forkOwner, branchName, files, commitMessage)
+	}
+	if err != nil {
+		g.rollbackBranch(ctx, forkOwner, branchName)
+		return nil, fmt.Errorf("failed to commit files: %w", err)
+	}
+
+	// Create PR
+	prURL, err := g.createPullRequest(ctx, forkOwner, branchName, opts)
+	if err != nil {
+		g.rollbackBranch(ctx, forkOwner, branchName)
+		return nil, fmt.Errorf("failed to create PR: %w", err)
+	}
+
+	return &PullRequestResult{URL: prURL, Branch: branchName, Attempt: attempt}, nil
+}
+
+// resumePR returns the pull request of a branch pushed by an earlier attempt
+// for the same version, creating it if the earlier attempt stopped before
+// opening it. The manifest directory dir on the branch must hold exactly the
+// manifests in files; the branch is never modified or deleted. A branch whose
+// pull requests were all closed or merged is not submitted again.
+func (g *Client) resumePR(ctx context.Context, forkOwner, branch, dir string, files map[string]string, opts PullRequestOptions) (string, error) {
+	entries, err := g.listContents(ctx, forkOwner, dir, branch)
+	if isNotFound(err) {
+		return "", fmt.Errorf("%w: %s is missing on %s", ErrBranchConflict, dir, branch)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to compare %s: %w", dir, err)
+	}
+	shas := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if _, ok := files[entry.Path]; !ok {
+			return "", fmt.Errorf("%w: %s is not part of the manifests on %s", ErrBranchConflict, entry.Path, branch)
+		}
+		shas[entry.Path] = entry.SHA
+	}
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		if shas[path] != gitBlobSHA(files[path]) {
+			return "", fmt.Errorf("%w: %s differs on %s", ErrBranchConflict, path, branch)
+		}
+	}
+
+	pr, err := g.openPullRequest(ctx, forkOwner, branch)
+	if err != nil {
+		return "", err
+	}
+	if pr != nil {
+		return pr.HTMLURL, nil
+	}
+
+	states, err := g.pullRequestStates(ctx, forkOwner, branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up PR: %w", err)
+	}
+	if len(states) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrPullRequestClosed, branch)
+	}
+
+	prURL, err := g.createPullRequest(ctx, forkOwner, branch, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create PR: %w", err)
+	}
+	return prURL, nil
+}
+
+// BranchName returns the fork branch used for a package version.
+func BranchName(packageID, version string) string {
+	return fmt.Sprintf("winget/%s/%s", strings.ReplaceAll(packageID, ".", "-"), version)
+}
+
+// rollbackBranch deletes a partially populated branch after a failed
+// submission so no orphaned branch is left in the fork. The deletion runs
+// detached from ctx, which may already be cancelled.
+func (g *Client) rollbackBranch(ctx context.Context, owner, branch string) {
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	_ = g.deleteBranch(cleanupCtx, owner, branch)
+}
+
+// RollbackPR closes the open pull request of a fork branch and deletes the
+// branch. It returns the URL of the closed pull request, if any.
+func (g *Client) RollbackPR(ctx context.Context, branch string) (string, error) {
+	forkOwner := g.forkOwner
+	if forkOwner == "" {
+		user, err := g.currentUser(ctx)
+		if err != nil {
+			return "", err
+		}
+		forkOwner = user
+	}
+
+	pr, err := g.openPullRequest(ctx, forkOwner, branch)
+	if err != nil {
+		return "", err
+	}
+
+	var prURL string
+	if pr != nil {
+		if err := g.closePullRequest(ctx, pr.Number); err != nil {
+			return "", fmt.Errorf("failed to close PR %s: %w", pr.HTMLURL, err)
+		}
+		prURL = pr.HTMLURL
+	}
+
+	if err := g.deleteBranch(ctx, forkOwner, branch); err != nil {
+		return prURL, fmt.Errorf("failed to delete branch %s: %w", branch, err)
+	}
+
+	return prURL, nil
+}
+
+// PullRequestAnnotations are the labels, requested reviewers and assignees
+// added to a pull request by AnnotatePR.
+type PullRequestAnnotations struct {
+	Labels    []string
+	Reviewers []string
+	Assignees []string
+}
+
+// AnnotatePR adds labels, requested reviewers and assignees to the
+// winget-pkgs pull request at prURL. winget-pkgs only permits some of them
+// to contributors, so each is attempted even if another fails; the failures
+// are returned joined.
+func (g *Client) AnnotatePR(ctx context.Context, prURL string, annotations PullRequestAnnotations) error {
+	number, err := strconv.Atoi(path.Base(prURL))
+	if err != nil {
+		return fmt.Errorf("invalid pull request URL %s", prURL)
+	}
+
+	var errs []error
+	for _, a := range []struct {
+		what, endpoint, key string
+		values              []string
+	}{
+		{"labels", "issues/%d/labels", "labels", annotations.Labels},
+		{"reviewers", "pulls/%d/requested_reviewers", "reviewers", annotations.Reviewers},
+		{"assignees", "issues/%d/assignees", "assignees", annotations.Assignees},
+	} {
+		if len(a.values) == 0 {
+			continue
+		}
+		url := fmt.Sprintf("%s/repos/%s/%s/"+a.endpoint, g.baseURL, wingetPkgsOwner, wingetPkgsRepo, number)
+		jsonBody, _ := json.Marshal(map[string][]string{a.key: a.values})
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+		if err != nil {
+			return err
+		}
+		if err := g.doRequest(req, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add %s: %w", a.what, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PullRequestStatus is the state of a submitted pull request.
+type PullRequestStatus struct {
+	Number int
+	URL    string
+	// State is "open" or "closed"; merged pull requests are closed.
+	State    string
+	Merged   bool
+	MergedAt time.Time
+	// Labels are the labels of the pull request, such as those the
+	// winget-pkgs validation pipeline sets.
+	Labels []string
+}
+
+// BranchPullRequest returns the status of the most recent pull request of a
+// fork branch, or nil if the branch has none.
+func (g *Client) BranchPullRequest(ctx context.Context, branch string) (*PullRequestStatus, error) {
+	forkOwner := g.forkOwner
+	if forkOwner == "" {
+		user, err := g.currentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		forkOwner = user
+	}
+
+	url := g.pullRequestsURL("all", forkOwner, branch)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		Number   int        `json:"number"`
+		HTMLURL  string     `json:"html_url"`
+		State    string     `json:"state"`
+		MergedAt *time.Time `json:"merged_at"`
+		Labels   []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to look up PR: %w", err)
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+
+	// Pull requests are listed newest first
+	pr := result[0]
+	status := &PullRequestStatus{Number: pr.Number, URL: pr.HTMLURL, State: pr.State}
+	if pr.MergedAt != nil {
+		status.Merged = true
+		status.MergedAt = *pr.MergedAt
+	}
+	for _, label := range pr.Labels {
+		status.Labels = append(status.Labels, label.Name)
+	}
+	return status, nil
+}
+
+// Issue is an issue of a repository.
+type Issue struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+// IssueOptions configures the issue opened by CreateIssue.
+type IssueOptions struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// FindIssue returns the open issue of a repository with the given title, or
+// nil if there is none. Only issues with all of labels are considered.
+func (g *Client) FindIssue(ctx context.Context, owner, repo, title string, labels []string) (*Issue, error) {
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/issues?state=open&per_page=100&page=%d", g.baseURL, owner, repo, page)
+		if len(labels) > 0 {
+			url += "&labels=" + neturl.QueryEscape(strings.Join(labels, ","))
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []struct {
+			Issue
+			PullRequest *struct{} `json:"pull_request"`
+		}
+		if err := g.doRequest(req, &result); err != nil {
+			return nil, fmt.Errorf("failed to list issues: %w", err)
+		}
+		for _, item := range result {
+			// The issues API lists pull requests too
+			if item.PullRequest == nil && item.Title == title {
+				return &item.Issue, nil
+			}
+		}
+		if len(result) < 100 {
+			return nil, nil
+		}
+	}
+}
+
+// CreateIssue opens an issue in a repository.
+func (g *Client) CreateIssue(ctx context.Context, owner, repo string, opts IssueOptions) (*Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues", g.baseURL, owner, repo)
+	body := map[string]any{"title": opts.Title, "body": opts.Body}
+	if len(opts.Labels) > 0 {
+		body["labels"] = opts.Labels
+	}
+	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	var issue Issue
+	if err := g.doRequest(req, &issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// UpdateIssueBody replaces the body of an issue.
+func (g *Client) UpdateIssueBody(ctx context.Context, owner, repo string, number int, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/issues/%d", g.baseURL, owner, repo, number)
+	jsonBody, _ := json.Marshal(map[string]string{"body": body})
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	if err := g.doRequest(req, nil); err != nil {
+		return fmt.Errorf("failed to update issue: %w", err)
+	}
+	return nil
+}
+
+// CleanupBranches deletes the branches in the fork starting with prefix, such
+// as "winget/", whose pull requests have all been merged or closed. An empty
+// forkOwner is the token's user, and a missing fork has no branches to
+// delete. Branches that fail to be checked or deleted are logged and
+// skipped. It returns the names of deleted branches.
+func (g *Client) CleanupBranches(ctx context.Context, forkOwner, prefix string) ([]string, error) {
+	if prefix == "" {
+		return nil, errors.New("a branch prefix is required")
+	}
+	if forkOwner == "" {
+		user, err := g.currentUser(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current user: %w", err)
+		}
+		forkOwner = user
+	}
+	branches, err := g.listBranches(ctx, forkOwner, prefix)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var deleted []string
+	for _, branch := range branches {
+		states, err := g.pullRequestStates(ctx, forkOwner, branch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return deleted, ctx.Err()
+			}
+			g.logger.Warn("Failed to get pull requests of branch", "branch", branch, "error", err)
+			continue
+		}
+
+		if !isStaleBranch(states) {
+			continue
+		}
+
+		if err := g.deleteBranch(ctx, forkOwner, branch); err != nil {
+			if ctx.Err() != nil {
+				return deleted, ctx.Err()
+			}
+			g.logger.Warn("Failed to delete stale branch", "branch", branch, "error", err)
+			continue
+		}
+		deleted = append(deleted, branch)
+	}
+
+	return deleted, nil
+}
+
+// PullRequestSummary describes an open winget-pkgs pull request.
+type PullRequestSummary struct {
+	Number    int
+	Title     string
+	URL       string
+	Labels    []string
+	CreatedAt time.Time
+}
+
+// OpenPullRequestCount returns the number of open winget-pkgs pull requests
+// authored by the token's user.
+func (g *Client) OpenPullRequestCount(ctx context.Context) (int, error) {
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	if err := g.searchOpenPullRequests(ctx, "", "per_page=1", &result); err != nil {
+		return 0, err
+	}
+	return result.TotalCount, nil
+}
+
+// OpenPullRequests returns the open winget-pkgs pull requests authored by the
+// token's user that mention packageID in their title, oldest first.
+func (g *Client) OpenPullRequests(ctx context.Context, packageID string) ([]PullRequestSummary, error) {
+	var pulls []PullRequestSummary
+	for page := 1; ; page++ {
+		var result struct {
+			Items []struct {
+				Number  int    `json:"number"`
+				Title   string `json:"title"`
+				HTMLURL string `json:"html_url"`
+				Labels  []struct {
+					Name string `json:"name"`
+				} `json:"labels"`
+				CreatedAt time.Time `json:"created_at"`
+			} `json:"items"`
+		}
+		params := fmt.Sprintf("sort=created&order=asc&per_page=100&page=%d", page)
+		if err := g.searchOpenPullRequests(ctx, fmt.Sprintf("%q in:title", packageID), params, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			pull := PullRequestSummary{Number: item.Number, Title: item.Title, URL: item.HTMLURL, CreatedAt: item.CreatedAt}
+			for _, label := range item.Labels {
+				pull.Labels = append(pull.Labels, label.Name)
+			}
+			pulls = append(pulls, pull)
+		}
+		if len(result.Items) < 100 {
+			return pulls, nil
+		}
+	}
+}
+
+// searchOpenPullRequests searches the open winget-pkgs pull requests authored
+// by the token's user, narrowed by terms.
+func (g *Client) searchOpenPullRequests(ctx context.Context, terms, params string, result any) error {
+	user, err := g.currentUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	query := fmt.Sprintf("repo:%s/%s is:pr is:open author:%s", wingetPkgsOwner, wingetPkgsRepo, user)
+	if terms != "" {
+		query += " " + terms
+	}
+	url := fmt.Sprintf("%s/search/issues?q=%s&%s", g.baseURL, neturl.QueryEscape(query), params)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := g.doRequest(req, result); err != nil {
+		return fmt.Errorf("failed to search open pull requests: %w", err)
+	}
+	return nil
+}
+
+// PublishedVersions returns the versions of a package published in
+// winget-pkgs, in directory listing order. It returns no versions if the
+// package has not been published yet.
+func (g *Client) PublishedVersions(ctx context.Context, packageID string) ([]string, error) {
+	entries, err := g.listContents(ctx, wingetPkgsOwner, manifest.PublishedDir(packageID), "")
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list published versions: %w", err)
+	}
+
+	// Version directories sit next to the directories of nested packages,
+	// whose names may look like versions too, as in Microsoft/VisualStudio/2022;
+	// only directories holding manifests themselves are versions
+	var versions []string
+	for _, entry := range entries {
+		if entry.Type != "dir" || !looksLikeVersion(entry.Name) {
+			continue
+		}
+		ok, err := g.holdsManifests(ctx, manifest.PublishedDir(packageID)+"/"+entry.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list published versions: %w", err)
+		}
+		if ok {
+			versions = append(versions, entry.Name)
+		}
+	}
+	return versions, nil
+}
+
+// holdsManifests reports whether a winget-pkgs directory contains YAML
+// manifests rather than only the directories of nested packages.
+func (g *Client) holdsManifests(ctx context.Context, dir string) (bool, error) {
+	entries, err := g.listContents(ctx, wingetPkgsOwner, dir, "")
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Type == "file" && strings.HasSuffix(entry.Name, ".yaml") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PublishedManifests returns the manifests of the latest version of a package
+// published in winget-pkgs, keyed by file name. It returns an empty version
+// if the package has not been published yet.
+func (g *Client) PublishedManifests(ctx context.Context, packageID string) (string, map[string]string, error) {
+	versions, err := g.PublishedVersions(ctx, packageID)
+	if err != nil {
+		return "", nil, err
+	}
+	latest := manifest.LatestVersion(versions)
+	if latest == "" {
+		return "", nil, nil
+	}
+
+	files, err := g.VersionManifests(ctx, packageID, latest)
+	if err != nil {
+		return "", nil, err
+	}
+	return latest, files, nil
+}
+
+// VersionManifests returns the manifests of a version of a package published
+// in winget-pkgs, keyed by file name. It returns nil if the version is not
+// published.
+func (g *Client) VersionManifests(ctx context.Context, packageID, version string) (map[string]string, error) {
+	dir := manifest.PublishedDir(packageID)
+	entries, err := g.listContents(ctx, wingetPkgsOwner, dir+"/"+version, "")
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list manifests of version %s: %w", version, err)
+	}
+
+	files := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Type != "file" {
+			continue
+		}
+		content, err := g.getFileContent(ctx, wingetPkgsOwner, entry.Path, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", entry.Path, err)
+		}
+		files[entry.Name] = content
+	}
+	return files, nil
+}
+
+// ReleaseAsset is a file attached to a GitHub release.
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	// Digest is the "sha256:<hex>" digest GitHub computed for the asset,
+	// empty for assets uploaded before GitHub published digests.
+	Digest string `json:"digest"`
+}
+
+// ReleaseAssets returns the assets of the release of owner/repo with the given tag.
+func (g *Client) ReleaseAssets(ctx context.Context, owner, repo, tag string) ([]ReleaseAsset, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", g.baseURL, owner, repo, tag)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Assets []ReleaseAsset `json:"assets"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", tag, err)
+	}
+
+	return result.Assets, nil
+}
+
+// RepositoryTopics returns the topics of owner/repo.
+func (g *Client) RepositoryTopics(ctx context.Context, owner, repo string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/topics", g.baseURL, owner, repo)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Names []string `json:"names"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to get topics of %s/%s: %w", owner, repo, err)
+	}
+	return result.Names, nil
+}
+
+// Release is a published GitHub release.
+type Release struct {
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// Releases returns up to limit published releases of owner/repo, newest
+// first. Drafts and prereleases are skipped.
+func (g *Client) Releases(ctx context.Context, owner, repo string, limit int) ([]Release, error) {
+	var releases []Release
+	for page := 1; len(releases) < limit; page++ {
+		url := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=100&page=%d", g.baseURL, owner, repo, page)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []Release
+		if err := g.doRequest(req, &result); err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, release := range result {
+			if !release.Draft && !release.Prerelease && len(releases) < limit {
+				releases = append(releases, release)
+			}
+		}
+		if len(result) < 100 {
+			break
+		}
+	}
+	return releases, nil
+}
+
+// Attestation is an artifact attestation stored by GitHub, such as SLSA
+// build provenance. Bundle is the Sigstore bundle holding the signed
+// statement.
+type Attestation struct {
+	Bundle json.RawMessage `json:"bundle"`
+}
+
+// Attestations returns the attestations of owner/repo for an artifact
+// digest of the form "sha256:<hex>". It returns no attestations if there are
+// none.
+func (g *Client) Attestations(ctx context.Context, owner, repo, digest string) ([]Attestation, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/attestations/%s", g.baseURL, owner, repo, neturl.PathEscape(digest))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Attestations []Attestation `json:"attestations"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get attestations for %s: %w", digest, err)
+	}
+
+	return result.Attestations, nil
+}
+
+// BranchSHA returns the commit SHA at the head of a branch of an owner's
+// winget-pkgs repository.
+func (g *Client) BranchSHA(ctx context.Context, owner, branch string) (string, error) {
+	sha, err := g.getBranchSHA(ctx, owner, wingetPkgsRepo, branch)
+	if err != nil {
+		return "", fmt.Errorf("failed to get branch %s: %w", branch, err)
+	}
+	return sha, nil
+}
+
+// GistFile returns the content of a file of a gist, or "" if the gist has no
+// such file.
+func (g *Client) GistFile(ctx context.Context, gistID, name string) (string, error) {
+	url := fmt.Sprintf("%s/gists/%s", g.baseURL, neturl.PathEscape(gistID))
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Files map[string]struct {
+			Content string `json:"content"`
+		} `json:"files"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		return "", fmt.Errorf("failed to get gist %s: %w", gistID, err)
+	}
+	return result.Files[name].Content, nil
+}
+
+// UpdateGistFile replaces the content of a file of a gist, creating the file
+// if needed.
+func (g *Client) UpdateGistFile(ctx context.Context, gistID, name, content string) error {
+	url := fmt.Sprintf("%s/gists/%s", g.baseURL, neturl.PathEscape(gistID))
+	body, err := json.Marshal(map[string]any{
+		"files": map[string]any{name: map[string]string{"content": content}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if err := g.doRequest(req, nil); err != nil {
+		return fmt.Errorf("failed to update gist %s: %w", gistID, err)
+	}
+	return nil
+}
+
+// looksLikeVersion reports whether a winget-pkgs directory name may be a
+// package version rather than a nested package identifier segment. Names
+// starting with a digit still need their contents checked.
+func looksLikeVersion(name string) bool {
+	return name != "" && name[0] >= '0' && name[0] <= '9'
+}
+
+// contentEntry is an entry of a repository directory listing.
+type contentEntry struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// listContents lists a directory of an owner's winget-pkgs repository at
+// ref, or at the default branch if ref is empty.
+func (g *Client) listContents(ctx context.Context, owner, path, ref string) ([]contentEntry, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.baseURL, owner, wingetPkgsRepo, path)
+	if ref != "" {
+		url += "?ref=" + neturl.QueryEscape(ref)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []contentEntry
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// getFileContent returns a file of an owner's winget-pkgs repository at ref,
+// or at the default branch if ref is empty.
+func (g *Client) getFileContent(ctx context.Context, owner, path, ref string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.baseURL, owner, wingetPkgsRepo, path)
+	if ref != "" {
+		url += "?ref=" + neturl.QueryEscape(ref)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := g.doRequest(req, &result); err != nil {
+		return "", err
+	}
+
+	if result.Encoding != "base64" {
+		return "", fmt.Errorf("unsupported content encoding %q", result.Encoding)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(result.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode content: %w", err)
+	}
+
+	return string(data), nil
+}
+
+func (g *Client) getCurrentUser(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/user", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Login string `json:"login"`
+	}
+
+	if err := g.doRequest(req, &result); err != nil {
+		return "", err
+	}
+
+	return result.Login, nil
+}
+
+func (g *Client) forkExists(ctx context.Context, owner string) (bool, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s", g.baseURL, owner, wingetPkgsRepo)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// forkReadyWait is how long EnsureFork waits for a fork it created to be
+// ready.
+var forkReadyWait = 5 * time.Second
+
+func (g *Client) createFork(ctx context.Context) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/forks", g.baseURL, wingetPkgsOwner, wingetPkgsRepo)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create fork: %s", string(body))
+	}
+
+	return nil
+}
+
+func (g *Client) getBranchSHA(ctx context.Context, owner, repo, branch string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/ref/heads/%s", g.baseURL, owner, repo, branch)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+
+	if err := g.doRequest(req, &result); err != nil {
+		return "", err
+	}
+
+	return result.Object.SHA, nil
+}
+
+func (g *Client) createBranch(ctx context.Context, owner, branch, sha string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/refs", g.baseURL, owner, wingetPkgsRepo)
+
+	body := map[string]string{
+		"ref": "refs/heads/" + branch,
+		"sha": sha,
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create branch: %s", string(respBody))
+	}
+
+	return nil
+}
+
+func (g *Client) listBranches(ctx context.Context, owner, prefix string) ([]string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/matching-refs/heads/%s", g.baseURL, owner, wingetPkgsRepo, prefix)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		Ref string `json:"ref"`
+	}
+
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	branches := make([]string, 0, len(result))
+	for _, ref := range result {
+		branches = append(branches, strings.TrimPrefix(ref.Ref, "refs/heads/"))
+	}
+
+	return branches, nil
+}
+
+func (g *Client) deleteBranch(ctx context.Context, owner, branch string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/git/refs/heads/%s", g.baseURL, owner, wingetPkgsRepo, branch)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete branch: %s", string(respBody))
+	}
+
+	return nil
+}
+
+// pullRequestsURL returns the URL listing winget-pkgs pull requests in state
+// opened from a fork branch. The branch is query-escaped because winget
+// versions may contain characters such as '+'.
+func (g *Client) pullRequestsURL(state, forkOwner, branch string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/pulls?state=%s&head=%s:%s",
+		g.baseURL, wingetPkgsOwner, wingetPkgsRepo, state, forkOwner, neturl.QueryEscape(branch))
+}
+
+// pullRequestState describes a pull request opened from a fork branch.
+type pullRequestState struct {
+	State    string  `json:"state"`
+	MergedAt *string `json:"merged_at"`
+}
+
+func (g *Client) pullRequestStates(ctx context.Context, forkOwner, branch string) ([]pullRequestState, error) {
+	url := g.pullRequestsURL("all", forkOwner, branch)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []pullRequestState
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// isStaleBranch reports whether a branch only has merged or closed pull
+// requests. Branches without any pull request are kept.
+func isStaleBranch(states []pullRequestState) bool {
+	if len(states) == 0 {
+		return false
+	}
+	for _, pr := range states {
+		if pr.State == "open" {
+			return false
+		}
+	}
+	return true
+}
+
+// commitConcurrency is the number of manifest files committed at once.
+const commitConcurrency = 4
+
+// maxCommitConflicts bounds the retries of a file commit that conflicts with
+// a concurrent commit to the same branch.
+const maxCommitConflicts = 5
+
+// commitConflictBackoff is the delay before the first retry of a conflicting
+// file commit; later retries wait longer.
+var commitConflictBackoff = 200 * time.Millisecond
+
+// commitFiles commits files to branch through the Contents API, one commit
+// per file, using a small worker pool. The files are verified afterwards,
+// since concurrent commits may land in any order.
+func (g *Client) commitFiles(ctx context.Context, owner, branch string, files map[string]string, message string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	paths := slices.Sorted(maps.Keys(files))
+	errs := make([]error, len(paths))
+	sem := make(chan struct{}, commitConcurrency)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			if err := g.commitFile(ctx, owner, branch, path, files[path], message); err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the failure that stopped the pool rather than the
+	// cancellations it caused
+	var first error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	if first != nil {
+		return first
+	}
+
+	return g.verifyFiles(ctx, owner, branch, files)
+}
+
+// commitFile creates a file on branch, retrying when the commit conflicts
+// with another one moving the branch head.
+func (g *Client) commitFile(ctx context.Context, owner, branch, path, content, message string) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", g.baseURL, owner, wingetPkgsRepo, path)
+
+	body := map[string]string{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString([]byte(content)),
+		"branch":  branch,
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(jsonBody))
+		if err != nil {
+			return err
+		}
+
+		resp, err := g.doRequestRaw(req)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK:
+			return nil
+		case resp.StatusCode == http.StatusConflict && attempt <= maxCommitConflicts:
+		default:
+			return fmt.Errorf("failed to create file %s: status %d", path, resp.StatusCode)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * commitConflictBackoff):
+		}
+	}
+}
+
+// verifyFiles checks that branch holds every file with the expected
+// content, comparing git blob SHAs from one listing per directory.
+func (g *Client) verifyFiles(ctx context.Context, owner, branch string, files map[string]string) error {
+	dirs := make(map[string][]string)
+	for p := range files {
+		dir := path.Dir(p)
+		dirs[dir] = append(dirs[dir], p)
+	}
+
+	for _, dir := range slices.Sorted(maps.Keys(dirs)) {
+		entries, err := g.listContents(ctx, owner, dir, branch)
+		if err != nil {
+			return fmt.Errorf("failed to verify committed files: %w", err)
+		}
+		shas := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			shas[entry.Path] = entry.SHA
+		}
+		slices.Sort(dirs[dir])
+		for _, p := range dirs[dir] {
+			if shas[p] != gitBlobSHA(files[p]) {
+				return fmt.Errorf("file %s does not have the committed content on %s", p, branch)
+			}
+		}
+	}
+	return nil
+}
+
+// gitBlobSHA returns the git object ID of a file with the given content.
+func gitBlobSHA(content string) string {
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "blob %d\x00", len(content))
+	_, _ = io.WriteString(h, content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (g *Client) createPullRequest(ctx context.Context, forkOwner, branch string, opts PullRequestOptions) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", g.baseURL, wingetPkgsOwner, wingetPkgsRepo)
+
+	body := map[string]string{
+		"title": opts.Title,
+		"head":  fmt.Sprintf("%s:%s", forkOwner, branch),
+		"base":  opts.BaseBranch,
+		"body":  opts.Body,
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		HTMLURL string `json:"html_url"`
+	}
+
+	if err := g.doRequest(req, &result); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.isPRAlreadyExists() {
+			return g.findPullRequest(ctx, forkOwner, branch)
+		}
+		return "", err
+	}
+
+	return result.HTMLURL, nil
+}
+
+// findPullRequest returns the URL of the open pull request for a fork branch.
+func (g *Client) findPullRequest(ctx context.Context, forkOwner, branch string) (string, error) {
+	pr, err := g.openPullRequest(ctx, forkOwner, branch)
+	if err != nil {
+		return "", err
+	}
+
+	if pr == nil {
+		return "", fmt.Errorf("pull request for %s:%s reported as existing but not found", forkOwner, branch)
+	}
+
+	return pr.HTMLURL, nil
+}
+
+// pullRequest is the subset of a GitHub pull request used by the plugin.
+type pullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+}
+
+// openPullRequest returns the open pull request for a fork branch, or nil if
+// there is none.
+func (g *Client) openPullRequest(ctx context.Context, forkOwner, branch string) (*pullRequest, error) {
+	url := g.pullRequestsURL("open", forkOwner, branch)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []pullRequest
+	if err := g.doRequest(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to look up PR: %w", err)
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return &result[0], nil
+}
+
+func (g *Client) closePullRequest(ctx context.Context, number int) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls/%d", g.baseURL, wingetPkgsOwner, wingetPkgsRepo, number)
+
+	jsonBody, _ := json.Marshal(map[string]string{"state": "closed"})
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+
+	return g.doRequest(req, nil)
+}
+
+func (g *Client) doRequest(req *http.Request, result any) error {
+	resp, err := g.doRequestRaw(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, body)
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Client) doRequestRaw(req *http.Request) (*http.Response, error) {
+	// Public reads such as published manifests work without a token
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if g.userAgent != "" {
+		req.Header.Set("User-Agent", g.userAgent)
+	}
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	backoff := g.retry.Backoff
+	var retries, rateLimitWaits int
+	for {
+		if g.requests != nil {
+			g.requests.Add(1)
+		}
+		resp, err := g.client.Do(req)
+
+		var delay time.Duration
+		if wait, ok := rateLimitDelay(resp, time.Now()); ok && rateLimitWaits < g.rateLimit.MaxRetries {
+			if wait > g.rateLimit.MaxWait {
+				g.logger.Warn("GitHub rate limit wait exceeds the maximum, giving up",
+					"method", req.Method, "path", req.URL.Path, "status", resp.StatusCode,
+					"wait", wait, "max_wait", g.rateLimit.MaxWait)
+				return resp, err
+			}
+			rateLimitWaits++
+			delay = wait
+			g.logger.Warn("GitHub rate limit reached, waiting before retrying",
+				"method", req.Method, "path", req.URL.Path, "status", resp.StatusCode,
+				"wait", wait, "attempt", rateLimitWaits, "max_attempts", g.rateLimit.MaxRetries)
+		} else if retries < g.retry.MaxRetries && isRetryable(resp, err) {
+			retries++
+			delay = backoff
+			backoff *= 2
+		} else {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		// Rewind the body for the next attempt
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// defaultRateLimitWait is the wait for a 429, or a 403 secondary rate limit,
+// response without a Retry-After or reset header: GitHub asks clients to
+// wait at least a minute.
+const defaultRateLimitWait = time.Minute
+
+// maxRateLimitBodyPeek is how much of a 403 response body is read to
+// recognize a secondary rate limit message.
+const maxRateLimitBodyPeek = 4 << 10
+
+// rateLimitDelay reports whether resp is a rate limit response and how long
+// to wait before retrying. A 403 is only a rate limit when it carries a
+// Retry-After header, an exhausted X-RateLimit-Remaining with a reset time
+// or a body mentioning a secondary rate limit or abuse detection; other 403s
+// are permission errors.
+func rateLimitDelay(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests) {
+		return 0, false
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp) {
+		return defaultRateLimitWait, true
+	}
+	return 0, false
+}
+
+// isSecondaryRateLimit reports whether the body of a 403 response mentions a
+// secondary rate limit or abuse detection. The start of the body that is
+// read is put back so the response can still be decoded.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden || resp.Body == nil {
+		return false
+	}
+	peek, _ := io.ReadAll(io.LimitReader(resp.Body, maxRateLimitBodyPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+
+	message := strings.ToLower(string(peek))
+	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse")
+}
+
+// isRetryable reports whether a request outcome is a transient failure.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// ErrBranchConflict is returned when the fork branch of a version already
+// exists with different manifests, for example from an earlier release
+// attempt with other installers.
+var ErrBranchConflict = errors.New("branch already exists with different manifests")
+
+// ErrPullRequestClosed is returned when the fork branch of a version already
+// holds the same manifests but its pull request was closed or merged, so
+// submitting them again would repeat a rejected or completed submission.
+var ErrPullRequestClosed = errors.New("branch already exists with the same manifests and a closed or merged pull request")
+
+// isNotFound reports whether err is a GitHub 404 response.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// APIError is returned for GitHub API responses with an error status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Errors     []APIErrorDetail
+	Body       string
+}
+
+// APIErrorDetail is a single entry of the "errors" array in a GitHub API error.
+type APIErrorDetail struct {
+	Resource string `json:"resource"`
+	Code     string `json:"code"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+// newAPIError builds an APIError from a response status and body.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Body:       string(body),
+	}
+
+	var payload struct {
+		Message string           `json:"message"`
+		Errors  []APIErrorDetail `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		apiErr.Message = payload.Message
+		apiErr.Errors = payload.Errors
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// RateLimited reports whether the error is a primary or secondary rate limit
+// response.
+func (e *APIError) RateLimited() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return strings.Contains(strings.ToLower(e.Message+e.Body), "rate limit")
+	}
+	return false
+}
+
+// isPRAlreadyExists reports whether the error is GitHub's 422 response for a
+// pull request that already exists for the same head branch.
+func (e *APIError) isPRAlreadyExists() bool {
+	if e.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	for _, detail := range e.Errors {
+		if strings.Contains(detail.Message, "A pull request already exists") {
+			return true
+		}
+	}
+	return false
+}
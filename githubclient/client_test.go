@@ -0,0 +1,1555 @@
+package githubclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+func TestNew(t *testing.T) {
+	client := New("test-token", "myuser")
+
+	if client.token != "test-token" {
+		t.Errorf("expected token 'test-token', got '%s'", client.token)
+	}
+	if client.forkOwner != "myuser" {
+		t.Errorf("expected forkOwner 'myuser', got '%s'", client.forkOwner)
+	}
+}
+
+func TestClientEnsureForkWithOwner(t *testing.T) {
+	client := New("test-token", "specified-owner")
+
+	owner, err := client.EnsureFork(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if owner != "specified-owner" {
+		t.Errorf("expected owner 'specified-owner', got '%s'", owner)
+	}
+}
+
+func TestClientEnsureForkCachesIdentity(t *testing.T) {
+	var userLookups, forkLookups int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			userLookups++
+			_ = json.NewEncoder(w).Encode(map[string]string{"login": "testuser"})
+		case "/repos/testuser/winget-pkgs":
+			forkLookups++
+			w.WriteHeader(http.StatusOK)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	for range 2 {
+		owner, err := client.EnsureFork(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if owner != "testuser" {
+			t.Errorf("expected owner 'testuser', got '%s'", owner)
+		}
+	}
+	if user, err := client.currentUser(context.Background()); err != nil || user != "testuser" {
+		t.Errorf("expected cached user 'testuser', got '%s' (%v)", user, err)
+	}
+
+	if userLookups != 1 || forkLookups != 1 {
+		t.Errorf("expected 1 user and 1 fork lookup, got %d and %d", userLookups, forkLookups)
+	}
+	if got := client.Identity(); got != (Identity{User: "testuser", ForkExists: true}) {
+		t.Errorf("unexpected identity: %+v", got)
+	}
+}
+
+func TestClientEnsureForkCreatesFork(t *testing.T) {
+	var forks int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user":
+			_ = json.NewEncoder(w).Encode(map[string]string{"login": "testuser"})
+		case r.Method == "GET" && r.URL.Path == "/repos/testuser/winget-pkgs":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/repos/microsoft/winget-pkgs/forks":
+			forks++
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	orig := forkReadyWait
+	forkReadyWait = time.Millisecond
+	defer func() { forkReadyWait = orig }()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	owner, err := client.EnsureFork(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner != "testuser" || forks != 1 {
+		t.Errorf("expected owner 'testuser' and 1 fork, got '%s' and %d", owner, forks)
+	}
+
+	// The wait for a new fork ends with the context.
+	forkReadyWait = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client = New("test-token", "", WithBaseURL(server.URL))
+	if _, err := client.EnsureFork(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestClientWithIdentity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+
+	client := New("test-token", "",
+		WithBaseURL(server.URL),
+		WithIdentity(Identity{User: "cached", ForkExists: true}))
+
+	owner, err := client.EnsureFork(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owner != "cached" {
+		t.Errorf("expected owner 'cached', got '%s'", owner)
+	}
+}
+
+func TestClientGetCurrentUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		// Check auth header
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			t.Error("missing Bearer token")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(map[string]string{"login": "testuser"})
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	user, err := client.getCurrentUser(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if user != "testuser" {
+		t.Errorf("expected user 'testuser', got '%s'", user)
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		_ = json.NewEncoder(w).Encode(map[string]string{"login": "testuser"})
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL), WithUserAgent("Relicta-WinGet-Plugin/1.4.0 (run 42)"))
+	if _, err := client.getCurrentUser(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if userAgent != "Relicta-WinGet-Plugin/1.4.0 (run 42)" {
+		t.Errorf("unexpected User-Agent '%s'", userAgent)
+	}
+}
+
+func TestClientCheckToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		fork     string
+		expected TokenInfo
+	}{
+		{"classic token", map[string]string{"X-OAuth-Scopes": "public_repo, workflow", "GitHub-Authentication-Token-Expiration": "2030-06-30 12:00:00 UTC"}, `{"permissions":{"push":true}}`,
+			TokenInfo{User: "myuser", Scopes: []string{"public_repo", "workflow"}, ExpiresAt: time.Date(2030, 6, 30, 12, 0, 0, 0, time.UTC), RateLimitRemaining: 4999, RateLimitReset: time.Unix(1900000000, 0), ForkExists: true, ForkWritable: true}},
+		{"fine-grained token", map[string]string{"GitHub-Authentication-Token-Expiration": "2030-06-30 12:00:00 +0000"}, `{"permissions":{"push":false}}`,
+			TokenInfo{User: "myuser", ExpiresAt: time.Date(2030, 6, 30, 12, 0, 0, 0, time.UTC), RateLimitRemaining: 4999, RateLimitReset: time.Unix(1900000000, 0), ForkExists: true}},
+		{"no fork", nil, "",
+			TokenInfo{User: "myuser", RateLimitRemaining: 4999, RateLimitReset: time.Unix(1900000000, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					for key, value := range tt.headers {
+						w.Header().Set(key, value)
+					}
+					_, _ = w.Write([]byte(`{"login":"myuser"}`))
+				case "/rate_limit":
+					_, _ = w.Write([]byte(`{"resources":{"core":{"remaining":4999,"reset":1900000000}}}`))
+				case "/repos/myuser/winget-pkgs":
+					if tt.fork == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(tt.fork))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			info, err := New("test-token", "", WithBaseURL(server.URL)).CheckToken(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !info.ExpiresAt.Equal(tt.expected.ExpiresAt) {
+				t.Errorf("expected expiry %v, got %v", tt.expected.ExpiresAt, info.ExpiresAt)
+			}
+			info.ExpiresAt = tt.expected.ExpiresAt
+			if !reflect.DeepEqual(*info, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, *info)
+			}
+		})
+	}
+}
+
+func TestClientCheckTokenUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	_, err := New("test-token", "", WithBaseURL(server.URL)).CheckToken(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401 APIError, got %v", err)
+	}
+}
+
+func TestClientForkExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		expected   bool
+	}{
+		{"exists", http.StatusOK, true},
+		{"not found", http.StatusNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/myuser/winget-pkgs" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			client := New("test-token", "", WithBaseURL(server.URL))
+
+			exists, err := client.forkExists(context.Background(), "myuser")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+
+			if exists != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, exists)
+			}
+		})
+	}
+}
+
+func TestClientDoRequestSetsHeaders(t *testing.T) {
+	var receivedHeaders http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		token:  "my-secret-token",
+		client: &http.Client{},
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
+	_, _ = client.doRequestRaw(req)
+
+	// Check Authorization header
+	if auth := receivedHeaders.Get("Authorization"); auth != "Bearer my-secret-token" {
+		t.Errorf("expected 'Bearer my-secret-token', got '%s'", auth)
+	}
+
+	// Check Accept header
+	if accept := receivedHeaders.Get("Accept"); accept != "application/vnd.github+json" {
+		t.Errorf("expected 'application/vnd.github+json', got '%s'", accept)
+	}
+
+	// Check API version header
+	if version := receivedHeaders.Get("X-GitHub-Api-Version"); version != "2022-11-28" {
+		t.Errorf("expected '2022-11-28', got '%s'", version)
+	}
+}
+
+func TestClientDoRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		token:  "invalid-token",
+		client: &http.Client{},
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), "GET", server.URL, nil)
+	var result map[string]any
+	err := client.doRequest(req, &result)
+
+	if err == nil {
+		t.Error("expected error for 403 response")
+	}
+
+	if !strings.Contains(err.Error(), "403") {
+		t.Errorf("error should mention status code: %v", err)
+	}
+}
+
+func TestClientCreateBranch(t *testing.T) {
+	var receivedBody map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/repos/myuser/winget-pkgs/git/refs" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&receivedBody); err != nil {
+			t.Errorf("failed to decode body: %v", err)
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+
+	if err := client.createBranch(context.Background(), "myuser", "test-branch", "abc123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receivedBody["ref"] != "refs/heads/test-branch" {
+		t.Errorf("expected ref 'refs/heads/test-branch', got '%s'", receivedBody["ref"])
+	}
+	if receivedBody["sha"] != "abc123" {
+		t.Errorf("expected sha 'abc123', got '%s'", receivedBody["sha"])
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	httpClient := &http.Client{}
+	policy := RetryPolicy{MaxRetries: 3, Backoff: time.Second}
+
+	client := New("test-token", "",
+		WithBaseURL("https://github.example.com/api/v3/"),
+		WithHTTPClient(httpClient),
+		WithRetryPolicy(policy))
+
+	if client.baseURL != "https://github.example.com/api/v3" {
+		t.Errorf("expected trimmed base URL, got '%s'", client.baseURL)
+	}
+	if client.client != httpClient {
+		t.Error("expected custom HTTP client")
+	}
+	if client.retry != policy {
+		t.Errorf("expected retry policy %+v, got %+v", policy, client.retry)
+	}
+
+	defaults := New("test-token", "")
+	if defaults.baseURL != DefaultBaseURL {
+		t.Errorf("expected default base URL '%s', got '%s'", DefaultBaseURL, defaults.baseURL)
+	}
+}
+
+func TestClientRetryPolicy(t *testing.T) {
+	var attempts int
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if attempts < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var requests atomic.Int64
+	client := New("test-token", "",
+		WithBaseURL(server.URL),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}),
+		WithRequestCounter(&requests))
+
+	if err := client.createBranch(context.Background(), "myuser", "test-branch", "abc123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 counted requests, got %d", requests.Load())
+	}
+	for i, body := range bodies {
+		if !strings.Contains(body, "abc123") {
+			t.Errorf("attempt %d sent an empty body", i+1)
+		}
+	}
+}
+
+func TestClientNoRetryByDefault(t *testing.T) {
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	if _, err := client.getCurrentUser(context.Background()); err == nil {
+		t.Error("expected error for 502 response")
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRateLimitDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		body    string
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"retry-after seconds", http.StatusForbidden, map[string]string{"Retry-After": "30"}, "", 30 * time.Second, true},
+		{"retry-after date", http.StatusTooManyRequests, map[string]string{"Retry-After": now.Add(time.Minute).Format(http.TimeFormat)}, "", time.Minute, true},
+		{"exhausted with reset", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(90*time.Second).Unix(), 10)}, "", 90 * time.Second, true},
+		{"reset in the past", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)}, "", 0, true},
+		{"429 without headers", http.StatusTooManyRequests, nil, "", time.Minute, true},
+		{"permission error", http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`, 0, false},
+		{"remaining requests", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": "1"}, "", 0, false},
+		{"secondary rate limit", http.StatusForbidden, nil, `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`, time.Minute, true},
+		{"abuse detection", http.StatusForbidden, nil, `{"message":"You have triggered an abuse detection mechanism."}`, time.Minute, true},
+		{"server error", http.StatusBadGateway, map[string]string{"Retry-After": "30"}, "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			got, ok := rateLimitDelay(resp, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("rateLimitDelay() = %s, %v; want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("expected the body to be kept, got %q", body)
+			}
+		})
+	}
+}
+
+func TestClientWaitsOutRateLimit(t *testing.T) {
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"login":"myuser"}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := New("test-token", "",
+		WithBaseURL(server.URL),
+		WithRateLimitPolicy(RateLimitPolicy{MaxRetries: 3, MaxWait: time.Minute}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	user, err := client.getCurrentUser(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user != "myuser" {
+		t.Errorf("expected user 'myuser', got '%s'", user)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if got := strings.Count(logs.String(), "GitHub rate limit reached"); got != 2 {
+		t.Errorf("expected 2 rate limit log entries, got %d:\n%s", got, logs.String())
+	}
+}
+
+func TestClientRateLimitWaitTooLong(t *testing.T) {
+	var attempts int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := New("test-token", "",
+		WithBaseURL(server.URL),
+		WithRateLimitPolicy(RateLimitPolicy{MaxRetries: 3, MaxWait: time.Minute}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	_, err := client.getCurrentUser(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.RateLimited() {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+	if !strings.Contains(logs.String(), "exceeds the maximum") {
+		t.Errorf("expected a log entry about the wait, got:\n%s", logs.String())
+	}
+}
+
+func TestClientCreatePullRequestExisting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"message":"Validation Failed","errors":[{"message":"A pull request already exists for myuser:my-branch."}]}`))
+		case r.Method == "GET" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			if head := r.URL.Query().Get("head"); head != "myuser:my-branch" {
+				t.Errorf("unexpected head filter: %s", head)
+			}
+			_, _ = w.Write([]byte(`[{"html_url":"https://github.com/microsoft/winget-pkgs/pull/42"}]`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+
+	url, err := client.createPullRequest(context.Background(), "myuser", "my-branch", PullRequestOptions{BaseBranch: "master", Title: "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if url != "https://github.com/microsoft/winget-pkgs/pull/42" {
+		t.Errorf("expected existing PR URL, got '%s'", url)
+	}
+}
+
+func TestClientCleanupBranches(t *testing.T) {
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/myuser/winget-pkgs/git/matching-refs/heads/winget/":
+			_, _ = w.Write([]byte(`[{"ref":"refs/heads/winget/a/1.0.0+1"},{"ref":"refs/heads/winget/a/1.1.0"},{"ref":"refs/heads/winget/a/1.2.0"}]`))
+		case r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			switch r.URL.Query().Get("head") {
+			case "myuser:winget/a/1.0.0+1":
+				_, _ = w.Write([]byte(`[{"state":"closed","merged_at":"2024-01-01T00:00:00Z"}]`))
+			case "myuser:winget/a/1.1.0":
+				_, _ = w.Write([]byte(`[{"state":"open"}]`))
+			default:
+				_, _ = w.Write([]byte(`[]`))
+			}
+		case r.Method == "DELETE":
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/refs/heads/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+
+	result, err := client.CleanupBranches(context.Background(), "myuser", "winget/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result) != 1 || result[0] != "winget/a/1.0.0+1" {
+		t.Errorf("expected only merged branch to be reported, got %v", result)
+	}
+	if len(deleted) != 1 || deleted[0] != "winget/a/1.0.0+1" {
+		t.Errorf("expected only merged branch to be deleted, got %v", deleted)
+	}
+}
+
+func TestClientCleanupBranchesSkipsFailures(t *testing.T) {
+	var deleted []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user":
+			_, _ = w.Write([]byte(`{"login":"myuser"}`))
+		case r.URL.Path == "/repos/myuser/winget-pkgs/git/matching-refs/heads/winget/":
+			_, _ = w.Write([]byte(`[{"ref":"refs/heads/winget/a/1.0.0"},{"ref":"refs/heads/winget/a/1.1.0"},{"ref":"refs/heads/winget/a/1.2.0"}]`))
+		case r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			if r.URL.Query().Get("head") == "myuser:winget/a/1.0.0" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"state":"closed"}]`))
+		case r.Method == "DELETE":
+			branch := strings.TrimPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/refs/heads/")
+			if branch == "winget/a/1.1.0" {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			deleted = append(deleted, branch)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	result, err := client.CleanupBranches(context.Background(), "", "winget/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 || result[0] != "winget/a/1.2.0" || len(deleted) != 1 {
+		t.Errorf("expected the sweep to continue past failing branches, got %v", result)
+	}
+}
+
+func TestAPIErrorIsPRAlreadyExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		expected   bool
+	}{
+		{
+			name:       "existing PR",
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `{"message":"Validation Failed","errors":[{"resource":"PullRequest","code":"custom","message":"A pull request already exists for myuser:winget/MyOrg-MyApp/1.0.0."}]}`,
+			expected:   true,
+		},
+		{
+			name:       "other validation error",
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `{"message":"Validation Failed","errors":[{"resource":"PullRequest","field":"head","code":"invalid"}]}`,
+			expected:   false,
+		},
+		{
+			name:       "different status",
+			statusCode: http.StatusForbidden,
+			body:       `{"message":"A pull request already exists"}`,
+			expected:   false,
+		},
+		{
+			name:       "non-JSON body",
+			statusCode: http.StatusUnprocessableEntity,
+			body:       `not json`,
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := newAPIError(tt.statusCode, []byte(tt.body))
+			if result := apiErr.isPRAlreadyExists(); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestClientDoRequestReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message":"Validation Failed","errors":[{"message":"A pull request already exists for a:b."}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		token:  "test-token",
+		client: &http.Client{},
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), "POST", server.URL, nil)
+	err := client.doRequest(req, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.Message != "Validation Failed" {
+		t.Errorf("expected message 'Validation Failed', got '%s'", apiErr.Message)
+	}
+	if !apiErr.isPRAlreadyExists() {
+		t.Error("expected existing PR to be detected")
+	}
+}
+
+func TestIsStaleBranch(t *testing.T) {
+	merged := "2024-01-01T00:00:00Z"
+
+	tests := []struct {
+		name     string
+		states   []pullRequestState
+		expected bool
+	}{
+		{"no pull requests", nil, false},
+		{"open", []pullRequestState{{State: "open"}}, false},
+		{"merged", []pullRequestState{{State: "closed", MergedAt: &merged}}, true},
+		{"closed", []pullRequestState{{State: "closed"}}, true},
+		{"closed and reopened", []pullRequestState{{State: "closed"}, {State: "open"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isStaleBranch(tt.states); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestClientCreatePRCleansUpOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var deletedBranch string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/microsoft/winget-pkgs/git/ref/heads/"):
+			_, _ = w.Write([]byte(`{"object":{"sha":"abc123"}}`))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/ref/heads/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/repos/myuser/winget-pkgs/git/refs":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT":
+			// Simulate the run being cancelled mid-commit
+			cancel()
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == "DELETE":
+			deletedBranch = strings.TrimPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/refs/heads/")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	pkg := manifest.Package{Identifier: "MyOrg.MyApp", Publisher: "My Org", Name: "My App", License: "MIT", ShortDescription: "App"}
+	manifests, err := manifest.Generate(pkg, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+	_, err = client.CreatePR(ctx, manifests, PullRequestOptions{BaseBranch: "master", Title: "title"})
+	if err == nil {
+		t.Fatal("expected error after cancellation")
+	}
+
+	if deletedBranch != "winget/MyOrg-MyApp/1.0.0" {
+		t.Errorf("expected created branch to be deleted, got '%s'", deletedBranch)
+	}
+}
+
+func TestClientPublishedManifests(t *testing.T) {
+	const dir = "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp"
+	content := base64.StdEncoding.EncodeToString([]byte("PackageVersion: 1.10.0\n"))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no Authorization header without a token, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case dir:
+			_, _ = w.Write([]byte(`[{"name":"1.2.0","type":"dir"},{"name":"1.10.0","type":"dir"},{"name":"Preview","type":"dir"}]`))
+		case dir + "/1.2.0", dir + "/1.10.0":
+			_, _ = w.Write([]byte(`[{"name":"MyOrg.MyApp.yaml","path":"manifests/m/MyOrg/MyApp/1.10.0/MyOrg.MyApp.yaml","type":"file"}]`))
+		case dir + "/1.10.0/MyOrg.MyApp.yaml":
+			_, _ = w.Write([]byte(`{"encoding":"base64","content":"` + content[:8] + `\n` + content[8:] + `"}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("", "", WithBaseURL(server.URL))
+
+	version, files, err := client.PublishedManifests(context.Background(), "MyOrg.MyApp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "1.10.0" {
+		t.Errorf("expected latest version '1.10.0', got '%s'", version)
+	}
+	if files["MyOrg.MyApp.yaml"] != "PackageVersion: 1.10.0\n" {
+		t.Errorf("unexpected file content: %q", files["MyOrg.MyApp.yaml"])
+	}
+}
+
+func TestClientPublishedManifestsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	version, files, err := client.PublishedManifests(context.Background(), "MyOrg.MyApp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "" || files != nil {
+		t.Errorf("expected no published version, got '%s' %v", version, files)
+	}
+}
+
+func TestClientReleaseAssets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/myorg/myapp/releases/tags/v1.2.3" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"assets":[{"name":"myapp-x64.msi","browser_download_url":"https://example.com/myapp-x64.msi"}]}`))
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	assets, err := client.ReleaseAssets(context.Background(), "myorg", "myapp", "v1.2.3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(assets) != 1 || assets[0].Name != "myapp-x64.msi" || assets[0].URL != "https://example.com/myapp-x64.msi" {
+		t.Errorf("unexpected assets: %v", assets)
+	}
+}
+
+func TestClientRepositoryTopics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/myorg/myapp/topics" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"names":["cli","windows"]}`))
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	topics, err := client.RepositoryTopics(context.Background(), "myorg", "myapp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(topics, ",") != "cli,windows" {
+		t.Errorf("unexpected topics: %v", topics)
+	}
+}
+
+// forkContents stands in for the Contents API of a fork branch: it stores
+// committed files and lists them with their git blob SHAs.
+type forkContents struct {
+	mu    sync.Mutex
+	files map[string]string
+}
+
+const forkContentsPrefix = "/repos/myuser/winget-pkgs/contents/"
+
+// put handles a PUT of a file.
+func (f *forkContents) put(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Content string `json:"content"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	content, _ := base64.StdEncoding.DecodeString(body.Content)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.files == nil {
+		f.files = make(map[string]string)
+	}
+	f.files[strings.TrimPrefix(r.URL.Path, forkContentsPrefix)] = string(content)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// list handles a GET of a directory.
+func (f *forkContents) list(w http.ResponseWriter, r *http.Request) {
+	dir := strings.TrimPrefix(r.URL.Path, forkContentsPrefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var entries []contentEntry
+	for p, content := range f.files {
+		if path.Dir(p) == dir {
+			entries = append(entries, contentEntry{Name: path.Base(p), Path: p, Type: "file", SHA: gitBlobSHA(content)})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(entries)
+}
+
+func TestClientCreatePRRollsBackOnFailure(t *testing.T) {
+	var deletedBranch string
+	var fork forkContents
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/microsoft/winget-pkgs/git/ref/heads/"):
+			_, _ = w.Write([]byte(`{"object":{"sha":"abc123"}}`))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/ref/heads/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/repos/myuser/winget-pkgs/git/refs":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "PUT":
+			fork.put(w, r)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, forkContentsPrefix):
+			fork.list(w, r)
+		case r.Method == "POST" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+		case r.Method == "DELETE":
+			deletedBranch = strings.TrimPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/refs/heads/")
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	pkg := manifest.Package{Identifier: "MyOrg.MyApp", Publisher: "My Org", Name: "My App", License: "MIT", ShortDescription: "App"}
+	manifests, err := manifest.Generate(pkg, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+	_, err = client.CreatePR(context.Background(), manifests, PullRequestOptions{BaseBranch: "master", Title: "title"})
+	if err == nil {
+		t.Fatal("expected error when PR creation fails")
+	}
+
+	if deletedBranch != "winget/MyOrg-MyApp/1.0.0" {
+		t.Errorf("expected created branch to be deleted, got '%s'", deletedBranch)
+	}
+}
+
+// recordingCommitter records the commit made through it.
+type recordingCommitter struct {
+	branch  string
+	files   map[string]string
+	message string
+}
+
+func (c *recordingCommitter) Commit(ctx context.Context, owner, repo, branch string, files map[string]string, message string) error {
+	if owner != "myuser" || repo != "winget-pkgs" {
+		return errors.New("unexpected repository " + owner + "/" + repo)
+	}
+	c.branch, c.files, c.message = branch, files, message
+	return nil
+}
+
+func TestClientCreatePRWithCommitter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/microsoft/winget-pkgs/git/ref/heads/"):
+			_, _ = w.Write([]byte(`{"object":{"sha":"abc123"}}`))
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/myuser/winget-pkgs/git/ref/heads/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && r.URL.Path == "/repos/myuser/winget-pkgs/git/refs":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == "POST" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"html_url":"https://github.com/microsoft/winget-pkgs/pull/1"}`))
+		default:
+			// Files must not be committed through the Contents API
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	pkg := manifest.Package{Identifier: "MyOrg.MyApp", Publisher: "My Org", Name: "My App", License: "MIT", ShortDescription: "App"}
+	manifests, err := manifest.Generate(pkg, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	committer := &recordingCommitter{}
+	client := New("test-token", "myuser", WithBaseURL(server.URL), WithCommitter(committer))
+	result, err := client.CreatePR(context.Background(), manifests, PullRequestOptions{BaseBranch: "master", Title: "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.URL != "https://github.com/microsoft/winget-pkgs/pull/1" {
+		t.Errorf("unexpected pull request URL %s", result.URL)
+	}
+	if committer.branch != "winget/MyOrg-MyApp/1.0.0" || len(committer.files) != 3 || committer.message != "New version: MyOrg.MyApp version 1.0.0" {
+		t.Errorf("unexpected commit of %d files to %s: %s", len(committer.files), committer.branch, committer.message)
+	}
+}
+
+func TestClientRollbackPR(t *testing.T) {
+	tests := []struct {
+		name     string
+		pulls    string
+		expected string
+	}{
+		{"open PR", `[{"number":42,"html_url":"https://github.com/microsoft/winget-pkgs/pull/42"}]`, "https://github.com/microsoft/winget-pkgs/pull/42"},
+		{"no PR", `[]`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var closed, deleted bool
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "GET" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+					if r.URL.Query().Get("head") != "myuser:winget/MyOrg-MyApp/1.0.0+1" {
+						t.Errorf("unexpected head: %s", r.URL.Query().Get("head"))
+					}
+					_, _ = w.Write([]byte(tt.pulls))
+				case r.Method == "PATCH" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls/42":
+					body, _ := io.ReadAll(r.Body)
+					if !strings.Contains(string(body), `"state":"closed"`) {
+						t.Errorf("expected PR to be closed, got %s", body)
+					}
+					closed = true
+					_, _ = w.Write([]byte(`{}`))
+				case r.Method == "DELETE" && r.URL.Path == "/repos/myuser/winget-pkgs/git/refs/heads/winget/MyOrg-MyApp/1.0.0+1":
+					deleted = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := New("test-token", "myuser", WithBaseURL(server.URL))
+			prURL, err := client.RollbackPR(context.Background(), BranchName("MyOrg.MyApp", "1.0.0+1"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prURL != tt.expected {
+				t.Errorf("expected PR URL '%s', got '%s'", tt.expected, prURL)
+			}
+			if closed != (tt.expected != "") {
+				t.Errorf("expected PR closed %v, got %v", tt.expected != "", closed)
+			}
+			if !deleted {
+				t.Error("expected branch to be deleted")
+			}
+		})
+	}
+}
+
+func TestClientAnnotatePR(t *testing.T) {
+	requests := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests[r.Method+" "+r.URL.Path] = string(body)
+		if strings.HasSuffix(r.URL.Path, "/labels") {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"Must have push access"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+	err := client.AnnotatePR(context.Background(), "https://github.com/microsoft/winget-pkgs/pull/42", PullRequestAnnotations{
+		Labels:    []string{"New-Package"},
+		Assignees: []string{"myuser"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to add labels") {
+		t.Errorf("expected the labels failure, got: %v", err)
+	}
+	if body := requests["POST /repos/microsoft/winget-pkgs/issues/42/assignees"]; body != `{"assignees":["myuser"]}` {
+		t.Errorf("expected assignees to be added despite the labels failure, got %q", body)
+	}
+	if _, ok := requests["POST /repos/microsoft/winget-pkgs/pulls/42/requested_reviewers"]; ok {
+		t.Error("expected no reviewer request without reviewers")
+	}
+
+	if err := client.AnnotatePR(context.Background(), "https://github.com/microsoft/winget-pkgs/pulls", PullRequestAnnotations{}); err == nil {
+		t.Error("expected an error for a URL without a PR number")
+	}
+}
+
+func TestClientCreatePRResumesExistingBranch(t *testing.T) {
+	pkg := manifest.Package{Identifier: "MyOrg.MyApp", Publisher: "My Org", Name: "My App", License: "MIT", ShortDescription: "App"}
+	manifests, err := manifest.Generate(pkg, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, err := manifests.GetFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		openPR       string
+		allPRs       string
+		modified     bool
+		extraFile    bool
+		attempts     int
+		expectURL    string
+		expectBranch string
+		expectErr    error
+	}{
+		{"open pull request", `[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7"}]`, "", false, false, 0, "https://github.com/microsoft/winget-pkgs/pull/7", "winget/MyOrg-MyApp/1.0.0", nil},
+		{"missing pull request", `[]`, `[]`, false, false, 0, "https://github.com/microsoft/winget-pkgs/pull/8", "winget/MyOrg-MyApp/1.0.0", nil},
+		{"closed pull request", `[]`, `[{"state":"closed"}]`, false, false, 3, "", "", ErrPullRequestClosed},
+		{"different manifests", `[]`, `[]`, true, false, 0, "", "", ErrBranchConflict},
+		{"extra manifest", `[]`, `[]`, false, true, 0, "", "", ErrBranchConflict},
+		{"next attempt", `[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7"}]`, "", true, false, 3, "https://github.com/microsoft/winget-pkgs/pull/7", "winget/MyOrg-MyApp/1.0.0-2", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := "/repos/myuser/winget-pkgs/contents/" + manifests.Path
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == "GET" && strings.Contains(r.URL.Path, "/git/ref/heads/"):
+					_, _ = w.Write([]byte(`{"object":{"sha":"abc123"}}`))
+				case r.Method == "GET" && r.URL.Path == listing:
+					var entries []contentEntry
+					for p, content := range files {
+						// Only the first attempt's branch was edited
+						if tt.modified && r.URL.Query().Get("ref") == "winget/MyOrg-MyApp/1.0.0" {
+							content += "# edited\n"
+						}
+						entries = append(entries, contentEntry{Name: path.Base(p), Path: p, Type: "file", SHA: gitBlobSHA(content)})
+					}
+					if tt.extraFile {
+						p := manifests.Path + "/MyOrg.MyApp.locale.de-DE.yaml"
+						entries = append(entries, contentEntry{Name: path.Base(p), Path: p, Type: "file", SHA: gitBlobSHA("stale")})
+					}
+					_ = json.NewEncoder(w).Encode(entries)
+				case r.Method == "GET" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+					if tt.expectBranch != "" && r.URL.Query().Get("head") != "myuser:"+tt.expectBranch {
+						t.Errorf("unexpected head: %s", r.URL.Query().Get("head"))
+					}
+					if r.URL.Query().Get("state") == "all" {
+						_, _ = w.Write([]byte(tt.allPRs))
+						return
+					}
+					_, _ = w.Write([]byte(tt.openPR))
+				case r.Method == "POST" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+					if tt.expectErr != nil {
+						t.Error("expected no pull request to be created")
+					}
+					_, _ = w.Write([]byte(`{"html_url":"https://github.com/microsoft/winget-pkgs/pull/8"}`))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := New("test-token", "myuser", WithBaseURL(server.URL))
+			pr, err := client.CreatePR(context.Background(), manifests, PullRequestOptions{BaseBranch: "master", Title: "title", BranchAttempts: tt.attempts})
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("expected %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pr.URL != tt.expectURL || pr.Branch != tt.expectBranch || !pr.Resumed {
+				t.Errorf("expected PR '%s' on '%s', got %+v", tt.expectURL, tt.expectBranch, pr)
+			}
+		})
+	}
+}
+
+func TestAttemptBranch(t *testing.T) {
+	for attempt, expected := range map[int]string{0: "winget/a", 1: "winget/a", 2: "winget/a-2", 10: "winget/a-10"} {
+		if result := AttemptBranch("winget/a", attempt); result != expected {
+			t.Errorf("AttemptBranch(%d) = '%s', expected '%s'", attempt, result, expected)
+		}
+	}
+}
+
+func TestClientCommitFilesConcurrently(t *testing.T) {
+	orig := commitConflictBackoff
+	commitConflictBackoff = time.Millisecond
+	defer func() { commitConflictBackoff = orig }()
+
+	var fork forkContents
+	var inFlight, maxInFlight, conflicts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT":
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			// The first commit of a file conflicts with a concurrent one
+			if strings.HasSuffix(r.URL.Path, "/b.yaml") && conflicts.Add(1) == 1 {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			fork.put(w, r)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, forkContentsPrefix):
+			if r.URL.Query().Get("ref") != "my-branch" {
+				t.Errorf("expected ref 'my-branch', got %q", r.URL.Query().Get("ref"))
+			}
+			fork.list(w, r)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	files := map[string]string{
+		"manifests/m/MyOrg/MyApp/1.0.0/a.yaml": "a\n",
+		"manifests/m/MyOrg/MyApp/1.0.0/b.yaml": "b\n",
+		"manifests/m/MyOrg/MyApp/1.0.0/c.yaml": "c\n",
+		"manifests/m/MyOrg/MyApp/1.0.0/d.yaml": "d\n",
+		"manifests/m/MyOrg/MyApp/1.0.0/e.yaml": "e\n",
+	}
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+	if err := client.commitFiles(context.Background(), "myuser", "my-branch", files, "message"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if maxInFlight.Load() < 2 || maxInFlight.Load() > commitConcurrency {
+		t.Errorf("expected between 2 and %d concurrent commits, got %d", commitConcurrency, maxInFlight.Load())
+	}
+	if conflicts.Load() != 2 {
+		t.Errorf("expected the conflicting commit to be retried once, got %d attempts", conflicts.Load())
+	}
+	if !maps.Equal(fork.files, files) {
+		t.Errorf("unexpected committed files: %v", fork.files)
+	}
+}
+
+func TestClientCommitFilesVerifies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			w.WriteHeader(http.StatusCreated)
+		case "GET":
+			// A racing commit replaced the file
+			_ = json.NewEncoder(w).Encode([]contentEntry{{Name: "a.yaml", Path: "dir/a.yaml", Type: "file", SHA: gitBlobSHA("other\n")}})
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "myuser", WithBaseURL(server.URL))
+	err := client.commitFiles(context.Background(), "myuser", "my-branch", map[string]string{"dir/a.yaml": "a\n"}, "message")
+	if err == nil || !strings.Contains(err.Error(), "dir/a.yaml") {
+		t.Errorf("expected verification error for dir/a.yaml, got %v", err)
+	}
+}
+
+func TestGitBlobSHA(t *testing.T) {
+	// git hash-object of "hello\n"
+	if got := gitBlobSHA("hello\n"); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("unexpected blob SHA: %s", got)
+	}
+}
+
+func TestClientAttestations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/myorg/myapp/attestations/sha256:abc":
+			_, _ = w.Write([]byte(`{"attestations":[{"bundle":{"mediaType":"application/vnd.dev.sigstore.bundle.v0.3+json"},"repository_id":1}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+
+	attestations, err := client.Attestations(context.Background(), "myorg", "myapp", "sha256:abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attestations) != 1 || !strings.Contains(string(attestations[0].Bundle), "sigstore") {
+		t.Errorf("unexpected attestations: %v", attestations)
+	}
+
+	attestations, err = client.Attestations(context.Background(), "myorg", "myapp", "sha256:def")
+	if err != nil || attestations != nil {
+		t.Errorf("expected no attestations, got %v (%v)", attestations, err)
+	}
+}
+
+func TestClientBranchPullRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		pulls    string
+		expected *PullRequestStatus
+	}{
+		{"open", `[{"number":42,"html_url":"https://github.com/microsoft/winget-pkgs/pull/42","state":"open"}]`,
+			&PullRequestStatus{Number: 42, URL: "https://github.com/microsoft/winget-pkgs/pull/42", State: "open"}},
+		{"merged", `[{"number":42,"html_url":"https://github.com/microsoft/winget-pkgs/pull/42","state":"closed","merged_at":"2024-05-01T10:00:00Z","labels":[{"name":"Validation-Completed"}]}]`,
+			&PullRequestStatus{Number: 42, URL: "https://github.com/microsoft/winget-pkgs/pull/42", State: "closed", Merged: true, MergedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Labels: []string{"Validation-Completed"}}},
+		{"none", `[]`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/repos/microsoft/winget-pkgs/pulls" {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				if r.URL.Query().Get("head") != "myuser:winget/MyOrg-MyApp/1.0.0" || r.URL.Query().Get("state") != "all" {
+					t.Errorf("unexpected query: %s", r.URL.RawQuery)
+				}
+				_, _ = w.Write([]byte(tt.pulls))
+			}))
+			defer server.Close()
+
+			client := New("test-token", "myuser", WithBaseURL(server.URL))
+			status, err := client.BranchPullRequest(context.Background(), BranchName("MyOrg.MyApp", "1.0.0"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(status, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestClientIssues(t *testing.T) {
+	var created, updated string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/myorg/myapp/issues":
+			if r.URL.Query().Get("labels") != "winget,release" || r.URL.Query().Get("state") != "open" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[{"number":7,"title":"winget: MyOrg.MyApp 1.0.0","pull_request":{}},{"number":8,"title":"winget: MyOrg.MyApp 1.0.0","body":"old"}]`))
+		case r.Method == "POST" && r.URL.Path == "/repos/myorg/myapp/issues":
+			created = string(body)
+			_, _ = w.Write([]byte(`{"number":9,"html_url":"https://github.com/myorg/myapp/issues/9"}`))
+		case r.Method == "PATCH" && r.URL.Path == "/repos/myorg/myapp/issues/8":
+			updated = string(body)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	issue, err := client.FindIssue(context.Background(), "myorg", "myapp", "winget: MyOrg.MyApp 1.0.0", []string{"winget", "release"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if issue == nil || issue.Number != 8 || issue.Body != "old" {
+		t.Fatalf("expected issue 8, skipping the pull request, got %+v", issue)
+	}
+	if err := client.UpdateIssueBody(context.Background(), "myorg", "myapp", 8, "new"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated != `{"body":"new"}` {
+		t.Errorf("unexpected update: %s", updated)
+	}
+
+	issue, err = client.FindIssue(context.Background(), "myorg", "myapp", "winget: MyOrg.MyApp 2.0.0", []string{"winget", "release"})
+	if err != nil || issue != nil {
+		t.Errorf("expected no issue, got %+v (%v)", issue, err)
+	}
+
+	issue, err = client.CreateIssue(context.Background(), "myorg", "myapp", IssueOptions{Title: "winget: MyOrg.MyApp 2.0.0", Body: "body", Labels: []string{"winget"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if issue.URL != "https://github.com/myorg/myapp/issues/9" || created != `{"body":"body","labels":["winget"],"title":"winget: MyOrg.MyApp 2.0.0"}` {
+		t.Errorf("unexpected issue %+v from request %s", issue, created)
+	}
+}
+
+func TestClientReleases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/myorg/myapp/releases" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`[{"tag_name":"v1.3.0","draft":true},{"tag_name":"v1.2.0"},{"tag_name":"v1.2.0-rc.1","prerelease":true},{"tag_name":"v1.1.0"},{"tag_name":"v1.0.0"}]`))
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	releases, err := client.Releases(context.Background(), "myorg", "myapp", 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(releases) != 2 || releases[0].TagName != "v1.2.0" || releases[1].TagName != "v1.1.0" {
+		t.Errorf("unexpected releases: %+v", releases)
+	}
+}
+
+func TestClientPublishedVersions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp":
+			_, _ = w.Write([]byte(`[{"name":"1.0.0","type":"dir"},{"name":"1.10.0","type":"dir"},{"name":"2022","type":"dir"},{"name":"Beta","type":"dir"},{"name":"README.md","type":"file"}]`))
+		case "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp/1.0.0", "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp/1.10.0":
+			_, _ = w.Write([]byte(`[{"name":"MyOrg.MyApp.installer.yaml","type":"file"},{"name":"MyOrg.MyApp.yaml","type":"file"}]`))
+		case "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp/2022":
+			// A nested package such as MyOrg.MyApp.2022.Community
+			_, _ = w.Write([]byte(`[{"name":"Community","type":"dir"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	versions, err := client.PublishedVersions(context.Background(), "MyOrg.MyApp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"1.0.0", "1.10.0"}) {
+		t.Errorf("unexpected versions: %v", versions)
+	}
+
+	versions, err = client.PublishedVersions(context.Background(), "MyOrg.Other")
+	if err != nil || versions != nil {
+		t.Errorf("expected no versions for an unpublished package, got %v (%v)", versions, err)
+	}
+}
+
+func TestClientOpenPullRequestCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login":"myuser"}`))
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); q != "repo:microsoft/winget-pkgs is:pr is:open author:myuser" {
+				t.Errorf("unexpected query: %s", q)
+			}
+			_, _ = w.Write([]byte(`{"total_count":3,"items":[]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	count, err := client.OpenPullRequestCount(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 open pull requests, got %d", count)
+	}
+}
+
+func TestClientOpenPullRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login":"myuser"}`))
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); q != `repo:microsoft/winget-pkgs is:pr is:open author:myuser "MyOrg.MyApp" in:title` {
+				t.Errorf("unexpected query: %s", q)
+			}
+			if r.URL.Query().Get("sort") != "created" || r.URL.Query().Get("order") != "asc" {
+				t.Errorf("expected oldest first, got %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"total_count":1,"items":[{"number":42,"title":"New version: MyOrg.MyApp version 1.0.0","html_url":"https://github.com/microsoft/winget-pkgs/pull/42","labels":[{"name":"Validation-Completed"}],"created_at":"2024-05-01T10:00:00Z"}]}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New("test-token", "", WithBaseURL(server.URL))
+	pulls, err := client.OpenPullRequests(context.Background(), "MyOrg.MyApp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []PullRequestSummary{{
+		Number:    42,
+		Title:     "New version: MyOrg.MyApp version 1.0.0",
+		URL:       "https://github.com/microsoft/winget-pkgs/pull/42",
+		Labels:    []string{"Validation-Completed"},
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}}
+	if !reflect.DeepEqual(pulls, expected) {
+		t.Errorf("expected %+v, got %+v", expected, pulls)
+	}
+}
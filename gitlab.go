@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// GitLabConfig defines the GitLab project holding a winget-pkgs style
+// manifest tree, used by the gitlab backend.
+type GitLabConfig struct {
+	// URL is the GitLab instance, https://gitlab.com by default.
+	URL string `json:"url"`
+	// Project is the path of the project, such as "contoso/winget-manifests",
+	// or its numeric ID.
+	Project string `json:"project"`
+	// TargetBranch is the branch merge requests are opened against.
+	TargetBranch string `json:"target_branch"`
+	// Token is a personal, group or project access token with the api scope.
+	Token string `json:"token"`
+}
+
+// validateGitLab checks the gitlab backend configuration.
+func validateGitLab(cfg GitLabConfig, allowInsecure bool) []fieldError {
+	var problems []fieldError
+	if u, err := url.Parse(cfg.URL); err != nil || u.Host == "" {
+		problems = append(problems, fieldError{"gitlab.url", fmt.Sprintf("invalid GitLab URL %q", cfg.URL)})
+	} else if u.Scheme != "https" && !(allowInsecure && u.Scheme == "http") {
+		problems = append(problems, fieldError{"gitlab.url", "url must use https"})
+	}
+	if cfg.Project == "" {
+		problems = append(problems, fieldError{"gitlab.project", "project is required"})
+	} else if _, err := strconv.Atoi(cfg.Project); err != nil && !strings.Contains(cfg.Project, "/") {
+		problems = append(problems, fieldError{"gitlab.project", fmt.Sprintf("%q is not a project path such as contoso/winget-manifests or a project ID", cfg.Project)})
+	}
+	if cfg.TargetBranch == "" {
+		problems = append(problems, fieldError{"gitlab.target_branch", "target_branch is required"})
+	}
+	if cfg.Token == "" {
+		problems = append(problems, fieldError{"gitlab.token", "token is required"})
+	}
+	return problems
+}
+
+// GitLabClient pushes manifests to a GitLab project and opens merge requests
+// for them.
+type GitLabClient struct {
+	projectURL string
+	token      string
+	client     *http.Client
+}
+
+// NewGitLabClient creates a client for a project of a GitLab instance,
+// authenticating with an access token.
+func NewGitLabClient(baseURL, project, token string) *GitLabClient {
+	return &GitLabClient{
+		projectURL: strings.TrimSuffix(baseURL, "/") + "/api/v4/projects/" + url.PathEscape(project),
+		token:      token,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// SubmitMergeRequest commits the manifests to the source branch, creating it
+// from the target branch if needed, and opens a merge request. An open merge
+// request for the branch is reused. It returns the merge request web URL.
+func (c *GitLabClient) SubmitMergeRequest(ctx context.Context, m *manifest.Set, cr changeRequest) (string, error) {
+	files, err := m.GetFiles()
+	if err != nil {
+		return "", fmt.Errorf("failed to get manifest files: %w", err)
+	}
+
+	// A retried run commits on top of the branch of the previous attempt
+	ref := cr.SourceBranch
+	branchExists, err := c.exists(ctx, "/repository/branches/"+url.PathEscape(cr.SourceBranch))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up branch %s: %w", cr.SourceBranch, err)
+	}
+	if !branchExists {
+		ref = cr.TargetBranch
+	}
+
+	actions := make([]map[string]string, 0, len(files))
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		fileExists, err := c.exists(ctx, "/repository/files/"+url.PathEscape(path)+"?ref="+url.QueryEscape(ref))
+		if err != nil {
+			return "", fmt.Errorf("failed to look up %s: %w", path, err)
+		}
+		action := "create"
+		if fileExists {
+			action = "update"
+		}
+		actions = append(actions, map[string]string{"action": action, "file_path": path, "content": files[path]})
+	}
+	commit := map[string]any{
+		"branch":         cr.SourceBranch,
+		"commit_message": cr.CommitMessage,
+		"actions":        actions,
+	}
+	if !branchExists {
+		commit["start_branch"] = cr.TargetBranch
+	}
+	if err := c.send(ctx, "POST", "/repository/commits", commit, nil); err != nil {
+		return "", fmt.Errorf("failed to commit manifests: %w", err)
+	}
+
+	query := url.Values{
+		"source_branch": {cr.SourceBranch},
+		"target_branch": {cr.TargetBranch},
+		"state":         {"opened"},
+	}
+	var open []gitLabMergeRequest
+	if err := c.send(ctx, "GET", "/merge_requests?"+query.Encode(), nil, &open); err != nil {
+		return "", fmt.Errorf("failed to look up merge request: %w", err)
+	}
+	if len(open) > 0 {
+		return open[0].WebURL, nil
+	}
+
+	var mr gitLabMergeRequest
+	err = c.send(ctx, "POST", "/merge_requests", map[string]any{
+		"source_branch":        cr.SourceBranch,
+		"target_branch":        cr.TargetBranch,
+		"title":                cr.Title,
+		"description":          cr.Description,
+		"remove_source_branch": true,
+	}, &mr)
+	if err != nil {
+		return "", fmt.Errorf("failed to create merge request: %w", err)
+	}
+	return mr.WebURL, nil
+}
+
+// gitLabMergeRequest is the subset of a GitLab merge request used by the
+// plugin.
+type gitLabMergeRequest struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+}
+
+// exists reports whether a project resource exists.
+func (c *GitLabClient) exists(ctx context.Context, path string) (bool, error) {
+	err := c.send(ctx, "HEAD", path, nil, nil)
+	var statusErr *scmStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// send issues a JSON request against the project API and decodes the
+// response into result, if not nil.
+func (c *GitLabClient) send(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.projectURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &scmStatusError{Service: "GitLab", StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGitLabClientSubmitMergeRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		branchExists bool
+		mrExists     bool
+		action       string
+		expectCreate bool
+	}{
+		{"new branch", false, false, "create", true},
+		{"existing branch", true, false, "update", true},
+		{"existing merge request", true, true, "update", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commit struct {
+				Branch      string              `json:"branch"`
+				StartBranch string              `json:"start_branch"`
+				Actions     []map[string]string `json:"actions"`
+			}
+			var created map[string]any
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("PRIVATE-TOKEN") != "glpat-token" {
+					t.Errorf("expected the token header, got %q", r.Header.Get("PRIVATE-TOKEN"))
+				}
+
+				prefix := "/api/v4/projects/contoso%2Fwinget-manifests"
+				path := r.URL.EscapedPath()
+				if !strings.HasPrefix(path, prefix) {
+					t.Errorf("unexpected path %s", path)
+				}
+				path = strings.TrimPrefix(path, prefix)
+				switch {
+				case path == "/repository/branches/winget%2FMyOrg-MyApp%2F1.0.0":
+					if !tt.branchExists {
+						w.WriteHeader(http.StatusNotFound)
+					}
+				case strings.HasPrefix(path, "/repository/files/manifests%2Fm%2FMyOrg%2FMyApp%2F1.0.0%2F"):
+					wantRef := "main"
+					if tt.branchExists {
+						wantRef = "winget/MyOrg-MyApp/1.0.0"
+					}
+					if r.URL.Query().Get("ref") != wantRef {
+						t.Errorf("expected ref %s, got %s", wantRef, r.URL.Query().Get("ref"))
+					}
+					if !tt.branchExists {
+						w.WriteHeader(http.StatusNotFound)
+					}
+				case path == "/repository/commits":
+					if err := json.NewDecoder(r.Body).Decode(&commit); err != nil {
+						t.Errorf("failed to decode commit: %v", err)
+					}
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{}`))
+				case path == "/merge_requests" && r.Method == "GET":
+					if tt.mrExists {
+						_, _ = w.Write([]byte(`[{"iid":4,"web_url":"https://gitlab.com/contoso/winget-manifests/-/merge_requests/4"}]`))
+						return
+					}
+					_, _ = w.Write([]byte(`[]`))
+				case path == "/merge_requests":
+					if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+						t.Errorf("failed to decode merge request: %v", err)
+					}
+					_, _ = w.Write([]byte(`{"iid":5,"web_url":"https://gitlab.com/contoso/winget-manifests/-/merge_requests/5"}`))
+				default:
+					t.Errorf("unexpected request: %s %s", r.Method, path)
+				}
+			}))
+			defer server.Close()
+
+			client := NewGitLabClient(server.URL+"/", "contoso/winget-manifests", "glpat-token")
+			mrURL, err := client.SubmitMergeRequest(context.Background(), validTestManifests(t), changeRequest{
+				SourceBranch:  "winget/MyOrg-MyApp/1.0.0",
+				TargetBranch:  "main",
+				Title:         "New version: MyOrg.MyApp version 1.0.0",
+				Description:   "Automated",
+				CommitMessage: "New version: MyOrg.MyApp version 1.0.0",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			wantStart := "main"
+			if tt.branchExists {
+				wantStart = ""
+			}
+			if commit.Branch != "winget/MyOrg-MyApp/1.0.0" || commit.StartBranch != wantStart {
+				t.Errorf("unexpected commit branches %q from %q", commit.Branch, commit.StartBranch)
+			}
+			if len(commit.Actions) != 3 {
+				t.Fatalf("expected three file actions, got %v", commit.Actions)
+			}
+			for _, action := range commit.Actions {
+				if action["action"] != tt.action || action["content"] == "" {
+					t.Errorf("unexpected action %v", action)
+				}
+			}
+
+			if (created != nil) != tt.expectCreate {
+				t.Errorf("expected merge request creation %v, got %v", tt.expectCreate, created)
+			}
+			if created != nil && (created["title"] != "New version: MyOrg.MyApp version 1.0.0" || created["remove_source_branch"] != true) {
+				t.Errorf("unexpected merge request %v", created)
+			}
+			wantURL := "https://gitlab.com/contoso/winget-manifests/-/merge_requests/5"
+			if tt.mrExists {
+				wantURL = "https://gitlab.com/contoso/winget-manifests/-/merge_requests/4"
+			}
+			if mrURL != wantURL {
+				t.Errorf("expected %s, got %s", wantURL, mrURL)
+			}
+		})
+	}
+}
+
+func TestValidateGitLab(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       GitLabConfig
+		wantField string
+	}{
+		{"valid", GitLabConfig{URL: "https://gitlab.com", Project: "contoso/manifests", TargetBranch: "main", Token: "t"}, ""},
+		{"http", GitLabConfig{URL: "http://gitlab.local", Project: "contoso/manifests", TargetBranch: "main", Token: "t"}, "gitlab.url"},
+		{"project id", GitLabConfig{URL: "https://gitlab.com", Project: "42", TargetBranch: "main", Token: "t"}, ""},
+		{"invalid project", GitLabConfig{URL: "https://gitlab.com", Project: "manifests", TargetBranch: "main", Token: "t"}, "gitlab.project"},
+		{"no token", GitLabConfig{URL: "https://gitlab.com", Project: "contoso/manifests", TargetBranch: "main"}, "gitlab.token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateGitLab(tt.cfg, false)
+			if tt.wantField == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("expected a problem for %s, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
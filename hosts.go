@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// validateAllowedHosts checks the allowed_hosts of each installer: host
+// names, optionally starting with a "*." wildcard label.
+func validateAllowedHosts(installers []InstallerConfig) []fieldError {
+	var problems []fieldError
+	for i, installer := range installers {
+		for j, host := range installer.AllowedHosts {
+			name := strings.TrimPrefix(host, "*.")
+			if name == "" || strings.ContainsAny(name, "/:*@ ") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+				problems = append(problems, fieldError{fmt.Sprintf("installers[%d].allowed_hosts[%d]", i, j),
+					fmt.Sprintf("%q is not a host name such as example.com or *.example.com", host)})
+			}
+		}
+	}
+	return problems
+}
+
+// hostAllowed reports whether a host matches one of the allowed hosts,
+// ignoring case. A "*.example.com" entry matches the subdomains of
+// example.com but not example.com itself.
+func hostAllowed(host string, allowed []string) bool {
+	for _, entry := range allowed {
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok {
+			if len(host) > len(suffix) && strings.HasSuffix(strings.ToLower(host), strings.ToLower(suffix)) {
+				return true
+			}
+		} else if strings.EqualFold(host, entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFinalHost fails if an installer was downloaded, after redirects, from
+// a host that is not allowed, so a compromised or misconfigured redirect
+// cannot make the plugin hash and publish an unexpected file. Installers
+// without allowed hosts may be downloaded from anywhere.
+func checkFinalHost(finalURL string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	u, err := url.Parse(finalURL)
+	if err != nil {
+		return fmt.Errorf("invalid final URL %s: %w", finalURL, err)
+	}
+	if !hostAllowed(u.Hostname(), allowed) {
+		return fmt.Errorf("the installer was downloaded from %s, whose host is not one of the allowed hosts %s", finalURL, strings.Join(allowed, ", "))
+	}
+	return nil
+}
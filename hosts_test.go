@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateAllowedHosts(t *testing.T) {
+	installers := []InstallerConfig{
+		{AllowedHosts: []string{"github.com", "*.githubusercontent.com"}},
+		{AllowedHosts: []string{"https://example.com", "example.com:443", "*", "*.", ".example.com"}},
+	}
+	problems := validateAllowedHosts(installers)
+	if len(problems) != 5 {
+		t.Fatalf("expected 5 problems, got %v", problems)
+	}
+	for j, problem := range problems {
+		if want := fmt.Sprintf("installers[1].allowed_hosts[%d]", j); problem.Field != want {
+			t.Errorf("expected field %s, got %s", want, problem.Field)
+		}
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"github.com", "*.githubusercontent.com"}
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"github.com", true},
+		{"GitHub.com", true},
+		{"objects.githubusercontent.com", true},
+		{"githubusercontent.com", false},
+		{"evilgithubusercontent.com", false},
+		{"api.github.com", false},
+		{"example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := hostAllowed(tt.host, allowed); got != tt.expected {
+				t.Errorf("hostAllowed(%q) = %v, expected %v", tt.host, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteAllowedHosts(t *testing.T) {
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer final.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(final.URL, "127.0.0.1", "localhost", 1)+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	execute := func(allowed ...any) *plugin.ExecuteResponse {
+		t.Helper()
+		cfg := validTestConfig()
+		installer := cfg["installers"].([]any)[0].(map[string]any)
+		installer["url"] = redirect.URL + "/app-{{.Version}}.msi"
+		installer["allowed_hosts"] = allowed
+		cfg["allow_insecure_urls"] = true
+		cfg["output_dir"] = t.TempDir()
+		cfg["pull_request"] = map[string]any{"enabled": false}
+		resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  cfg,
+			Context: plugin.ReleaseContext{Version: "1.2.3"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	if resp := execute("localhost"); !resp.Success {
+		t.Errorf("expected the redirect target to be allowed, got: %s", resp.Message)
+	}
+	resp := execute("127.0.0.1")
+	if resp.Success || !strings.Contains(resp.Message, "is not one of the allowed hosts") {
+		t.Errorf("expected the redirect to another host to fail, got: %s", resp.Message)
+	}
+}
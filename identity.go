@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+// identityCacheTTL bounds how long a cached GitHub identity is trusted, so a
+// deleted fork or renamed user is noticed within a day.
+const identityCacheTTL = 24 * time.Hour
+
+// identityCacheEntry is the content of the pull_request.identity_cache
+// file. The token is stored as a hash so the file can be cached by CI
+// without leaking it, and a different token invalidates the entry.
+type identityCacheEntry struct {
+	TokenHash  string    `json:"token_hash"`
+	APIBase    string    `json:"api_base"`
+	User       string    `json:"user"`
+	ForkExists bool      `json:"fork_exists"`
+	CachedAt   time.Time `json:"cached_at"`
+}
+
+// tokenHash returns the identity cache key of a GitHub token.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// loadIdentityCache returns the identity cached at path for token. It
+// reports false if the file is missing, unreadable, stale or belongs to
+// another token or API.
+func loadIdentityCache(path, token string, now time.Time) (githubclient.Identity, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return githubclient.Identity{}, false
+	}
+
+	var entry identityCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return githubclient.Identity{}, false
+	}
+	if entry.TokenHash != tokenHash(token) || entry.APIBase != githubAPIBase || entry.User == "" {
+		return githubclient.Identity{}, false
+	}
+	if now.Sub(entry.CachedAt) > identityCacheTTL || entry.CachedAt.After(now) {
+		return githubclient.Identity{}, false
+	}
+	return githubclient.Identity{User: entry.User, ForkExists: entry.ForkExists}, true
+}
+
+// saveIdentityCache writes identity to the cache file at path.
+func saveIdentityCache(path, token string, identity githubclient.Identity, now time.Time) error {
+	data, err := json.MarshalIndent(identityCacheEntry{
+		TokenHash:  tokenHash(token),
+		APIBase:    githubAPIBase,
+		User:       identity.User,
+		ForkExists: identity.ForkExists,
+		CachedAt:   now.UTC(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create identity cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write identity cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+func TestIdentityCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "cache", "identity.json")
+	identity := githubclient.Identity{User: "myuser", ForkExists: true}
+
+	if _, ok := loadIdentityCache(path, "ghp_token", now); ok {
+		t.Fatal("expected no identity before saving")
+	}
+	if err := saveIdentityCache(path, "ghp_token", identity, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "ghp_token") {
+		t.Errorf("identity cache contains the token:\n%s", data)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		now    time.Time
+		wantOK bool
+	}{
+		{"same token", "ghp_token", now.Add(time.Hour), true},
+		{"other token", "ghp_other", now.Add(time.Hour), false},
+		{"stale", "ghp_token", now.Add(25 * time.Hour), false},
+		{"from the future", "ghp_token", now.Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := loadIdentityCache(path, tt.token, tt.now)
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if ok && got != identity {
+				t.Errorf("expected %+v, got %+v", identity, got)
+			}
+		})
+	}
+}
+
+func TestIdentityCacheOtherAPI(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "identity.json")
+	if err := saveIdentityCache(path, "token", githubclient.Identity{User: "myuser"}, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orig := githubAPIBase
+	githubAPIBase = "https://github.example.com/api/v3"
+	defer func() { githubAPIBase = orig }()
+
+	if _, ok := loadIdentityCache(path, "token", now); ok {
+		t.Error("expected the identity of another API to be ignored")
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/plugin-winget/manifest"
+	"gopkg.in/yaml.v3"
+)
+
+// importedManifest holds the fields of a winget-pkgs manifest file that map to
+// plugin configuration. Installer fields may be set at the root of installer
+// manifests, in which case they apply to every installer.
+type importedManifest struct {
+	PackageIdentifier   string              `yaml:"PackageIdentifier"`
+	PackageVersion      string              `yaml:"PackageVersion"`
+	PackageLocale       string              `yaml:"PackageLocale"`
+	ManifestType        string              `yaml:"ManifestType"`
+	Publisher           string              `yaml:"Publisher"`
+	PublisherURL        string              `yaml:"PublisherUrl"`
+	PublisherSupportURL string              `yaml:"PublisherSupportUrl"`
+	PackageName         string              `yaml:"PackageName"`
+	License             string              `yaml:"License"`
+	LicenseURL          string              `yaml:"LicenseUrl"`
+	Copyright           string              `yaml:"Copyright"`
+	ShortDescription    string              `yaml:"ShortDescription"`
+	Description         string              `yaml:"Description"`
+	Moniker             string              `yaml:"Moniker"`
+	Tags                []string            `yaml:"Tags"`
+	Agreements          []importedAgreement `yaml:"Agreements"`
+	PackageURL          string              `yaml:"PackageUrl"`
+	ReleaseNotesURL     string              `yaml:"ReleaseNotesUrl"`
+	InstallationNotes   string              `yaml:"InstallationNotes"`
+	InstallerType       string              `yaml:"InstallerType"`
+	Scope               string              `yaml:"Scope"`
+	InstallerSwitches   map[string]string   `yaml:"InstallerSwitches"`
+	ProductCode         string              `yaml:"ProductCode"`
+	Installers          []importedInstaller `yaml:"Installers"`
+
+	UnsupportedArguments      []string `yaml:"UnsupportedArguments"`
+	DownloadCommandProhibited bool     `yaml:"DownloadCommandProhibited"`
+	RepairBehavior            string   `yaml:"RepairBehavior"`
+}
+
+// importedAgreement is an entry of the Agreements list of a locale manifest.
+type importedAgreement struct {
+	AgreementLabel string `yaml:"AgreementLabel"`
+	Agreement      string `yaml:"Agreement"`
+	AgreementURL   string `yaml:"AgreementUrl"`
+}
+
+// importedInstaller is an entry of the Installers list of a manifest.
+type importedInstaller struct {
+	Architecture           string            `yaml:"Architecture"`
+	InstallerType          string            `yaml:"InstallerType"`
+	InstallerURL           string            `yaml:"InstallerUrl"`
+	Scope                  string            `yaml:"Scope"`
+	InstallerSwitches      map[string]string `yaml:"InstallerSwitches"`
+	ProductCode            string            `yaml:"ProductCode"`
+	AppsAndFeaturesEntries []struct {
+		UpgradeCode string `yaml:"UpgradeCode"`
+	} `yaml:"AppsAndFeaturesEntries"`
+	UnsupportedArguments      []string `yaml:"UnsupportedArguments"`
+	DownloadCommandProhibited bool     `yaml:"DownloadCommandProhibited"`
+	RepairBehavior            string   `yaml:"RepairBehavior"`
+}
+
+// importManifestDir converts the manifests of one package version, as found in
+// a winget-pkgs version directory, into plugin configuration. Occurrences of
+// the version in URLs are replaced with {{.Version}} so the configuration
+// applies to later releases.
+func importManifestDir(dir string) (map[string]any, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no manifests found in %s", dir)
+	}
+
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		files[filepath.Base(path)] = string(data)
+	}
+	config, err := importManifests(files)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return config, nil
+}
+
+// importPublishedPackage converts the manifests of a package published in
+// winget-pkgs into plugin configuration like importManifestDir: those of
+// version, or of the latest published version if version is empty. Installer
+// URL templates are inferred by comparing the installer URLs with those of
+// the previous published version, if any; installers whose URL template
+// cannot be inferred are returned as warnings. It also returns the imported
+// version.
+func importPublishedPackage(ctx context.Context, client *githubclient.Client, packageID, version string) (map[string]any, string, []string, error) {
+	versions, err := client.PublishedVersions(ctx, packageID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if version == "" {
+		version = manifest.LatestVersion(versions)
+		if version == "" {
+			return nil, "", nil, fmt.Errorf("%s is not published in winget-pkgs", packageID)
+		}
+	}
+
+	config, err := importPublishedVersion(ctx, client, packageID, version)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	previousVersion := ""
+	for _, v := range versions {
+		if manifest.CompareVersions(v, version) < 0 && (previousVersion == "" || manifest.CompareVersions(v, previousVersion) > 0) {
+			previousVersion = v
+		}
+	}
+	if previousVersion == "" {
+		return config, version, []string{fmt.Sprintf("no version before %s is published; check the {{.Version}} placeholders of the installer URLs", version)}, nil
+	}
+	previous, err := importPublishedVersion(ctx, client, packageID, previousVersion)
+	if err != nil {
+		return config, version, []string{fmt.Sprintf("failed to import version %s to infer installer URL templates: %v", previousVersion, err)}, nil
+	}
+	return config, version, inferURLTemplates(config, previous, version, previousVersion), nil
+}
+
+// importPublishedVersion converts the manifests of a published version into
+// plugin configuration.
+func importPublishedVersion(ctx context.Context, client *githubclient.Client, packageID, version string) (map[string]any, error) {
+	files, err := client.VersionManifests(ctx, packageID, version)
+	if err != nil {
+		return nil, err
+	}
+	if files == nil {
+		return nil, fmt.Errorf("%s version %s is not published in winget-pkgs", packageID, version)
+	}
+	config, err := importManifests(files)
+	if err != nil {
+		return nil, fmt.Errorf("%s version %s: %w", packageID, version, err)
+	}
+	return config, nil
+}
+
+// importManifests converts the manifest files of one package version, keyed
+// by file name, into plugin configuration like importManifestDir.
+func importManifests(files map[string]string) (map[string]any, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var installerManifest, localeManifest *importedManifest
+	var otherLocales []*importedManifest
+	packageID := ""
+	for _, name := range names {
+		var m importedManifest
+		if err := yaml.Unmarshal([]byte(files[name]), &m); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if packageID == "" {
+			packageID = m.PackageIdentifier
+		}
+
+		switch m.ManifestType {
+		case "installer":
+			installerManifest = &m
+		case "defaultLocale":
+			localeManifest = &m
+		case "locale":
+			otherLocales = append(otherLocales, &m)
+		case "singleton":
+			installerManifest, localeManifest = &m, &m
+		}
+	}
+
+	if installerManifest == nil {
+		return nil, fmt.Errorf("no installer manifest found")
+	}
+	if localeManifest == nil {
+		return nil, fmt.Errorf("no default locale manifest found")
+	}
+
+	version := installerManifest.PackageVersion
+	templated := func(s string) string {
+		if version == "" {
+			return s
+		}
+		return strings.ReplaceAll(s, version, "{{.Version}}")
+	}
+
+	installers := make([]any, 0, len(installerManifest.Installers))
+	for _, installer := range installerManifest.Installers {
+		entry := map[string]any{
+			"url":          templated(installer.InstallerURL),
+			"architecture": installer.Architecture,
+			"type":         firstNonEmpty(installer.InstallerType, installerManifest.InstallerType),
+		}
+		if scope := firstNonEmpty(installer.Scope, installerManifest.Scope); scope != "" {
+			entry["scope"] = scope
+		}
+		switches := installer.InstallerSwitches
+		if switches == nil {
+			switches = installerManifest.InstallerSwitches
+		}
+		if len(switches) > 0 {
+			rawSwitches := make(map[string]any, len(switches))
+			for key, value := range switches {
+				rawSwitches[key] = value
+			}
+			entry["switches"] = rawSwitches
+		}
+		if productCode := firstNonEmpty(installer.ProductCode, installerManifest.ProductCode); productCode != "" {
+			entry["product_code"] = productCode
+		}
+		for _, arp := range installer.AppsAndFeaturesEntries {
+			if arp.UpgradeCode != "" {
+				entry["upgrade_code"] = arp.UpgradeCode
+				break
+			}
+		}
+		args := installer.UnsupportedArguments
+		if args == nil {
+			args = installerManifest.UnsupportedArguments
+		}
+		if len(args) > 0 {
+			rawArgs := make([]any, len(args))
+			for i, arg := range args {
+				rawArgs[i] = arg
+			}
+			entry["unsupported_arguments"] = rawArgs
+		}
+		if installer.DownloadCommandProhibited || installerManifest.DownloadCommandProhibited {
+			entry["download_command_prohibited"] = true
+		}
+		if repair := firstNonEmpty(installer.RepairBehavior, installerManifest.RepairBehavior); repair != "" {
+			entry["repair_behavior"] = repair
+		}
+		installers = append(installers, entry)
+	}
+
+	metadata := map[string]any{}
+	setString := func(key, value string) {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+	setString("publisher", localeManifest.Publisher)
+	setString("publisher_url", localeManifest.PublisherURL)
+	setString("publisher_support_url", localeManifest.PublisherSupportURL)
+	setString("name", localeManifest.PackageName)
+	setString("short_description", localeManifest.ShortDescription)
+	setString("license", localeManifest.License)
+	setString("license_url", localeManifest.LicenseURL)
+	setString("copyright", localeManifest.Copyright)
+	setString("package_url", localeManifest.PackageURL)
+	setString("moniker", localeManifest.Moniker)
+	setString("release_notes_url", templated(localeManifest.ReleaseNotesURL))
+	setString("installation_notes", localeManifest.InstallationNotes)
+	if len(localeManifest.Tags) > 0 {
+		tags := make([]any, len(localeManifest.Tags))
+		for i, tag := range localeManifest.Tags {
+			tags[i] = tag
+		}
+		metadata["tags"] = tags
+	}
+
+	config := map[string]any{
+		"package_id": packageID,
+		"installers": installers,
+		"metadata":   metadata,
+	}
+	var locales []any
+	for i, locale := range append([]*importedManifest{localeManifest}, otherLocales...) {
+		entry := map[string]any{}
+		setLocaleString := func(key, value string) {
+			if value != "" {
+				entry[key] = value
+			}
+		}
+		setLocaleString("description", locale.Description)
+		if i > 0 {
+			// The default locale's other fields are imported as metadata
+			setLocaleString("publisher", locale.Publisher)
+			setLocaleString("name", locale.PackageName)
+			setLocaleString("short_description", locale.ShortDescription)
+			setLocaleString("release_notes_url", templated(locale.ReleaseNotesURL))
+			setLocaleString("installation_notes", locale.InstallationNotes)
+			if len(locale.Tags) > 0 {
+				tags := make([]any, len(locale.Tags))
+				for j, tag := range locale.Tags {
+					tags[j] = tag
+				}
+				entry["tags"] = tags
+			}
+		}
+		if len(locale.Agreements) > 0 {
+			agreements := make([]any, 0, len(locale.Agreements))
+			for _, agreement := range locale.Agreements {
+				imported := map[string]any{}
+				for key, value := range map[string]string{"label": agreement.AgreementLabel, "text": agreement.Agreement, "url": agreement.AgreementURL} {
+					if value != "" {
+						imported[key] = value
+					}
+				}
+				agreements = append(agreements, imported)
+			}
+			entry["agreements"] = agreements
+		}
+		if len(entry) > 0 {
+			entry["locale"] = firstNonEmpty(locale.PackageLocale, manifest.DefaultLocale)
+			locales = append(locales, entry)
+		}
+	}
+	if len(locales) > 0 {
+		config["locales"] = locales
+	}
+	return config, nil
+}
+
+// wingetcreateSettings is the subset of the wingetcreate settings.json file
+// that maps to plugin configuration.
+type wingetcreateSettings struct {
+	WindowsPackageManagerRepository struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	} `json:"WindowsPackageManagerRepository"`
+	Manifest struct {
+		Format string `json:"format"`
+	} `json:"Manifest"`
+}
+
+// importWingetcreateSettings converts a wingetcreate settings.json file into
+// plugin configuration. It returns warnings for settings the plugin cannot
+// honour.
+func importWingetcreateSettings(path string) (map[string]any, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var settings wingetcreateSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var warnings []string
+	repo := settings.WindowsPackageManagerRepository
+	if (repo.Owner != "" && repo.Owner != "microsoft") || (repo.Name != "" && repo.Name != "winget-pkgs") {
+		warnings = append(warnings, fmt.Sprintf("submissions to %s/%s are not supported, manifests are submitted to microsoft/winget-pkgs", repo.Owner, repo.Name))
+	}
+	if format := strings.ToLower(settings.Manifest.Format); format != "" && format != "yaml" {
+		warnings = append(warnings, fmt.Sprintf("manifest format %q is not supported, manifests are written as YAML", settings.Manifest.Format))
+	}
+
+	return map[string]any{"backend": backendWingetcreate}, warnings, nil
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
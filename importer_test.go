@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+func TestImportManifestDir(t *testing.T) {
+	dir, err := validTestManifests(t).WriteTo(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := importManifestDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg, problems := decodePluginConfig(raw)
+	if len(problems) > 0 {
+		t.Fatalf("unexpected decode problems: %v", problems)
+	}
+	if cfg.PackageID != "MyOrg.MyApp" {
+		t.Errorf("expected package_id 'MyOrg.MyApp', got '%s'", cfg.PackageID)
+	}
+	if cfg.Metadata.Publisher != "My Organization" || cfg.Metadata.Name != "My Application" {
+		t.Errorf("unexpected metadata: %+v", cfg.Metadata)
+	}
+	if len(cfg.Installers) != 1 {
+		t.Fatalf("expected 1 installer, got %d", len(cfg.Installers))
+	}
+	installer := cfg.Installers[0]
+	if installer.URL != "https://example.com/myapp-{{.Version}}-x64.msi" {
+		t.Errorf("expected templated URL, got '%s'", installer.URL)
+	}
+	if installer.Architecture != "x64" || installer.Type != "msi" || installer.Scope != "machine" {
+		t.Errorf("unexpected installer: %+v", installer)
+	}
+	if installer.Switches["Silent"] != "/quiet" {
+		t.Errorf("expected switches to be imported, got %v", installer.Switches)
+	}
+}
+
+func TestImportManifestDirRootInstallerFields(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"MyOrg.MyApp.installer.yaml": `PackageIdentifier: MyOrg.MyApp
+PackageVersion: 2.1.0
+InstallerType: wix
+Scope: user
+ProductCode: '{01234567-89AB-CDEF-0123-456789ABCDEF}'
+UnsupportedArguments:
+  - log
+Installers:
+  - Architecture: x64
+    InstallerUrl: https://example.com/v2.1.0/app.msi
+  - Architecture: arm64
+    InstallerType: exe
+    InstallerUrl: https://example.com/v2.1.0/app-arm64.exe
+    DownloadCommandProhibited: true
+    RepairBehavior: uninstaller
+ManifestType: installer
+ManifestVersion: 1.6.0
+`,
+		"MyOrg.MyApp.locale.en-US.yaml": `PackageIdentifier: MyOrg.MyApp
+PackageVersion: 2.1.0
+PackageLocale: en-US
+Publisher: My Org
+PackageName: My App
+License: MIT
+ShortDescription: A test app
+Description: The full description
+ReleaseNotesUrl: https://example.com/releases/2.1.0
+ManifestType: defaultLocale
+ManifestVersion: 1.6.0
+`,
+		"MyOrg.MyApp.locale.de-DE.yaml": `PackageIdentifier: MyOrg.MyApp
+PackageVersion: 2.1.0
+PackageLocale: de-DE
+Description: Die vollständige Beschreibung
+Tags:
+  - werkzeug
+Agreements:
+  - AgreementLabel: Lizenz
+    AgreementUrl: https://example.com/de/eula
+ManifestType: locale
+ManifestVersion: 1.6.0
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raw, err := importManifestDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, problems := decodePluginConfig(raw)
+	if len(problems) > 0 {
+		t.Fatalf("unexpected decode problems: %v", problems)
+	}
+
+	if len(cfg.Installers) != 2 {
+		t.Fatalf("expected 2 installers, got %d", len(cfg.Installers))
+	}
+	if cfg.Installers[0].Type != "wix" || cfg.Installers[0].Scope != "user" || cfg.Installers[0].ProductCode == "" {
+		t.Errorf("expected root fields to apply, got %+v", cfg.Installers[0])
+	}
+	if cfg.Installers[1].Type != "exe" {
+		t.Errorf("expected installer type to override root, got '%s'", cfg.Installers[1].Type)
+	}
+	if len(cfg.Installers[0].UnsupportedArguments) != 1 || cfg.Installers[0].DownloadCommandProhibited {
+		t.Errorf("unexpected installer options: %+v", cfg.Installers[0])
+	}
+	if !cfg.Installers[1].DownloadCommandProhibited || cfg.Installers[1].RepairBehavior != "uninstaller" {
+		t.Errorf("expected download and repair settings to be imported, got %+v", cfg.Installers[1])
+	}
+	if cfg.Installers[1].URL != "https://example.com/v{{.Version}}/app-arm64.exe" {
+		t.Errorf("unexpected URL: %s", cfg.Installers[1].URL)
+	}
+	if cfg.Metadata.ReleaseNotesURL != "https://example.com/releases/{{.Version}}" {
+		t.Errorf("unexpected release notes URL: %s", cfg.Metadata.ReleaseNotesURL)
+	}
+	if len(cfg.Locales) != 2 || cfg.Locales[0].Locale != "en-US" || cfg.Locales[1].Locale != "de-DE" {
+		t.Fatalf("unexpected locales: %+v", cfg.Locales)
+	}
+	de := cfg.Locales[1]
+	if len(de.Tags) != 1 || len(de.Agreements) != 1 || de.Agreements[0].Label != "Lizenz" || de.Agreements[0].URL != "https://example.com/de/eula" {
+		t.Errorf("expected de-DE tags and agreements to be imported, got %+v", de)
+	}
+}
+
+func TestImportManifestDirErrors(t *testing.T) {
+	if _, err := importManifestDir(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no manifests found") {
+		t.Errorf("expected missing manifests error, got %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "MyOrg.MyApp.yaml"), []byte("PackageIdentifier: MyOrg.MyApp\nManifestType: version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importManifestDir(dir); err == nil || !strings.Contains(err.Error(), "no installer manifest") {
+		t.Errorf("expected missing installer manifest error, got %v", err)
+	}
+}
+
+func TestImportWingetcreateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		warnings int
+	}{
+		{"defaults", `{"WindowsPackageManagerRepository": {"owner": "microsoft", "name": "winget-pkgs"}, "Manifest": {"format": "yaml"}}`, 0},
+		{"custom repository", `{"WindowsPackageManagerRepository": {"owner": "myorg", "name": "winget-pkgs"}}`, 1},
+		{"json format", `{"Manifest": {"format": "json"}}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, warnings, err := importWingetcreateSettings(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config["backend"] != backendWingetcreate {
+				t.Errorf("expected wingetcreate backend, got %v", config["backend"])
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("expected %d warnings, got %v", tt.warnings, warnings)
+			}
+		})
+	}
+}
+
+func TestImportPublishedPackage(t *testing.T) {
+	newWingetPkgsServer(t, "MyOrg.MyApp", map[string]map[string]string{
+		"1.1.0": manifestFiles(t, testVersionManifests(t, "1.1.0", "https://example.com/v1.1.0/myapp_1_1_0-x64.msi")),
+		"1.0.0": manifestFiles(t, testVersionManifests(t, "1.0.0", "https://example.com/v1.0.0/myapp_1_0_0-x64.msi")),
+	})
+	client := newGitHubClient(context.Background(), defaultConfig(), "", slog.Default())
+
+	raw, version, warnings, err := importPublishedPackage(context.Background(), client, "MyOrg.MyApp", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if version != "1.1.0" || raw["package_id"] != "MyOrg.MyApp" || len(warnings) != 0 {
+		t.Errorf("expected MyOrg.MyApp 1.1.0 without warnings, got %s: %v %v", version, raw, warnings)
+	}
+	installer := raw["installers"].([]any)[0].(map[string]any)
+	if want := `https://example.com/v{{.Version}}/myapp_{{.Version | replace "." "_"}}-x64.msi`; installer["url"] != want {
+		t.Errorf("expected URL template %s, got %v", want, installer["url"])
+	}
+
+	// The oldest version has no previous version to compare with
+	raw, _, warnings, err = importPublishedPackage(context.Background(), client, "MyOrg.MyApp", "1.0.0")
+	if err != nil || len(warnings) != 1 || !strings.Contains(warnings[0], "no version before 1.0.0") {
+		t.Errorf("expected a warning about the missing previous version, got %v, %v", warnings, err)
+	}
+	if installer := raw["installers"].([]any)[0].(map[string]any); installer["url"] != "https://example.com/v{{.Version}}/myapp_1_0_0-x64.msi" {
+		t.Errorf("expected the version replaced in the URL, got %v", installer["url"])
+	}
+
+	if _, _, _, err := importPublishedPackage(context.Background(), client, "MyOrg.MyApp", "2.0.0"); err == nil || !strings.Contains(err.Error(), "version 2.0.0 is not published") {
+		t.Errorf("expected an unpublished version error, got %v", err)
+	}
+	if _, _, _, err := importPublishedPackage(context.Background(), client, "MyOrg.Other", ""); err == nil || !strings.Contains(err.Error(), "is not published") {
+		t.Errorf("expected an unpublished package error, got %v", err)
+	}
+}
+
+// testVersionManifests returns the manifests of a version of validTestManifests
+// with an installer URL.
+func testVersionManifests(t *testing.T, version, url string) *manifest.Set {
+	t.Helper()
+
+	manifests := validTestManifests(t)
+	manifests.Version.PackageVersion = version
+	manifests.Installer.PackageVersion = version
+	manifests.Installer.Installers[0].InstallerURL = url
+	manifests.Locale.PackageVersion = version
+	return manifests
+}
+
+// newWingetPkgsServer serves the published manifests of the versions of a
+// package, keyed by file name, through the GitHub contents API.
+func newWingetPkgsServer(t *testing.T, packageID string, versions map[string]map[string]string) *httptest.Server {
+	t.Helper()
+	return newGitHubAPIServer(t, wingetPkgsHandler(packageID, versions))
+}
+
+// newGitHubAPIServer serves handler as the GitHub API for the test.
+func newGitHubAPIServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	t.Cleanup(func() { githubAPIBase = originalBase })
+	return server
+}
+
+// wingetPkgsHandler serves the published manifests of the versions of a
+// package like newWingetPkgsServer.
+func wingetPkgsHandler(packageID string, versions map[string]map[string]string) http.HandlerFunc {
+	dir := manifest.PublishedDir(packageID)
+	return func(w http.ResponseWriter, r *http.Request) {
+		contents := strings.TrimPrefix(r.URL.Path, "/repos/microsoft/winget-pkgs/contents/")
+		var entries []map[string]string
+		switch {
+		case contents == dir:
+			for version := range versions {
+				entries = append(entries, map[string]string{"name": version, "path": dir + "/" + version, "type": "dir"})
+			}
+		case path.Dir(contents) == dir && versions[path.Base(contents)] != nil:
+			for name := range versions[path.Base(contents)] {
+				entries = append(entries, map[string]string{"name": name, "path": contents + "/" + name, "type": "file"})
+			}
+		case path.Dir(path.Dir(contents)) == dir:
+			content, ok := versions[path.Base(path.Dir(contents))][path.Base(contents)]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(content)), "encoding": "base64"})
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(entries)
+	}
+}
+
+// manifestFiles returns the files of a manifest set keyed by file name.
+func manifestFiles(t *testing.T, manifests *manifest.Set) map[string]string {
+	t.Helper()
+
+	files, err := manifests.GetFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := make(map[string]string, len(files))
+	for file, content := range files {
+		named[path.Base(file)] = content
+	}
+	return named
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// Inno Setup 5.1.5 and later locate their setup data through an offset
+// table stored as an RT_RCDATA resource.
+const (
+	innoOffsetTableID      = 11111
+	innoOffsetTableMagic   = "rDlPtS\xcd\xe6\xd7\x7b\x0b\x2a"
+	innoOffsetTableSize    = 44
+	innoOffsetTableVersion = 1
+)
+
+// Layout of the compressed setup header.
+const (
+	innoSetupIDSize = 64
+	innoChunkSize   = 4096
+	innoHeaderLimit = 1 << 16
+)
+
+// innoMaxAppIDLength is the longest AppId Inno Setup uses as is in the name
+// of the uninstall registry key.
+const innoMaxAppIDLength = 57
+
+// innoApp identifies the Apps & Features entry an Inno Setup installer
+// registers.
+type innoApp struct {
+	// ProductCode is the name of the uninstall registry key, the AppId with
+	// an "_is1" suffix.
+	ProductCode string
+	// DisplayName is the AppVerName, which the entry is named after unless
+	// the script sets UninstallDisplayName.
+	DisplayName string
+}
+
+// readInnoApp reads the Apps & Features entry of the Inno Setup installer at
+// file. Values that are resolved at install time are left empty; when the
+// setup header was read, the DisplayName is returned along with an error for
+// an AppId that cannot be used.
+func readInnoApp(file string) (innoApp, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return innoApp{}, fmt.Errorf("failed to open installer: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return readInnoSetupApp(f)
+}
+
+// readInnoSetupApp reads the AppName, AppVerName and AppId from the setup
+// header of an Inno Setup installer, falling back to the AppName for the
+// AppId like Inno Setup does. Values with constants other than "{{" are
+// resolved at install time and are not used.
+func readInnoSetupApp(r io.ReaderAt) (innoApp, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return innoApp{}, fmt.Errorf("failed to open installer: %w", err)
+	}
+	table, err := peResource(f, rtRCData, innoOffsetTableID)
+	if err != nil {
+		return innoApp{}, fmt.Errorf("not an Inno Setup installer: %w", err)
+	}
+	if len(table) < innoOffsetTableSize || string(table[:12]) != innoOffsetTableMagic {
+		return innoApp{}, errors.New("not an Inno Setup installer: unknown setup loader")
+	}
+	if version := binary.LittleEndian.Uint32(table[12:]); version != innoOffsetTableVersion {
+		return innoApp{}, fmt.Errorf("unsupported Inno Setup loader version %d", version)
+	}
+	if crc32.ChecksumIEEE(table[:40]) != binary.LittleEndian.Uint32(table[40:]) {
+		return innoApp{}, errors.New("corrupt Inno Setup offset table")
+	}
+	headerOffset := int64(binary.LittleEndian.Uint32(table[32:]))
+
+	// The setup data starts with its ID, such as
+	// "Inno Setup Setup Data (6.2.0) (u)", and the compressed header
+	id := make([]byte, innoSetupIDSize)
+	if _, err := r.ReadAt(id, headerOffset); err != nil {
+		return innoApp{}, fmt.Errorf("failed to read Inno Setup data: %w", err)
+	}
+	setupID := string(bytes.TrimRight(id, "\x00"))
+	if !strings.HasPrefix(setupID, "Inno Setup Setup Data (") {
+		return innoApp{}, fmt.Errorf("unknown Inno Setup data %q", setupID)
+	}
+	unicode := strings.Contains(setupID, "(u)") || !strings.HasPrefix(setupID, "Inno Setup Setup Data (5.")
+
+	header, err := readInnoBlock(r, headerOffset+innoSetupIDSize)
+	if err != nil {
+		return innoApp{}, fmt.Errorf("failed to read Inno Setup header: %w", err)
+	}
+
+	// The header starts with the AppName, AppVerName and AppId strings
+	var strs []string
+	for range 3 {
+		if len(header) < 4 {
+			return innoApp{}, errors.New("truncated Inno Setup header")
+		}
+		size := int(binary.LittleEndian.Uint32(header))
+		if size > len(header)-4 {
+			return innoApp{}, errors.New("truncated Inno Setup header")
+		}
+		value := header[4 : 4+size]
+		if unicode {
+			strs = append(strs, decodeUTF16(value))
+		} else {
+			strs = append(strs, string(value))
+		}
+		header = header[4+size:]
+	}
+
+	var app innoApp
+	if displayName, ok := innoLiteral(strs[1]); ok {
+		app.DisplayName = displayName
+	}
+	raw := strs[2]
+	if raw == "" {
+		raw = strs[0]
+	}
+	appID, ok := innoLiteral(raw)
+	switch {
+	case !ok:
+		return app, fmt.Errorf("AppId %q depends on the installation", raw)
+	case appID == "":
+		return app, errors.New("installer has no AppId")
+	case len(appID) > innoMaxAppIDLength || strings.Contains(appID, `\`):
+		return app, fmt.Errorf("AppId %q is not used as the uninstall key", appID)
+	}
+	app.ProductCode = appID + "_is1"
+	return app, nil
+}
+
+// readInnoBlock reads the start of a compressed block of Inno Setup data at
+// offset: a CRC32 of the stored size and compression flag, then the stored
+// data in chunks. Only the first innoHeaderLimit bytes are decoded.
+func readInnoBlock(r io.ReaderAt, offset int64) ([]byte, error) {
+	var header [9]byte
+	if _, err := r.ReadAt(header[:], offset); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(header[4:]) != binary.LittleEndian.Uint32(header[:]) {
+		return nil, errors.New("corrupt block header")
+	}
+	stored := &innoChunkReader{
+		r:         r,
+		offset:    offset + int64(len(header)),
+		remaining: int64(binary.LittleEndian.Uint32(header[4:])),
+	}
+
+	if header[8] == 0 {
+		return io.ReadAll(io.LimitReader(stored, innoHeaderLimit))
+	}
+	return decodeInnoLZMA(stored, innoHeaderLimit)
+}
+
+// innoChunkReader reads the stored data of an Inno Setup block, chunks of up
+// to 4096 bytes each preceded by its CRC32, one chunk at a time.
+type innoChunkReader struct {
+	r         io.ReaderAt
+	offset    int64
+	remaining int64
+	chunk     []byte
+}
+
+func (c *innoChunkReader) Read(p []byte) (int, error) {
+	if len(c.chunk) == 0 {
+		if c.remaining == 0 {
+			return 0, io.EOF
+		}
+		if c.remaining <= 4 {
+			return 0, errors.New("truncated block")
+		}
+		buf := make([]byte, 4+min(innoChunkSize, c.remaining-4))
+		if _, err := c.r.ReadAt(buf, c.offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, errors.New("truncated block")
+			}
+			return 0, err
+		}
+		if crc32.ChecksumIEEE(buf[4:]) != binary.LittleEndian.Uint32(buf) {
+			return 0, errors.New("corrupt block")
+		}
+		c.offset += int64(len(buf))
+		c.remaining -= int64(len(buf))
+		c.chunk = buf[4:]
+	}
+	n := copy(p, c.chunk)
+	c.chunk = c.chunk[n:]
+	return n, nil
+}
+
+// decodeInnoLZMA decodes LZMA1 data as stored by Inno Setup: a properties
+// byte and a 32-bit dictionary size, followed by the compressed stream
+// without the uncompressed size of the .lzma format. It returns at most limit
+// bytes, and streams without an end marker up to the end of data.
+func decodeInnoLZMA(data io.Reader, limit int) ([]byte, error) {
+	var props [5]byte
+	if _, err := io.ReadFull(data, props[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errors.New("truncated LZMA data")
+		}
+		return nil, err
+	}
+
+	// Only limit bytes are decoded, so no match reaches further back and
+	// the dictionary does not need to be larger
+	header := make([]byte, lzma.HeaderLen)
+	header[0] = props[0]
+	binary.LittleEndian.PutUint32(header[1:], min(binary.LittleEndian.Uint32(props[1:]), uint32(max(limit, lzma.MinDictCap))))
+	binary.LittleEndian.PutUint64(header[5:], math.MaxUint64)
+
+	r, err := lzma.NewReader(io.MultiReader(bytes.NewReader(header), data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(innoLZMAReader{r}, int64(limit)))
+}
+
+// innoLZMAReader reads an LZMA stream that may end without an end marker,
+// returning the data decoded up to the end of the compressed data.
+type innoLZMAReader struct {
+	r io.Reader
+}
+
+func (r innoLZMAReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	// The decoder returns the rest of its data and io.EOF on the next read
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = nil
+	}
+	return n, err
+}
+
+// innoLiteral resolves the "{{" escapes of an Inno Setup string, reporting
+// false if it contains constants.
+func innoLiteral(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) || s[i+1] != '{' {
+			return "", false
+		}
+		b.WriteByte('{')
+		i++
+	}
+	return b.String(), true
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// testInnoHeaderLZMA is an LZMA compressed setup header starting with the
+// AppName "My Application", the AppVerName "My Application 1.2.3" and the
+// AppId "{{A1B2C3D4-0000-1111-2222-333344445555}".
+const testInnoHeaderLZMA = "5d00000100000e00334899b749620fa88a28c81f0ff098d570c7f1236acae669b77b7499b92e3c79fd6501a2d23543170f1ef716615fa98ef5d3027d0ac603ab34128a23436184bdd5bd5103766bc64143a4d2d3d82ebe89369c977c4ee7577682d8cc89c7260ffffffaa41540"
+
+// innoString encodes s as a string of a Unicode Inno Setup header.
+func innoString(s string) []byte {
+	value := utf16z(s)
+	value = value[:len(value)-2]
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(value))), value...)
+}
+
+// testInnoInstaller returns an Inno Setup installer whose setup data has the
+// given ID and header block, stored in 4096 byte chunks.
+func testInnoInstaller(t testing.TB, setupID string, block []byte, compressed bool) []byte {
+	t.Helper()
+
+	build := func(offset uint32) []byte {
+		table := []byte(innoOffsetTableMagic)
+		for _, field := range []uint32{innoOffsetTableVersion, 0, 0, 0, 0, offset, 0} {
+			table = binary.LittleEndian.AppendUint32(table, field)
+		}
+		table = binary.LittleEndian.AppendUint32(table, crc32.ChecksumIEEE(table))
+		return testPEFile(t, testResourceSection(rtRCData, innoOffsetTableID, table))
+	}
+	installer := build(uint32(len(build(0))))
+
+	var stored []byte
+	for chunk := range slices.Chunk(block, innoChunkSize) {
+		stored = binary.LittleEndian.AppendUint32(stored, crc32.ChecksumIEEE(chunk))
+		stored = append(stored, chunk...)
+	}
+	header := binary.LittleEndian.AppendUint32(nil, uint32(len(stored)))
+	if compressed {
+		header = append(header, 1)
+	} else {
+		header = append(header, 0)
+	}
+
+	id := make([]byte, innoSetupIDSize)
+	copy(id, setupID)
+	installer = append(installer, id...)
+	installer = binary.LittleEndian.AppendUint32(installer, crc32.ChecksumIEEE(header))
+	installer = append(installer, header...)
+	return append(installer, stored...)
+}
+
+func TestDecodeInnoLZMA(t *testing.T) {
+	data, err := hex.DecodeString(testInnoHeaderLZMA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := decodeInnoLZMA(bytes.NewReader(data), 1<<16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(full, innoString("My Application")) {
+		t.Fatalf("unexpected output %q", full)
+	}
+
+	// Decoding stops at the limit
+	out, err := decodeInnoLZMA(bytes.NewReader(data), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(out, full[:10]) {
+		t.Errorf("expected the first 10 bytes, got %q", out)
+	}
+
+	// Streams without an end marker are decoded up to the end of data
+	out, err = decodeInnoLZMA(bytes.NewReader(data[:len(data)/2]), 1<<16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) == 0 || !bytes.HasPrefix(full, out) {
+		t.Errorf("expected a prefix of the header, got %q", out)
+	}
+
+	for name, corrupt := range map[string][]byte{
+		"short":      data[:4],
+		"properties": append([]byte{225}, data[1:]...),
+	} {
+		if _, err := decodeInnoLZMA(bytes.NewReader(corrupt), 1<<16); err == nil {
+			t.Errorf("%s: expected an error for corrupt data", name)
+		}
+	}
+}
+
+func TestReadInnoSetupApp(t *testing.T) {
+	compressed, err := hex.DecodeString(testInnoHeaderLZMA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := func(appVerName, appID string) []byte {
+		b := append(innoString("My Application"), innoString(appVerName)...)
+		b = append(b, innoString(appID)...)
+		// Make the header span several chunks
+		return append(b, make([]byte, 2*innoChunkSize)...)
+	}
+
+	tests := []struct {
+		name        string
+		setupID     string
+		block       []byte
+		compressed  bool
+		productCode string
+		displayName string
+		expectErr   string
+	}{
+		{"compressed", "Inno Setup Setup Data (6.2.0) (u)", compressed, true, "{A1B2C3D4-0000-1111-2222-333344445555}_is1", "My Application 1.2.3", ""},
+		{"stored", "Inno Setup Setup Data (6.2.0) (u)", header("My Application version 1.2.3", "MyApp"), false, "MyApp_is1", "My Application version 1.2.3", ""},
+		{"AppName", "Inno Setup Setup Data (6.2.0) (u)", header("My Application 1.2.3", ""), false, "My Application_is1", "My Application 1.2.3", ""},
+		{"AppVerName constant", "Inno Setup Setup Data (6.2.0) (u)", header("{cm:NameAndVersion,My Application,1.2.3}", "MyApp"), false, "MyApp_is1", "", ""},
+		{"constant", "Inno Setup Setup Data (6.2.0) (u)", header("My Application 1.2.3", "{code:GetAppId}"), false, "", "My Application 1.2.3", "depends on the installation"},
+		{"long", "Inno Setup Setup Data (6.2.0) (u)", header("My Application 1.2.3", strings.Repeat("a", 58)), false, "", "My Application 1.2.3", "not used as the uninstall key"},
+		{"unknown data", "Not Inno Setup", header("My Application 1.2.3", "MyApp"), false, "", "", "unknown Inno Setup data"},
+		{"truncated", "Inno Setup Setup Data (6.2.0) (u)", innoString("My Application"), false, "", "", "truncated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, err := readInnoSetupApp(bytes.NewReader(testInnoInstaller(t, tt.setupID, tt.block, tt.compressed)))
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if app.ProductCode != tt.productCode {
+				t.Errorf("expected ProductCode %q, got %q", tt.productCode, app.ProductCode)
+			}
+			if app.DisplayName != tt.displayName {
+				t.Errorf("expected DisplayName %q, got %q", tt.displayName, app.DisplayName)
+			}
+		})
+	}
+
+	// Other PE files have no offset table
+	if _, err := readInnoSetupApp(bytes.NewReader(testPE(t, map[string]string{"ProductName": "My Application"}))); err == nil {
+		t.Error("expected an error for an installer that is not an Inno Setup installer")
+	}
+
+	// Only the start of large blocks is read
+	large := testInnoInstaller(t, "Inno Setup Setup Data (6.2.0) (u)", make([]byte, 4*innoHeaderLimit), false)
+	offset := bytes.Index(large, []byte("Inno Setup Setup Data"))
+	block, err := readInnoBlock(bytes.NewReader(large), int64(offset+innoSetupIDSize))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(block) != innoHeaderLimit {
+		t.Errorf("expected %d bytes, got %d", innoHeaderLimit, len(block))
+	}
+
+	// A corrupt chunk is detected
+	installer := testInnoInstaller(t, "Inno Setup Setup Data (6.2.0) (u)", compressed, true)
+	installer[len(installer)-1] ^= 0xff
+	if _, err := readInnoSetupApp(bytes.NewReader(installer)); err == nil || !strings.Contains(err.Error(), "corrupt block") {
+		t.Errorf("expected a corrupt block error, got %v", err)
+	}
+}
+
+func TestExecuteInnoProductCode(t *testing.T) {
+	compressed, err := hex.DecodeString(testInnoHeaderLZMA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installer := testInnoInstaller(t, "Inno Setup Setup Data (6.2.0) (u)", compressed, true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(installer)
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	installerCfg := cfg["installers"].([]any)[0].(map[string]any)
+	installerCfg["url"] = server.URL + "/setup.exe"
+	installerCfg["type"] = "inno"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["download"] = map[string]any{"min_size": 0}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "AppsAndFeaturesEntries:\n        - DisplayName: My Application 1.2.3\n          ProductCode: '{A1B2C3D4-0000-1111-2222-333344445555}_is1'"
+	if !strings.Contains(string(content), want) {
+		t.Errorf("expected the AppVerName and AppId in the Apps & Features entry, got:\n%s", content)
+	}
+}
+
+func FuzzReadInnoSetupApp(f *testing.F) {
+	compressed, err := hex.DecodeString(testInnoHeaderLZMA)
+	if err != nil {
+		f.Fatal(err)
+	}
+	stored := append(innoString("My Application"), innoString("My Application 1.2.3")...)
+	stored = append(stored, innoString("MyApp")...)
+
+	// The setup data after the loader is mutated
+	var loader []byte
+	for _, installer := range [][]byte{
+		testInnoInstaller(f, "Inno Setup Setup Data (6.2.0) (u)", compressed, true),
+		testInnoInstaller(f, "Inno Setup Setup Data (5.5.0)", stored, false),
+	} {
+		offset := bytes.Index(installer, []byte("Inno Setup Setup Data"))
+		loader = installer[:offset]
+		f.Add(installer[offset:])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = readInnoSetupApp(bytes.NewReader(append(slices.Clip(loader), data...)))
+	})
+}
@@ -0,0 +1,341 @@
+// Package installerhash downloads winget installers to compute the SHA256
+// hashes recorded in installer manifests.
+package installerhash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an installer URL does not exist.
+var ErrNotFound = errors.New("installer not found")
+
+// ErrPayloadMutated is returned when the downloaded bytes may differ from the
+// installer file, such as when the server compresses the response, so its
+// hash would not match the installer winget downloads.
+var ErrPayloadMutated = errors.New("installer payload was altered in transit")
+
+// StatusError is returned when an installer download fails with an
+// unexpected HTTP status other than not found.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("download failed with status %d", e.StatusCode)
+}
+
+// Stats describes an installer download.
+type Stats struct {
+	// Size is the number of bytes downloaded and hashed.
+	Size int64
+	// Duration is the time from the request to the end of hashing.
+	Duration time.Duration
+	// Path is the cached copy of the installer, if the Downloader has a
+	// cache directory.
+	Path string
+	// FinalURL is the URL the installer was downloaded from after
+	// redirects.
+	FinalURL string
+	// ETag and LastModified are the validators the server sent for the
+	// installer, if any, which identify the exact file that was hashed.
+	ETag         string
+	LastModified string
+	// ContentType is the Content-Type the server sent for the installer.
+	ContentType string
+}
+
+// DefaultBufferSize is the default size of the buffer installers are hashed
+// through.
+const DefaultBufferSize = 128 << 10
+
+const (
+	// downloadTimeout bounds a single installer download; large installers
+	// may take time.
+	downloadTimeout = 10 * time.Minute
+	probeTimeout    = 30 * time.Second
+	maxRedirects    = 10
+)
+
+// sharedClient is the HTTP client of all downloads. Installers are often
+// served from one CDN, so connections are pooled and reused across them.
+var sharedClient = &http.Client{
+	Transport: newTransport(),
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("too many redirects")
+		}
+		return nil
+	},
+}
+
+// newTransport returns the pooled, HTTP/2-capable transport of sharedClient.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConns = 32
+	transport.MaxIdleConnsPerHost = 8
+	transport.IdleConnTimeout = 90 * time.Second
+	return transport
+}
+
+// DefaultUserAgent is the User-Agent of downloads unless WithUserAgent is
+// given.
+const DefaultUserAgent = "Relicta-WinGet-Plugin/1.0"
+
+// Downloader downloads and hashes installers.
+type Downloader struct {
+	client     *http.Client
+	bufferSize int
+	cacheDir   string
+	userAgent  string
+}
+
+// Option customizes a Downloader.
+type Option func(*Downloader)
+
+// WithHTTPClient sets the HTTP client of downloads instead of the shared,
+// pooled client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Downloader) {
+		d.client = client
+	}
+}
+
+// WithBufferSize sets the size of the buffer installers are hashed through.
+// Sizes below one are ignored.
+func WithBufferSize(size int) Option {
+	return func(d *Downloader) {
+		if size > 0 {
+			d.bufferSize = size
+		}
+	}
+}
+
+// WithCacheDir keeps a copy of every downloaded installer in dir, written in
+// the same pass as the hash, so later steps can use the installer without
+// downloading it again. Installers are stored as <dir>/<SHA256>/<file name>.
+func WithCacheDir(dir string) Option {
+	return func(d *Downloader) {
+		d.cacheDir = dir
+	}
+}
+
+// WithUserAgent sets the User-Agent header of downloads and probes. An
+// empty user agent is ignored.
+func WithUserAgent(userAgent string) Option {
+	return func(d *Downloader) {
+		if userAgent != "" {
+			d.userAgent = userAgent
+		}
+	}
+}
+
+// NewDownloader creates a Downloader. Downloaders share one pooled HTTP
+// client unless WithHTTPClient is given.
+func NewDownloader(opts ...Option) *Downloader {
+	d := &Downloader{client: sharedClient, bufferSize: DefaultBufferSize, userAgent: DefaultUserAgent}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// defaultDownloader backs the package-level functions.
+var defaultDownloader = NewDownloader()
+
+// Calculate downloads an installer and calculates its SHA256 hash.
+func Calculate(ctx context.Context, url string) (string, error) {
+	hash, _, err := defaultDownloader.CalculateWithStats(ctx, url)
+	return hash, err
+}
+
+// CalculateWithStats downloads an installer and calculates its SHA256 hash,
+// reporting the size and duration of the download.
+func CalculateWithStats(ctx context.Context, url string) (string, Stats, error) {
+	return defaultDownloader.CalculateWithStats(ctx, url)
+}
+
+// Probe checks that a URL is reachable without downloading its content.
+func Probe(ctx context.Context, url string) error {
+	return defaultDownloader.Probe(ctx, url)
+}
+
+// CalculateWithStats downloads an installer and calculates its SHA256 hash,
+// reporting the size, duration and origin of the download.
+func (d *Downloader) CalculateWithStats(ctx context.Context, url string) (string, Stats, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", Stats{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set User-Agent to avoid blocks
+	req.Header.Set("User-Agent", d.userAgent)
+	// Hash the installer file itself: an explicit Accept-Encoding also
+	// keeps the transport from requesting gzip and decompressing it
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", Stats{}, fmt.Errorf("failed to download installer: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return "", Stats{}, fmt.Errorf("download failed with status %d: %w", resp.StatusCode, ErrNotFound)
+	default:
+		return "", Stats{}, &StatusError{StatusCode: resp.StatusCode}
+	}
+	if err := checkIdentityEncoding(resp); err != nil {
+		return "", Stats{}, err
+	}
+
+	var body io.Reader = resp.Body
+	var file *os.File
+	if d.cacheDir != "" {
+		if err := os.MkdirAll(d.cacheDir, 0o755); err != nil {
+			return "", Stats{}, fmt.Errorf("failed to create download cache: %w", err)
+		}
+		file, err = os.CreateTemp(d.cacheDir, ".download-*")
+		if err != nil {
+			return "", Stats{}, fmt.Errorf("failed to create download cache file: %w", err)
+		}
+		defer func() {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}()
+		body = io.TeeReader(resp.Body, file)
+	}
+
+	hash := sha256.New()
+	size, err := io.CopyBuffer(hash, body, make([]byte, d.bufferSize))
+	if err != nil {
+		return "", Stats{}, fmt.Errorf("failed to calculate hash: %w", err)
+	}
+	if resp.ContentLength >= 0 && size != resp.ContentLength {
+		return "", Stats{}, fmt.Errorf("downloaded %d bytes but the server announced %d: %w", size, resp.ContentLength, ErrPayloadMutated)
+	}
+	sum := strings.ToUpper(hex.EncodeToString(hash.Sum(nil)))
+
+	stats := Stats{
+		Size:         size,
+		FinalURL:     resp.Request.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  resp.Header.Get("Content-Type"),
+	}
+	if file != nil {
+		if stats.Path, err = d.keep(file, sum, req.URL); err != nil {
+			return "", Stats{}, err
+		}
+	}
+	stats.Duration = time.Since(start)
+	return sum, stats, nil
+}
+
+// checkIdentityEncoding fails for responses whose body is not the installer
+// file as stored on the server: encoded with a Content-Encoding despite the
+// identity request, or decompressed by the transport.
+func checkIdentityEncoding(resp *http.Response) error {
+	if resp.Uncompressed {
+		return fmt.Errorf("the response was decompressed by the HTTP transport: %w", ErrPayloadMutated)
+	}
+	for _, encoding := range resp.Header.Values("Content-Encoding") {
+		for _, e := range strings.Split(encoding, ",") {
+			if e = strings.TrimSpace(e); e != "" && !strings.EqualFold(e, "identity") {
+				return fmt.Errorf("the server sent Content-Encoding %q for an identity request: %w", e, ErrPayloadMutated)
+			}
+		}
+	}
+	return nil
+}
+
+// keep moves a downloaded temporary file into the cache directory under
+// its hash and the file name of its URL.
+func (d *Downloader) keep(file *os.File, hash string, u *neturl.URL) (string, error) {
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write download cache file: %w", err)
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "installer"
+	}
+	dir := filepath.Join(d.cacheDir, hash)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create download cache: %w", err)
+	}
+	target := filepath.Join(dir, name)
+	if err := os.Rename(file.Name(), target); err != nil {
+		return "", fmt.Errorf("failed to store installer in download cache: %w", err)
+	}
+	return target, nil
+}
+
+// Probe checks that a URL is reachable without downloading its content. It
+// tries a HEAD request first and falls back to a single-byte ranged GET for
+// servers that do not support HEAD.
+func (d *Downloader) Probe(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	status, err := d.probe(ctx, http.MethodHead, url)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		return nil
+	}
+
+	status, err = d.probe(ctx, http.MethodGet, url)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK || status == http.StatusPartialContent {
+		return nil
+	}
+
+	return fmt.Errorf("URL returned status %d", status)
+}
+
+func (d *Downloader) probe(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", d.userAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach URL: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// FromBytes calculates the SHA256 hash of data in manifest format.
+func FromBytes(data []byte) string {
+	hash := sha256.Sum256(data)
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,375 @@
+package installerhash
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCalculate(t *testing.T) {
+	// Create test server
+	testContent := []byte("test installer content")
+	expectedHash := FromBytes(testContent)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(testContent)
+	}))
+	defer server.Close()
+
+	hash, err := Calculate(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hash != expectedHash {
+		t.Errorf("expected hash '%s', got '%s'", expectedHash, hash)
+	}
+}
+
+func TestCalculateWithStats(t *testing.T) {
+	testContent := []byte("test installer content")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 10:00:00 GMT")
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(testContent)
+	}))
+	defer server.Close()
+
+	hash, stats, err := CalculateWithStats(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash != FromBytes(testContent) {
+		t.Errorf("unexpected hash '%s'", hash)
+	}
+	if stats.Size != int64(len(testContent)) {
+		t.Errorf("expected size %d, got %d", len(testContent), stats.Size)
+	}
+	if stats.Duration <= 0 {
+		t.Error("expected a positive duration")
+	}
+	if stats.ETag != `"v1"` || stats.LastModified != "Wed, 01 May 2024 10:00:00 GMT" || stats.FinalURL != server.URL || stats.ContentType != "application/octet-stream" {
+		t.Errorf("unexpected download origin %+v", stats)
+	}
+}
+
+func TestCalculateStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := Calculate(context.Background(), server.URL)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected StatusError 503, got %v", err)
+	}
+}
+
+func TestCalculateNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := Calculate(context.Background(), server.URL)
+	if err == nil {
+		t.Error("expected error for 404 response")
+	}
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCalculateRedirect(t *testing.T) {
+	testContent := []byte("redirected content")
+	expectedHash := FromBytes(testContent)
+
+	finalServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(testContent)
+	}))
+	defer finalServer.Close()
+
+	redirectServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, finalServer.URL, http.StatusFound)
+	}))
+	defer redirectServer.Close()
+
+	hash, stats, err := CalculateWithStats(context.Background(), redirectServer.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hash != expectedHash {
+		t.Errorf("expected hash '%s', got '%s'", expectedHash, hash)
+	}
+	if stats.FinalURL != finalServer.URL {
+		t.Errorf("expected final URL %s, got %s", finalServer.URL, stats.FinalURL)
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "empty",
+			data:     []byte{},
+			expected: "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855",
+		},
+		{
+			name:     "hello",
+			data:     []byte("hello"),
+			expected: "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824",
+		},
+		{
+			name:     "test content",
+			data:     []byte("test installer content"),
+			expected: "19EB2AA2B331FDAA7935E86424A3AA04BAF374AD7DE0DDDB57D5F0F3B7030934",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FromBytes(tt.data)
+			if result != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCalculateInvalidURL(t *testing.T) {
+	_, err := Calculate(context.Background(), "http://invalid.nonexistent.url.test/file.exe")
+	if err == nil {
+		t.Error("expected error for invalid URL")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.HandlerFunc
+		expectErr bool
+	}{
+		{
+			name: "HEAD supported",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("expected HEAD, got %s", r.Method)
+				}
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			name: "ranged GET fallback",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				if r.Header.Get("Range") != "bytes=0-0" {
+					t.Errorf("expected ranged GET, got Range '%s'", r.Header.Get("Range"))
+				}
+				w.WriteHeader(http.StatusPartialContent)
+			},
+		},
+		{
+			name: "not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			err := Probe(context.Background(), server.URL)
+			if tt.expectErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloaderReusesConnections(t *testing.T) {
+	var conns atomic.Int32
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	downloader := NewDownloader(WithBufferSize(4))
+	for _, name := range []string{"/x64.msi", "/x86.msi", "/arm64.msi"} {
+		hash, stats, err := downloader.CalculateWithStats(context.Background(), server.URL+name)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hash != FromBytes([]byte(name)) || stats.Size != int64(len(name)) {
+			t.Errorf("unexpected hash or size for %s: %s, %d", name, hash, stats.Size)
+		}
+	}
+
+	if conns.Load() != 1 {
+		t.Errorf("expected 1 connection for 3 downloads, got %d", conns.Load())
+	}
+}
+
+func TestNewDownloaderOptions(t *testing.T) {
+	client := &http.Client{}
+	downloader := NewDownloader(WithHTTPClient(client), WithBufferSize(1024))
+	if downloader.client != client {
+		t.Error("expected custom HTTP client")
+	}
+	if downloader.bufferSize != 1024 {
+		t.Errorf("expected buffer size 1024, got %d", downloader.bufferSize)
+	}
+
+	defaults := NewDownloader(WithBufferSize(0))
+	if defaults.client != sharedClient {
+		t.Error("expected the shared HTTP client")
+	}
+	if defaults.bufferSize != DefaultBufferSize {
+		t.Errorf("expected default buffer size, got %d", defaults.bufferSize)
+	}
+}
+
+func TestDownloaderUserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	downloader := NewDownloader(WithUserAgent("MyPipeline/2.0 (run 42)"))
+	if _, _, err := downloader.CalculateWithStats(context.Background(), server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := downloader.Probe(context.Background(), server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := NewDownloader(WithUserAgent("")).CalculateWithStats(context.Background(), server.URL); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"MyPipeline/2.0 (run 42)", "MyPipeline/2.0 (run 42)", DefaultUserAgent}
+	if len(userAgents) != len(expected) {
+		t.Fatalf("expected %d requests, got %v", len(expected), userAgents)
+	}
+	for i, userAgent := range expected {
+		if userAgents[i] != userAgent {
+			t.Errorf("request %d: expected User-Agent '%s', got '%s'", i, userAgent, userAgents[i])
+		}
+	}
+}
+
+func TestCalculateIdentityEncoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if encoding := r.Header.Get("Accept-Encoding"); encoding != "identity" {
+			t.Errorf("expected Accept-Encoding identity, got %q", encoding)
+		}
+		if r.URL.Path == "/gzip.exe" {
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	if _, _, err := NewDownloader().CalculateWithStats(context.Background(), server.URL+"/plain.exe"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, err := NewDownloader().CalculateWithStats(context.Background(), server.URL+"/gzip.exe")
+	if !errors.Is(err, ErrPayloadMutated) {
+		t.Errorf("expected ErrPayloadMutated, got %v", err)
+	}
+}
+
+func TestCheckIdentityEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		wantErr bool
+	}{
+		{"no encoding", &http.Response{Header: http.Header{}}, false},
+		{"identity", &http.Response{Header: http.Header{"Content-Encoding": {"identity"}}}, false},
+		{"zstd", &http.Response{Header: http.Header{"Content-Encoding": {"zstd"}}}, true},
+		{"stacked", &http.Response{Header: http.Header{"Content-Encoding": {"identity, br"}}}, true},
+		{"decompressed by the transport", &http.Response{Header: http.Header{}, Uncompressed: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkIdentityEncoding(tt.resp)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkIdentityEncoding() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloaderCacheDir(t *testing.T) {
+	content := []byte("cached installer content")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.exe" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	downloader := NewDownloader(WithCacheDir(dir))
+
+	hash, stats, err := downloader.CalculateWithStats(context.Background(), server.URL+"/releases/setup%20x64.exe?token=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hash != FromBytes(content) {
+		t.Errorf("unexpected hash '%s'", hash)
+	}
+
+	want := filepath.Join(dir, hash, "setup x64.exe")
+	if stats.Path != want {
+		t.Errorf("expected path %s, got %s", want, stats.Path)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != string(content) {
+		t.Errorf("unexpected cached content: %q, %v", data, err)
+	}
+
+	if _, _, err := downloader.CalculateWithStats(context.Background(), server.URL+"/missing.exe"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the cached installer directory, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// IssueConfig defines the issue opened in the product's own repository for
+// each submission, so failures can be triaged there rather than in
+// winget-pkgs.
+type IssueConfig struct {
+	Enabled bool `json:"enabled"`
+	// Repository is the "owner/name" repository of the issue. It defaults
+	// to the released repository.
+	Repository string `json:"repository"`
+	// Title identifies the issue of a version: an open issue with the same
+	// title is updated instead of opening another one.
+	Title  string   `json:"title"`
+	Labels []string `json:"labels"`
+}
+
+// repositoryPattern matches an "owner/name" repository.
+var repositoryPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// issueStatusLine matches the status line of an issue body written by
+// issueBody.
+var issueStatusLine = regexp.MustCompile(`(?m)^\*\*Status:\*\* .*$`)
+
+// validateIssue checks the tracking issue settings.
+func validateIssue(cfg *Config) []fieldError {
+	if !cfg.Issue.Enabled {
+		return nil
+	}
+
+	var problems []fieldError
+	if cfg.Backend != backendGitHub || !cfg.PullRequest.Enabled {
+		problems = append(problems, fieldError{"issue.enabled", "tracking issues require the github backend with pull requests enabled"})
+	}
+	if cfg.Issue.Repository != "" && !repositoryPattern.MatchString(cfg.Issue.Repository) {
+		problems = append(problems, fieldError{"issue.repository", fmt.Sprintf("%q is not a repository such as myorg/myapp", cfg.Issue.Repository)})
+	}
+	if strings.TrimSpace(cfg.Issue.Title) == "" {
+		problems = append(problems, fieldError{"issue.title", "title is required"})
+	}
+	return problems
+}
+
+// issueRepository returns the owner and name of the repository of the
+// tracking issue.
+func issueRepository(cfg *Config, releaseCtx *plugin.ReleaseContext) (string, string, error) {
+	if cfg.Issue.Repository != "" {
+		owner, name, _ := strings.Cut(cfg.Issue.Repository, "/")
+		return owner, name, nil
+	}
+	if releaseCtx.RepositoryOwner == "" || releaseCtx.RepositoryName == "" {
+		return "", "", fmt.Errorf("the release has no repository, set issue.repository")
+	}
+	return releaseCtx.RepositoryOwner, releaseCtx.RepositoryName, nil
+}
+
+// issueBody returns the body of a tracking issue: the pull request, if one
+// was opened, its status and the diff of the manifests against the
+// published version, if any.
+func issueBody(prURL, status, publishedVersion, diff string) string {
+	var sb strings.Builder
+	if prURL != "" {
+		fmt.Fprintf(&sb, "winget-pkgs pull request: %s\n\n", prURL)
+	}
+	fmt.Fprintf(&sb, "**Status:** %s\n", status)
+	if diff != "" {
+		fmt.Fprintf(&sb, "\n<details>\n<summary>Manifest diff against %s</summary>\n\n```diff\n%s\n```\n\n</details>\n",
+			publishedVersion, strings.TrimRight(diff, "\n"))
+	}
+	return sb.String()
+}
+
+// withIssueStatus replaces the status line of an issue body, keeping the
+// rest of the body.
+func withIssueStatus(body, status string) string {
+	line := "**Status:** " + status
+	if !issueStatusLine.MatchString(body) {
+		return strings.TrimRight(body, "\n") + "\n\n" + line + "\n"
+	}
+	return issueStatusLine.ReplaceAllLiteralString(body, line)
+}
+
+// issueStatus describes the state of the pull request and, while it is
+// open, of its winget-pkgs validation as told by its labels.
+func issueStatus(state string, labels []string) string {
+	switch state {
+	case prStateMerged:
+		return "merged"
+	case prStateClosed:
+		return "closed without merging"
+	case prStateNotFound:
+		return "pull request not found"
+	}
+
+	var failures []string
+	passed := false
+	for _, label := range labels {
+		switch {
+		case strings.HasSuffix(label, "-Error"), label == "Needs-Author-Feedback":
+			failures = append(failures, label)
+		case label == "Validation-Completed":
+			passed = true
+		}
+	}
+	switch {
+	case len(failures) > 0:
+		return fmt.Sprintf("open, validation failed (%s)", strings.Join(failures, ", "))
+	case passed:
+		return "open, validation passed"
+	default:
+		return "open, validation pending"
+	}
+}
+
+// upsertIssue updates the open tracking issue of the release with the body
+// returned by body, or opens it if there is none. body receives the
+// existing issue, or nil. It returns the URL of the issue.
+func upsertIssue(ctx context.Context, ghClient *githubclient.Client, cfg *Config, releaseCtx *plugin.ReleaseContext, body func(existing *githubclient.Issue) string) (string, error) {
+	owner, repo, err := issueRepository(cfg, releaseCtx)
+	if err != nil {
+		return "", err
+	}
+	issue, err := ghClient.FindIssue(ctx, owner, repo, cfg.Issue.Title, cfg.Issue.Labels)
+	if err != nil {
+		return "", err
+	}
+	if issue != nil {
+		return issue.URL, ghClient.UpdateIssueBody(ctx, owner, repo, issue.Number, body(issue))
+	}
+
+	issue, err = ghClient.CreateIssue(ctx, owner, repo, githubclient.IssueOptions{
+		Title:  cfg.Issue.Title,
+		Body:   body(nil),
+		Labels: cfg.Issue.Labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return issue.URL, nil
+}
+
+// reportIssue opens or updates the tracking issue of a submission with the
+// manifest diff in outputs. Failures are only logged.
+func (p *WinGetPlugin) reportIssue(ctx context.Context, ghClient *githubclient.Client, cfg *Config, releaseCtx *plugin.ReleaseContext, prURL, status string, outputs map[string]any, logger *slog.Logger) {
+	publishedVersion, _ := outputs["published_version"].(string)
+	diff, _ := outputs["manifest_diff"].(string)
+	issueURL, err := upsertIssue(ctx, ghClient, cfg, releaseCtx, func(*githubclient.Issue) string {
+		return issueBody(prURL, status, publishedVersion, diff)
+	})
+	if err != nil {
+		logger.Warn("Failed to update tracking issue", "error", err)
+		return
+	}
+	logger.Info("Updated tracking issue", "url", issueURL)
+	outputs["issue_url"] = issueURL
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateIssue(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   string
+		issue     IssueConfig
+		wantField string
+	}{
+		{"disabled", backendREST, IssueConfig{}, ""},
+		{"enabled", backendGitHub, IssueConfig{Enabled: true, Title: "winget", Repository: "myorg/myapp"}, ""},
+		{"other backend", backendREST, IssueConfig{Enabled: true, Title: "winget"}, "issue.enabled"},
+		{"invalid repository", backendGitHub, IssueConfig{Enabled: true, Title: "winget", Repository: "https://github.com/myorg/myapp"}, "issue.repository"},
+		{"no title", backendGitHub, IssueConfig{Enabled: true}, "issue.title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Backend: tt.backend, PullRequest: PRConfig{Enabled: true}, Issue: tt.issue}
+			problems := validateIssue(cfg)
+			if tt.wantField == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("expected a problem for %s, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
+
+func TestIssueStatus(t *testing.T) {
+	tests := []struct {
+		state    string
+		labels   []string
+		expected string
+	}{
+		{prStateOpen, nil, "open, validation pending"},
+		{prStateOpen, []string{"Azure-Pipeline-Passed", "Validation-Completed"}, "open, validation passed"},
+		{prStateOpen, []string{"Validation-Installation-Error", "Needs-Author-Feedback"}, "open, validation failed (Validation-Installation-Error, Needs-Author-Feedback)"},
+		{prStateMerged, []string{"Validation-Completed"}, "merged"},
+		{prStateClosed, nil, "closed without merging"},
+		{prStateNotFound, nil, "pull request not found"},
+	}
+
+	for _, tt := range tests {
+		if result := issueStatus(tt.state, tt.labels); result != tt.expected {
+			t.Errorf("issueStatus(%q, %v) = '%s', expected '%s'", tt.state, tt.labels, result, tt.expected)
+		}
+	}
+}
+
+func TestIssueBody(t *testing.T) {
+	body := issueBody("https://github.com/microsoft/winget-pkgs/pull/7", "open, validation pending", "1.2.2", "--- a\n+++ b\n")
+	for _, want := range []string{
+		"winget-pkgs pull request: https://github.com/microsoft/winget-pkgs/pull/7\n",
+		"**Status:** open, validation pending\n",
+		"<summary>Manifest diff against 1.2.2</summary>\n\n```diff\n--- a\n+++ b\n```",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the body, got:\n%s", want, body)
+		}
+	}
+
+	updated := withIssueStatus(body, "merged")
+	if !strings.Contains(updated, "**Status:** merged\n") || strings.Contains(updated, "pending") || !strings.Contains(updated, "```diff") {
+		t.Errorf("expected only the status to change, got:\n%s", updated)
+	}
+	if got := withIssueStatus("Edited by hand", "merged"); got != "Edited by hand\n\n**Status:** merged\n" {
+		t.Errorf("expected the status to be appended, got %q", got)
+	}
+	if strings.Contains(issueBody("", "submission failed", "", ""), "pull request:") {
+		t.Error("expected no pull request line without a pull request")
+	}
+}
+
+func TestExecuteOnSuccessUpdatesIssue(t *testing.T) {
+	var patched map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			_, _ = w.Write([]byte(`[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7","state":"open","labels":[{"name":"Validation-Shell-Execute-Error"}]}]`))
+		case r.Method == "GET" && r.URL.Path == "/repos/myorg/myapp/issues":
+			_, _ = w.Write([]byte(`[{"number":3,"html_url":"https://github.com/myorg/myapp/issues/3","title":"winget: MyOrg.MyApp 1.2.3","body":"winget-pkgs pull request: https://github.com/microsoft/winget-pkgs/pull/7\n\n**Status:** open, validation pending\n"}]`))
+		case r.Method == "PATCH" && r.URL.Path == "/repos/myorg/myapp/issues/3":
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &patched); err != nil {
+				t.Errorf("invalid update: %v", err)
+			}
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	cfg := validTestConfig()
+	cfg["pull_request"] = map[string]any{"fork_owner": "myuser"}
+	cfg["track"] = map[string]any{"enabled": true}
+	cfg["issue"] = map[string]any{"enabled": true}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookOnSuccess,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3", RepositoryOwner: "myorg", RepositoryName: "myapp"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["issue_url"] != "https://github.com/myorg/myapp/issues/3" {
+		t.Errorf("expected the issue to be updated, got %v: %s", resp.Outputs, resp.Message)
+	}
+	want := "winget-pkgs pull request: https://github.com/microsoft/winget-pkgs/pull/7\n\n**Status:** open, validation failed (Validation-Shell-Execute-Error)\n"
+	if patched["body"] != want {
+		t.Errorf("unexpected issue body %q", patched["body"])
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// komacCommand is the komac executable.
+var komacCommand = "komac"
+
+// KomacAvailable reports whether the komac CLI can be found in PATH.
+func KomacAvailable() bool {
+	_, err := exec.LookPath(komacCommand)
+	return err == nil
+}
+
+// KomacUpdate updates the published manifests of a package with new installer
+// URLs and submits them with `komac update --submit`. It returns the pull
+// request URL and the command output.
+func KomacUpdate(ctx context.Context, m *manifest.Set, token string) (string, string, error) {
+	args := []string{"update", m.Version.PackageIdentifier, "--version", m.Version.PackageVersion, "--submit"}
+	if m.Locale.ReleaseNotesURL != "" {
+		args = append(args, "--release-notes-url", m.Locale.ReleaseNotesURL)
+	}
+	args = append(args, "--urls")
+	for _, installer := range m.Installer.Installers {
+		args = append(args, installer.InstallerURL)
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, komacCommand, args...)
+	// komac reads the token from the environment, which keeps it out of
+	// process listings
+	cmd.Env = append(os.Environ(), "GITHUB_TOKEN="+token)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if err != nil {
+		return "", out, fmt.Errorf("komac update failed: %w", err)
+	}
+
+	prURL := pullRequestURLPattern.FindString(out)
+	if prURL == "" {
+		return "", out, fmt.Errorf("komac update did not report a pull request URL")
+	}
+	return prURL, out, nil
+}
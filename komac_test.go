@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeKomac installs a shell script as the komac command for the test.
+func fakeKomac(t *testing.T, script string) {
+	t.Helper()
+	fakeCommand(t, "komac", &komacCommand, script)
+}
+
+func TestKomacAvailable(t *testing.T) {
+	original := komacCommand
+	komacCommand = "komac-does-not-exist"
+	defer func() { komacCommand = original }()
+
+	if KomacAvailable() {
+		t.Error("expected komac to be unavailable")
+	}
+}
+
+func TestKomacUpdate(t *testing.T) {
+	fakeKomac(t, `echo "args: $@"
+echo "token: $GITHUB_TOKEN"
+echo "Pull request created: https://github.com/microsoft/winget-pkgs/pull/777"
+`)
+
+	m := validTestManifests(t)
+	m.Locale.ReleaseNotesURL = "https://example.com/notes"
+
+	prURL, output, err := KomacUpdate(context.Background(), m, "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if prURL != "https://github.com/microsoft/winget-pkgs/pull/777" {
+		t.Errorf("unexpected PR URL '%s'", prURL)
+	}
+	expected := "args: update MyOrg.MyApp --version 1.0.0 --submit --release-notes-url https://example.com/notes --urls " + m.Installer.Installers[0].InstallerURL
+	if !strings.Contains(output, expected) {
+		t.Errorf("expected '%s' in output, got: %s", expected, output)
+	}
+	if !strings.Contains(output, "token: secret") {
+		t.Errorf("expected token in environment, got: %s", output)
+	}
+}
+
+func TestKomacUpdateFailure(t *testing.T) {
+	fakeKomac(t, "echo 'error: package not found'\nexit 1\n")
+
+	_, output, err := KomacUpdate(context.Background(), validTestManifests(t), "secret")
+	if err == nil {
+		t.Fatal("expected error for failing komac")
+	}
+	if !strings.Contains(output, "package not found") {
+		t.Errorf("expected komac output, got: %s", output)
+	}
+}
+
+func TestSubmitKomacOutlastsGitHubTimeout(t *testing.T) {
+	fakeKomac(t, `sleep 0.2
+echo "https://github.com/microsoft/winget-pkgs/pull/777"
+`)
+
+	cfg := defaultConfig()
+	cfg.Timeouts.GitHub = 50 * time.Millisecond
+	resp, err := (&WinGetPlugin{}).submitKomac(context.Background(), cfg, validTestManifests(t), map[string]any{}, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Errorf("expected komac to run under timeouts.cli, got: %s", resp.Message)
+	}
+}
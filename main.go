@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
+	"os"
+
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
 )
 
 func main() {
+	if isCLICommand(os.Args[1:]) {
+		os.Exit(runCLI(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
 	plugin.Serve(&WinGetPlugin{})
 }
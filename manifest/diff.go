@@ -0,0 +1,192 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Diff returns a unified diff from the published manifests of
+// publishedVersion, keyed by file name, to the generated manifests. Files are
+// matched by name, so unchanged file layouts produce per-file diffs.
+func Diff(publishedVersion string, published map[string]string, m *Set) (string, error) {
+	generated, err := m.GetFiles()
+	if err != nil {
+		return "", err
+	}
+
+	current := make(map[string]string, len(generated))
+	for filePath, content := range generated {
+		current[path.Base(filePath)] = content
+	}
+	return DiffFiles(publishedVersion, published, m.Version.PackageVersion, current), nil
+}
+
+// DiffFiles returns a unified diff between two sets of manifests of the
+// given versions, keyed by file name. It is empty if the sets are
+// byte-identical.
+func DiffFiles(oldVersion string, published map[string]string, newVersion string, current map[string]string) string {
+	names := make(map[string]bool)
+	for name := range published {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, name := range sorted {
+		oldName, newName := oldVersion+"/"+name, newVersion+"/"+name
+		old, hasOld := published[name]
+		updated, hasNew := current[name]
+		if !hasOld {
+			oldName = "/dev/null"
+		}
+		if !hasNew {
+			newName = "/dev/null"
+		}
+		sb.WriteString(unifiedDiff(oldName, newName, old, updated))
+	}
+
+	return sb.String()
+}
+
+// diffOp is a single line of an edit script.
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// unifiedDiff returns the unified diff between two texts, or an empty string
+// if they are equal.
+func unifiedDiff(oldName, newName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Group changes into hunks with surrounding context
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		hunkStart := max(0, start-diffContext)
+		end, unchanged := start, 0
+		for end < len(ops) && unchanged <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		hunkEnd := min(len(ops), end-unchanged+diffContext)
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats a hunk range; empty ranges start at the preceding line.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines computes a line edit script using the longest common subsequence.
+// Manifests are small, so the quadratic table is cheap.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitLines splits text into lines, ignoring a trailing newline and
+// normalizing CRLF line endings.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
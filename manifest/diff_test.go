@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{
+			name:     "changed line",
+			a:        "a\nb\nc\n",
+			b:        "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "added file",
+			a:        "",
+			b:        "a\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:     "separate hunks",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:        "X\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nY\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := unifiedDiff("old", "new", tt.a, tt.b)
+			if result != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	m := validTestManifests(t)
+	files, err := m.GetFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	published := make(map[string]string)
+	for path, content := range files {
+		name := path[strings.LastIndex(path, "/")+1:]
+		published[name] = strings.ReplaceAll(content, "PackageVersion: 1.0.0", "PackageVersion: 0.9.0")
+	}
+	published["MyOrg.MyApp.locale.de-DE.yaml"] = "PackageLocale: de-DE\n"
+
+	diff, err := Diff("0.9.0", published, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"--- 0.9.0/MyOrg.MyApp.installer.yaml\n+++ 1.0.0/MyOrg.MyApp.installer.yaml\n",
+		"-PackageVersion: 0.9.0\n+PackageVersion: 1.0.0\n",
+		"--- 0.9.0/MyOrg.MyApp.locale.de-DE.yaml\n+++ /dev/null\n",
+	} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("expected diff to contain %q, got:\n%s", expected, diff)
+		}
+	}
+}
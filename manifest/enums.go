@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Problem describes an invalid installer field.
+type Problem struct {
+	Field   string
+	Message string
+}
+
+// Enums lists the values a manifest schema version accepts for the
+// enumerated installer fields.
+type Enums struct {
+	Architectures  []string
+	InstallerTypes []string
+	Scopes         []string
+	// RepairBehaviors is empty for versions without repair support.
+	RepairBehaviors      []string
+	UnsupportedArguments []string
+}
+
+// enumTables holds the enumerations for each supported manifest schema
+// version. They must match the embedded schemas in schemas/.
+var enumTables = map[string]Enums{
+	"1.6.0": {
+		Architectures:        []string{"x86", "x64", "arm", "arm64", "neutral"},
+		InstallerTypes:       []string{"msix", "msi", "appx", "exe", "zip", "inno", "nullsoft", "wix", "burn", "pwa", "portable"},
+		Scopes:               []string{"user", "machine"},
+		UnsupportedArguments: []string{"log", "location"},
+	},
+	"1.7.0": {
+		Architectures:        []string{"x86", "x64", "arm", "arm64", "neutral"},
+		InstallerTypes:       []string{"msix", "msi", "appx", "exe", "zip", "inno", "nullsoft", "wix", "burn", "pwa", "portable"},
+		Scopes:               []string{"user", "machine"},
+		RepairBehaviors:      []string{"modify", "uninstaller", "installer"},
+		UnsupportedArguments: []string{"log", "location"},
+	},
+}
+
+// EnumsFor returns the enumerations for a manifest schema version.
+func EnumsFor(version string) (Enums, error) {
+	enums, ok := enumTables[version]
+	if !ok {
+		return Enums{}, fmt.Errorf("unsupported manifest version %s", version)
+	}
+	return enums, nil
+}
+
+// CheckInstallerEnums checks an installer's architecture, type and scope
+// against the enumerations of a manifest schema version. Field names in the
+// returned problems are relative to the installer.
+func CheckInstallerEnums(version, architecture, installerType, scope string) []Problem {
+	enums, err := EnumsFor(version)
+	if err != nil {
+		return []Problem{{"", err.Error()}}
+	}
+
+	var problems []Problem
+	if !slices.Contains(enums.Architectures, architecture) {
+		problems = append(problems, Problem{"architecture", enumProblem("architecture", architecture, version, enums.Architectures)})
+	}
+	if !slices.Contains(enums.InstallerTypes, installerType) {
+		problems = append(problems, Problem{"type", enumProblem("installer type", installerType, version, enums.InstallerTypes)})
+	}
+	// Scope is optional
+	if scope != "" && !slices.Contains(enums.Scopes, scope) {
+		problems = append(problems, Problem{"scope", enumProblem("scope", scope, version, enums.Scopes)})
+	}
+	return problems
+}
+
+// CheckRepairBehavior checks an installer's repair behavior, which is
+// optional, against the enumeration of a manifest schema version.
+func CheckRepairBehavior(version, behavior string) []Problem {
+	if behavior == "" {
+		return nil
+	}
+	enums, err := EnumsFor(version)
+	if err != nil {
+		return []Problem{{"", err.Error()}}
+	}
+	if len(enums.RepairBehaviors) == 0 {
+		return []Problem{{"repair_behavior", fmt.Sprintf("repair behavior is not supported by manifest version %s", version)}}
+	}
+	if !slices.Contains(enums.RepairBehaviors, behavior) {
+		return []Problem{{"repair_behavior", enumProblem("repair behavior", behavior, version, enums.RepairBehaviors)}}
+	}
+	return nil
+}
+
+// CheckUnsupportedArguments checks an installer's unsupported winget
+// arguments against the enumeration of a manifest schema version.
+func CheckUnsupportedArguments(version string, args []string) []Problem {
+	if len(args) == 0 {
+		return nil
+	}
+	enums, err := EnumsFor(version)
+	if err != nil {
+		return []Problem{{"", err.Error()}}
+	}
+
+	var problems []Problem
+	for i, arg := range args {
+		field := fmt.Sprintf("unsupported_arguments[%d]", i)
+		switch {
+		case !slices.Contains(enums.UnsupportedArguments, arg):
+			problems = append(problems, Problem{field, enumProblem("unsupported argument", arg, version, enums.UnsupportedArguments)})
+		case slices.Contains(args[:i], arg):
+			problems = append(problems, Problem{field, fmt.Sprintf("unsupported argument %q is duplicated", arg)})
+		}
+	}
+	return problems
+}
+
+// enumProblem describes a value missing from an enumeration.
+func enumProblem(name, value, version string, allowed []string) string {
+	if value == "" {
+		return fmt.Sprintf("%s is required, expected one of: %s", name, strings.Join(allowed, ", "))
+	}
+	return fmt.Sprintf("%s %q is not valid for manifest version %s, expected one of: %s",
+		name, value, version, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,94 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnumsFor(t *testing.T) {
+	if _, err := EnumsFor(SchemaVersion); err != nil {
+		t.Errorf("expected enums for current manifest version, got %v", err)
+	}
+	if _, err := EnumsFor("0.1.0"); err == nil {
+		t.Error("expected error for unsupported manifest version")
+	}
+}
+
+func TestEnumTablesMatchSchemas(t *testing.T) {
+	for version := range enumTables {
+		if _, err := loadSchema("installer", version); err != nil {
+			t.Errorf("enum table for %s has no embedded schema: %v", version, err)
+		}
+	}
+}
+
+func TestCheckInstallerEnums(t *testing.T) {
+	tests := []struct {
+		name         string
+		architecture string
+		typ          string
+		scope        string
+		problems     []string
+	}{
+		{"valid", "x64", "msi", "machine", nil},
+		{"no scope", "neutral", "zip", "", nil},
+		{"bad scope", "x64", "msi", "perUser", []string{`scope "perUser" is not valid for manifest version 1.7.0`}},
+		{"bad architecture", "amd64", "msi", "", []string{`architecture "amd64"`}},
+		{"missing type", "x64", "", "", []string{"installer type is required"}},
+		{"wrong case", "X64", "MSI", "", []string{`architecture "X64"`, `installer type "MSI"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := CheckInstallerEnums(SchemaVersion, tt.architecture, tt.typ, tt.scope)
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("expected %d problems, got %v", len(tt.problems), problems)
+			}
+			for i, expected := range tt.problems {
+				if !strings.Contains(problems[i].Message, expected) {
+					t.Errorf("expected problem containing '%s', got '%s'", expected, problems[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckRepairBehavior(t *testing.T) {
+	tests := []struct {
+		version  string
+		behavior string
+		problem  string
+	}{
+		{SchemaVersion, "", ""},
+		{SchemaVersion, "modify", ""},
+		{SchemaVersion, "repair", `repair behavior "repair" is not valid`},
+		{"1.6.0", "modify", "not supported by manifest version 1.6.0"},
+	}
+
+	for _, tt := range tests {
+		problems := CheckRepairBehavior(tt.version, tt.behavior)
+		switch {
+		case tt.problem == "" && len(problems) > 0:
+			t.Errorf("%s %q: unexpected problems %v", tt.version, tt.behavior, problems)
+		case tt.problem != "" && (len(problems) != 1 || !strings.Contains(problems[0].Message, tt.problem)):
+			t.Errorf("%s %q: expected a problem containing %q, got %v", tt.version, tt.behavior, tt.problem, problems)
+		}
+	}
+}
+
+func TestCheckUnsupportedArguments(t *testing.T) {
+	if problems := CheckUnsupportedArguments(SchemaVersion, []string{"log", "location"}); len(problems) != 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+
+	problems := CheckUnsupportedArguments(SchemaVersion, []string{"log", "silent", "log"})
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if problems[0].Field != "unsupported_arguments[1]" || !strings.Contains(problems[0].Message, `"silent" is not valid`) {
+		t.Errorf("unexpected problem: %v", problems[0])
+	}
+	if problems[1].Field != "unsupported_arguments[2]" || !strings.Contains(problems[1].Message, "duplicated") {
+		t.Errorf("unexpected problem: %v", problems[1])
+	}
+}
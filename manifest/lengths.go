@@ -0,0 +1,105 @@
+package manifest
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// MaxURLLength is the maximum length of a manifest URL.
+const MaxURLLength = 2048
+
+// lengthBounds are the minimum and maximum lengths in characters of the
+// bounded string fields of the locale manifest schema.
+var lengthBounds = map[string][2]int{
+	"Publisher":         {2, 256},
+	"PackageName":       {2, 256},
+	"License":           {3, 512},
+	"Copyright":         {3, 512},
+	"ShortDescription":  {3, 256},
+	"Description":       {3, 10000},
+	"Moniker":           {1, 40},
+	"ReleaseNotes":      {1, 10000},
+	"InstallationNotes": {1, 10000},
+	"AgreementLabel":    {1, 100},
+	"Agreement":         {1, 10000},
+}
+
+// CheckLength reports a non-empty value of a locale manifest field that is
+// shorter or longer than the schema allows. It returns "" for valid values,
+// empty values and unbounded fields.
+func CheckLength(field, value string) string {
+	bounds, ok := lengthBounds[field]
+	if !ok || value == "" {
+		return ""
+	}
+	switch length := utf8.RuneCountInString(value); {
+	case length < bounds[0]:
+		return fmt.Sprintf("%s must be at least %d characters, got %d", field, bounds[0], length)
+	case length > bounds[1]:
+		return fmt.Sprintf("%s must be at most %d characters, got %d", field, bounds[1], length)
+	}
+	return ""
+}
+
+// CheckURL reports a non-empty manifest URL that is not an absolute http or
+// https URL of at most MaxURLLength characters. It returns "" for valid and
+// empty URLs.
+func CheckURL(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	if length := utf8.RuneCountInString(value); length > MaxURLLength {
+		return fmt.Sprintf("%s must be at most %d characters, got %d", field, MaxURLLength, length)
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Sprintf("%s %q is not an http or https URL", field, value)
+	}
+	return ""
+}
+
+// CheckLocaleLengths reports the fields of a locale manifest that are
+// outside the schema length bounds and its invalid URLs.
+func CheckLocaleLengths(m *LocaleManifest) []Problem {
+	var problems []Problem
+	for _, f := range []struct{ name, value string }{
+		{"Publisher", m.Publisher},
+		{"PackageName", m.PackageName},
+		{"License", m.License},
+		{"Copyright", m.Copyright},
+		{"ShortDescription", m.ShortDescription},
+		{"Description", m.Description},
+		{"Moniker", m.Moniker},
+		{"ReleaseNotes", m.ReleaseNotes},
+		{"InstallationNotes", m.InstallationNotes},
+	} {
+		if message := CheckLength(f.name, f.value); message != "" {
+			problems = append(problems, Problem{f.name, message})
+		}
+	}
+	for _, f := range []struct{ name, value string }{
+		{"PublisherUrl", m.PublisherURL},
+		{"PublisherSupportUrl", m.PublisherSupportURL},
+		{"LicenseUrl", m.LicenseURL},
+		{"PackageUrl", m.PackageURL},
+		{"ReleaseNotesUrl", m.ReleaseNotesURL},
+	} {
+		if message := CheckURL(f.name, f.value); message != "" {
+			problems = append(problems, Problem{f.name, message})
+		}
+	}
+	for i, agreement := range m.Agreements {
+		field := fmt.Sprintf("Agreements[%d]", i)
+		if message := CheckLength("AgreementLabel", agreement.AgreementLabel); message != "" {
+			problems = append(problems, Problem{field + ".AgreementLabel", message})
+		}
+		if message := CheckLength("Agreement", agreement.Agreement); message != "" {
+			problems = append(problems, Problem{field + ".Agreement", message})
+		}
+		if message := CheckURL("AgreementUrl", agreement.AgreementURL); message != "" {
+			problems = append(problems, Problem{field + ".AgreementUrl", message})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckLength(t *testing.T) {
+	tests := []struct {
+		field    string
+		value    string
+		contains string
+	}{
+		{"Description", "", ""},
+		{"Description", "abc", ""},
+		{"Description", "ab", "at least 3 characters, got 2"},
+		{"Description", strings.Repeat("é", 10001), "at most 10000 characters, got 10001"},
+		{"Copyright", strings.Repeat("c", 513), "at most 512 characters"},
+		{"Moniker", strings.Repeat("m", 41), "at most 40 characters"},
+		{"ReleaseNotes", "x", ""},
+		{"InstallationNotes", strings.Repeat("n", 10001), "at most 10000 characters"},
+		{"Unbounded", strings.Repeat("x", 20000), ""},
+	}
+
+	for _, tt := range tests {
+		result := CheckLength(tt.field, tt.value)
+		if tt.contains == "" && result != "" || !strings.Contains(result, tt.contains) {
+			t.Errorf("CheckLength(%s, %d characters) = %q, expected %q", tt.field, len([]rune(tt.value)), result, tt.contains)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		value    string
+		contains string
+	}{
+		{"", ""},
+		{"https://example.com/notes", ""},
+		{"http://example.com", ""},
+		{"ftp://example.com/app.msi", "not an http or https URL"},
+		{"example.com", "not an http or https URL"},
+		{"https://example.com/" + strings.Repeat("a", MaxURLLength), "at most 2048 characters"},
+	}
+
+	for _, tt := range tests {
+		result := CheckURL("PackageUrl", tt.value)
+		if tt.contains == "" && result != "" || !strings.Contains(result, tt.contains) {
+			t.Errorf("CheckURL(%.40q) = %q, expected %q", tt.value, result, tt.contains)
+		}
+	}
+}
+
+func TestGenerateRejectsOutOfBoundsFields(t *testing.T) {
+	pkg := Package{Identifier: "MyOrg.MyApp", Publisher: "My Org", Name: "My App", License: "MIT", ShortDescription: "App"}
+
+	long := pkg
+	long.Locales = []Locale{{Locale: "de-DE", Description: strings.Repeat("x", 10001)}}
+	if _, err := Generate(long, "1.0.0", nil); err == nil || !strings.Contains(err.Error(), "invalid de-DE locale: Description must be at most 10000") {
+		t.Errorf("expected a description length error, got %v", err)
+	}
+
+	badURL := pkg
+	badURL.LicenseURL = "file:///LICENSE"
+	if _, err := Generate(badURL, "1.0.0", nil); err == nil || !strings.Contains(err.Error(), "LicenseUrl") {
+		t.Errorf("expected a license URL error, got %v", err)
+	}
+
+	installers := []Installer{{Architecture: "x64", InstallerType: "msi", InstallerURL: "https://example.com/" + strings.Repeat("a", MaxURLLength), InstallerSha256: strings.Repeat("A", 64)}}
+	if _, err := Generate(pkg, "1.0.0", installers); err == nil || !strings.Contains(err.Error(), "InstallerUrl must be at most") {
+		t.Errorf("expected an installer URL error, got %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+// Package manifest generates and validates winget package manifests in the
+// multi-file format used by the winget-pkgs repository.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the current winget manifest schema version.
+const SchemaVersion = "1.7.0"
+
+// DefaultLocale is the locale of the default locale manifest.
+const DefaultLocale = "en-US"
+
+// VersionManifest represents the version manifest file.
+type VersionManifest struct {
+	PackageIdentifier string `yaml:"PackageIdentifier"`
+	PackageVersion    string `yaml:"PackageVersion"`
+	DefaultLocale     string `yaml:"DefaultLocale"`
+	ManifestType      string `yaml:"ManifestType"`
+	ManifestVersion   string `yaml:"ManifestVersion"`
+}
+
+// InstallerManifest represents the installer manifest file.
+type InstallerManifest struct {
+	PackageIdentifier string      `yaml:"PackageIdentifier"`
+	PackageVersion    string      `yaml:"PackageVersion"`
+	Installers        []Installer `yaml:"Installers"`
+	ManifestType      string      `yaml:"ManifestType"`
+	ManifestVersion   string      `yaml:"ManifestVersion"`
+}
+
+// Installer represents a single installer entry.
+type Installer struct {
+	Architecture      string            `yaml:"Architecture"`
+	InstallerType     string            `yaml:"InstallerType"`
+	InstallerURL      string            `yaml:"InstallerUrl"`
+	InstallerSha256   string            `yaml:"InstallerSha256"`
+	SignatureSha256   string            `yaml:"SignatureSha256,omitempty"`
+	Scope             string            `yaml:"Scope,omitempty"`
+	InstallerSwitches map[string]string `yaml:"InstallerSwitches,omitempty"`
+	ProductCode       string            `yaml:"ProductCode,omitempty"`
+	PackageFamilyName string            `yaml:"PackageFamilyName,omitempty"`
+
+	UnsupportedArguments      []string               `yaml:"UnsupportedArguments,omitempty"`
+	AppsAndFeaturesEntries    []AppsAndFeaturesEntry `yaml:"AppsAndFeaturesEntries,omitempty"`
+	DownloadCommandProhibited bool                   `yaml:"DownloadCommandProhibited,omitempty"`
+	RepairBehavior            string                 `yaml:"RepairBehavior,omitempty"`
+}
+
+// AppsAndFeaturesEntry describes the Apps & Features (ARP) entry written by an
+// installer.
+type AppsAndFeaturesEntry struct {
+	DisplayName    string `yaml:"DisplayName,omitempty"`
+	DisplayVersion string `yaml:"DisplayVersion,omitempty"`
+	ProductCode    string `yaml:"ProductCode,omitempty"`
+	UpgradeCode    string `yaml:"UpgradeCode,omitempty"`
+}
+
+// LocaleManifest represents the default locale manifest file, or with
+// ManifestType "locale" an additional locale manifest file, in which only the
+// identifying fields are required.
+type LocaleManifest struct {
+	PackageIdentifier   string      `yaml:"PackageIdentifier"`
+	PackageVersion      string      `yaml:"PackageVersion"`
+	PackageLocale       string      `yaml:"PackageLocale"`
+	Publisher           string      `yaml:"Publisher"`
+	PublisherURL        string      `yaml:"PublisherUrl,omitempty"`
+	PublisherSupportURL string      `yaml:"PublisherSupportUrl,omitempty"`
+	PackageName         string      `yaml:"PackageName"`
+	License             string      `yaml:"License"`
+	LicenseURL          string      `yaml:"LicenseUrl,omitempty"`
+	Copyright           string      `yaml:"Copyright,omitempty"`
+	ShortDescription    string      `yaml:"ShortDescription"`
+	Description         string      `yaml:"Description,omitempty"`
+	Moniker             string      `yaml:"Moniker,omitempty"`
+	Tags                []string    `yaml:"Tags,omitempty"`
+	Agreements          []Agreement `yaml:"Agreements,omitempty"`
+	PackageURL          string      `yaml:"PackageUrl,omitempty"`
+	ReleaseNotes        string      `yaml:"ReleaseNotes,omitempty"`
+	ReleaseNotesURL     string      `yaml:"ReleaseNotesUrl,omitempty"`
+	InstallationNotes   string      `yaml:"InstallationNotes,omitempty"`
+	ManifestType        string      `yaml:"ManifestType"`
+	ManifestVersion     string      `yaml:"ManifestVersion"`
+}
+
+// MarshalYAML omits the unset fields that are required only in the default
+// locale manifest from additional locale manifests.
+func (m LocaleManifest) MarshalYAML() (any, error) {
+	type plain LocaleManifest
+	var node yaml.Node
+	if err := node.Encode(plain(m)); err != nil {
+		return nil, err
+	}
+	if m.ManifestType == "defaultLocale" {
+		return &node, nil
+	}
+
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!str" && value.Value == "" {
+			continue
+		}
+		content = append(content, key, value)
+	}
+	node.Content = content
+	return &node, nil
+}
+
+// Agreement is a license agreement or similar text shown before install.
+// Either Agreement or AgreementURL must be set.
+type Agreement struct {
+	AgreementLabel string `yaml:"AgreementLabel,omitempty"`
+	Agreement      string `yaml:"Agreement,omitempty"`
+	AgreementURL   string `yaml:"AgreementUrl,omitempty"`
+}
+
+// Set contains all generated manifest files.
+type Set struct {
+	Version   *VersionManifest
+	Installer *InstallerManifest
+	Locale    *LocaleManifest
+	// Locales holds the manifests of locales other than the default.
+	Locales []*LocaleManifest
+	Path    string
+}
+
+// Package describes the package-level metadata of a manifest set.
+type Package struct {
+	Identifier          string
+	Publisher           string
+	PublisherURL        string
+	PublisherSupportURL string
+	Name                string
+	License             string
+	LicenseURL          string
+	Copyright           string
+	ShortDescription    string
+	Description         string
+	Moniker             string
+	Tags                []string
+	Agreements          []Agreement
+	PackageURL          string
+	ReleaseNotes        string
+	ReleaseNotesURL     string
+	InstallationNotes   string
+	// Locales localizes the package into the default locale, which
+	// overrides the fields it sets, and additional locales.
+	Locales []Locale
+}
+
+// Locale holds the metadata of one package locale. Empty fields are taken
+// from the Package for the default locale and omitted otherwise.
+type Locale struct {
+	Locale            string
+	Publisher         string
+	Name              string
+	ShortDescription  string
+	Description       string
+	Tags              []string
+	Agreements        []Agreement
+	ReleaseNotes      string
+	ReleaseNotesURL   string
+	InstallationNotes string
+}
+
+// Generate generates all winget manifest files.
+func Generate(pkg Package, version string, installers []Installer) (*Set, error) {
+	// Parse package ID
+	parts := strings.SplitN(pkg.Identifier, ".", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid package ID format: %s", pkg.Identifier)
+	}
+	seen := make(map[string]bool)
+	for _, locale := range pkg.Locales {
+		if locale.Locale == "" {
+			return nil, fmt.Errorf("locale without a name")
+		}
+		if seen[strings.ToLower(locale.Locale)] {
+			return nil, fmt.Errorf("duplicate locale %s", locale.Locale)
+		}
+		seen[strings.ToLower(locale.Locale)] = true
+	}
+
+	for i, installer := range installers {
+		problems := CheckInstallerEnums(SchemaVersion, installer.Architecture, installer.InstallerType, installer.Scope)
+		problems = append(problems, CheckRepairBehavior(SchemaVersion, installer.RepairBehavior)...)
+		problems = append(problems, CheckUnsupportedArguments(SchemaVersion, installer.UnsupportedArguments)...)
+		if message := CheckURL("InstallerUrl", installer.InstallerURL); message != "" {
+			problems = append(problems, Problem{"InstallerUrl", message})
+		}
+		if len(problems) > 0 {
+			return nil, fmt.Errorf("invalid installer %d: %s", i, problems[0].Message)
+		}
+	}
+
+	// Version manifest
+	versionManifest := &VersionManifest{
+		PackageIdentifier: pkg.Identifier,
+		PackageVersion:    version,
+		DefaultLocale:     DefaultLocale,
+		ManifestType:      "version",
+		ManifestVersion:   SchemaVersion,
+	}
+
+	// Installer manifest
+	installerManifest := &InstallerManifest{
+		PackageIdentifier: pkg.Identifier,
+		PackageVersion:    version,
+		Installers:        installers,
+		ManifestType:      "installer",
+		ManifestVersion:   SchemaVersion,
+	}
+
+	// Locale manifest
+	localeManifest := &LocaleManifest{
+		PackageIdentifier:   pkg.Identifier,
+		PackageVersion:      version,
+		PackageLocale:       DefaultLocale,
+		Publisher:           pkg.Publisher,
+		PublisherURL:        pkg.PublisherURL,
+		PublisherSupportURL: pkg.PublisherSupportURL,
+		PackageName:         pkg.Name,
+		License:             pkg.License,
+		LicenseURL:          pkg.LicenseURL,
+		Copyright:           pkg.Copyright,
+		ShortDescription:    pkg.ShortDescription,
+		Description:         pkg.Description,
+		Moniker:             pkg.Moniker,
+		Tags:                pkg.Tags,
+		Agreements:          pkg.Agreements,
+		PackageURL:          pkg.PackageURL,
+		ReleaseNotes:        pkg.ReleaseNotes,
+		ReleaseNotesURL:     pkg.ReleaseNotesURL,
+		InstallationNotes:   pkg.InstallationNotes,
+		ManifestType:        "defaultLocale",
+		ManifestVersion:     SchemaVersion,
+	}
+
+	var locales []*LocaleManifest
+	for _, locale := range pkg.Locales {
+		if strings.EqualFold(locale.Locale, DefaultLocale) {
+			localeManifest.Publisher = firstNonEmpty(locale.Publisher, localeManifest.Publisher)
+			localeManifest.PackageName = firstNonEmpty(locale.Name, localeManifest.PackageName)
+			localeManifest.ShortDescription = firstNonEmpty(locale.ShortDescription, localeManifest.ShortDescription)
+			localeManifest.Description = firstNonEmpty(locale.Description, localeManifest.Description)
+			localeManifest.ReleaseNotes = firstNonEmpty(locale.ReleaseNotes, localeManifest.ReleaseNotes)
+			localeManifest.ReleaseNotesURL = firstNonEmpty(locale.ReleaseNotesURL, localeManifest.ReleaseNotesURL)
+			localeManifest.InstallationNotes = firstNonEmpty(locale.InstallationNotes, localeManifest.InstallationNotes)
+			if len(locale.Tags) > 0 {
+				localeManifest.Tags = locale.Tags
+			}
+			if len(locale.Agreements) > 0 {
+				localeManifest.Agreements = locale.Agreements
+			}
+			continue
+		}
+		locales = append(locales, &LocaleManifest{
+			PackageIdentifier: pkg.Identifier,
+			PackageVersion:    version,
+			PackageLocale:     locale.Locale,
+			Publisher:         locale.Publisher,
+			PackageName:       locale.Name,
+			ShortDescription:  locale.ShortDescription,
+			Description:       locale.Description,
+			Tags:              locale.Tags,
+			Agreements:        locale.Agreements,
+			ReleaseNotes:      locale.ReleaseNotes,
+			ReleaseNotesURL:   locale.ReleaseNotesURL,
+			InstallationNotes: locale.InstallationNotes,
+			ManifestType:      "locale",
+			ManifestVersion:   SchemaVersion,
+		})
+	}
+
+	for _, m := range append([]*LocaleManifest{localeManifest}, locales...) {
+		if problems := CheckLocaleLengths(m); len(problems) > 0 {
+			return nil, fmt.Errorf("invalid %s locale: %s", m.PackageLocale, problems[0].Message)
+		}
+	}
+
+	// Build path: manifests/p/Publisher/PackageName/version
+	path := PublishedDir(pkg.Identifier) + "/" + version
+
+	return &Set{
+		Version:   versionManifest,
+		Installer: installerManifest,
+		Locale:    localeManifest,
+		Locales:   locales,
+		Path:      path,
+	}, nil
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// PublishedDir returns the winget-pkgs directory holding the version
+// directories of a published package, e.g. manifests/m/MyOrg/MyApp.
+func PublishedDir(packageID string) string {
+	return fmt.Sprintf("manifests/%s/%s", strings.ToLower(packageID[:1]), strings.ReplaceAll(packageID, ".", "/"))
+}
+
+// VersionYAML returns the version manifest as YAML.
+func (m *Set) VersionYAML() (string, error) {
+	return toYAML(m.Version)
+}
+
+// InstallerYAML returns the installer manifest as YAML.
+func (m *Set) InstallerYAML() (string, error) {
+	return toYAML(m.Installer)
+}
+
+// LocaleYAML returns the locale manifest as YAML.
+func (m *Set) LocaleYAML() (string, error) {
+	return toYAML(m.Locale)
+}
+
+// GetFiles returns a map of file paths to content for committing.
+func (m *Set) GetFiles() (map[string]string, error) {
+	files := make(map[string]string)
+
+	versionYAML, err := m.VersionYAML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate version manifest: %w", err)
+	}
+	files[fmt.Sprintf("%s/%s.yaml", m.Path, m.Version.PackageIdentifier)] = addYAMLHeader(versionYAML)
+
+	installerYAML, err := m.InstallerYAML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate installer manifest: %w", err)
+	}
+	files[fmt.Sprintf("%s/%s.installer.yaml", m.Path, m.Installer.PackageIdentifier)] = addYAMLHeader(installerYAML)
+
+	localeYAML, err := m.LocaleYAML()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate locale manifest: %w", err)
+	}
+	files[fmt.Sprintf("%s/%s.locale.%s.yaml", m.Path, m.Locale.PackageIdentifier, m.Locale.PackageLocale)] = addYAMLHeader(localeYAML)
+
+	for _, locale := range m.Locales {
+		localeYAML, err := toYAML(locale)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate %s locale manifest: %w", locale.PackageLocale, err)
+		}
+		files[fmt.Sprintf("%s/%s.locale.%s.yaml", m.Path, locale.PackageIdentifier, locale.PackageLocale)] = addYAMLHeader(localeYAML)
+	}
+
+	return files, nil
+}
+
+// WriteTo writes all manifest files below dir using the winget-pkgs layout and
+// returns the directory containing the manifests of this version.
+func (m *Set) WriteTo(dir string) (string, error) {
+	files, err := m.GetFiles()
+	if err != nil {
+		return "", err
+	}
+
+	for path, content := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(m.Path)), nil
+}
+
+// toYAML converts a struct to YAML string.
+func toYAML(v any) (string, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// addYAMLHeader adds the winget manifest YAML header comment.
+func addYAMLHeader(content string) string {
+	header := "# Created using Relicta\n# yaml-language-server: $schema=https://aka.ms/winget-manifest.version." + SchemaVersion + ".schema.json\n\n"
+	return header + content
+}
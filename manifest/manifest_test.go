@@ -0,0 +1,319 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	pkg := Package{
+		Identifier:       "MyOrg.MyApp",
+		Publisher:        "My Organization",
+		PublisherURL:     "https://myorg.com",
+		Name:             "My Application",
+		ShortDescription: "A useful application",
+		License:          "MIT",
+		LicenseURL:       "https://github.com/myorg/myapp/LICENSE",
+		Moniker:          "myapp",
+		Tags:             []string{"utility", "productivity"},
+		Description:      "A full description of the application",
+	}
+
+	installers := []Installer{
+		{
+			Architecture:    "x64",
+			InstallerType:   "msi",
+			InstallerURL:    "https://example.com/myapp-1.0.0-x64.msi",
+			InstallerSha256: "ABC123",
+		},
+	}
+
+	manifests, err := Generate(pkg, "1.0.0", installers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Check version manifest
+	if manifests.Version.PackageIdentifier != "MyOrg.MyApp" {
+		t.Errorf("expected PackageIdentifier 'MyOrg.MyApp', got '%s'", manifests.Version.PackageIdentifier)
+	}
+	if manifests.Version.PackageVersion != "1.0.0" {
+		t.Errorf("expected PackageVersion '1.0.0', got '%s'", manifests.Version.PackageVersion)
+	}
+	if manifests.Version.ManifestType != "version" {
+		t.Errorf("expected ManifestType 'version', got '%s'", manifests.Version.ManifestType)
+	}
+
+	// Check installer manifest
+	if len(manifests.Installer.Installers) != 1 {
+		t.Errorf("expected 1 installer, got %d", len(manifests.Installer.Installers))
+	}
+	if manifests.Installer.ManifestType != "installer" {
+		t.Errorf("expected ManifestType 'installer', got '%s'", manifests.Installer.ManifestType)
+	}
+
+	// Check locale manifest
+	if manifests.Locale.Publisher != "My Organization" {
+		t.Errorf("expected Publisher 'My Organization', got '%s'", manifests.Locale.Publisher)
+	}
+	if manifests.Locale.ShortDescription != "A useful application" {
+		t.Errorf("expected ShortDescription 'A useful application', got '%s'", manifests.Locale.ShortDescription)
+	}
+	if manifests.Locale.Description != "A full description of the application" {
+		t.Errorf("expected Description, got '%s'", manifests.Locale.Description)
+	}
+	if manifests.Locale.ManifestType != "defaultLocale" {
+		t.Errorf("expected ManifestType 'defaultLocale', got '%s'", manifests.Locale.ManifestType)
+	}
+
+	// Check path
+	expectedPath := "manifests/m/MyOrg/MyApp/1.0.0"
+	if manifests.Path != expectedPath {
+		t.Errorf("expected path '%s', got '%s'", expectedPath, manifests.Path)
+	}
+}
+
+func TestGenerateInvalidPackageID(t *testing.T) {
+	pkg := Package{Identifier: "InvalidPackageID"}
+
+	_, err := Generate(pkg, "1.0.0", nil)
+	if err == nil {
+		t.Error("expected error for invalid package ID")
+	}
+}
+
+func TestGenerateInvalidEnum(t *testing.T) {
+	pkg := Package{Identifier: "MyOrg.MyApp"}
+	installers := []Installer{{Architecture: "x64", InstallerType: "msi", Scope: "perUser"}}
+
+	_, err := Generate(pkg, "1.0.0", installers)
+	if err == nil || !strings.Contains(err.Error(), `scope "perUser"`) {
+		t.Errorf("expected scope error, got %v", err)
+	}
+}
+
+func TestSetYAML(t *testing.T) {
+	manifests := &Set{
+		Version: &VersionManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			DefaultLocale:     "en-US",
+			ManifestType:      "version",
+			ManifestVersion:   SchemaVersion,
+		},
+		Installer: &InstallerManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			Installers: []Installer{
+				{
+					Architecture:    "x64",
+					InstallerType:   "msi",
+					InstallerURL:    "https://example.com/app.msi",
+					InstallerSha256: "ABC123",
+				},
+			},
+			ManifestType:    "installer",
+			ManifestVersion: SchemaVersion,
+		},
+		Locale: &LocaleManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			PackageLocale:     "en-US",
+			Publisher:         "My Org",
+			PackageName:       "My App",
+			License:           "MIT",
+			ShortDescription:  "A test app",
+			ManifestType:      "defaultLocale",
+			ManifestVersion:   SchemaVersion,
+		},
+		Path: "manifests/m/MyOrg/MyApp/1.0.0",
+	}
+
+	// Test version YAML
+	versionYAML, err := manifests.VersionYAML()
+	if err != nil {
+		t.Fatalf("failed to generate version YAML: %v", err)
+	}
+	if !strings.Contains(versionYAML, "PackageIdentifier: MyOrg.MyApp") {
+		t.Error("version YAML missing PackageIdentifier")
+	}
+
+	// Test installer YAML
+	installerYAML, err := manifests.InstallerYAML()
+	if err != nil {
+		t.Fatalf("failed to generate installer YAML: %v", err)
+	}
+	if !strings.Contains(installerYAML, "InstallerUrl: https://example.com/app.msi") {
+		t.Error("installer YAML missing InstallerUrl")
+	}
+
+	// Test locale YAML
+	localeYAML, err := manifests.LocaleYAML()
+	if err != nil {
+		t.Fatalf("failed to generate locale YAML: %v", err)
+	}
+	if !strings.Contains(localeYAML, "Publisher: My Org") {
+		t.Error("locale YAML missing Publisher")
+	}
+}
+
+func TestSetGetFiles(t *testing.T) {
+	manifests := &Set{
+		Version: &VersionManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			DefaultLocale:     "en-US",
+			ManifestType:      "version",
+			ManifestVersion:   SchemaVersion,
+		},
+		Installer: &InstallerManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			Installers:        []Installer{},
+			ManifestType:      "installer",
+			ManifestVersion:   SchemaVersion,
+		},
+		Locale: &LocaleManifest{
+			PackageIdentifier: "MyOrg.MyApp",
+			PackageVersion:    "1.0.0",
+			PackageLocale:     "en-US",
+			Publisher:         "My Org",
+			PackageName:       "My App",
+			License:           "MIT",
+			ShortDescription:  "A test app",
+			ManifestType:      "defaultLocale",
+			ManifestVersion:   SchemaVersion,
+		},
+		Path: "manifests/m/MyOrg/MyApp/1.0.0",
+	}
+
+	files, err := manifests.GetFiles()
+	if err != nil {
+		t.Fatalf("failed to get files: %v", err)
+	}
+
+	expectedFiles := []string{
+		"manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.yaml",
+		"manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.installer.yaml",
+		"manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.locale.en-US.yaml",
+	}
+
+	if len(files) != len(expectedFiles) {
+		t.Errorf("expected %d files, got %d", len(expectedFiles), len(files))
+	}
+
+	for _, path := range expectedFiles {
+		if _, ok := files[path]; !ok {
+			t.Errorf("missing file: %s", path)
+		}
+	}
+
+	// Check that files have YAML header
+	for path, content := range files {
+		if !strings.HasPrefix(content, "# Created using Relicta") {
+			t.Errorf("file %s missing YAML header", path)
+		}
+	}
+}
+
+func TestAddYAMLHeader(t *testing.T) {
+	content := "PackageIdentifier: Test.App"
+	result := addYAMLHeader(content)
+
+	if !strings.HasPrefix(result, "# Created using Relicta") {
+		t.Error("missing Relicta header")
+	}
+	if !strings.Contains(result, content) {
+		t.Error("original content missing")
+	}
+}
+
+func TestSetWriteTo(t *testing.T) {
+	manifests := validTestManifests(t)
+	dir := t.TempDir()
+
+	versionDir, err := manifests.WriteTo(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedDir := filepath.Join(dir, "manifests", "m", "MyOrg", "MyApp", "1.0.0")
+	if versionDir != expectedDir {
+		t.Errorf("expected directory '%s', got '%s'", expectedDir, versionDir)
+	}
+
+	for _, name := range []string{"MyOrg.MyApp.yaml", "MyOrg.MyApp.installer.yaml", "MyOrg.MyApp.locale.en-US.yaml"} {
+		content, err := os.ReadFile(filepath.Join(versionDir, name))
+		if err != nil {
+			t.Errorf("missing file %s: %v", name, err)
+			continue
+		}
+		if !strings.HasPrefix(string(content), "# Created using Relicta") {
+			t.Errorf("file %s missing YAML header", name)
+		}
+	}
+}
+
+func TestPublishedDir(t *testing.T) {
+	if dir := PublishedDir("MyOrg.MyApp.Preview"); dir != "manifests/m/MyOrg/MyApp/Preview" {
+		t.Errorf("unexpected directory '%s'", dir)
+	}
+}
+
+func TestGenerateLocales(t *testing.T) {
+	pkg := Package{
+		Identifier:       "MyOrg.MyApp",
+		Publisher:        "My Organization",
+		Name:             "My Application",
+		ShortDescription: "A useful application",
+		License:          "MIT",
+		Tags:             []string{"utility"},
+		Locales: []Locale{
+			{Locale: "en-US", Tags: []string{"tool"}, Agreements: []Agreement{{AgreementLabel: "EULA", AgreementURL: "https://myorg.com/eula"}}},
+			{Locale: "de-DE", ShortDescription: "Eine nützliche Anwendung", Tags: []string{"werkzeug"}, Agreements: []Agreement{{AgreementLabel: "Lizenz", Agreement: "Bitte lesen"}}, InstallationNotes: "Neu starten"},
+		},
+	}
+
+	manifests, err := Generate(pkg, "1.0.0", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(manifests.Locale.Tags, []string{"tool"}) || len(manifests.Locale.Agreements) != 1 {
+		t.Errorf("expected the en-US locale to override tags and agreements, got %+v", manifests.Locale)
+	}
+	if manifests.Locale.ShortDescription != "A useful application" {
+		t.Errorf("expected unset fields to keep the package metadata, got %q", manifests.Locale.ShortDescription)
+	}
+	if len(manifests.Locales) != 1 {
+		t.Fatalf("expected one additional locale, got %d", len(manifests.Locales))
+	}
+	de := manifests.Locales[0]
+	if de.ManifestType != "locale" || de.PackageLocale != "de-DE" || de.Publisher != "" || de.Agreements[0].Agreement != "Bitte lesen" {
+		t.Errorf("unexpected de-DE manifest: %+v", de)
+	}
+
+	files, err := manifests.GetFiles()
+	if err != nil {
+		t.Fatalf("failed to get files: %v", err)
+	}
+	content, ok := files["manifests/m/MyOrg/MyApp/1.0.0/MyOrg.MyApp.locale.de-DE.yaml"]
+	if !ok {
+		t.Fatalf("missing de-DE locale file, got %v", len(files))
+	}
+	for _, want := range []string{"PackageLocale: de-DE", "- werkzeug", "AgreementLabel: Lizenz", "InstallationNotes: Neu starten", "ManifestType: locale"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "Publisher") {
+		t.Errorf("expected unset fields to be omitted:\n%s", content)
+	}
+
+	if _, err := Generate(Package{Identifier: "MyOrg.MyApp", Locales: []Locale{{Locale: "de-DE"}, {Locale: "de-de"}}}, "1.0.0", nil); err == nil {
+		t.Error("expected error for duplicate locales")
+	}
+}
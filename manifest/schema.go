@@ -0,0 +1,197 @@
+package manifest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+// schemaFS holds trimmed copies of the winget manifest JSON schemas, one
+// directory per manifest schema version. They only cover the fields modeled
+// by Set, such as no Dependencies, Markets or NestedInstallerFiles. Manifests
+// and installers accept properties outside the subset, which winget-pkgs may
+// allow, while objects kept whole such as InstallerSwitches still reject
+// unknown keys.
+//
+//go:embed schemas
+var schemaFS embed.FS
+
+var (
+	schemaCacheMu sync.Mutex
+	schemaCache   = make(map[string]*jsonschema.Schema)
+)
+
+// SchemaViolation describes a single schema validation failure.
+type SchemaViolation struct {
+	File    string
+	Path    string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.File, v.Path, v.Message)
+}
+
+// SchemaValidationError is returned when generated manifests violate the
+// winget manifest schema.
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return fmt.Sprintf("manifest schema validation failed:\n%s", strings.Join(lines, "\n"))
+}
+
+// Validate validates all generated manifests against the embedded subsets
+// of the winget JSON schemas for their ManifestVersion.
+func Validate(m *Set) error {
+	type manifestFile struct {
+		name         string
+		manifestType string
+		version      string
+		render       func() (string, error)
+	}
+	files := []manifestFile{
+		{"version", "version", m.Version.ManifestVersion, m.VersionYAML},
+		{"installer", "installer", m.Installer.ManifestVersion, m.InstallerYAML},
+		{"locale." + m.Locale.PackageLocale, "defaultLocale", m.Locale.ManifestVersion, m.LocaleYAML},
+	}
+	for _, locale := range m.Locales {
+		render := func() (string, error) { return toYAML(locale) }
+		files = append(files, manifestFile{"locale." + locale.PackageLocale, "locale", locale.ManifestVersion, render})
+	}
+
+	var violations []SchemaViolation
+	for _, f := range files {
+		content, err := f.render()
+		if err != nil {
+			return fmt.Errorf("failed to render %s manifest: %w", f.name, err)
+		}
+
+		found, err := validateYAML(f.manifestType, f.version, content)
+		if err != nil {
+			return fmt.Errorf("failed to validate %s manifest: %w", f.name, err)
+		}
+		for _, v := range found {
+			v.File = f.name
+			violations = append(violations, v)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validateYAML validates a YAML manifest document against the schema for the
+// given manifest type and schema version.
+func validateYAML(manifestType, version, content string) ([]SchemaViolation, error) {
+	sch, err := loadSchema(manifestType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// Round-trip through JSON so values have the types the validator expects
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert YAML to JSON: %w", err)
+	}
+
+	err = sch.Validate(inst)
+	if err == nil {
+		return nil, nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+
+	return collectViolations(validationErr), nil
+}
+
+// collectViolations flattens a validation error tree into leaf violations.
+func collectViolations(err *jsonschema.ValidationError) []SchemaViolation {
+	printer := message.NewPrinter(language.English)
+
+	var violations []SchemaViolation
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, cause := range e.Causes {
+				walk(cause)
+			}
+			return
+		}
+
+		path := "/" + strings.Join(e.InstanceLocation, "/")
+		violations = append(violations, SchemaViolation{
+			Path:    path,
+			Message: e.ErrorKind.LocalizedString(printer),
+		})
+	}
+	walk(err)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations
+}
+
+// loadSchema compiles and caches the schema for a manifest type and version.
+func loadSchema(manifestType, version string) (*jsonschema.Schema, error) {
+	name := fmt.Sprintf("schemas/%s/manifest.%s.%s.json", version, manifestType, version)
+
+	schemaCacheMu.Lock()
+	defer schemaCacheMu.Unlock()
+
+	if sch, ok := schemaCache[name]; ok {
+		return sch, nil
+	}
+
+	data, err := schemaFS.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("no %s schema available for manifest version %s", manifestType, version)
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", name, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(name, doc); err != nil {
+		return nil, fmt.Errorf("failed to load schema %s: %w", name, err)
+	}
+
+	sch, err := compiler.Compile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", name, err)
+	}
+
+	schemaCache[name] = sch
+	return sch, nil
+}
@@ -0,0 +1,212 @@
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validTestManifests(t *testing.T) *Set {
+	t.Helper()
+
+	pkg := Package{
+		Identifier:       "MyOrg.MyApp",
+		Publisher:        "My Organization",
+		PublisherURL:     "https://myorg.com",
+		Name:             "My Application",
+		ShortDescription: "A useful application",
+		License:          "MIT",
+		Tags:             []string{"utility"},
+	}
+
+	installers := []Installer{
+		{
+			Architecture:      "x64",
+			InstallerType:     "msi",
+			InstallerURL:      "https://example.com/myapp-1.0.0-x64.msi",
+			InstallerSha256:   strings.Repeat("A", 64),
+			Scope:             "machine",
+			InstallerSwitches: map[string]string{"Silent": "/quiet"},
+		},
+	}
+
+	manifests, err := Generate(pkg, "1.0.0", installers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return manifests
+}
+
+func TestValidateValid(t *testing.T) {
+	if err := Validate(validTestManifests(t)); err != nil {
+		t.Errorf("expected valid manifests, got: %v", err)
+	}
+}
+
+func TestValidateAppsAndFeaturesEntries(t *testing.T) {
+	m := validTestManifests(t)
+	m.Installer.Installers[0].AppsAndFeaturesEntries = []AppsAndFeaturesEntry{{
+		ProductCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}",
+		UpgradeCode: "{FEDCBA98-7654-3210-FEDC-BA9876543210}",
+	}}
+	if err := Validate(m); err != nil {
+		t.Errorf("expected valid manifests, got: %v", err)
+	}
+}
+
+func TestValidateRepairAndDownload(t *testing.T) {
+	m := validTestManifests(t)
+	installer := &m.Installer.Installers[0]
+	installer.DownloadCommandProhibited = true
+	installer.RepairBehavior = "installer"
+	installer.InstallerSwitches["Repair"] = "/repair"
+	installer.UnsupportedArguments = []string{"log", "location"}
+	if err := Validate(m); err != nil {
+		t.Errorf("expected valid manifests, got: %v", err)
+	}
+
+	content, err := m.InstallerYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"DownloadCommandProhibited: true", "RepairBehavior: installer", "Repair: /repair", "UnsupportedArguments:\n        - log\n        - location"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in installer manifest, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(m *Set)
+		file     string
+		path     string
+		contains string
+	}{
+		{
+			name:   "bad hash",
+			mutate: func(m *Set) { m.Installer.Installers[0].InstallerSha256 = "ABC123" },
+			file:   "installer",
+			path:   "/Installers/0/InstallerSha256",
+		},
+		{
+			name:   "unknown architecture",
+			mutate: func(m *Set) { m.Installer.Installers[0].Architecture = "amd64" },
+			file:   "installer",
+			path:   "/Installers/0/Architecture",
+		},
+		{
+			name:   "unknown switch",
+			mutate: func(m *Set) { m.Installer.Installers[0].InstallerSwitches = map[string]string{"silent": "/S"} },
+			file:   "installer",
+			path:   "/Installers/0/InstallerSwitches",
+		},
+		{
+			name:   "no installers",
+			mutate: func(m *Set) { m.Installer.Installers = nil },
+			file:   "installer",
+			path:   "/Installers",
+		},
+		{
+			name:   "short description too short",
+			mutate: func(m *Set) { m.Locale.ShortDescription = "ab" },
+			file:   "locale.en-US",
+			path:   "/ShortDescription",
+		},
+		{
+			name:     "missing publisher",
+			mutate:   func(m *Set) { m.Locale.Publisher = "" },
+			file:     "locale.en-US",
+			path:     "/Publisher",
+			contains: "minLength",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests := validTestManifests(t)
+			tt.mutate(manifests)
+
+			err := Validate(manifests)
+			var schemaErr *SchemaValidationError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("expected *SchemaValidationError, got %v", err)
+			}
+
+			found := false
+			for _, v := range schemaErr.Violations {
+				if v.File == tt.file && v.Path == tt.path {
+					found = true
+					if tt.contains != "" && !strings.Contains(v.Message, tt.contains) {
+						t.Errorf("expected message to contain '%s', got '%s'", tt.contains, v.Message)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("expected violation at %s %s, got %v", tt.file, tt.path, schemaErr.Violations)
+			}
+		})
+	}
+}
+
+func TestValidateUnknownVersion(t *testing.T) {
+	manifests := validTestManifests(t)
+	manifests.Version.ManifestVersion = "0.0.1"
+
+	err := Validate(manifests)
+	if err == nil {
+		t.Fatal("expected error for unknown manifest version")
+	}
+	if !strings.Contains(err.Error(), "no version schema available") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateYAMLFieldsOutsideSubset(t *testing.T) {
+	content := `PackageIdentifier: MyOrg.MyApp
+PackageVersion: 1.0.0
+Installers:
+  - Architecture: x64
+    InstallerType: msi
+    InstallerUrl: https://example.com/app.msi
+    InstallerSha256: ` + strings.Repeat("A", 64) + `
+    ExpectedReturnCodes:
+      - InstallerReturnCode: 1
+        ReturnResponse: installInProgress
+Markets:
+  AllowedMarkets: [US]
+ManifestType: installer
+ManifestVersion: 1.7.0
+`
+	violations, err := validateYAML("installer", "1.7.0", content)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("expected winget fields outside the subset to be accepted, got %v", violations)
+	}
+}
+
+func TestValidateLocales(t *testing.T) {
+	m := validTestManifests(t)
+	m.Locale.Agreements = []Agreement{{AgreementLabel: "EULA", AgreementURL: "https://myorg.com/eula"}}
+	m.Locales = []*LocaleManifest{{
+		PackageIdentifier: "MyOrg.MyApp",
+		PackageVersion:    "1.0.0",
+		PackageLocale:     "de-DE",
+		Tags:              []string{"werkzeug"},
+		ManifestType:      "locale",
+		ManifestVersion:   SchemaVersion,
+	}}
+	if err := Validate(m); err != nil {
+		t.Fatalf("expected valid manifests, got: %v", err)
+	}
+
+	m.Locales[0].Agreements = []Agreement{{AgreementLabel: strings.Repeat("x", 101)}}
+	err := Validate(m)
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) || schemaErr.Violations[0].File != "locale.de-DE" {
+		t.Errorf("expected a de-DE locale violation, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// Special versions winget orders apart from all others.
+const (
+	// VersionUnknown is the version winget records when a package version
+	// cannot be determined. It sorts lower than any other version.
+	VersionUnknown = "Unknown"
+	// VersionLatest sorts higher than any other version.
+	VersionLatest = "Latest"
+)
+
+// versionPart is a segment of a version: a leading number, and whatever text
+// follows it.
+type versionPart struct {
+	number uint64
+	other  string
+}
+
+// parseVersionPart splits a segment into its leading number and the rest.
+// Segments without a leading number, or whose number overflows, are compared
+// as text only.
+func parseVersionPart(s string) versionPart {
+	s = strings.TrimSpace(s)
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	if digits == 0 {
+		return versionPart{other: s}
+	}
+	n, err := strconv.ParseUint(s[:digits], 10, 64)
+	if err != nil {
+		return versionPart{other: s}
+	}
+	return versionPart{number: n, other: s[digits:]}
+}
+
+// compare orders parts by number, then a part without text after one with
+// text (so 0 is greater than 0-rc), then by text case-insensitively.
+func (p versionPart) compare(o versionPart) int {
+	if c := cmp.Compare(p.number, o.number); c != 0 {
+		return c
+	}
+	switch {
+	case p.other == "" && o.other == "":
+		return 0
+	case p.other == "":
+		return 1
+	case o.other == "":
+		return -1
+	}
+	return cmp.Compare(strings.ToLower(p.other), strings.ToLower(o.other))
+}
+
+// parseVersion splits a version into its parts the way winget does: text
+// before the first digit (like "v" or "Version ") is dropped when it does not
+// span a separator, and trailing zero parts are removed, so 1.0 equals 1.
+func parseVersion(v string) []versionPart {
+	v = strings.TrimSpace(v)
+	if digit := strings.IndexAny(v, "0123456789"); digit > 0 {
+		if dot := strings.IndexByte(v, '.'); dot < 0 || digit < dot {
+			v = v[digit:]
+		}
+	}
+
+	var parts []versionPart
+	for _, s := range strings.Split(v, ".") {
+		parts = append(parts, parseVersionPart(s))
+	}
+	for len(parts) > 0 && parts[len(parts)-1] == (versionPart{}) {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+// CompareVersions compares two package versions following winget's version
+// ordering, and returns -1, 0 or 1. Versions are compared part by part, where
+// parts are separated by "." and compared by their leading number, then by
+// any text that follows it. Missing parts count as zero. "Unknown" sorts
+// lower and "Latest" higher than any other version.
+func CompareVersions(a, b string) int {
+	aLatest, bLatest := strings.EqualFold(strings.TrimSpace(a), VersionLatest), strings.EqualFold(strings.TrimSpace(b), VersionLatest)
+	switch {
+	case aLatest && bLatest:
+		return 0
+	case aLatest:
+		return 1
+	case bLatest:
+		return -1
+	}
+	aUnknown, bUnknown := strings.EqualFold(strings.TrimSpace(a), VersionUnknown), strings.EqualFold(strings.TrimSpace(b), VersionUnknown)
+	switch {
+	case aUnknown && bUnknown:
+		return 0
+	case aUnknown:
+		return -1
+	case bUnknown:
+		return 1
+	}
+
+	as, bs := parseVersion(a), parseVersion(b)
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y versionPart
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if c := x.compare(y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// LatestVersion returns the greatest of versions, or "" if there are none.
+func LatestVersion(versions []string) string {
+	latest := ""
+	for _, v := range versions {
+		if latest == "" || CompareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
@@ -0,0 +1,62 @@
+package manifest
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.0", "1.10.0", -1},
+		{"2.0.0-beta", "2.0.0-alpha", 1},
+
+		// winget's own version ordering test vectors
+		{"1", "2", -1},
+		{"1.0.0", "2.0.0", -1},
+		{"0.0.1", "0.0.2", -1},
+		{"0.0.1-alpha", "0.0.2-alpha", -1},
+		{"0.0.1-beta", "0.0.2-alpha", -1},
+		{"13.9.8", "14.1", -1},
+		{"1.0", "1.0.0", 0},
+		{"1.0", "1.0 ", 0},
+		{"1.0", "1. 0", 0},
+		{"1.0", "Version 1.0", 0},
+		{"foo1", "bar1", 0},
+		{"v0.0.1", "0.0.2", -1},
+		{"v0.0.1", "v0.0.2", -1},
+		{"1.a2", "1.b1", -1},
+		{"alpha", "beta", -1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.2-rc", "1.2", -1},
+		{"1.0-Beta", "1.0-beta", 0},
+		{"1.0.0.1", "1.0.0", 1},
+		{"99999999999999999999", "1", -1}, // overflowing numbers compare as text
+		{"Unknown", "0", -1},
+		{"unknown", "Unknown", 0},
+		{"Latest", "99.0", 1},
+		{"Latest", "Unknown", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if result := CompareVersions(tt.a, tt.b); result != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, result)
+			}
+			if result := CompareVersions(tt.b, tt.a); result != -tt.expected {
+				t.Errorf("reversed: expected %d, got %d", -tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	if latest := LatestVersion([]string{"1.9.0", "1.10.0", "1.2.0"}); latest != "1.10.0" {
+		t.Errorf("expected 1.10.0, got %s", latest)
+	}
+	if latest := LatestVersion(nil); latest != "" {
+		t.Errorf("expected no version, got %s", latest)
+	}
+}
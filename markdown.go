@@ -0,0 +1,105 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Markdown inline syntax rewritten by markdownToText, in order.
+var markdownInline = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// Images become their alt text, links "text (url)"
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]+)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`), "$1 ($2)"},
+	{regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`), "$1"},
+	// HTML tags are dropped
+	{regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`), ""},
+	{regexp.MustCompile("`([^`]+)`"), "$1"},
+	{regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`), "$1$2"},
+	{regexp.MustCompile(`~~([^~]+)~~`), "$1"},
+	{regexp.MustCompile(`\*([^*\s][^*]*)\*`), "$1"},
+	{regexp.MustCompile(`(^|[^\w])_([^_\s][^_]*)_([^\w]|$)`), "$1$2$3"},
+	{regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!~>|])`), "$1"},
+}
+
+var (
+	markdownHeading    = regexp.MustCompile(`^#{1,6}\s+(.*?)(?:\s+#+)?$`)
+	markdownBullet     = regexp.MustCompile(`^(\s*)[-*+]\s+(?:\[([ xX])\]\s+)?`)
+	markdownRule       = regexp.MustCompile(`^(?:-\s*){3,}$|^(?:\*\s*){3,}$|^(?:_\s*){3,}$`)
+	markdownSetextRule = regexp.MustCompile(`^(?:=+|-+)$`)
+	markdownLinkDef    = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s+\S+`)
+)
+
+// markdownToText renders Markdown as plain text readable in `winget show`:
+// headings, emphasis, code and HTML markup are removed, bullets become "- ",
+// task list items "[ ]" or "[x]", links "text (url)" and images their alt
+// text. Code blocks are kept verbatim and runs of blank lines collapsed.
+func markdownToText(markdown string) string {
+	var lines []string
+	var fence string
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Fenced code blocks are kept without their fences
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+				continue
+			}
+			lines = append(lines, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+
+		for strings.HasPrefix(trimmed, ">") {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			line = trimmed
+		}
+		switch {
+		case markdownRule.MatchString(trimmed), markdownLinkDef.MatchString(line):
+			continue
+		case markdownSetextRule.MatchString(trimmed) && len(lines) > 0 && lines[len(lines)-1] != "":
+			// The underline of a setext heading
+			continue
+		case markdownHeading.MatchString(trimmed):
+			line = markdownHeading.ReplaceAllString(trimmed, "$1")
+		case markdownBullet.MatchString(line):
+			line = markdownBullet.ReplaceAllStringFunc(line, func(bullet string) string {
+				m := markdownBullet.FindStringSubmatch(bullet)
+				switch m[2] {
+				case "":
+					return m[1] + "- "
+				case " ":
+					return m[1] + "- [ ] "
+				default:
+					return m[1] + "- [x] "
+				}
+			})
+		}
+
+		for _, inline := range markdownInline {
+			line = inline.pattern.ReplaceAllString(line, inline.replace)
+		}
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// convertMarkdown converts the descriptions and release notes of the package
+// and its locales from Markdown to plain text.
+func convertMarkdown(cfg *Config) {
+	cfg.Metadata.ReleaseNotes = markdownToText(cfg.Metadata.ReleaseNotes)
+	for i := range cfg.Locales {
+		cfg.Locales[i].Description = markdownToText(cfg.Locales[i].Description)
+		cfg.Locales[i].ReleaseNotes = markdownToText(cfg.Locales[i].ReleaseNotes)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestMarkdownToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{"plain text", "Just text.", "Just text."},
+		{"headings", "## What's Changed ##\n\nFixes", "What's Changed\n\nFixes"},
+		{"setext heading", "Features\n========\nMore", "Features\nMore"},
+		{"bullets", "* one\n+ two\n  - nested\n1. first", "- one\n- two\n  - nested\n1. first"},
+		{"task list", "- [ ] todo\n- [x] done", "- [ ] todo\n- [x] done"},
+		{"links", "See [the docs](https://example.com/docs \"Docs\") and <https://example.com>", "See the docs (https://example.com/docs) and https://example.com"},
+		{"images", "![logo](https://example.com/logo.png) MyApp", "logo MyApp"},
+		{"emphasis", "**bold**, __strong__, *em*, _em_, ~~gone~~ and `code`", "bold, strong, em, em, gone and code"},
+		{"snake case", "set my_option_name", "set my_option_name"},
+		{"escapes", `1\. not a list \# or heading`, "1. not a list # or heading"},
+		{"html", "<details><summary>More</summary>Hidden</details>", "MoreHidden"},
+		{"quotes and rules", "> quoted\n\n---\n\nafter", "quoted\n\nafter"},
+		{"code block", "```go\nfunc **main**() {}\n```", "func **main**() {}"},
+		{"blank lines", "one\n\n\n\ntwo\r\n", "one\n\ntwo"},
+		{"link definitions", "[docs]: https://example.com\ntext", "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := markdownToText(tt.markdown); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestExecuteMarkdownToText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+	metadata := cfg["metadata"].(map[string]any)
+	metadata["release_notes"] = "{{.ReleaseNotes}}"
+	metadata["markdown_to_text"] = true
+	cfg["locales"] = []any{map[string]any{"locale": "en-US", "description": "**MyApp** does [things](https://example.com)."}}
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3", ReleaseNotes: "## Fixes\n\n* Fixed a *crash*"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.locale.en-US.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Description: MyApp does things (https://example.com).", "ReleaseNotes: |-\n    Fixes\n\n    - Fixed a crash"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in the locale manifest, got:\n%s", want, content)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+// runMetrics records where an execution spends its time. It travels in the
+// context so clients created deep in a hook report into the same run.
+type runMetrics struct {
+	start          time.Time
+	githubRequests atomic.Int64
+
+	mu         sync.Mutex
+	steps      []stepMetric
+	installers []installerMetric
+}
+
+// stepMetric is the duration of one phase of an execution.
+type stepMetric struct {
+	name     string
+	duration time.Duration
+}
+
+// installerMetric describes the download of one installer.
+type installerMetric struct {
+	index    int
+	url      string
+	size     int64
+	duration time.Duration
+}
+
+type runMetricsKey struct{}
+
+// newRunMetrics starts recording an execution.
+func newRunMetrics() *runMetrics {
+	return &runMetrics{start: time.Now()}
+}
+
+// withRunMetrics returns a context carrying m.
+func withRunMetrics(ctx context.Context, m *runMetrics) context.Context {
+	return context.WithValue(ctx, runMetricsKey{}, m)
+}
+
+// runMetricsFrom returns the metrics of the execution running in ctx, or nil.
+func runMetricsFrom(ctx context.Context) *runMetrics {
+	m, _ := ctx.Value(runMetricsKey{}).(*runMetrics)
+	return m
+}
+
+// step starts timing a phase and returns a function that ends it. It is safe
+// to call on nil metrics.
+func (m *runMetrics) step(name string) func() {
+	if m == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.steps = append(m.steps, stepMetric{name: name, duration: time.Since(start)})
+	}
+}
+
+// addInstaller records an installer download.
+func (m *runMetrics) addInstaller(index int, url string, stats installerhash.Stats) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.installers = append(m.installers, installerMetric{index: index, url: url, size: stats.Size, duration: stats.Duration})
+}
+
+// summary returns the metrics as a structured output. Durations are in
+// milliseconds.
+func (m *runMetrics) summary() map[string]any {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	steps := make(map[string]any, len(m.steps))
+	for _, s := range m.steps {
+		steps[s.name] = s.duration.Milliseconds()
+	}
+	installers := make([]any, len(m.installers))
+	var downloaded int64
+	for i, inst := range m.installers {
+		installers[i] = map[string]any{
+			"index":       inst.index,
+			"url":         inst.url,
+			"bytes":       inst.size,
+			"duration_ms": inst.duration.Milliseconds(),
+		}
+		downloaded += inst.size
+	}
+
+	return map[string]any{
+		"total_ms":         time.Since(m.start).Milliseconds(),
+		"github_requests":  m.githubRequests.Load(),
+		"downloaded_bytes": downloaded,
+		"steps_ms":         steps,
+		"installers":       installers,
+	}
+}
+
+// log writes the summary as one log line.
+func (m *runMetrics) log(logger *slog.Logger) {
+	summary := m.summary()
+	args := []any{
+		"total_ms", summary["total_ms"],
+		"github_requests", summary["github_requests"],
+		"downloaded_bytes", summary["downloaded_bytes"],
+	}
+	m.mu.Lock()
+	for _, s := range m.steps {
+		args = append(args, s.name+"_ms", s.duration.Milliseconds())
+	}
+	m.mu.Unlock()
+	logger.Info("Execution summary", args...)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+func TestRunMetricsSummary(t *testing.T) {
+	m := newRunMetrics()
+	end := m.step("download")
+	end()
+	m.addInstaller(0, "https://example.com/app.msi", installerhash.Stats{Size: 1024, Duration: 1500 * time.Millisecond})
+	m.addInstaller(1, "https://example.com/app.zip", installerhash.Stats{Size: 512, Duration: time.Second})
+	m.githubRequests.Add(3)
+
+	summary := m.summary()
+	if summary["github_requests"] != int64(3) {
+		t.Errorf("expected 3 GitHub requests, got %v", summary["github_requests"])
+	}
+	if summary["downloaded_bytes"] != int64(1536) {
+		t.Errorf("expected 1536 downloaded bytes, got %v", summary["downloaded_bytes"])
+	}
+	if _, ok := summary["steps_ms"].(map[string]any)["download"]; !ok {
+		t.Errorf("expected download step, got %v", summary["steps_ms"])
+	}
+	installers := summary["installers"].([]any)
+	if len(installers) != 2 || installers[0].(map[string]any)["duration_ms"] != int64(1500) {
+		t.Errorf("unexpected installers: %v", installers)
+	}
+}
+
+func TestRunMetricsNil(t *testing.T) {
+	var m *runMetrics
+	m.step("download")()
+	m.addInstaller(0, "https://example.com/app.msi", installerhash.Stats{})
+	if runMetricsFrom(context.Background()) != nil {
+		t.Error("expected no metrics in a bare context")
+	}
+}
+
+func TestExecuteMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	metrics, ok := resp.Outputs["metrics"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected metrics output, got %v", resp.Outputs)
+	}
+	if metrics["downloaded_bytes"] != int64(len("installer")) {
+		t.Errorf("expected %d downloaded bytes, got %v", len("installer"), metrics["downloaded_bytes"])
+	}
+	steps := metrics["steps_ms"].(map[string]any)
+	for _, step := range []string{"download", "generate", "submit"} {
+		if _, ok := steps[step]; !ok {
+			t.Errorf("expected %s step, got %v", step, steps)
+		}
+	}
+}
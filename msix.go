@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const (
+	// msixPackageManifest is the manifest of an MSIX or APPX package,
+	// holding its identity.
+	msixPackageManifest = "AppxManifest.xml"
+	// msixBundleManifest is the bundle manifest inside an MSIX or APPX
+	// bundle, listing the packages it contains.
+	msixBundleManifest = "AppxMetadata/AppxBundleManifest.xml"
+	// msixSignature is the package signature, whose hash winget uses as the
+	// SignatureSha256 of MSIX installers.
+	msixSignature = "AppxSignature.p7x"
+)
+
+// msixBundleExtensions are the file extensions of MSIX and APPX bundles.
+var msixBundleExtensions = []string{".msixbundle", ".appxbundle"}
+
+// isMSIXBundle reports whether an installer is an MSIX or APPX bundle, going
+// by the file extension of its URL or asset pattern.
+func isMSIXBundle(installer InstallerConfig) bool {
+	if !isMSIXInstaller(installer) {
+		return false
+	}
+	name := installer.URL
+	if name == "" {
+		name = installer.Asset
+	}
+	name, _, _ = strings.Cut(name, "?")
+	return slices.Contains(msixBundleExtensions, strings.ToLower(path.Ext(name)))
+}
+
+// isMSIXInstaller reports whether an installer is an MSIX or APPX package or
+// bundle.
+func isMSIXInstaller(installer InstallerConfig) bool {
+	return installer.Type == "msix" || installer.Type == "appx"
+}
+
+// msixPackage describes the content of an MSIX or APPX package or bundle.
+type msixPackage struct {
+	// FamilyName is the PackageFamilyName of the package identity.
+	FamilyName string
+	// Architectures lists the architectures of the application packages
+	// of a bundle, in bundle manifest order. It is nil for packages.
+	Architectures []string
+	// SignatureSha256 is the SHA256 of the package signature.
+	SignatureSha256 string
+}
+
+// appxIdentity is the package identity of an AppxManifest.xml or
+// AppxBundleManifest.xml.
+type appxIdentity struct {
+	Name      string `xml:"Name,attr"`
+	Publisher string `xml:"Publisher,attr"`
+}
+
+// appxManifest is the part of AppxManifest.xml read by readMSIXPackage.
+type appxManifest struct {
+	Identity appxIdentity `xml:"Identity"`
+}
+
+// appxBundleManifest is the part of AppxBundleManifest.xml read by
+// readMSIXPackage.
+type appxBundleManifest struct {
+	Identity appxIdentity `xml:"Identity"`
+	Packages []struct {
+		Type         string `xml:"Type,attr"`
+		Architecture string `xml:"Architecture,attr"`
+	} `xml:"Packages>Package"`
+}
+
+// readMSIXPackage reads the identity, signature hash and, for bundles, the
+// architectures of the package or bundle file at file. Bundles of resource
+// packages only are neutral.
+func readMSIXPackage(file string) (*msixPackage, error) {
+	r, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	pkg := &msixPackage{}
+	var identity *appxIdentity
+	for _, f := range r.File {
+		switch f.Name {
+		case msixPackageManifest:
+			var m appxManifest
+			if err := readZipFile(f, func(r io.Reader) error { return xml.NewDecoder(r).Decode(&m) }); err != nil {
+				return nil, fmt.Errorf("failed to read package manifest: %w", err)
+			}
+			identity = &m.Identity
+		case msixBundleManifest:
+			var m appxBundleManifest
+			if err := readZipFile(f, func(r io.Reader) error { return xml.NewDecoder(r).Decode(&m) }); err != nil {
+				return nil, fmt.Errorf("failed to read bundle manifest: %w", err)
+			}
+			identity = &m.Identity
+			pkg.Architectures = []string{}
+			for _, p := range m.Packages {
+				arch := strings.ToLower(p.Architecture)
+				if (p.Type == "" || p.Type == "application") && arch != "" && !slices.Contains(pkg.Architectures, arch) {
+					pkg.Architectures = append(pkg.Architectures, arch)
+				}
+			}
+			if len(pkg.Architectures) == 0 {
+				pkg.Architectures = []string{"neutral"}
+			}
+		case msixSignature:
+			hash := sha256.New()
+			if err := readZipFile(f, func(r io.Reader) error { _, err := io.Copy(hash, r); return err }); err != nil {
+				return nil, fmt.Errorf("failed to read package signature: %w", err)
+			}
+			pkg.SignatureSha256 = strings.ToUpper(hex.EncodeToString(hash.Sum(nil)))
+		}
+	}
+	if identity == nil {
+		return nil, fmt.Errorf("neither %s nor %s found, the file is not an MSIX package", msixPackageManifest, msixBundleManifest)
+	}
+	if identity.Name == "" || identity.Publisher == "" {
+		return nil, errors.New("package identity has no Name or Publisher")
+	}
+	pkg.FamilyName = packageFamilyName(identity.Name, identity.Publisher)
+	return pkg, nil
+}
+
+// publisherIDAlphabet is the base32 alphabet of package publisher IDs.
+const publisherIDAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// packageFamilyName returns the PackageFamilyName of a package identity: its
+// name and the publisher ID, which encodes the first 64 bits of the SHA256 of
+// the UTF-16LE publisher in 13 base32 characters.
+func packageFamilyName(name, publisher string) string {
+	hash := sha256.New()
+	for _, u := range utf16.Encode([]rune(publisher)) {
+		_, _ = hash.Write([]byte{byte(u), byte(u >> 8)})
+	}
+	bits := binary.BigEndian.Uint64(hash.Sum(nil)[:8])
+
+	// 64 bits padded with a zero bit make 13 groups of 5 bits
+	id := make([]byte, 13)
+	for i := range id {
+		shift := 59 - 5*i
+		var group uint64
+		if shift >= 0 {
+			group = bits >> shift
+		} else {
+			group = bits << -shift
+		}
+		id[i] = publisherIDAlphabet[group&0x1f]
+	}
+	return name + "_" + string(id)
+}
+
+// readZipFile passes the content of a zip entry to read.
+func readZipFile(f *zip.File, read func(io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rc.Close() }()
+	return read(rc)
+}
+
+// msixInstallers completes an installer with the family name and signature
+// hash of its package. Bundles are described once per architecture they
+// contain; if the installer has an architecture, only that architecture is
+// kept, and it must be in the bundle.
+func msixInstallers(installer manifest.Installer, pkg *msixPackage) ([]manifest.Installer, error) {
+	installer.PackageFamilyName = pkg.FamilyName
+	installer.SignatureSha256 = pkg.SignatureSha256
+	if pkg.Architectures == nil {
+		return []manifest.Installer{installer}, nil
+	}
+
+	architectures := pkg.Architectures
+	if installer.Architecture != "" {
+		if !slices.Contains(architectures, installer.Architecture) {
+			return nil, fmt.Errorf("bundle has no %s package, found: %s", installer.Architecture, strings.Join(architectures, ", "))
+		}
+		architectures = []string{installer.Architecture}
+	}
+
+	installers := make([]manifest.Installer, 0, len(architectures))
+	for _, arch := range architectures {
+		entry := installer
+		entry.Architecture = arch
+		installers = append(installers, entry)
+	}
+	return installers, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const testBundleManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<Bundle xmlns="http://schemas.microsoft.com/appx/2013/bundle" SchemaVersion="5.0">
+  <Identity Name="MyOrg.MyApp" Publisher="CN=MyOrg" Version="1.2.3.0"/>
+  <Packages>
+    <Package Type="application" Version="1.2.3.0" Architecture="x64" FileName="MyApp_x64.msix"/>
+    <Package Type="application" Version="1.2.3.0" Architecture="ARM64" FileName="MyApp_arm64.msix"/>
+    <Package Type="resource" Version="1.2.3.0" ResourceId="split.scale-200" FileName="MyApp_scale-200.msix"/>
+  </Packages>
+</Bundle>`
+
+// testPublisherID is the publisher ID of CN=MyOrg.
+var testPublisherID = strings.TrimPrefix(packageFamilyName("", "CN=MyOrg"), "_")
+
+// testBundle returns a bundle with the given entries.
+func testBundle(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testSignatureSha256(signature string) string {
+	sum := sha256.Sum256([]byte(signature))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestIsMSIXBundle(t *testing.T) {
+	tests := []struct {
+		installer InstallerConfig
+		want      bool
+	}{
+		{InstallerConfig{URL: "https://example.com/MyApp.msixbundle", Type: "msix"}, true},
+		{InstallerConfig{URL: "https://example.com/MyApp.AppxBundle?raw=1", Type: "appx"}, true},
+		{InstallerConfig{Asset: "MyApp-*.msixbundle", Type: "msix"}, true},
+		{InstallerConfig{URL: "https://example.com/MyApp.msix", Type: "msix"}, false},
+		{InstallerConfig{URL: "https://example.com/MyApp.msixbundle", Type: "exe"}, false},
+	}
+	for _, tt := range tests {
+		if got := isMSIXBundle(tt.installer); got != tt.want {
+			t.Errorf("isMSIXBundle(%+v) = %v, want %v", tt.installer, got, tt.want)
+		}
+	}
+}
+
+func TestReadMSIXPackage(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    *msixPackage
+		wantErr string
+	}{
+		{
+			name: "application packages",
+			entries: map[string]string{
+				msixBundleManifest: testBundleManifest,
+				msixSignature:      "signature",
+			},
+			want: &msixPackage{FamilyName: "MyOrg.MyApp_" + testPublisherID, Architectures: []string{"x64", "arm64"}, SignatureSha256: testSignatureSha256("signature")},
+		},
+		{
+			name: "resource packages only",
+			entries: map[string]string{
+				msixBundleManifest: `<Bundle><Identity Name="MyOrg.MyApp" Publisher="CN=MyOrg"/><Packages><Package Type="resource" FileName="a.msix"/></Packages></Bundle>`,
+			},
+			want: &msixPackage{FamilyName: "MyOrg.MyApp_" + testPublisherID, Architectures: []string{"neutral"}},
+		},
+		{
+			name: "package",
+			entries: map[string]string{
+				msixPackageManifest: `<Package><Identity Name="MyOrg.MyApp" Publisher="CN=MyOrg" ProcessorArchitecture="x64"/></Package>`,
+				msixSignature:       "signature",
+			},
+			want: &msixPackage{FamilyName: "MyOrg.MyApp_" + testPublisherID, SignatureSha256: testSignatureSha256("signature")},
+		},
+		{
+			name:    "no identity",
+			entries: map[string]string{msixPackageManifest: "<Package/>"},
+			wantErr: "no Name or Publisher",
+		},
+		{
+			name:    "not a package",
+			entries: map[string]string{"setup.exe": "installer"},
+			wantErr: "not an MSIX package",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "MyApp.msixbundle")
+			if err := os.WriteFile(file, testBundle(t, tt.entries), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readMSIXPackage(file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageFamilyName(t *testing.T) {
+	publisher := "CN=Microsoft Corporation, O=Microsoft Corporation, L=Redmond, S=Washington, C=US"
+	if got := packageFamilyName("Microsoft.WindowsTerminal", publisher); got != "Microsoft.WindowsTerminal_8wekyb3d8bbwe" {
+		t.Errorf("packageFamilyName() = %q", got)
+	}
+}
+
+func TestMSIXInstallers(t *testing.T) {
+	installers, err := msixInstallers(manifest.Installer{Architecture: "x64"}, &msixPackage{FamilyName: "MyApp_123", SignatureSha256: "SIG"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(installers) != 1 || installers[0].Architecture != "x64" || installers[0].PackageFamilyName != "MyApp_123" || installers[0].SignatureSha256 != "SIG" {
+		t.Errorf("unexpected installers: %+v", installers)
+	}
+
+	bundle := &msixPackage{FamilyName: "MyApp_123", Architectures: []string{"x64", "arm64"}, SignatureSha256: "SIG"}
+	installers, err = msixInstallers(manifest.Installer{InstallerType: "msix"}, bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(installers) != 2 || installers[0].Architecture != "x64" || installers[1].Architecture != "arm64" || installers[1].SignatureSha256 != "SIG" {
+		t.Errorf("unexpected installers: %+v", installers)
+	}
+
+	installers, err = msixInstallers(manifest.Installer{Architecture: "arm64"}, bundle)
+	if err != nil || len(installers) != 1 || installers[0].Architecture != "arm64" {
+		t.Errorf("expected the arm64 installer only, got %+v, %v", installers, err)
+	}
+
+	if _, err := msixInstallers(manifest.Installer{Architecture: "x86"}, bundle); err == nil {
+		t.Error("expected an error for an architecture missing from the bundle")
+	}
+}
+
+func TestValidateBundleArchitecture(t *testing.T) {
+	cfg := validTestConfig()
+	installer := cfg["installers"].([]any)[0].(map[string]any)
+	installer["url"] = "https://example.com/MyApp.msixbundle"
+	installer["type"] = "msix"
+	delete(installer, "architecture")
+
+	resp, err := (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasValidationError(resp, "installers[0].architecture") {
+		t.Errorf("expected no architecture error for a bundle, got: %+v", resp.Errors)
+	}
+
+	installer["url"] = "https://example.com/MyApp.msix"
+	resp, err = (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasValidationError(resp, "installers[0].architecture") {
+		t.Error("expected an architecture error for a plain msix installer")
+	}
+}
+
+func TestExecuteMSIXBundle(t *testing.T) {
+	bundle := testBundle(t, map[string]string{
+		msixBundleManifest: testBundleManifest,
+		msixSignature:      "signature",
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bundle)
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	installer := cfg["installers"].([]any)[0].(map[string]any)
+	installer["url"] = server.URL + "/MyApp.msixbundle"
+	installer["type"] = "msix"
+	delete(installer, "architecture")
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if _, ok := resp.Outputs["installer_files"]; ok {
+		t.Error("expected no installer_files output without download.cache_dir")
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Architecture: x64", "Architecture: arm64", "SignatureSha256: " + testSignatureSha256("signature"), "PackageFamilyName: MyOrg.MyApp_" + testPublisherID} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected %q in the installer manifest, got:\n%s", want, content)
+		}
+	}
+}
@@ -1,69 +1,367 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/helpers"
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/plugin-winget/manifest"
 )
 
 // Version is set at build time.
 var Version = "0.1.0"
 
+// githubAPIBase is the GitHub API base URL used by the plugin's clients.
+var githubAPIBase = githubclient.DefaultBaseURL
+
+const (
+	// backendGitHub submits manifests as a pull request to winget-pkgs.
+	backendGitHub = "github"
+	// backendREST publishes manifests to a private winget REST source.
+	backendREST = "rest"
+	// backendWingetcreate submits manifests with Microsoft's wingetcreate CLI.
+	backendWingetcreate = "wingetcreate"
+	// backendKomac submits manifests with the komac CLI when it is installed.
+	backendKomac = "komac"
+	// backendAzureDevOps opens a pull request against a manifest repository
+	// hosted in Azure DevOps.
+	backendAzureDevOps = "azure_devops"
+	// backendGitLab opens a merge request against a manifest repository
+	// hosted in GitLab.
+	backendGitLab = "gitlab"
+)
+
+const (
+	// dryRunHashPlaceholder uses an all-zero hash for installers in dry-run.
+	dryRunHashPlaceholder = "placeholder"
+	// dryRunHashReal downloads and hashes installers in dry-run.
+	dryRunHashReal = "real"
+)
+
 // Config represents WinGet plugin configuration.
 type Config struct {
-	PackageID   string            `json:"package_id"`
-	GitHubToken string            `json:"github_token"`
-	Installers  []InstallerConfig `json:"installers"`
-	Metadata    MetadataConfig    `json:"metadata"`
-	Locales     []LocaleConfig    `json:"locales"`
-	PullRequest PRConfig          `json:"pull_request"`
-	Validate    bool              `json:"validate"`
-	TestInstall bool              `json:"test_install"`
-	DryRun      bool              `json:"dry_run"`
+	PackageID    string             `json:"package_id"`
+	Backend      string             `json:"backend"`
+	GitHubToken  string             `json:"github_token"`
+	Installers   []InstallerConfig  `json:"installers"`
+	Metadata     MetadataConfig     `json:"metadata"`
+	Locales      []LocaleConfig     `json:"locales"`
+	PullRequest  PRConfig           `json:"pull_request"`
+	RESTSource   RESTSourceConfig   `json:"rest_source"`
+	AzureDevOps  AzureDevOpsConfig  `json:"azure_devops"`
+	GitLab       GitLabConfig       `json:"gitlab"`
+	Wingetcreate WingetcreateConfig `json:"wingetcreate"`
+	Timeouts     TimeoutConfig      `json:"timeouts"`
+	GitHubRetry  GitHubRetryConfig  `json:"github_retry"`
+	Download     DownloadConfig     `json:"download"`
+	Attestation  AttestationConfig  `json:"attestation"`
+	Scan         ScanConfig         `json:"scan"`
+	Track        TrackConfig        `json:"track"`
+	Issue        IssueConfig        `json:"issue"`
+	Backfill     BackfillConfig     `json:"backfill"`
+	Throttle     ThrottleConfig     `json:"throttle"`
+	State        StateConfig        `json:"state"`
+	URLCheck     URLCheckConfig     `json:"url_check"`
+	TokenCheck   TokenCheckConfig   `json:"token_check"`
+	Checksums    ChecksumsConfig    `json:"checksums"`
+	// UserAgent is the User-Agent of installer downloads and GitHub API
+	// requests, a template of the plugin version and run ID.
+	UserAgent string `json:"user_agent"`
+	// RunID identifies the pipeline run, defaulting to the run ID of
+	// GitHub Actions, GitLab CI or Azure Pipelines.
+	RunID              string `json:"run_id"`
+	Validate           bool   `json:"validate"`
+	ValidateWithWinget bool   `json:"validate_with_winget"`
+	TestInstall        bool   `json:"test_install"`
+	TestInstallSandbox bool   `json:"test_install_sandbox"`
+	DryRun             bool   `json:"dry_run"`
+	NormalizeVersion   bool   `json:"normalize_version"`
+	PackageVersion     string `json:"package_version"`
+	AllowOlderVersion  bool   `json:"allow_older_version"`
+	AllowInsecureURLs  bool   `json:"allow_insecure_urls"`
+	OutputDir          string `json:"output_dir"`
+	Diff               bool   `json:"diff"`
+	DryRunHash         string `json:"dry_run_hash"`
+	MinInstallers      int    `json:"min_installers"`
+	Strict             bool   `json:"strict"`
+	// Profile pre-populates the installer settings of a common packaging
+	// scenario from configProfiles.
+	Profile           string                  `json:"profile"`
+	ConfigFile        string                  `json:"config_file"`
+	VersionTransforms VersionTransformsConfig `json:"version_transforms"`
+	Env               EnvConfig               `json:"env"`
+	// DefaultSwitches holds installer switches per installer type, which
+	// the switches of individual installers override key by key.
+	DefaultSwitches map[string]map[string]string `json:"default_switches"`
+	// PatchAssets are glob patterns of patch and delta installers, such as
+	// MSP files, which are never published: asset patterns do not match
+	// them and installer URLs may not point to them.
+	PatchAssets []string `json:"patch_assets"`
+	// ArchitectureAliases maps architecture tokens of asset file names,
+	// such as amd64, to winget architectures. They render {{.Arch}} and
+	// name the architecture of asset installers that set none.
+	ArchitectureAliases map[string]string `json:"architecture_aliases"`
+	// ScopeRules set the scope of asset installers that set none from
+	// the asset file name.
+	ScopeRules []ScopeRule `json:"scope_rules"`
 }
 
 // InstallerConfig defines installer settings.
 type InstallerConfig struct {
-	URL          string            `json:"url"`
-	Architecture string            `json:"architecture"`
-	Type         string            `json:"type"`
-	Switches     map[string]string `json:"switches"`
-	Scope        string            `json:"scope"`
-	ProductCode  string            `json:"product_code"`
+	URL   string `json:"url"`
+	Asset string `json:"asset"`
+	// Architecture is optional for MSIX and APPX bundles, which are
+	// described once per architecture they contain, or only for this
+	// architecture if set.
+	Architecture string `json:"architecture"`
+	// Architectures expands the installer into one installer per
+	// architecture, with {{.Arch}} rendered as each architecture.
+	Architectures []string          `json:"architectures"`
+	ArchAliases   map[string]string `json:"arch_aliases"`
+	Type          string            `json:"type"`
+	Switches      map[string]string `json:"switches"`
+	Scope         string            `json:"scope"`
+	// Scopes expands the installer into one installer per scope, with
+	// ScopeOverrides replacing settings of individual scopes.
+	Scopes         []string                 `json:"scopes"`
+	ScopeOverrides map[string]ScopeOverride `json:"scope_overrides"`
+	ProductCode    string                   `json:"product_code"`
+	UpgradeCode    string                   `json:"upgrade_code"`
+	Optional       bool                     `json:"optional"`
+	// DownloadCommandProhibited keeps `winget download` from downloading
+	// the installer, such as for licensed installers.
+	DownloadCommandProhibited bool `json:"download_command_prohibited"`
+	// RepairBehavior is how `winget repair` repairs the package: modify,
+	// uninstaller or installer (which runs the Repair switch).
+	RepairBehavior string `json:"repair_behavior"`
+	// UnsupportedArguments lists the winget arguments the installer does
+	// not honor (log, location), which winget warns about.
+	UnsupportedArguments []string `json:"unsupported_arguments"`
+	// AllowedHosts are the hosts the installer may be downloaded from after
+	// redirects, such as objects.githubusercontent.com or *.example.com.
+	AllowedHosts []string `json:"allowed_hosts"`
+
+	// sha256 is the digest GitHub published for the release asset the
+	// installer was resolved to, if any.
+	sha256 string
+	// inspected is set once the installer was read after downloading, and
+	// correlated if its Apps & Features entry could be derived from it.
+	inspected  bool
+	correlated bool
 }
 
 // MetadataConfig defines package metadata.
 type MetadataConfig struct {
-	Publisher           string   `json:"publisher"`
-	PublisherURL        string   `json:"publisher_url"`
-	PublisherSupportURL string   `json:"publisher_support_url"`
-	Name                string   `json:"name"`
-	ShortDescription    string   `json:"short_description"`
-	License             string   `json:"license"`
-	LicenseURL          string   `json:"license_url"`
-	Copyright           string   `json:"copyright"`
-	PackageURL          string   `json:"package_url"`
-	Tags                []string `json:"tags"`
-	Moniker             string   `json:"moniker"`
-	ReleaseNotesURL     string   `json:"release_notes_url"`
-}
-
-// LocaleConfig defines locale-specific metadata.
+	Publisher           string `json:"publisher"`
+	PublisherURL        string `json:"publisher_url"`
+	PublisherSupportURL string `json:"publisher_support_url"`
+	Name                string `json:"name"`
+	ShortDescription    string `json:"short_description"`
+	// ShortDescriptionFile reads the short description from a file of the
+	// released repository instead.
+	ShortDescriptionFile string   `json:"short_description_file"`
+	License              string   `json:"license"`
+	LicenseURL           string   `json:"license_url"`
+	Copyright            string   `json:"copyright"`
+	PackageURL           string   `json:"package_url"`
+	Tags                 []string `json:"tags"`
+	NormalizeTags        bool     `json:"normalize_tags"`
+	// InheritTags adds the tags of these sources to Tags: "published" and
+	// "topics".
+	InheritTags []string `json:"inherit_tags"`
+	Moniker     string   `json:"moniker"`
+	// ReleaseNotes is rendered as a template, such as "{{.ReleaseNotes}}".
+	ReleaseNotes    string `json:"release_notes"`
+	ReleaseNotesURL string `json:"release_notes_url"`
+	// InstallationNotes are shown after a successful install, rendered as a
+	// template.
+	InstallationNotes string `json:"installation_notes"`
+	// MarkdownToText converts Markdown in descriptions and release notes
+	// to plain text.
+	MarkdownToText bool `json:"markdown_to_text"`
+}
+
+// LocaleConfig defines locale-specific metadata. The en-US locale overrides
+// the metadata of the default locale manifest; other locales get their own
+// locale manifest.
 type LocaleConfig struct {
-	Locale      string `json:"locale"`
-	Description string `json:"description"`
+	Locale           string `json:"locale"`
+	Publisher        string `json:"publisher"`
+	Name             string `json:"name"`
+	ShortDescription string `json:"short_description"`
+	Description      string `json:"description"`
+	// DescriptionFile reads the description from a file of the released
+	// repository instead.
+	DescriptionFile   string            `json:"description_file"`
+	Tags              []string          `json:"tags"`
+	Agreements        []AgreementConfig `json:"agreements"`
+	ReleaseNotes      string            `json:"release_notes"`
+	ReleaseNotesURL   string            `json:"release_notes_url"`
+	InstallationNotes string            `json:"installation_notes"`
+}
+
+// AgreementConfig defines an agreement shown before install. Either text or
+// url is required.
+type AgreementConfig struct {
+	Label string `json:"label"`
+	Text  string `json:"text"`
+	URL   string `json:"url"`
 }
 
 // PRConfig defines pull request settings.
 type PRConfig struct {
-	ForkOwner    string `json:"fork_owner"`
-	BaseBranch   string `json:"base_branch"`
-	Title        string `json:"title"`
-	DeleteBranch bool   `json:"delete_branch"`
+	Enabled    bool   `json:"enabled"`
+	ForkOwner  string `json:"fork_owner"`
+	BaseBranch string `json:"base_branch"`
+	// Title defaults to the title of TitlePreset.
+	Title string `json:"title"`
+	// TitlePreset selects one of the conventional winget-pkgs titles in
+	// prTitlePresets.
+	TitlePreset string `json:"title_preset"`
+	Body        string `json:"body"`
+	Branch      string `json:"branch"`
+	// OnExistingBranch is what happens when the branch already exists with
+	// different manifests: fail ("reuse") or push to the next free attempt
+	// branch ("new").
+	OnExistingBranch string `json:"on_existing_branch"`
+	// CommitStrategy is how manifests are committed to the fork branch:
+	// one Contents API request per file ("api") or a single commit pushed
+	// from a local clone ("git"), optionally signed with SigningKey.
+	CommitStrategy string `json:"commit_strategy"`
+	SigningKey     string `json:"signing_key"`
+	DeleteBranch   bool   `json:"delete_branch"`
+	// CleanupBranches deletes the fork branches under the literal prefix
+	// of the Branch template whose pull requests were merged or closed.
+	CleanupBranches bool `json:"cleanup_branches"`
+	// branchPrefix is that prefix, taken before Branch is rendered.
+	branchPrefix    string
+	RollbackOnError bool `json:"rollback_on_error"`
+	// Labels, Reviewers and Assignees are added to the pull request where
+	// winget-pkgs permits it; failures are only logged.
+	Labels    []string `json:"labels"`
+	Reviewers []string `json:"reviewers"`
+	Assignees []string `json:"assignees"`
+	// TrackingIssue is an issue such as "myorg/myapp#123" referenced from
+	// the pull request body, and closed by its merge if CloseTrackingIssue
+	// is set.
+	TrackingIssue      string `json:"tracking_issue"`
+	CloseTrackingIssue bool   `json:"close_tracking_issue"`
+	// IdentityCache is a file remembering the token's GitHub user and fork
+	// across runs.
+	IdentityCache string `json:"identity_cache"`
+}
+
+// PR title presets, named after the winget-pkgs title conventions.
+const (
+	prTitleNewVersion = "new-version"
+	prTitleNewPackage = "new-package"
+	prTitleUpdate     = "update"
+)
+
+// prTitlePresets are the pull_request.title templates of each title_preset.
+var prTitlePresets = map[string]string{
+	prTitleNewVersion: "New version: {{.PackageId}} version {{.PackageVersion}}",
+	prTitleNewPackage: "New package: {{.PackageId}} version {{.PackageVersion}}",
+	prTitleUpdate:     "Update {{.PackageId}} to {{.PackageVersion}}",
+}
+
+// pull_request.on_existing_branch policies.
+const (
+	// branchPolicyReuse resumes a branch with identical manifests and fails
+	// on one with different manifests.
+	branchPolicyReuse = "reuse"
+	// branchPolicyNew moves on to the -2, -3, ... suffixed branches instead
+	// of failing, up to maxBranchAttempts branches.
+	branchPolicyNew = "new"
+)
+
+// maxBranchAttempts is the number of branches tried with the "new" policy.
+const maxBranchAttempts = 10
+
+// RESTSourceConfig defines how to publish to a winget REST source.
+type RESTSourceConfig struct {
+	URL     string        `json:"url"`
+	APIKey  string        `json:"api_key"`
+	AzureAD AzureADConfig `json:"azure_ad"`
+}
+
+// WingetcreateConfig defines how the wingetcreate backend drives the CLI.
+type WingetcreateConfig struct {
+	Mode string `json:"mode"`
+}
+
+// AzureADConfig defines the Azure AD application used to authenticate to a
+// REST source with the client credentials flow.
+type AzureADConfig struct {
+	TenantID     string `json:"tenant_id"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Scope        string `json:"scope"`
+}
+
+// TimeoutConfig defines per-phase time limits. A zero value disables the limit.
+type TimeoutConfig struct {
+	Download time.Duration `json:"download"`
+	// GitHub is the deadline of each GitHub operation, such as submitting a
+	// pull request, including retries.
+	GitHub time.Duration `json:"github"`
+	// GitHubRequest is the time limit of a single GitHub API request.
+	GitHubRequest time.Duration `json:"github_request"`
+	// CLI is the deadline of a submission through a CLI backend, which
+	// downloads and hashes the installers itself before submitting.
+	CLI     time.Duration `json:"cli"`
+	Sandbox time.Duration `json:"sandbox"`
+	Execute time.Duration `json:"execute"`
+}
+
+// DownloadConfig defines how installers are downloaded.
+type DownloadConfig struct {
+	// BufferSize is the size in bytes of the buffer installers are hashed
+	// through.
+	BufferSize int `json:"buffer_size"`
+	// CacheDir keeps the downloaded installers, written while they are
+	// hashed, for steps that need the installer files.
+	CacheDir string `json:"cache_dir"`
+	// MinSize is the size in bytes below which a download is taken for an
+	// error page rather than an installer; 0 disables the check.
+	MinSize int64 `json:"min_size"`
+	// RejectContentTypes are the media types of error pages, which fail
+	// the download instead of being hashed as installers.
+	RejectContentTypes []string `json:"reject_content_types"`
+}
+
+// GitHubRetryConfig defines how failed GitHub API requests are retried.
+type GitHubRetryConfig struct {
+	MaxRetries int           `json:"max_retries"`
+	Backoff    time.Duration `json:"backoff"`
+	// RateLimitRetries is the number of rate limit responses, such as
+	// secondary rate limits, waited out per request. Waits longer than
+	// RateLimitMaxWait fail the request instead.
+	RateLimitRetries int           `json:"rate_limit_retries"`
+	RateLimitMaxWait time.Duration `json:"rate_limit_max_wait"`
+}
+
+// URLCheckConfig defines installer URL reachability checks during Validate.
+type URLCheckConfig struct {
+	Enabled bool   `json:"enabled"`
+	Version string `json:"version"`
 }
 
 // WinGetPlugin implements the WinGet package manager plugin.
@@ -77,23 +375,131 @@ func (p *WinGetPlugin) GetInfo() plugin.Info {
 		Description: "Windows Package Manager (winget) manifest generation and PR submission",
 		Hooks: []plugin.Hook{
 			plugin.HookPostPublish,
+			plugin.HookOnSuccess,
+			plugin.HookOnError,
 		},
+		ConfigSchema: configSchema(),
 	}
 }
 
 // Validate validates plugin configuration.
 func (p *WinGetPlugin) Validate(ctx context.Context, config map[string]any) (*plugin.ValidateResponse, error) {
-	cfg := p.parseConfig(config)
+	cfg, problems := decodePluginConfig(config)
+	resp := p.validate(ctx, cfg, problems)
+	newRedactor(cfg).validateResponse(resp)
+	return resp, nil
+}
+
+// validate validates decoded plugin configuration.
+func (p *WinGetPlugin) validate(ctx context.Context, cfg *Config, problems []fieldError) *plugin.ValidateResponse {
 	vb := helpers.NewValidationBuilder()
 
+	// Report unknown keys and wrong types first, as they explain later errors
+	for _, problem := range problems {
+		vb.AddError(problem.Field, problem.Message)
+	}
+
 	// Validate package ID
-	if !isValidPackageID(cfg.PackageID) {
-		vb.AddError("package_id", "Package ID must be in format Publisher.PackageName")
+	if err := validatePackageID(cfg.PackageID); err != nil {
+		vb.AddError("package_id", err.Error())
 	}
 
-	// Check GitHub token
-	if cfg.GitHubToken == "" {
-		vb.AddError("github_token", "GitHub token is required")
+	for _, problem := range validateConfigTemplates(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateVersionTransforms(cfg.VersionTransforms) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateGitHubClientConfig(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateDownloadConfig(cfg.Download) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateAttestation(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateScan(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateTrack(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateIssue(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateBackfill(cfg.Backfill) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateThrottle(cfg.Throttle) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validatePullRequest(cfg.PullRequest) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateState(cfg.State) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+
+	if cfg.DryRunHash != dryRunHashPlaceholder && cfg.DryRunHash != dryRunHashReal {
+		vb.AddError("dry_run_hash", fmt.Sprintf("dry_run_hash must be %q or %q", dryRunHashPlaceholder, dryRunHashReal))
+	}
+
+	if cfg.PullRequest.CleanupBranches && cfg.PullRequest.branchPrefix == "" {
+		vb.AddError("pull_request.cleanup_branches", `cleanup_branches requires pull_request.branch to start with a literal prefix ending in "/", such as "winget/"`)
+	}
+	if cfg.PullRequest.RollbackOnError && !cfg.State.enabled() {
+		vb.AddError("pull_request.rollback_on_error", "rollback_on_error requires state.path or state.gist_id to record the pull request a run submitted")
+	}
+	if cfg.PullRequest.OnExistingBranch != branchPolicyReuse && cfg.PullRequest.OnExistingBranch != branchPolicyNew {
+		vb.AddError("pull_request.on_existing_branch", fmt.Sprintf("on_existing_branch must be %q or %q", branchPolicyReuse, branchPolicyNew))
+	}
+	switch cfg.PullRequest.CommitStrategy {
+	case commitStrategyAPI:
+		if cfg.PullRequest.SigningKey != "" {
+			vb.AddError("pull_request.signing_key", fmt.Sprintf("signing_key requires commit_strategy %q", commitStrategyGit))
+		}
+	case commitStrategyGit:
+		if _, err := exec.LookPath(gitCommand); err != nil {
+			vb.AddError("pull_request.commit_strategy", fmt.Sprintf("commit_strategy %q requires git in PATH", commitStrategyGit))
+		}
+	default:
+		vb.AddError("pull_request.commit_strategy", fmt.Sprintf("commit_strategy must be %q or %q", commitStrategyAPI, commitStrategyGit))
+	}
+	if _, ok := prTitlePresets[cfg.PullRequest.TitlePreset]; !ok {
+		vb.AddError("pull_request.title_preset", fmt.Sprintf("title_preset must be one of: %s, %s, %s", prTitleNewVersion, prTitleNewPackage, prTitleUpdate))
+	}
+
+	switch cfg.Backend {
+	case backendGitHub:
+		// Check GitHub token
+		if cfg.PullRequest.Enabled && cfg.GitHubToken == "" {
+			vb.AddError("github_token", "GitHub token is required")
+		}
+		if !cfg.PullRequest.Enabled && cfg.OutputDir == "" {
+			vb.AddError("output_dir", "output_dir is required when pull_request.enabled is false")
+		}
+	case backendREST:
+		for _, problem := range validateRESTSource(cfg.RESTSource, cfg.AllowInsecureURLs) {
+			vb.AddError(problem.Field, problem.Message)
+		}
+	case backendAzureDevOps:
+		for _, problem := range validateAzureDevOps(cfg.AzureDevOps, cfg.AllowInsecureURLs) {
+			vb.AddError(problem.Field, problem.Message)
+		}
+	case backendGitLab:
+		for _, problem := range validateGitLab(cfg.GitLab, cfg.AllowInsecureURLs) {
+			vb.AddError(problem.Field, problem.Message)
+		}
+	case backendWingetcreate, backendKomac:
+		if cfg.GitHubToken == "" {
+			vb.AddError("github_token", "GitHub token is required")
+		}
+		if cfg.Backend == backendWingetcreate && cfg.Wingetcreate.Mode != wingetcreateModeSubmit && cfg.Wingetcreate.Mode != wingetcreateModeUpdate {
+			vb.AddError("wingetcreate.mode", fmt.Sprintf("mode must be %q or %q", wingetcreateModeSubmit, wingetcreateModeUpdate))
+		}
+	default:
+		vb.AddError("backend", fmt.Sprintf("backend must be one of: %s, %s, %s, %s, %s, %s", backendGitHub, backendREST, backendWingetcreate, backendKomac, backendAzureDevOps, backendGitLab))
 	}
 
 	// Validate installers
@@ -102,15 +508,84 @@ func (p *WinGetPlugin) Validate(ctx context.Context, config map[string]any) (*pl
 	}
 
 	for i, installer := range cfg.Installers {
-		if installer.URL == "" {
-			vb.AddError(fmt.Sprintf("installers[%d].url", i), "Installer URL is required")
+		switch {
+		case installer.URL != "" && installer.Asset != "":
+			vb.AddError(fmt.Sprintf("installers[%d].asset", i), "Only one of url and asset may be set")
+		case installer.Asset != "":
+			if err := validateAssetPattern(installer.Asset, sampleTemplateData(cfg)); err != nil {
+				vb.AddError(fmt.Sprintf("installers[%d].asset", i), err.Error())
+			}
+		case installer.URL == "":
+			vb.AddError(fmt.Sprintf("installers[%d].url", i), "Installer URL or asset pattern is required")
+		default:
+			if err := validateInstallerURL(installer.URL, cfg.AllowInsecureURLs); err != nil {
+				vb.AddError(fmt.Sprintf("installers[%d].url", i), err.Error())
+			}
+		}
+		// Bundles take their architectures from the bundle manifest
+		architecture := installer.Architecture
+		if architecture == "" && isMSIXBundle(installer) {
+			architecture = "neutral"
+		}
+		for _, problem := range manifest.CheckInstallerEnums(manifest.SchemaVersion, architecture, installer.Type, installer.Scope) {
+			// The architecture of asset installers is read from the asset name
+			if problem.Field == "architecture" && architecture == "" && installer.Asset != "" {
+				continue
+			}
+			vb.AddError(fmt.Sprintf("installers[%d].%s", i, problem.Field), problem.Message)
+		}
+		for _, problem := range manifest.CheckRepairBehavior(manifest.SchemaVersion, installer.RepairBehavior) {
+			vb.AddError(fmt.Sprintf("installers[%d].%s", i, problem.Field), problem.Message)
+		}
+		for _, problem := range manifest.CheckUnsupportedArguments(manifest.SchemaVersion, installer.UnsupportedArguments) {
+			vb.AddError(fmt.Sprintf("installers[%d].%s", i, problem.Field), problem.Message)
+		}
+		for _, problem := range validateInstallerSwitches(installer.Switches) {
+			vb.AddError(fmt.Sprintf("installers[%d].switches", i), problem)
 		}
-		if !isValidArchitecture(installer.Architecture) {
-			vb.AddError(fmt.Sprintf("installers[%d].architecture", i),
-				"Architecture must be x86, x64, arm, or arm64")
+		for _, problem := range validateInstallerGUIDs(installer) {
+			vb.AddError(fmt.Sprintf("installers[%d].%s", i, problem.Field), problem.Message)
 		}
 	}
 
+	if cfg.MinInstallers < 1 {
+		vb.AddError("min_installers", "min_installers must be at least 1")
+	} else if len(cfg.Installers) > 0 && cfg.MinInstallers > len(cfg.Installers) {
+		vb.AddError("min_installers", fmt.Sprintf("min_installers is %d but only %d installers are configured", cfg.MinInstallers, len(cfg.Installers)))
+	}
+
+	enums, _ := manifest.EnumsFor(manifest.SchemaVersion)
+	for _, installerType := range slices.Sorted(maps.Keys(cfg.DefaultSwitches)) {
+		field := "default_switches." + installerType
+		if !slices.Contains(enums.InstallerTypes, installerType) {
+			vb.AddError(field, fmt.Sprintf("unknown installer type %q, expected one of: %s", installerType, strings.Join(enums.InstallerTypes, ", ")))
+			continue
+		}
+		for _, problem := range validateInstallerSwitches(cfg.DefaultSwitches[installerType]) {
+			vb.AddError(field, problem)
+		}
+	}
+
+	for _, problem := range validateArchitectureAliases(cfg.ArchitectureAliases) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateScopeRules(cfg.ScopeRules) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validatePatchAssets(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateProfile(cfg.Profile) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateAllowedHosts(cfg.Installers) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+
+	for _, problem := range validateInstallerCombinations(cfg.Installers) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+
 	// Validate metadata
 	if cfg.Metadata.Publisher == "" {
 		vb.AddError("metadata.publisher", "Publisher is required")
@@ -118,27 +593,142 @@ func (p *WinGetPlugin) Validate(ctx context.Context, config map[string]any) (*pl
 	if cfg.Metadata.Name == "" {
 		vb.AddError("metadata.name", "Package name is required")
 	}
-	if cfg.Metadata.ShortDescription == "" {
+	if cfg.Metadata.ShortDescription == "" && cfg.Metadata.ShortDescriptionFile == "" {
 		vb.AddError("metadata.short_description", "Short description is required")
-	} else if len(cfg.Metadata.ShortDescription) > 256 {
-		vb.AddError("metadata.short_description", "Short description must be <= 256 characters")
+	}
+	for _, problem := range validateDescriptionFiles(cfg) {
+		vb.AddError(problem.Field, problem.Message)
 	}
 	if cfg.Metadata.License == "" {
 		vb.AddError("metadata.license", "License is required")
 	}
+	tags := cfg.Metadata.Tags
+	if cfg.Metadata.NormalizeTags {
+		tags, _ = normalizeTags(tags)
+	}
+	for _, problem := range validateTags(tags) {
+		vb.AddError("metadata.tags", problem)
+	}
+	for _, problem := range validateLengths(cfg) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateLocales(cfg.Locales, cfg.Metadata.NormalizeTags) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	for _, problem := range validateInheritTags(cfg.Metadata.InheritTags) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+
+	// Probe installer URLs
+	if cfg.URLCheck.Enabled {
+		p.checkInstallerURLs(ctx, cfg, vb)
+	}
+
+	// Check the GitHub token against the API
+	for _, problem := range validateTokenCheck(cfg.TokenCheck) {
+		vb.AddError(problem.Field, problem.Message)
+	}
+	if cfg.TokenCheck.Enabled && cfg.GitHubToken != "" && submitsToWingetPkgs(cfg) {
+		for _, problem := range checkGitHubToken(ctx, cfg, time.Now()) {
+			vb.AddError(problem.Field, problem.Message)
+		}
+	}
+
+	// Advisory findings are errors in strict mode and warnings otherwise
+	warnings := advisoryFindings(cfg)
+	if cfg.Strict {
+		for _, warning := range warnings {
+			vb.AddErrorWithCode(warning.Field, warning.Message, validationCodeStrict)
+		}
+		return vb.Build()
+	}
+
+	resp := vb.Build()
+	for _, warning := range warnings {
+		resp.Errors = append(resp.Errors, plugin.ValidationError{
+			Field:   warning.Field,
+			Message: warning.Message,
+			Code:    validationCodeWarning,
+		})
+	}
+	return resp
+}
+
+// checkInstallerURLs verifies that every installer URL, rendered with the
+// configured check version, is reachable.
+func (p *WinGetPlugin) checkInstallerURLs(ctx context.Context, cfg *Config, vb *helpers.ValidationBuilder) {
+	downloader := newDownloader(cfg, cfg.Download.CacheDir)
+	for i, installer := range cfg.Installers {
+		if installer.URL == "" {
+			continue
+		}
+
+		field := fmt.Sprintf("installers[%d].url", i)
+		if strings.Contains(installer.URL, "{{") && cfg.URLCheck.Version == "" {
+			vb.AddError(field, "url_check.version is required to check templated installer URLs")
+			continue
+		}
+
+		data := newTemplateData(&plugin.ReleaseContext{Version: cfg.URLCheck.Version}, cfg)
+		data.Arch = installer.templateArch()
+		url, err := renderTemplate(installer.URL, data)
+		if err != nil {
+			// Reported by the template check
+			continue
+		}
 
-	return vb.Build(), nil
+		if err := downloader.Probe(ctx, url); err != nil {
+			vb.AddError(field, fmt.Sprintf("Installer URL %s is not reachable: %v", url, err))
+		}
+	}
 }
 
 // Execute runs the plugin for a given hook.
 func (p *WinGetPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
 	cfg := p.parseConfig(req.Config)
 	cfg.DryRun = cfg.DryRun || req.DryRun
-	logger := slog.Default().With("plugin", "winget", "hook", req.Hook)
+
+	// Secrets are masked in every log line and returned message
+	redactor := newRedactor(cfg)
+	logger := slog.New(redactor.handler(slog.Default().Handler())).With("plugin", "winget", "hook", req.Hook)
+
+	metrics := newRunMetrics()
+	resp, err := p.execute(withRunMetrics(ctx, metrics), req, cfg, logger)
+	if err != nil {
+		return nil, redactor.error(err)
+	}
+	metrics.log(logger)
+	if resp.Outputs == nil {
+		resp.Outputs = make(map[string]any)
+	}
+	resp.Outputs["metrics"] = metrics.summary()
+	redactor.executeResponse(resp)
+	return resp, nil
+}
+
+// execute runs a hook with decoded configuration.
+func (p *WinGetPlugin) execute(ctx context.Context, req plugin.ExecuteRequest, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	for _, warning := range advisoryFindings(cfg) {
+		logger.Warn("Configuration warning", "field", warning.Field, "message", warning.Message)
+	}
+
+	if cfg.Timeouts.Execute > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeouts.Execute)
+		defer cancel()
+	}
 
 	switch req.Hook {
 	case plugin.HookPostPublish:
-		return p.executePostPublish(ctx, &req.Context, cfg, logger)
+		resp, err := p.executePostPublish(ctx, &req.Context, cfg, logger)
+		if err == nil && cfg.State.enabled() && !cfg.DryRun {
+			p.recordSubmission(ctx, &req.Context, cfg, resp, logger)
+		}
+		return resp, err
+	case plugin.HookOnSuccess:
+		return p.executeOnSuccess(ctx, &req.Context, cfg, logger)
+	case plugin.HookOnError:
+		return p.executeOnError(ctx, &req.Context, cfg, logger)
 	default:
 		return &plugin.ExecuteResponse{
 			Success: true,
@@ -148,17 +738,104 @@ func (p *WinGetPlugin) Execute(ctx context.Context, req plugin.ExecuteRequest) (
 }
 
 func (p *WinGetPlugin) executePostPublish(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
-	version := releaseCtx.Version
-	logger = logger.With("version", version, "package_id", cfg.PackageID)
+	packageVersion, err := packageVersionFor(cfg, releaseCtx)
+	if err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+	logger = logger.With("version", packageVersion, "package_id", cfg.PackageID)
+	metrics := runMetricsFrom(ctx)
+
+	if err := validatePackageVersion(packageVersion); err != nil {
+		return failureResponse(categoryValidation, "Invalid package version: %v", err), nil
+	}
+
+	data := newTemplateData(releaseCtx, cfg)
+	if err := renderConfigTemplates(cfg, data); err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+	if err := checkUnrendered(cfg); err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+	if cfg.Metadata.ReleaseNotesURL == "" {
+		cfg.Metadata.ReleaseNotesURL = defaultReleaseNotesURL(releaseCtx)
+	}
+
+	// Clean up fork branches of merged or closed PRs
+	if cfg.PullRequest.CleanupBranches && cfg.Backend == backendGitHub {
+		p.cleanupBranches(ctx, cfg, logger)
+	}
+
+	// winget-pkgs rejects submissions that are not newer than the
+	// published versions
+	if submitsToWingetPkgs(cfg) && !cfg.DryRun && !cfg.AllowOlderVersion {
+		versionCtx, cancelVersion := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+		latest, err := p.latestPublishedVersion(versionCtx, cfg, logger)
+		cancelVersion()
+		if err != nil {
+			return failureResponse(classifyError(err), "Failed to check published versions: %v", err), nil
+		}
+		if latest != "" && manifest.CompareVersions(packageVersion, latest) <= 0 {
+			logger.Warn("Skipping submission of a version that is not newer than the published version", "published_version", latest)
+			return &plugin.ExecuteResponse{
+				Success: true,
+				Message: fmt.Sprintf("Skipped %s version %s: version %s is already published (set allow_older_version to submit it)", cfg.PackageID, packageVersion, latest),
+				Outputs: map[string]any{
+					"skipped":           true,
+					"published_version": latest,
+					"install_command":   InstallCommand(cfg.PackageID, packageVersion),
+				},
+			}, nil
+		}
+	}
+
+	// Resolve installers declared by release asset patterns
+	if hasAssetInstallers(cfg.Installers) {
+		logger.Info("Resolving installers from release assets", "tag", releaseCtx.TagName)
+		assetCtx, cancelAssets := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+		endStep := metrics.step("resolve_assets")
+		installerCfgs, err := p.resolveAssetInstallers(assetCtx, releaseCtx, cfg, logger)
+		endStep()
+		cancelAssets()
+		if err != nil {
+			return failureResponse(classifyError(err), "Failed to resolve installers from release assets: %v", err), nil
+		}
+		cfg.Installers = installerCfgs
+	}
 
 	// Calculate installer hashes
 	logger.Info("Calculating installer hashes")
-	var installers []Installer
+	downloadCtx, cancelDownload := withOptionalTimeout(ctx, cfg.Timeouts.Download)
+	defer cancelDownload()
+
+	endDownload := metrics.step("download")
+	// MSIX packages and installers with metadata are read once downloaded,
+	// so they are kept in a temporary directory when download.cache_dir is
+	// not set
+	cacheDir := cfg.Download.CacheDir
+	if slices.ContainsFunc(cfg.Installers, readsInstallerFile) && cacheDir == "" && (!cfg.DryRun || cfg.DryRunHash == dryRunHashReal) {
+		inspectDir, err := os.MkdirTemp("", "winget-installers-")
+		if err != nil {
+			return failureResponse(categoryUnknown, "Failed to create installer download directory: %v", err), nil
+		}
+		defer func() { _ = os.RemoveAll(inspectDir) }()
+		cacheDir = inspectDir
+	}
+	downloader := newDownloader(cfg, cacheDir)
+	var installers []manifest.Installer
+	var skipped []string
+	var scanReports []any
+	installerFiles := make(map[string]string)
+	installerHashes := make(map[string]any)
+	var checksums map[string]string
+	if cfg.Checksums.File != "" {
+		var err error
+		checksums, err = readChecksumsFile(cfg.Checksums.File)
+		if err != nil {
+			return failureResponse(categoryValidation, "Failed to read installer checksums: %v", err), nil
+		}
+	}
 	for i, installerCfg := range cfg.Installers {
-		// Render URL with version
-		url := renderTemplate(installerCfg.URL, map[string]string{
-			"Version": version,
-		})
+		url := installerCfg.URL
 
 		logger.Info("Processing installer",
 			"index", i,
@@ -166,257 +843,1184 @@ func (p *WinGetPlugin) executePostPublish(ctx context.Context, releaseCtx *plugi
 			"url", url)
 
 		var hash string
-		if cfg.DryRun {
+		var msix *msixPackage
+		var meta *exeMetadata
+		var inno innoApp
+		var inspected bool
+		if cfg.DryRun && cfg.DryRunHash != dryRunHashReal {
 			logger.Info("[DRY-RUN] Would download and hash installer")
 			hash = "0000000000000000000000000000000000000000000000000000000000000000"
+		} else if known, source := knownChecksum(installerCfg, checksums); known != "" && !cfg.Checksums.Verify && !needsInstallerFile(cfg, installerCfg) {
+			logger.Info("Reusing installer checksum", "index", i, "source", source)
+			hash = known
+			installerHashes[url] = map[string]any{"sha256": hash}
 		} else {
+			var stats installerhash.Stats
 			var err error
-			hash, err = CalculateInstallerHash(ctx, url)
+			hash, stats, err = downloader.CalculateWithStats(downloadCtx, url)
+			if errors.Is(err, installerhash.ErrNotFound) && installerCfg.Optional {
+				logger.Warn("Optional installer not found, skipping", "index", i, "url", url)
+				skipped = append(skipped, url)
+				continue
+			}
 			if err != nil {
-				return &plugin.ExecuteResponse{
-					Success: false,
-					Message: fmt.Sprintf("Failed to calculate hash for installer %d: %v", i, err),
-				}, nil
+				return failureResponse(classifyError(err), "Failed to calculate hash for installer %d: %v", i, err), nil
+			}
+			if err := checkFinalHost(stats.FinalURL, installerCfg.AllowedHosts); err != nil {
+				return failureResponse(categoryValidation, "Installer %d: %v", i, err), nil
+			}
+			if err := checkInstallerDownload(cfg.Download, stats); err != nil {
+				return failureResponse(categoryValidation, "Installer %d: %v", i, err), nil
+			}
+			if known != "" && !strings.EqualFold(hash, known) {
+				return failureResponse(categoryValidation, "Installer %d hash %s does not match the %s checksum %s", i, hash, source, known), nil
+			}
+			metrics.addInstaller(i, url, stats)
+			installerHashes[url] = installerHashOutput(hash, stats)
+			if cfg.Download.CacheDir != "" {
+				installerFiles[url] = stats.Path
+			}
+			inspected = readsInstallerFile(installerCfg)
+			if isMSIXInstaller(installerCfg) {
+				msix, err = readMSIXPackage(stats.Path)
+				if err != nil {
+					return failureResponse(categoryValidation, "Failed to read MSIX package %d: %v", i, err), nil
+				}
+			}
+			if exeMetadataTypes[installerCfg.Type] {
+				// The metadata only completes the manifest, so installers
+				// without it are submitted as configured
+				meta, err = readExeMetadata(stats.Path)
+				if err != nil {
+					logger.Warn("Failed to read installer metadata", "index", i, "error", err)
+				}
+			}
+			if installerCfg.Type == "inno" {
+				inno, err = readInnoApp(stats.Path)
+				if err != nil && installerCfg.ProductCode == "" {
+					logger.Warn("Failed to read Inno Setup AppId", "index", i, "error", err)
+				}
+				if installerCfg.ProductCode == "" {
+					installerCfg.ProductCode = inno.ProductCode
+				}
+			}
+			if burnTypes[installerCfg.Type] {
+				bundle, err := readBurnBundle(stats.Path)
+				switch {
+				case err == nil:
+					installerCfg = burnInstaller(installerCfg, bundle, logger.With("index", i))
+				case installerCfg.Type == "burn" || !errors.Is(err, errNotBurnBundle):
+					logger.Warn("Failed to read Burn bundle", "index", i, "error", err)
+				}
+			}
+
+			// Refuse to publish hashes of binaries without provenance
+			if cfg.Attestation.Enabled {
+				owner, repo, err := attestationRepository(cfg, releaseCtx.RepositoryOwner, releaseCtx.RepositoryName)
+				if err != nil {
+					return failureResponse(categoryValidation, "Failed to verify attestation of installer %d: %v", i, err), nil
+				}
+				if err := verifyAttestation(downloadCtx, newGitHubClient(downloadCtx, cfg, "", logger), cfg, owner, repo, hash, stats.Path); err != nil {
+					category := classifyError(err)
+					if category == categoryUnknown {
+						category = categoryValidation
+					}
+					return failureResponse(category, "Installer %d is not attested: %v", i, err), nil
+				}
+				logger.Info("Verified installer attestation", "index", i, "repository", owner+"/"+repo)
+			}
+
+			// Block submissions of installers flagged by a malware scan
+			if cfg.Scan.Scanner != "" {
+				logger.Info("Scanning installer", "index", i, "scanner", cfg.Scan.Scanner)
+				report, err := scanInstaller(downloadCtx, cfg, url, hash, stats.Path)
+				if err != nil {
+					resp := failureResponse(classifyError(err), "Failed to scan installer %d: %v", i, err)
+					resp.Outputs["scan_report"] = append(scanReports, report.output())
+					return resp, nil
+				}
+				scanReports = append(scanReports, report.output())
+				if report.blocked(cfg.Scan.MaxDetections) {
+					resp := failureResponse(categoryValidation, "Installer %d was flagged by %d %s engines: %s", i, report.Malicious, cfg.Scan.Scanner, strings.Join(report.Engines, ", "))
+					resp.Outputs["scan_report"] = scanReports
+					return resp, nil
+				}
+				logger.Info("Installer scan passed", "index", i, "malicious", report.Malicious, "suspicious", report.Suspicious)
 			}
 		}
 
-		installer := Installer{
+		installer := manifest.Installer{
 			Architecture:    installerCfg.Architecture,
 			InstallerType:   installerCfg.Type,
 			InstallerURL:    url,
 			InstallerSha256: hash,
 			Scope:           installerCfg.Scope,
 			ProductCode:     installerCfg.ProductCode,
+
+			UnsupportedArguments:      installerCfg.UnsupportedArguments,
+			DownloadCommandProhibited: installerCfg.DownloadCommandProhibited,
+			RepairBehavior:            installerCfg.RepairBehavior,
 		}
 
 		if len(installerCfg.Switches) > 0 {
 			installer.InstallerSwitches = installerCfg.Switches
 		}
+		// exe, inno and nullsoft product codes are correlated through the
+		// Apps & Features entry
+		entry := manifest.AppsAndFeaturesEntry{
+			UpgradeCode: installerCfg.UpgradeCode,
+		}
+		if installerCfg.UpgradeCode != "" || productCodeTypes[installerCfg.Type] {
+			entry.ProductCode = installerCfg.ProductCode
+		}
+		// Inno Setup installers register their AppVerName as DisplayName and
+		// NSIS installers the product name of their version resource; the
+		// DisplayVersion of both is the product version of the resource
+		if installerCfg.Type == "inno" {
+			entry.DisplayName = inno.DisplayName
+		} else if meta != nil {
+			entry.DisplayName = meta.ProductName
+		}
+		if meta != nil && meta.ProductVersion != packageVersion {
+			entry.DisplayVersion = meta.ProductVersion
+		}
+		if !cfg.VersionTransforms.Display.IsZero() {
+			entry.DisplayVersion = data.DisplayVersion
+		}
+		cfg.Installers[i].inspected = inspected
+		cfg.Installers[i].correlated = installer.ProductCode != "" || entry.ProductCode != "" || entry.UpgradeCode != "" || entry.DisplayName != ""
+		if entry != (manifest.AppsAndFeaturesEntry{}) {
+			installer.AppsAndFeaturesEntries = []manifest.AppsAndFeaturesEntry{entry}
+		}
 
+		// MSIX packages carry their PackageFamilyName, and bundles are
+		// described once per architecture they contain
+		if msix != nil {
+			entries, err := msixInstallers(installer, msix)
+			if err != nil {
+				return failureResponse(categoryValidation, "Invalid MSIX package %d: %v", i, err), nil
+			}
+			installers = append(installers, entries...)
+			continue
+		}
+		if installer.Architecture == "" {
+			installer.Architecture = "neutral"
+		}
 		installers = append(installers, installer)
 	}
+	endDownload()
+
+	// Installers read once downloaded are only checked for a ProductCode
+	// now, so strict mode fails on them here
+	warnings := installerFindings(cfg)
+	for _, warning := range warnings {
+		logger.Warn("Configuration warning", "field", warning.Field, "message", warning.Message)
+	}
+	if cfg.Strict && len(warnings) > 0 {
+		return failureResponse(categoryValidation, "Strict mode: %s: %s", warnings[0].Field, warnings[0].Message), nil
+	}
+
+	if len(installers) < cfg.MinInstallers {
+		return failureResponse(categoryValidation, "Only %d installers available, at least %d required", len(installers), cfg.MinInstallers), nil
+	}
+
+	if err := loadDescriptionFiles(cfg); err != nil {
+		return failureResponse(categoryValidation, "Failed to load descriptions: %v", err), nil
+	}
+	canonicalizeConfigTags(cfg, p.inheritedTags(ctx, releaseCtx, cfg, logger), logger)
+	if cfg.Metadata.MarkdownToText {
+		convertMarkdown(cfg)
+	}
 
 	// Generate manifests
 	logger.Info("Generating manifests")
-	manifests, err := GenerateManifests(cfg, version, installers)
+	endGenerate := metrics.step("generate")
+	manifests, err := manifest.Generate(manifestPackage(cfg), packageVersion, installers)
 	if err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to generate manifests: %v", err),
-		}, nil
+		return failureResponse(categoryValidation, "Failed to generate manifests: %v", err), nil
+	}
+
+	// Validate manifests against the winget schemas
+	if cfg.Validate {
+		logger.Info("Validating manifests against schema", "manifest_version", manifest.SchemaVersion)
+		if err := manifest.Validate(manifests); err != nil {
+			return failureResponse(categoryValidation, "Generated manifests are invalid: %v", err), nil
+		}
+	}
+	endGenerate()
+
+	// Write manifests to the output directory; dry-runs without one write to
+	// a temporary directory so the result can be inspected
+	outputs := map[string]any{
+		"install_command": InstallCommand(cfg.PackageID, packageVersion),
+		"manifest_path":   manifests.Path,
+	}
+	if len(skipped) > 0 {
+		outputs["skipped_installers"] = skipped
+	}
+	if len(installerFiles) > 0 {
+		outputs["installer_files"] = installerFiles
+	}
+	if len(installerHashes) > 0 {
+		outputs["installer_hashes"] = installerHashes
+	}
+	if len(scanReports) > 0 {
+		outputs["scan_report"] = scanReports
+	}
+	if len(warnings) > 0 {
+		outputs["warnings"] = findingOutputs(warnings)
+	}
+	outputDir := cfg.OutputDir
+	if outputDir == "" && cfg.DryRun {
+		outputDir, err = os.MkdirTemp("", "winget-dry-run-")
+		if err != nil {
+			return failureResponse(categoryUnknown, "Failed to create dry-run output directory: %v", err), nil
+		}
+	}
+	if outputDir != "" {
+		dir, err := manifests.WriteTo(outputDir)
+		if err != nil {
+			return failureResponse(categoryUnknown, "Failed to write manifests to %s: %v", outputDir, err), nil
+		}
+		logger.Info("Wrote manifests", "dir", dir)
+		outputs["manifest_dir"] = dir
+	}
+
+	// Diff against the latest published version
+	var published map[string]string
+	if cfg.Diff || cfg.DryRun || cfg.Issue.Enabled {
+		diffCtx, cancelDiff := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+		diff, publishedVersion, publishedFiles, err := p.diffPublished(diffCtx, cfg, manifests, logger)
+		cancelDiff()
+		switch {
+		case err != nil:
+			logger.Warn("Failed to diff against published manifests", "error", err)
+		case publishedVersion == "":
+			logger.Info("No published version found to diff against")
+		default:
+			logger.Info("Manifest diff against published version", "published_version", publishedVersion, "diff", diff)
+			outputs["published_version"] = publishedVersion
+			outputs["manifest_diff"] = diff
+			published = publishedFiles
+		}
+	}
+
+	// Validate manifests with the winget CLI, or wingetcreate without it
+	if cfg.ValidateWithWinget {
+		var tool string
+		var validate func(context.Context, *manifest.Set) (string, error)
+		switch {
+		case WingetAvailable():
+			tool, validate = "winget", ValidateWithWinget
+		case WingetcreateAvailable():
+			tool, validate = "wingetcreate", ValidateWithWingetcreate
+		default:
+			resp := failureResponse(categoryValidation, "validate_with_winget requires winget or wingetcreate in PATH")
+			maps.Copy(resp.Outputs, outputs)
+			return resp, nil
+		}
+		logger.Info("Validating manifests", "tool", tool)
+		output, err := validate(ctx, manifests)
+		logger.Info(tool+" validate output", "output", output)
+		outputs["winget_validate_output"] = output
+		if err != nil {
+			resp := failureResponse(categoryValidation, "%s validation failed: %v\n%s", tool, err, output)
+			maps.Copy(resp.Outputs, outputs)
+			return resp, nil
+		}
+	}
+
+	// Test install from the generated manifests
+	if cfg.TestInstall {
+		switch {
+		case cfg.DryRun:
+			logger.Info("[DRY-RUN] Would test install from generated manifests")
+		case runtime.GOOS != "windows":
+			logger.Warn("Test install requires Windows, skipping", "os", runtime.GOOS)
+		case !WingetAvailable():
+			logger.Warn("winget not found in PATH, skipping test install")
+		default:
+			logger.Info("Test installing package from generated manifests")
+			output, err := TestInstall(ctx, manifests)
+			logger.Info("winget test install output", "output", output)
+			outputs["test_install_output"] = output
+			if err != nil {
+				resp := failureResponse(categoryValidation, "Test install failed: %v", err)
+				maps.Copy(resp.Outputs, outputs)
+				return resp, nil
+			}
+		}
+	}
+
+	// Test install inside Windows Sandbox
+	if cfg.TestInstallSandbox {
+		switch {
+		case cfg.DryRun:
+			logger.Info("[DRY-RUN] Would test install inside Windows Sandbox")
+		case runtime.GOOS != "windows":
+			logger.Warn("Sandbox test install requires Windows, skipping", "os", runtime.GOOS)
+		default:
+			logger.Info("Test installing package inside Windows Sandbox")
+			sandboxCtx, cancelSandbox := withOptionalTimeout(ctx, cfg.Timeouts.Sandbox)
+			output, err := SandboxTestInstall(sandboxCtx, manifests)
+			cancelSandbox()
+			logger.Info("Windows Sandbox transcript", "output", output)
+			outputs["test_install_sandbox_output"] = output
+			if err != nil {
+				resp := failureResponse(categoryValidation, "Sandbox test install failed: %v", err)
+				maps.Copy(resp.Outputs, outputs)
+				return resp, nil
+			}
+		}
 	}
 
 	if cfg.DryRun {
+		files, err := manifests.GetFiles()
+		if err != nil {
+			return failureResponse(categoryUnknown, "Failed to render manifests: %v", err), nil
+		}
+		paths := make([]string, 0, len(files))
+		for path, content := range files {
+			paths = append(paths, path)
+			logger.Debug("[DRY-RUN] Manifest", "path", path, "content", content)
+		}
+		sort.Strings(paths)
+
+		outputs["branch_name"] = cfg.PullRequest.Branch
+		outputs["pr_title"] = cfg.PullRequest.Title
+		outputs["submission_plan"] = submissionPlan(cfg)
+		outputs["files"] = paths
+		outputs["schema_validated"] = cfg.Validate
+		outputs["hashes_computed"] = cfg.DryRunHash == dryRunHashReal
+
 		logger.Info("[DRY-RUN] Generated manifests",
-			"path", manifests.Path,
+			"dir", outputs["manifest_dir"],
+			"branch", outputs["branch_name"],
 			"installers", len(installers))
 
-		// Log manifest content for dry-run
-		versionYAML, _ := manifests.VersionYAML()
-		installerYAML, _ := manifests.InstallerYAML()
-		localeYAML, _ := manifests.LocaleYAML()
+		message := fmt.Sprintf("[DRY-RUN] Would create PR for %s version %s", cfg.PackageID, packageVersion)
+		switch cfg.Backend {
+		case backendREST:
+			message = fmt.Sprintf("[DRY-RUN] Would publish %s version %s to %s", cfg.PackageID, packageVersion, cfg.RESTSource.URL)
+		case backendAzureDevOps:
+			message = fmt.Sprintf("[DRY-RUN] Would create PR for %s version %s in %s/%s", cfg.PackageID, packageVersion, cfg.AzureDevOps.Project, cfg.AzureDevOps.Repository)
+		case backendGitLab:
+			message = fmt.Sprintf("[DRY-RUN] Would create MR for %s version %s in %s", cfg.PackageID, packageVersion, cfg.GitLab.Project)
+		}
+		publishedVersion, _ := outputs["published_version"].(string)
+		diff, _ := outputs["manifest_diff"].(string)
+		outputs["summary_markdown"] = dryRunSummary(dryRunSummaryData{
+			Message:          strings.TrimPrefix(message, "[DRY-RUN] "),
+			ManifestPath:     manifests.Path,
+			Files:            paths,
+			Installers:       installers,
+			HashesComputed:   cfg.DryRunHash == dryRunHashReal,
+			PublishedVersion: publishedVersion,
+			Changes:          metadataChanges(published, files),
+			Diff:             diff,
+		})
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: message,
+			Outputs: outputs,
+		}, nil
+	}
+
+	defer metrics.step("submit")()
 
-		logger.Info("[DRY-RUN] Version manifest", "content", versionYAML)
-		logger.Info("[DRY-RUN] Installer manifest", "content", installerYAML)
-		logger.Info("[DRY-RUN] Locale manifest", "content", localeYAML)
+	switch cfg.Backend {
+	case backendREST:
+		return p.publishREST(ctx, cfg, manifests, outputs, logger)
+	case backendAzureDevOps:
+		return p.submitAzureDevOps(ctx, cfg, manifests, outputs, logger)
+	case backendGitLab:
+		return p.submitGitLab(ctx, cfg, manifests, outputs, logger)
+	case backendWingetcreate:
+		return p.submitWingetcreate(ctx, cfg, manifests, outputs, logger)
+	case backendKomac:
+		if KomacAvailable() {
+			return p.submitKomac(ctx, cfg, manifests, outputs, logger)
+		}
+		logger.Warn("komac not found in PATH, submitting through the GitHub API")
+	}
 
+	if !cfg.PullRequest.Enabled {
 		return &plugin.ExecuteResponse{
 			Success: true,
-			Message: fmt.Sprintf("[DRY-RUN] Would create PR for %s version %s", cfg.PackageID, version),
+			Message: fmt.Sprintf("Wrote manifests for %s version %s to %s", cfg.PackageID, packageVersion, outputs["manifest_dir"]),
+			Outputs: outputs,
 		}, nil
 	}
 
 	// Create pull request
 	logger.Info("Creating pull request to winget-pkgs")
-	ghClient := NewGitHubClient(cfg.GitHubToken, cfg.PullRequest.ForkOwner)
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, logger)
+
+	// Wait for the turn of this submission. The wait is bounded by
+	// throttle.max_wait and timeouts.execute only, so timeouts.github applies
+	// once the turn is acquired.
+	release, err := acquireSubmission(ctx, submissionQueue, cfg, ghClient.OpenPullRequestCount, logger)
+	if err != nil {
+		return failureResponse(classifyError(err), "Failed to queue PR: %v", err), nil
+	}
+
+	ctx, cancelGitHub := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancelGitHub()
 
 	// Ensure fork exists
 	logger.Info("Ensuring fork of winget-pkgs exists")
 	forkOwner, err := ghClient.EnsureFork(ctx)
 	if err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to ensure fork: %v", err),
-		}, nil
+		release(false)
+		return failureResponse(classifyError(err), "Failed to ensure fork: %v", err), nil
 	}
 	logger.Info("Using fork", "owner", forkOwner)
+	if identity := ghClient.Identity(); cfg.PullRequest.IdentityCache != "" && identity.User != "" {
+		if err := saveIdentityCache(cfg.PullRequest.IdentityCache, cfg.GitHubToken, identity, time.Now()); err != nil {
+			logger.Warn("Failed to save GitHub identity cache", "error", err)
+		}
+	}
 
 	// Create PR
-	prURL, err := ghClient.CreatePR(ctx, manifests, cfg.PullRequest)
+	attempts := 1
+	if cfg.PullRequest.OnExistingBranch == branchPolicyNew {
+		attempts = maxBranchAttempts
+	}
+	pr, err := ghClient.CreatePR(ctx, manifests, githubclient.PullRequestOptions{
+		BaseBranch:     cfg.PullRequest.BaseBranch,
+		Title:          cfg.PullRequest.Title,
+		Body:           prBody(cfg.PullRequest),
+		Branch:         cfg.PullRequest.Branch,
+		BranchAttempts: attempts,
+	})
+	release(err == nil)
 	if err != nil {
-		return &plugin.ExecuteResponse{
-			Success: false,
-			Message: fmt.Sprintf("Failed to create PR: %v", err),
-		}, nil
+		if cfg.Issue.Enabled {
+			p.reportIssue(ctx, ghClient, cfg, releaseCtx, "", newRedactor(cfg).String(fmt.Sprintf("submission failed: %v", err)), outputs, logger)
+		}
+		return failureResponse(classifyError(err), "Failed to create PR: %v", err), nil
 	}
 
-	logger.Info("Pull request created", "url", prURL)
+	prURL := pr.URL
+	logger.Info("Pull request created", "url", prURL, "branch", pr.Branch, "attempt", pr.Attempt)
+	if pr := cfg.PullRequest; len(pr.Labels)+len(pr.Reviewers)+len(pr.Assignees) > 0 {
+		err := ghClient.AnnotatePR(ctx, prURL, githubclient.PullRequestAnnotations{
+			Labels:    pr.Labels,
+			Reviewers: pr.Reviewers,
+			Assignees: pr.Assignees,
+		})
+		if err != nil {
+			logger.Warn("Failed to annotate PR", "url", prURL, "error", err)
+		}
+	}
+	if cfg.Issue.Enabled {
+		p.reportIssue(ctx, ghClient, cfg, releaseCtx, prURL, issueStatus(prStateOpen, nil), outputs, logger)
+	}
+	outputs["pr_url"] = prURL
+	outputs["branch_name"] = pr.Branch
+	outputs["pr_attempt"] = pr.Attempt
+	outputs["pr_resumed"] = pr.Resumed
+	if cfg.State.enabled() {
+		if sha, err := ghClient.BranchSHA(ctx, forkOwner, pr.Branch); err == nil {
+			outputs["commit_sha"] = sha
+		} else {
+			logger.Warn("Failed to get the commit of the pull request", "error", err)
+		}
+	}
 	return &plugin.ExecuteResponse{
 		Success: true,
-		Message: fmt.Sprintf("Created PR for %s version %s: %s", cfg.PackageID, version, prURL),
+		Message: fmt.Sprintf("Created PR for %s version %s: %s", cfg.PackageID, packageVersion, prURL),
+		Outputs: outputs,
 	}, nil
 }
 
-func (p *WinGetPlugin) parseConfig(raw map[string]any) *Config {
-	parser := helpers.NewConfigParser(raw)
-
-	// Parse installers
-	var installers []InstallerConfig
-	if installersRaw, ok := raw["installers"].([]any); ok {
-		for _, item := range installersRaw {
-			if m, ok := item.(map[string]any); ok {
-				installer := InstallerConfig{}
-				if url, ok := m["url"].(string); ok {
-					installer.URL = url
-				}
-				if arch, ok := m["architecture"].(string); ok {
-					installer.Architecture = arch
-				}
-				if t, ok := m["type"].(string); ok {
-					installer.Type = t
-				}
-				if scope, ok := m["scope"].(string); ok {
-					installer.Scope = scope
-				}
-				if productCode, ok := m["product_code"].(string); ok {
-					installer.ProductCode = productCode
-				}
-				if switches, ok := m["switches"].(map[string]any); ok {
-					installer.Switches = make(map[string]string)
-					for k, v := range switches {
-						if s, ok := v.(string); ok {
-							installer.Switches[k] = s
-						}
-					}
-				}
-				installers = append(installers, installer)
-			}
-		}
+// cleanupBranches deletes the fork branches under the branch template prefix
+// whose pull requests were merged or closed. Failures are logged, as they do
+// not affect the submission.
+func (p *WinGetPlugin) cleanupBranches(ctx context.Context, cfg *Config, logger *slog.Logger) {
+	prefix := cfg.PullRequest.branchPrefix
+	if cfg.DryRun {
+		logger.Info("[DRY-RUN] Would clean up stale branches in fork", "prefix", prefix)
+		return
 	}
 
-	// Parse metadata
-	metadata := MetadataConfig{}
-	if metaRaw, ok := raw["metadata"].(map[string]any); ok {
-		if pub, ok := metaRaw["publisher"].(string); ok {
-			metadata.Publisher = pub
-		}
-		if pubURL, ok := metaRaw["publisher_url"].(string); ok {
-			metadata.PublisherURL = pubURL
-		}
-		if pubSupport, ok := metaRaw["publisher_support_url"].(string); ok {
-			metadata.PublisherSupportURL = pubSupport
-		}
-		if name, ok := metaRaw["name"].(string); ok {
-			metadata.Name = name
+	logger.Info("Cleaning up stale branches in fork", "prefix", prefix)
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, logger)
+	deleted, err := ghClient.CleanupBranches(ctx, cfg.PullRequest.ForkOwner, prefix)
+	if err != nil {
+		logger.Warn("Failed to clean up stale branches", "error", err)
+	}
+	for _, branch := range deleted {
+		logger.Info("Deleted stale branch", "branch", branch)
+	}
+}
+
+// branchTemplatePrefix returns the literal text of a branch template up to
+// its last "/" before the first action, such as "winget/" for the default
+// template, or "" if it has none.
+func branchTemplatePrefix(branch string) string {
+	literal, _, _ := strings.Cut(branch, "{{")
+	if i := strings.LastIndex(literal, "/"); i > 0 {
+		return literal[:i+1]
+	}
+	return ""
+}
+
+// prBranch returns the fork branch of the latest pull request attempt. With
+// the "new" on_existing_branch policy, that is the last of the branch and its
+// suffixed attempt branches that has a pull request.
+func prBranch(ctx context.Context, ghClient *githubclient.Client, pr PRConfig) (string, error) {
+	branch := pr.Branch
+	if pr.OnExistingBranch != branchPolicyNew {
+		return branch, nil
+	}
+	for attempt := 2; attempt <= maxBranchAttempts; attempt++ {
+		status, err := ghClient.BranchPullRequest(ctx, githubclient.AttemptBranch(pr.Branch, attempt))
+		if err != nil {
+			return "", err
 		}
-		if desc, ok := metaRaw["short_description"].(string); ok {
-			metadata.ShortDescription = desc
+		if status == nil {
+			break
 		}
-		if lic, ok := metaRaw["license"].(string); ok {
-			metadata.License = lic
+		branch = githubclient.AttemptBranch(pr.Branch, attempt)
+	}
+	return branch, nil
+}
+
+// prBody returns the pull request body, ending with a reference to the
+// tracking issue if one is configured.
+func prBody(pr PRConfig) string {
+	if pr.TrackingIssue == "" {
+		return pr.Body
+	}
+	keyword := "Refs"
+	if pr.CloseTrackingIssue {
+		keyword = "Closes"
+	}
+	return strings.TrimRight(pr.Body, "\n") + "\n\n" + keyword + " " + pr.TrackingIssue
+}
+
+// publishREST publishes the manifests to the configured winget REST source.
+func (p *WinGetPlugin) publishREST(ctx context.Context, cfg *Config, manifests *manifest.Set, outputs map[string]any, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	logger.Info("Publishing manifests to REST source", "url", cfg.RESTSource.URL)
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+
+	var token string
+	if cfg.RESTSource.AzureAD.TenantID != "" {
+		var err error
+		token, err = AzureADToken(ctx, cfg.RESTSource.AzureAD)
+		if err != nil {
+			return failureResponse(classifyAuthError(err), "Failed to authenticate to REST source: %v", err), nil
 		}
-		if licURL, ok := metaRaw["license_url"].(string); ok {
-			metadata.LicenseURL = licURL
+	}
+
+	client := NewRESTSourceClient(cfg.RESTSource.URL, cfg.RESTSource.APIKey, token)
+	if err := client.Publish(ctx, manifests); err != nil {
+		resp := failureResponse(classifyError(err), "Failed to publish to REST source: %v", err)
+		maps.Copy(resp.Outputs, outputs)
+		return resp, nil
+	}
+
+	logger.Info("Published manifests to REST source")
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Published %s version %s to %s", manifests.Version.PackageIdentifier, manifests.Version.PackageVersion, cfg.RESTSource.URL),
+		Outputs: outputs,
+	}, nil
+}
+
+// submitAzureDevOps pushes the manifests to the configured Azure DevOps
+// repository and opens a pull request for them.
+func (p *WinGetPlugin) submitAzureDevOps(ctx context.Context, cfg *Config, manifests *manifest.Set, outputs map[string]any, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	ado := cfg.AzureDevOps
+	logger.Info("Creating pull request in Azure DevOps", "project", ado.Project, "repository", ado.Repository)
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+
+	client := NewAzureDevOpsClient(ado.OrganizationURL, ado.Project, ado.Repository, ado.Token)
+	prURL, err := client.SubmitPullRequest(ctx, manifests, changeRequest{
+		SourceBranch:  cfg.PullRequest.Branch,
+		TargetBranch:  ado.TargetBranch,
+		Title:         cfg.PullRequest.Title,
+		Description:   prBody(cfg.PullRequest),
+		CommitMessage: fmt.Sprintf("New version: %s version %s", manifests.Version.PackageIdentifier, manifests.Version.PackageVersion),
+	})
+	outputs["branch_name"] = cfg.PullRequest.Branch
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to create pull request in Azure DevOps: %v", err)
+		maps.Copy(resp.Outputs, outputs)
+		return resp, nil
+	}
+
+	logger.Info("Created pull request", "url", prURL)
+	outputs["pr_url"] = prURL
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Created PR for %s version %s: %s", cfg.PackageID, manifests.Version.PackageVersion, prURL),
+		Outputs: outputs,
+	}, nil
+}
+
+// submitGitLab commits the manifests to the configured GitLab project and
+// opens a merge request for them.
+func (p *WinGetPlugin) submitGitLab(ctx context.Context, cfg *Config, manifests *manifest.Set, outputs map[string]any, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	logger.Info("Creating merge request in GitLab", "project", cfg.GitLab.Project)
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+
+	client := NewGitLabClient(cfg.GitLab.URL, cfg.GitLab.Project, cfg.GitLab.Token)
+	mrURL, err := client.SubmitMergeRequest(ctx, manifests, changeRequest{
+		SourceBranch:  cfg.PullRequest.Branch,
+		TargetBranch:  cfg.GitLab.TargetBranch,
+		Title:         cfg.PullRequest.Title,
+		Description:   prBody(cfg.PullRequest),
+		CommitMessage: fmt.Sprintf("New version: %s version %s", manifests.Version.PackageIdentifier, manifests.Version.PackageVersion),
+	})
+	outputs["branch_name"] = cfg.PullRequest.Branch
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to create merge request in GitLab: %v", err)
+		maps.Copy(resp.Outputs, outputs)
+		return resp, nil
+	}
+
+	logger.Info("Created merge request", "url", mrURL)
+	outputs["pr_url"] = mrURL
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Created MR for %s version %s: %s", cfg.PackageID, manifests.Version.PackageVersion, mrURL),
+		Outputs: outputs,
+	}, nil
+}
+
+// submitWingetcreate submits the manifests with the wingetcreate CLI.
+func (p *WinGetPlugin) submitWingetcreate(ctx context.Context, cfg *Config, manifests *manifest.Set, outputs map[string]any, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	logger.Info("Submitting manifests with wingetcreate", "mode", cfg.Wingetcreate.Mode)
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.CLI)
+	defer cancel()
+
+	title := cfg.PullRequest.Title
+	var prURL, output string
+	var err error
+	if cfg.Wingetcreate.Mode == wingetcreateModeUpdate {
+		prURL, output, err = WingetcreateUpdate(ctx, manifests.Version.PackageIdentifier, manifests.Version.PackageVersion,
+			manifests.Installer.Installers, cfg.GitHubToken, title)
+	} else {
+		prURL, output, err = WingetcreateSubmit(ctx, manifests, cfg.GitHubToken, title)
+	}
+	logger.Info("wingetcreate output", "output", output)
+	outputs["wingetcreate_output"] = output
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to submit with wingetcreate: %v", err)
+		maps.Copy(resp.Outputs, outputs)
+		return resp, nil
+	}
+
+	logger.Info("Pull request created", "url", prURL)
+	outputs["pr_url"] = prURL
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Created PR for %s version %s: %s", cfg.PackageID, manifests.Version.PackageVersion, prURL),
+		Outputs: outputs,
+	}, nil
+}
+
+// submitKomac submits the new version with the komac CLI.
+func (p *WinGetPlugin) submitKomac(ctx context.Context, cfg *Config, manifests *manifest.Set, outputs map[string]any, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	logger.Info("Submitting new version with komac")
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.CLI)
+	defer cancel()
+
+	prURL, output, err := KomacUpdate(ctx, manifests, cfg.GitHubToken)
+	logger.Info("komac output", "output", output)
+	outputs["komac_output"] = output
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to submit with komac: %v", err)
+		maps.Copy(resp.Outputs, outputs)
+		return resp, nil
+	}
+
+	logger.Info("Pull request created", "url", prURL)
+	outputs["pr_url"] = prURL
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Created PR for %s version %s: %s", cfg.PackageID, manifests.Version.PackageVersion, prURL),
+		Outputs: outputs,
+	}, nil
+}
+
+// executeOnError rolls back the submission of a release that failed after the
+// post-publish hook ran: the pull request the run submitted for the version,
+// as recorded in the state, is closed and its fork branch deleted. Pull
+// requests of earlier runs, including ones this run resumed, are left open.
+func (p *WinGetPlugin) executeOnError(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	if cfg.Backend != backendGitHub || !cfg.PullRequest.Enabled || !cfg.PullRequest.RollbackOnError {
+		return &plugin.ExecuteResponse{Success: true, Message: "Rollback disabled"}, nil
+	}
+
+	packageVersion, err := packageVersionFor(cfg, releaseCtx)
+	if err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+	logger = logger.With("version", packageVersion, "package_id", cfg.PackageID)
+
+	if err := renderConfigTemplates(cfg, newTemplateData(releaseCtx, cfg)); err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+
+	if cfg.DryRun {
+		logger.Info("[DRY-RUN] Would close the PR submitted by this run and delete its branch")
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("[DRY-RUN] Would roll back submission of %s version %s", cfg.PackageID, packageVersion),
+		}, nil
+	}
+
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+
+	state, err := p.loadSubmissionState(ctx, cfg, logger)
+	if err != nil {
+		return failureResponse(classifyError(err), "Failed to roll back submission: %v", err), nil
+	}
+	record, ok := state.submittedByRun(cfg.PackageID, packageVersion, cfg.RunID)
+	if !ok {
+		logger.Info("No pull request submitted by this run", "run_id", cfg.RunID)
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("No submission of %s version %s by this run to roll back", cfg.PackageID, packageVersion),
+		}, nil
+	}
+
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, logger)
+	prURL, err := ghClient.RollbackPR(ctx, record.Branch)
+	if err != nil {
+		return failureResponse(classifyError(err), "Failed to roll back submission: %v", err), nil
+	}
+	state.add(cfg.PackageID, submissionRecord{
+		Version:   packageVersion,
+		Outcome:   outcomeRolledBack,
+		Backend:   cfg.Backend,
+		PRURL:     prURL,
+		Branch:    record.Branch,
+		RunID:     cfg.RunID,
+		Timestamp: time.Now().UTC(),
+	})
+	if err := p.saveSubmissionState(ctx, cfg, state, logger); err != nil {
+		logger.Warn("Failed to record submission state", "error", err)
+	}
+
+	if prURL != "" {
+		logger.Info("Closed pull request", "url", prURL)
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Closed PR %s and deleted its branch", prURL),
+			Outputs: map[string]any{"closed_pr_url": prURL},
+		}, nil
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Rolled back submission of %s version %s", cfg.PackageID, packageVersion),
+	}, nil
+}
+
+// submitsToWingetPkgs reports whether the configured backend opens a pull
+// request against winget-pkgs.
+func submitsToWingetPkgs(cfg *Config) bool {
+	switch cfg.Backend {
+	case backendGitHub:
+		return cfg.PullRequest.Enabled
+	case backendWingetcreate, backendKomac:
+		return true
+	}
+	return false
+}
+
+// latestPublishedVersion returns the latest version of the package in
+// winget-pkgs, or "" if it has not been published yet.
+func (p *WinGetPlugin) latestPublishedVersion(ctx context.Context, cfg *Config, logger *slog.Logger) (string, error) {
+	ghClient := newGitHubClient(ctx, cfg, "", logger)
+	versions, err := ghClient.PublishedVersions(ctx, cfg.PackageID)
+	if err != nil {
+		return "", err
+	}
+	return manifest.LatestVersion(versions), nil
+}
+
+// resolveAssetInstallers fetches the assets of the GitHub release being
+// published and expands asset patterns into installer URLs.
+func (p *WinGetPlugin) resolveAssetInstallers(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) ([]InstallerConfig, error) {
+	if releaseCtx.RepositoryOwner == "" || releaseCtx.RepositoryName == "" || releaseCtx.TagName == "" {
+		return nil, fmt.Errorf("release context has no repository owner, name or tag")
+	}
+
+	ghClient := newGitHubClient(ctx, cfg, "", logger)
+	assets, err := ghClient.ReleaseAssets(ctx, releaseCtx.RepositoryOwner, releaseCtx.RepositoryName, releaseCtx.TagName)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveInstallerAssets(cfg.Installers, assets, assetRulesFor(cfg))
+}
+
+// diffPublished diffs the generated manifests against the latest version of
+// the package published in winget-pkgs, returning the diff, the version and
+// its manifests keyed by file name. It returns an empty version if the
+// package has not been published yet.
+func (p *WinGetPlugin) diffPublished(ctx context.Context, cfg *Config, manifests *manifest.Set, logger *slog.Logger) (string, string, map[string]string, error) {
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, logger)
+	publishedVersion, published, err := ghClient.PublishedManifests(ctx, cfg.PackageID)
+	if err != nil || publishedVersion == "" {
+		return "", "", nil, err
+	}
+
+	diff, err := manifest.Diff(publishedVersion, published, manifests)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return diff, publishedVersion, published, nil
+}
+
+func (p *WinGetPlugin) parseConfig(raw map[string]any) *Config {
+	cfg, _ := decodePluginConfig(raw)
+	return cfg
+}
+
+// defaultConfig returns the configuration used for keys that are not set.
+func defaultConfig() *Config {
+	return &Config{
+		Backend:     backendGitHub,
+		PatchAssets: []string{"*.msp"},
+		UserAgent:   defaultUserAgent,
+		ScopeRules: []ScopeRule{
+			{Pattern: "*-user-*", Scope: "user"},
+			{Pattern: "*-machine-*", Scope: "machine"},
+		},
+		Wingetcreate: WingetcreateConfig{
+			Mode: wingetcreateModeSubmit,
+		},
+		AzureDevOps: AzureDevOpsConfig{
+			TargetBranch: "main",
+		},
+		GitLab: GitLabConfig{
+			URL:          "https://gitlab.com",
+			TargetBranch: "main",
+		},
+		PullRequest: PRConfig{
+			Enabled:          true,
+			BaseBranch:       "master",
+			TitlePreset:      prTitleNewVersion,
+			Body:             "This PR was automatically created by Relicta.",
+			Branch:           `winget/{{.PackageId | replace "." "-"}}/{{.PackageVersion}}`,
+			OnExistingBranch: branchPolicyReuse,
+			CommitStrategy:   commitStrategyAPI,
+			DeleteBranch:     true,
+		},
+		Timeouts: TimeoutConfig{
+			Download:      30 * time.Minute,
+			GitHub:        5 * time.Minute,
+			GitHubRequest: 60 * time.Second,
+			CLI:           35 * time.Minute,
+			Sandbox:       30 * time.Minute,
+			Execute:       time.Hour,
+		},
+		Download: DownloadConfig{
+			BufferSize:         installerhash.DefaultBufferSize,
+			MinSize:            defaultMinInstallerSize,
+			RejectContentTypes: []string{"text/html", "application/xhtml+xml", "application/json", "application/xml", "text/xml"},
+		},
+		Attestation: AttestationConfig{
+			PredicateType: defaultPredicateType,
+			Verifier:      attestationVerifierAPI,
+		},
+		GitHubRetry: GitHubRetryConfig{
+			Backoff:          time.Second,
+			RateLimitRetries: 3,
+			RateLimitMaxWait: 2 * time.Minute,
+		},
+		Track: TrackConfig{
+			PollInterval: 5 * time.Minute,
+		},
+		TokenCheck: TokenCheckConfig{
+			MinValidity: 24 * time.Hour,
+		},
+		Issue: IssueConfig{
+			Title: "winget: {{.PackageId}} {{.PackageVersion}}",
+		},
+		Backfill: BackfillConfig{
+			Interval: time.Minute,
+		},
+		Throttle: ThrottleConfig{
+			PollInterval: time.Minute,
+			MaxWait:      30 * time.Minute,
+		},
+		Validate:       true,
+		PackageVersion: "{{.Version}}",
+		DryRunHash:     dryRunHashPlaceholder,
+		MinInstallers:  1,
+	}
+}
+
+// decodePluginConfig decodes raw configuration on top of the defaults and
+// returns any unknown keys or type mismatches found.
+func decodePluginConfig(raw map[string]any) (*Config, []fieldError) {
+	raw, problems := normalizeConfigKeys("", raw, reflect.TypeOf(Config{}))
+	// Only the step's own configuration is expanded; ${VAR} in a committed
+	// config_file must not be able to read the CI environment
+	raw, envProblems := expandConfigEnv(raw)
+	problems = append(problems, envProblems...)
+	raw, fileProblems := applyConfigFile(raw)
+	problems = append(problems, fileProblems...)
+	cfg := defaultConfig()
+	problems = append(problems, decodeConfig(raw, cfg)...)
+
+	if cfg.GitHubToken == "" {
+		cfg.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	}
+	if cfg.RESTSource.APIKey == "" {
+		cfg.RESTSource.APIKey = os.Getenv("WINGET_REST_API_KEY")
+	}
+	if cfg.Backend == backendAzureDevOps && cfg.AzureDevOps.Token == "" {
+		cfg.AzureDevOps.Token = cmp.Or(os.Getenv("AZURE_DEVOPS_EXT_PAT"), os.Getenv("SYSTEM_ACCESSTOKEN"))
+	}
+	if cfg.Backend == backendGitLab && cfg.GitLab.Token == "" {
+		cfg.GitLab.Token = os.Getenv("GITLAB_TOKEN")
+	}
+	if cfg.RESTSource.AzureAD.TenantID != "" && cfg.RESTSource.AzureAD.ClientSecret == "" {
+		cfg.RESTSource.AzureAD.ClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+	}
+	if cfg.Scan.Scanner == scannerVirusTotal && cfg.Scan.VirusTotalAPIKey == "" {
+		cfg.Scan.VirusTotalAPIKey = os.Getenv("VIRUSTOTAL_API_KEY")
+	}
+	if cfg.RunID == "" {
+		cfg.RunID = cmp.Or(os.Getenv("GITHUB_RUN_ID"), os.Getenv("CI_PIPELINE_ID"), os.Getenv("BUILD_BUILDID"))
+	}
+	userAgent, err := renderUserAgent(cfg)
+	if err != nil {
+		problems = append(problems, fieldError{"user_agent", err.Error()})
+	}
+	cfg.UserAgent = userAgent
+	cfg.PullRequest.branchPrefix = branchTemplatePrefix(cfg.PullRequest.Branch)
+	applyProfile(cfg)
+	applyArchitectureAliases(cfg.Installers, cfg.ArchitectureAliases)
+	installers, archProblems := expandInstallerArchitectures(cfg.Installers)
+	problems = append(problems, archProblems...)
+	installers, scopeProblems := expandInstallerScopes(installers)
+	cfg.Installers = installers
+	problems = append(problems, scopeProblems...)
+	applyDefaultSwitches(cfg)
+	if cfg.PullRequest.Title == "" {
+		cfg.PullRequest.Title = prTitlePresets[cfg.PullRequest.TitlePreset]
+	}
+
+	// Canonicalize well-formed GUIDs; malformed ones are reported by Validate
+	for i := range cfg.Installers {
+		installer := &cfg.Installers[i]
+		if guidProductCodeTypes[strings.ToLower(installer.Type)] {
+			if guid, err := normalizeGUID(installer.ProductCode); err == nil {
+				installer.ProductCode = guid
+			}
 		}
-		if copyright, ok := metaRaw["copyright"].(string); ok {
-			metadata.Copyright = copyright
+		if guid, err := normalizeGUID(installer.UpgradeCode); err == nil {
+			installer.UpgradeCode = guid
 		}
-		if pkgURL, ok := metaRaw["package_url"].(string); ok {
-			metadata.PackageURL = pkgURL
+	}
+
+	return cfg, problems
+}
+
+// packageVersionFor returns the manifest PackageVersion of a release: the
+// package_version template rendered for the release, normalized if enabled
+// and passed through the package version transform.
+func packageVersionFor(cfg *Config, releaseCtx *plugin.ReleaseContext) (string, error) {
+	version := releaseCtx.Version
+	if cfg.PackageVersion != "" {
+		rendered, err := renderTemplate(cfg.PackageVersion, releaseTemplateData(releaseCtx, cfg))
+		if err != nil {
+			return "", fmt.Errorf("package_version: %w", err)
 		}
-		if moniker, ok := metaRaw["moniker"].(string); ok {
-			metadata.Moniker = moniker
+		version = strings.TrimSpace(rendered)
+	}
+	if cfg.NormalizeVersion {
+		version = normalizePackageVersion(version)
+	}
+	return cfg.VersionTransforms.Package.Apply(version), nil
+}
+
+// applyDefaultSwitches merges the default switches of each installer's type
+// under its own switches. An empty switch value removes a default.
+func applyDefaultSwitches(cfg *Config) {
+	for i := range cfg.Installers {
+		installer := &cfg.Installers[i]
+		defaults := cfg.DefaultSwitches[strings.ToLower(installer.Type)]
+		if len(defaults) == 0 && len(installer.Switches) == 0 {
+			continue
 		}
-		if releaseURL, ok := metaRaw["release_notes_url"].(string); ok {
-			metadata.ReleaseNotesURL = releaseURL
+
+		switches := make(map[string]string, len(defaults)+len(installer.Switches))
+		for key, value := range defaults {
+			switches[key] = value
 		}
-		if tags, ok := metaRaw["tags"].([]any); ok {
-			for _, t := range tags {
-				if s, ok := t.(string); ok {
-					metadata.Tags = append(metadata.Tags, s)
-				}
+		for key, value := range installer.Switches {
+			if value == "" {
+				delete(switches, key)
+				continue
 			}
+			switches[key] = value
 		}
+		installer.Switches = switches
 	}
+}
 
-	// Parse locales
-	var locales []LocaleConfig
-	if localesRaw, ok := raw["locales"].([]any); ok {
-		for _, item := range localesRaw {
-			if m, ok := item.(map[string]any); ok {
-				locale := LocaleConfig{}
-				if l, ok := m["locale"].(string); ok {
-					locale.Locale = l
-				}
-				if d, ok := m["description"].(string); ok {
-					locale.Description = d
-				}
-				locales = append(locales, locale)
-			}
-		}
+// readsInstallerFile reports whether an installer is read once downloaded,
+// for its MSIX package identity, its Inno Setup or NSIS metadata, or its Burn
+// bundle codes.
+func readsInstallerFile(installer InstallerConfig) bool {
+	return isMSIXInstaller(installer) || exeMetadataTypes[installer.Type] || burnTypes[installer.Type]
+}
+
+// newDownloader creates an installer downloader with the configured buffer
+// size that keeps downloads in cacheDir, if set. Downloaders share one pooled
+// HTTP client.
+func newDownloader(cfg *Config, cacheDir string) *installerhash.Downloader {
+	opts := []installerhash.Option{installerhash.WithBufferSize(cfg.Download.BufferSize), installerhash.WithUserAgent(cfg.UserAgent)}
+	if cacheDir != "" {
+		opts = append(opts, installerhash.WithCacheDir(cacheDir))
 	}
+	return installerhash.NewDownloader(opts...)
+}
 
-	// Parse PR config
-	prConfig := PRConfig{
-		BaseBranch:   "master",
-		Title:        "New version: {{.PackageId}} version {{.Version}}",
-		DeleteBranch: true,
+// installerHashOutput returns the installer_hashes entry of a downloaded
+// installer: its hash and size, and the final URL and validators of the
+// download, which prove which version of the file was hashed if the content
+// behind the URL changes later.
+func installerHashOutput(hash string, stats installerhash.Stats) map[string]any {
+	entry := map[string]any{
+		"sha256":    hash,
+		"size":      stats.Size,
+		"final_url": stats.FinalURL,
 	}
-	if prRaw, ok := raw["pull_request"].(map[string]any); ok {
-		if forkOwner, ok := prRaw["fork_owner"].(string); ok {
-			prConfig.ForkOwner = forkOwner
-		}
-		if baseBranch, ok := prRaw["base_branch"].(string); ok {
-			prConfig.BaseBranch = baseBranch
-		}
-		if title, ok := prRaw["title"].(string); ok {
-			prConfig.Title = title
-		}
-		if deleteBranch, ok := prRaw["delete_branch"].(bool); ok {
-			prConfig.DeleteBranch = deleteBranch
-		}
+	if stats.ETag != "" {
+		entry["etag"] = stats.ETag
+	}
+	if stats.LastModified != "" {
+		entry["last_modified"] = stats.LastModified
 	}
+	return entry
+}
 
-	return &Config{
-		PackageID:   parser.GetString("package_id", "", ""),
-		GitHubToken: parser.GetString("github_token", "GITHUB_TOKEN", ""),
-		Installers:  installers,
-		Metadata:    metadata,
-		Locales:     locales,
-		PullRequest: prConfig,
-		Validate:    parser.GetBool("validate", true),
-		TestInstall: parser.GetBool("test_install", false),
-		DryRun:      parser.GetBool("dry_run", false),
+// newGitHubClient creates a GitHub client for the plugin's API base URL with
+// the configured request timeout and retry policies, counting its requests in
+// the metrics of the execution running in ctx.
+func newGitHubClient(ctx context.Context, cfg *Config, forkOwner string, logger *slog.Logger) *githubclient.Client {
+	opts := []githubclient.Option{
+		githubclient.WithBaseURL(githubAPIBase),
+		githubclient.WithHTTPClient(&http.Client{Timeout: cfg.Timeouts.GitHubRequest}),
+		githubclient.WithRetryPolicy(githubclient.RetryPolicy{
+			MaxRetries: cfg.GitHubRetry.MaxRetries,
+			Backoff:    cfg.GitHubRetry.Backoff,
+		}),
+		githubclient.WithRateLimitPolicy(githubclient.RateLimitPolicy{
+			MaxRetries: cfg.GitHubRetry.RateLimitRetries,
+			MaxWait:    cfg.GitHubRetry.RateLimitMaxWait,
+		}),
+		githubclient.WithLogger(logger),
+		githubclient.WithUserAgent(cfg.UserAgent),
+	}
+	if metrics := runMetricsFrom(ctx); metrics != nil {
+		opts = append(opts, githubclient.WithRequestCounter(&metrics.githubRequests))
+	}
+	if cfg.PullRequest.CommitStrategy == commitStrategyGit {
+		opts = append(opts, githubclient.WithCommitter(&gitCommitter{
+			host:       gitHostURL(githubAPIBase),
+			token:      cfg.GitHubToken,
+			signingKey: cfg.PullRequest.SigningKey,
+		}))
 	}
+	if cfg.PullRequest.IdentityCache != "" {
+		if identity, ok := loadIdentityCache(cfg.PullRequest.IdentityCache, cfg.GitHubToken, time.Now()); ok {
+			logger.Debug("Using cached GitHub identity", "user", identity.User, "fork_exists", identity.ForkExists)
+			opts = append(opts, githubclient.WithIdentity(identity))
+		}
+	}
+	return githubclient.New(cfg.GitHubToken, forkOwner, opts...)
 }
 
-// isValidPackageID checks if a package ID is in valid format.
-func isValidPackageID(id string) bool {
-	if id == "" {
-		return false
+// manifestPackage returns the package metadata configured for the manifests.
+func manifestPackage(cfg *Config) manifest.Package {
+	pkg := manifest.Package{
+		Identifier:          cfg.PackageID,
+		Publisher:           cfg.Metadata.Publisher,
+		PublisherURL:        cfg.Metadata.PublisherURL,
+		PublisherSupportURL: cfg.Metadata.PublisherSupportURL,
+		Name:                cfg.Metadata.Name,
+		License:             cfg.Metadata.License,
+		LicenseURL:          cfg.Metadata.LicenseURL,
+		Copyright:           cfg.Metadata.Copyright,
+		ShortDescription:    cfg.Metadata.ShortDescription,
+		Moniker:             cfg.Metadata.Moniker,
+		Tags:                cfg.Metadata.Tags,
+		PackageURL:          cfg.Metadata.PackageURL,
+		ReleaseNotes:        cfg.Metadata.ReleaseNotes,
+		ReleaseNotesURL:     cfg.Metadata.ReleaseNotesURL,
+		InstallationNotes:   cfg.Metadata.InstallationNotes,
 	}
-	parts := strings.SplitN(id, ".", 2)
-	if len(parts) != 2 {
-		return false
+
+	for _, locale := range cfg.Locales {
+		agreements := make([]manifest.Agreement, len(locale.Agreements))
+		for i, agreement := range locale.Agreements {
+			agreements[i] = manifest.Agreement{AgreementLabel: agreement.Label, Agreement: agreement.Text, AgreementURL: agreement.URL}
+		}
+		pkg.Locales = append(pkg.Locales, manifest.Locale{
+			Locale:            locale.Locale,
+			Publisher:         locale.Publisher,
+			Name:              locale.Name,
+			ShortDescription:  locale.ShortDescription,
+			Description:       locale.Description,
+			Tags:              locale.Tags,
+			Agreements:        agreements,
+			ReleaseNotes:      locale.ReleaseNotes,
+			ReleaseNotesURL:   locale.ReleaseNotesURL,
+			InstallationNotes: locale.InstallationNotes,
+		})
 	}
-	return parts[0] != "" && parts[1] != ""
+
+	return pkg
 }
 
-// isValidArchitecture checks if architecture is valid.
+// isValidArchitecture checks if architecture is valid for the current
+// manifest version.
 func isValidArchitecture(arch string) bool {
-	switch arch {
-	case "x86", "x64", "arm", "arm64":
-		return true
+	enums, _ := manifest.EnumsFor(manifest.SchemaVersion)
+	return slices.Contains(enums.Architectures, arch)
+}
+
+// parseDuration parses a duration given as a Go duration string ("5m") or a
+// number of seconds.
+func parseDuration(v any) (time.Duration, bool) {
+	switch d := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			return 0, false
+		}
+		return parsed, true
+	case float64:
+		return time.Duration(d * float64(time.Second)), true
+	case int:
+		return time.Duration(d) * time.Second, true
 	default:
-		return false
+		return 0, false
 	}
 }
 
-// renderTemplate renders a simple template with placeholders.
-func renderTemplate(tmpl string, data map[string]string) string {
-	result := tmpl
-	for key, value := range data {
-		result = strings.ReplaceAll(result, "{{."+key+"}}", value)
+// withOptionalTimeout derives a context with a timeout, or a plain cancelable
+// context when the timeout is zero.
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
 	}
-	return result
+	return context.WithTimeout(ctx, timeout)
 }
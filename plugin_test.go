@@ -1,9 +1,23 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/plugin-winget/manifest"
 )
 
 func TestGetInfo(t *testing.T) {
@@ -18,13 +32,21 @@ func TestGetInfo(t *testing.T) {
 		t.Errorf("expected version '%s', got '%s'", Version, info.Version)
 	}
 
-	if len(info.Hooks) != 1 {
-		t.Errorf("expected 1 hook, got %d", len(info.Hooks))
+	if len(info.Hooks) != 3 {
+		t.Fatalf("expected 3 hooks, got %d", len(info.Hooks))
 	}
 
 	if info.Hooks[0] != plugin.HookPostPublish {
 		t.Error("expected PostPublish hook")
 	}
+
+	if info.Hooks[1] != plugin.HookOnSuccess {
+		t.Error("expected OnSuccess hook")
+	}
+
+	if info.Hooks[2] != plugin.HookOnError {
+		t.Error("expected OnError hook")
+	}
 }
 
 func TestParseConfig(t *testing.T) {
@@ -83,6 +105,36 @@ func TestParseConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "normalizes GUIDs",
+			raw: map[string]any{
+				"package_id": "MyOrg.MyApp",
+				"installers": []any{
+					map[string]any{
+						"url":          "https://example.com/app.msi",
+						"type":         "msi",
+						"product_code": "0123abcd-89ab-cdef-0123-456789abcdef",
+						"upgrade_code": "{fedcba98-7654-3210-fedc-ba9876543210}",
+					},
+					map[string]any{
+						"url":          "https://example.com/app.exe",
+						"type":         "inno",
+						"product_code": "MyApp_is1",
+					},
+				},
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				if cfg.Installers[0].ProductCode != "{0123ABCD-89AB-CDEF-0123-456789ABCDEF}" {
+					t.Errorf("expected normalized product code, got '%s'", cfg.Installers[0].ProductCode)
+				}
+				if cfg.Installers[0].UpgradeCode != "{FEDCBA98-7654-3210-FEDC-BA9876543210}" {
+					t.Errorf("expected normalized upgrade code, got '%s'", cfg.Installers[0].UpgradeCode)
+				}
+				if cfg.Installers[1].ProductCode != "MyApp_is1" {
+					t.Errorf("expected inno product code unchanged, got '%s'", cfg.Installers[1].ProductCode)
+				}
+			},
+		},
 		{
 			name: "with metadata",
 			raw: map[string]any{
@@ -112,6 +164,26 @@ func TestParseConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "with normalized tags",
+			raw: map[string]any{
+				"package_id": "MyOrg.MyApp",
+				"metadata": map[string]any{
+					"tags":           []any{"Developer Tools", "CLI", "cli"},
+					"normalize_tags": true,
+				},
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				// Tags are normalized right before manifest generation
+				if strings.Join(cfg.Metadata.Tags, ",") != "Developer Tools,CLI,cli" {
+					t.Errorf("expected the configured tags, got %v", cfg.Metadata.Tags)
+				}
+				canonicalizeConfigTags(cfg, nil, slog.New(slog.DiscardHandler))
+				if strings.Join(cfg.Metadata.Tags, ",") != "cli,developer-tools" {
+					t.Errorf("expected normalized tags, got %v", cfg.Metadata.Tags)
+				}
+			},
+		},
 		{
 			name: "with locales",
 			raw: map[string]any{
@@ -140,10 +212,11 @@ func TestParseConfig(t *testing.T) {
 			raw: map[string]any{
 				"package_id": "MyOrg.MyApp",
 				"pull_request": map[string]any{
-					"fork_owner":    "myuser",
-					"base_branch":   "main",
-					"title":         "Custom title: {{.PackageId}}",
-					"delete_branch": false,
+					"fork_owner":       "myuser",
+					"base_branch":      "main",
+					"title":            "Custom title: {{.PackageId}}",
+					"delete_branch":    false,
+					"cleanup_branches": true,
 				},
 			},
 			validate: func(t *testing.T, cfg *Config) {
@@ -159,6 +232,9 @@ func TestParseConfig(t *testing.T) {
 				if cfg.PullRequest.DeleteBranch {
 					t.Errorf("delete_branch should be false")
 				}
+				if !cfg.PullRequest.CleanupBranches {
+					t.Errorf("cleanup_branches should be true")
+				}
 			},
 		},
 		{
@@ -175,6 +251,51 @@ func TestParseConfig(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "with timeouts",
+			raw: map[string]any{
+				"package_id": "MyOrg.MyApp",
+				"timeouts": map[string]any{
+					"download": "2m",
+					"github":   float64(90),
+					"execute":  "0s",
+				},
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				if cfg.Timeouts.Download != 2*time.Minute {
+					t.Errorf("expected download timeout 2m, got %s", cfg.Timeouts.Download)
+				}
+				if cfg.Timeouts.GitHub != 90*time.Second {
+					t.Errorf("expected github timeout 90s, got %s", cfg.Timeouts.GitHub)
+				}
+				if cfg.Timeouts.Execute != 0 {
+					t.Errorf("expected execute timeout disabled, got %s", cfg.Timeouts.Execute)
+				}
+			},
+		},
+		{
+			name: "default timeouts",
+			raw: map[string]any{
+				"package_id": "MyOrg.MyApp",
+			},
+			validate: func(t *testing.T, cfg *Config) {
+				if cfg.Timeouts.Download != 30*time.Minute {
+					t.Errorf("expected default download timeout 30m, got %s", cfg.Timeouts.Download)
+				}
+				if cfg.Timeouts.GitHub != 5*time.Minute {
+					t.Errorf("expected default github timeout 5m, got %s", cfg.Timeouts.GitHub)
+				}
+				if cfg.Timeouts.GitHubRequest != 60*time.Second {
+					t.Errorf("expected default github request timeout 60s, got %s", cfg.Timeouts.GitHubRequest)
+				}
+				if cfg.Timeouts.CLI != 35*time.Minute {
+					t.Errorf("expected default cli timeout 35m, got %s", cfg.Timeouts.CLI)
+				}
+				if cfg.GitHubRetry.MaxRetries != 0 || cfg.GitHubRetry.Backoff != time.Second {
+					t.Errorf("expected no retries with 1s backoff, got %+v", cfg.GitHubRetry)
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -219,6 +340,7 @@ func TestIsValidArchitecture(t *testing.T) {
 		{"x64", true},
 		{"arm", true},
 		{"arm64", true},
+		{"neutral", true},
 		{"", false},
 		{"amd64", false},
 		{"i386", false},
@@ -234,45 +356,1075 @@ func TestIsValidArchitecture(t *testing.T) {
 	}
 }
 
-func TestRenderTemplate(t *testing.T) {
+func TestWithOptionalTimeout(t *testing.T) {
+	ctx, cancel := withOptionalTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline for zero timeout")
+	}
+
+	ctx, cancel = withOptionalTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("expected deadline for positive timeout")
+	}
+}
+
+// validTestConfig returns a raw plugin configuration that passes validation.
+func validTestConfig() map[string]any {
+	return map[string]any{
+		"package_id":   "MyOrg.MyApp",
+		"github_token": "test-token",
+		"installers": []any{
+			map[string]any{
+				"url":          "https://example.com/app-{{.Version}}.msi",
+				"architecture": "x64",
+				"type":         "msi",
+			},
+		},
+		"metadata": map[string]any{
+			"publisher":         "My Org",
+			"name":              "My App",
+			"short_description": "A test app",
+			"license":           "MIT",
+		},
+		// Test servers serve tiny installers
+		"download": map[string]any{"min_size": 0},
+	}
+}
+
+// validTestManifests returns schema-valid manifests with one x64 msi installer.
+func validTestManifests(t *testing.T) *manifest.Set {
+	t.Helper()
+
+	pkg := manifest.Package{
+		Identifier:       "MyOrg.MyApp",
+		Publisher:        "My Organization",
+		PublisherURL:     "https://myorg.com",
+		Name:             "My Application",
+		ShortDescription: "A useful application",
+		License:          "MIT",
+		Tags:             []string{"utility"},
+	}
+
+	installers := []manifest.Installer{
+		{
+			Architecture:      "x64",
+			InstallerType:     "msi",
+			InstallerURL:      "https://example.com/myapp-1.0.0-x64.msi",
+			InstallerSha256:   strings.Repeat("A", 64),
+			Scope:             "machine",
+			InstallerSwitches: map[string]string{"Silent": "/quiet"},
+		},
+	}
+
+	manifests, err := manifest.Generate(pkg, "1.0.0", installers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return manifests
+}
+
+// hasValidationError reports whether resp contains an error for field.
+func hasValidationError(resp *plugin.ValidateResponse, field string) bool {
+	for _, e := range resp.Errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	resp, err := p.Validate(context.Background(), validTestConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected valid config, got errors: %v", resp.Errors)
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	cfg := validTestConfig()
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected warnings not to invalidate config, got: %v", resp.Errors)
+	}
+	if !hasValidationError(resp, "metadata.publisher_url") {
+		t.Errorf("expected publisher_url warning, got: %v", resp.Errors)
+	}
+	for _, e := range resp.Errors {
+		if e.Code != validationCodeWarning {
+			t.Errorf("expected only warnings, got %v", e)
+		}
+	}
+
+	cfg["strict"] = true
+	resp, err = p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Valid {
+		t.Error("expected strict mode to promote warnings to errors")
+	}
+	if !hasValidationError(resp, "metadata.publisher_url") {
+		t.Errorf("expected publisher_url error, got: %v", resp.Errors)
+	}
+}
+
+func TestValidateURLCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/app-1.2.3.msi" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		url       string
+		version   string
+		expectErr bool
+	}{
+		{"reachable", server.URL + "/app-{{.Version}}.msi", "1.2.3", false},
+		{"broken template", server.URL + "/app-v{{.Version}}.msi", "1.2.3", true},
+		{"missing version", server.URL + "/app-{{.Version}}.msi", "", true},
+	}
+
+	p := &WinGetPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg["installers"].([]any)[0].(map[string]any)["url"] = tt.url
+			cfg["url_check"] = map[string]any{"enabled": true, "version": tt.version}
+			cfg["allow_insecure_urls"] = true
+
+			resp, err := p.Validate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hasValidationError(resp, "installers[0].url") != tt.expectErr {
+				t.Errorf("expected URL error %v, got errors: %v", tt.expectErr, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateOutputDirWithoutPR(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	cfg := validTestConfig()
+	delete(cfg, "github_token")
+	t.Setenv("GITHUB_TOKEN", "")
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasValidationError(resp, "output_dir") {
+		t.Errorf("expected output_dir error, got: %v", resp.Errors)
+	}
+	if hasValidationError(resp, "github_token") {
+		t.Errorf("expected no token error without PR, got: %v", resp.Errors)
+	}
+
+	cfg["output_dir"] = t.TempDir()
+	resp, err = p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected valid config, got errors: %v", resp.Errors)
+	}
+}
+
+func TestExecuteOutputDir(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["locales"] = []any{map[string]any{
+		"locale":     "de-DE",
+		"tags":       []any{"werkzeug"},
+		"agreements": []any{map[string]any{"label": "Lizenz", "url": "https://example.com/de/eula"}},
+	}}
+
+	p := &WinGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	if resp.Outputs["install_command"] != "winget install --id MyOrg.MyApp -v 1.2.3" {
+		t.Errorf("unexpected install_command %v", resp.Outputs["install_command"])
+	}
+	if resp.Outputs["manifest_path"] != "manifests/m/MyOrg/MyApp/1.2.3" {
+		t.Errorf("unexpected manifest_path %v", resp.Outputs["manifest_path"])
+	}
+
+	dir := filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3")
+	if resp.Outputs["manifest_dir"] != dir {
+		t.Errorf("expected manifest_dir '%s', got '%v'", dir, resp.Outputs["manifest_dir"])
+	}
+	for _, name := range []string{"MyOrg.MyApp.yaml", "MyOrg.MyApp.installer.yaml", "MyOrg.MyApp.locale.en-US.yaml", "MyOrg.MyApp.locale.de-DE.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+}
+
+func TestExecuteSkipsOlderVersions(t *testing.T) {
+	var listed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp":
+			listed = true
+			_, _ = w.Write([]byte(`[{"name":"1.2.3","type":"dir"},{"name":"1.10.0","type":"dir"}]`))
+		case "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp/1.2.3", "/repos/microsoft/winget-pkgs/contents/manifests/m/MyOrg/MyApp/1.10.0":
+			_, _ = w.Write([]byte(`[{"name":"MyOrg.MyApp.yaml","type":"file"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	for _, version := range []string{"1.2.3", "1.9.0"} {
+		cfg := validTestConfig()
+		cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+
+		resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  cfg,
+			Context: plugin.ReleaseContext{Version: version},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Success || resp.Outputs["skipped"] != true || resp.Outputs["published_version"] != "1.10.0" {
+			t.Errorf("%s: expected the submission to be skipped, got %v: %s", version, resp.Outputs, resp.Message)
+		}
+	}
+
+	// allow_older_version submits anyway, which fails here on the download
+	listed = false
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+	cfg["allow_older_version"] = true
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || resp.Outputs["skipped"] != nil {
+		t.Errorf("expected the submission to go ahead, got %v: %s", resp.Outputs, resp.Message)
+	}
+	if listed {
+		t.Error("expected published versions not to be listed with allow_older_version")
+	}
+}
+
+func TestExecuteCleansUpBranchesFirst(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/myuser/winget-pkgs/git/matching-refs/heads/winget/":
+			_, _ = w.Write([]byte(`[{"ref":"refs/heads/winget/MyOrg.MyApp/1.0.0"}]`))
+		case r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			_, _ = w.Write([]byte(`[{"state":"closed"}]`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	// The download fails, after the cleanup
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+	cfg["allow_older_version"] = true
+	cfg["pull_request"] = map[string]any{"fork_owner": "myuser", "cleanup_branches": true}
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success {
+		t.Fatalf("expected the download to fail, got: %s", resp.Message)
+	}
+	if len(deleted) != 1 || deleted[0] != "/repos/myuser/winget-pkgs/git/refs/heads/winget/MyOrg.MyApp/1.0.0" {
+		t.Errorf("expected the stale branch to be deleted before the download, got %v", deleted)
+	}
+}
+
+func TestExecuteDownloadCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["download"] = map[string]any{"cache_dir": cacheDir, "min_size": 0}
+
+	p := &WinGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	files, _ := resp.Outputs["installer_files"].(map[string]string)
+	want := filepath.Join(cacheDir, installerhash.FromBytes([]byte("installer")), "app.msi")
+	if files[server.URL+"/app.msi"] != want {
+		t.Fatalf("expected installer file %s, got %v", want, resp.Outputs["installer_files"])
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "installer" {
+		t.Errorf("unexpected cached installer: %q, %v", data, err)
+	}
+
+	hashes, _ := resp.Outputs["installer_hashes"].(map[string]any)
+	expected := map[string]any{"sha256": installerhash.FromBytes([]byte("installer")), "size": int64(len("installer")), "final_url": server.URL + "/app.msi"}
+	if !reflect.DeepEqual(hashes[server.URL+"/app.msi"], expected) {
+		t.Errorf("expected installer hash %v, got %v", expected, resp.Outputs["installer_hashes"])
+	}
+}
+
+func TestExecuteFlagsUncorrelatedInstallers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not a known installer"))
+	}))
+	defer server.Close()
+
+	for _, strict := range []bool{false, true} {
+		t.Run(strconv.FormatBool(strict), func(t *testing.T) {
+			cfg := validTestConfig()
+			installerCfg := cfg["installers"].([]any)[0].(map[string]any)
+			installerCfg["url"] = server.URL + "/setup.exe"
+			installerCfg["type"] = "exe"
+			cfg["output_dir"] = t.TempDir()
+			cfg["pull_request"] = map[string]any{"enabled": false}
+			cfg["download"] = map[string]any{"min_size": 0}
+			cfg["strict"] = strict
+
+			resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if strict {
+				if resp.Success || !strings.Contains(resp.Message, "installers[0].product_code") {
+					t.Errorf("expected strict mode to fail on the finding, got: %s", resp.Message)
+				}
+				return
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Message)
+			}
+			warnings, _ := resp.Outputs["warnings"].([]map[string]any)
+			if len(warnings) != 1 || warnings[0]["field"] != "installers[0].product_code" {
+				t.Errorf("expected a product_code warning, got %v", resp.Outputs["warnings"])
+			}
+		})
+	}
+}
+
+func TestExecuteValidateWithWinget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	execute := func() *plugin.ExecuteResponse {
+		cfg := validTestConfig()
+		cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+		cfg["output_dir"] = t.TempDir()
+		cfg["pull_request"] = map[string]any{"enabled": false}
+		cfg["download"] = map[string]any{"min_size": 0}
+		cfg["validate_with_winget"] = true
+		resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+			Hook:    plugin.HookPostPublish,
+			Config:  cfg,
+			Context: plugin.ReleaseContext{Version: "1.2.3"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	original := wingetCommand
+	wingetCommand = "winget-does-not-exist"
+	defer func() { wingetCommand = original }()
+
+	// Without winget, wingetcreate validates the manifests
+	fakeWingetcreate(t, `echo "wingetcreate $1"`)
+	resp := execute()
+	if !resp.Success || resp.Outputs["winget_validate_output"] != "wingetcreate validate" {
+		t.Errorf("expected validation with wingetcreate, got %v: %s", resp.Outputs["winget_validate_output"], resp.Message)
+	}
+
+	// Without either CLI, the run fails
+	wingetcreateCommand = "wingetcreate-does-not-exist"
+	resp = execute()
+	if resp.Success || !strings.Contains(resp.Message, "requires winget or wingetcreate") {
+		t.Errorf("expected validation to fail without winget and wingetcreate, got: %s", resp.Message)
+	}
+}
+
+func TestExecuteDryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/repos/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
 	tests := []struct {
 		name     string
-		tmpl     string
-		data     map[string]string
+		hashMode string
 		expected string
 	}{
-		{
-			name:     "simple version",
-			tmpl:     "https://example.com/app-{{.Version}}.msi",
-			data:     map[string]string{"Version": "1.0.0"},
-			expected: "https://example.com/app-1.0.0.msi",
-		},
-		{
-			name:     "multiple placeholders",
-			tmpl:     "{{.PackageId}} version {{.Version}}",
-			data:     map[string]string{"PackageId": "MyOrg.MyApp", "Version": "2.0.0"},
-			expected: "MyOrg.MyApp version 2.0.0",
-		},
-		{
-			name:     "no placeholders",
-			tmpl:     "https://example.com/app.msi",
-			data:     map[string]string{"Version": "1.0.0"},
-			expected: "https://example.com/app.msi",
+		{"placeholder hash", "", strings.Repeat("0", 64)},
+		{"real hash", "real", installerhash.FromBytes([]byte("installer"))},
+	}
+
+	p := &WinGetPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+			cfg["dry_run_hash"] = tt.hashMode
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3"},
+				DryRun:  true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("expected success, got: %s", resp.Message)
+			}
+
+			if resp.Outputs["branch_name"] != "winget/MyOrg-MyApp/1.2.3" {
+				t.Errorf("unexpected branch name: %v", resp.Outputs["branch_name"])
+			}
+			if resp.Outputs["pr_title"] != "New version: MyOrg.MyApp version 1.2.3" {
+				t.Errorf("unexpected PR title: %v", resp.Outputs["pr_title"])
+			}
+			if files, _ := resp.Outputs["files"].([]string); len(files) != 3 {
+				t.Errorf("expected 3 files, got %v", resp.Outputs["files"])
+			}
+
+			dir, _ := resp.Outputs["manifest_dir"].(string)
+			defer func() { _ = os.RemoveAll(filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(dir))))) }()
+			installer, err := os.ReadFile(filepath.Join(dir, "MyOrg.MyApp.installer.yaml"))
+			if err != nil {
+				t.Fatalf("expected installer manifest in %s: %v", dir, err)
+			}
+			if !strings.Contains(string(installer), tt.expected) {
+				t.Errorf("expected hash %s in installer manifest:\n%s", tt.expected, installer)
+			}
+		})
+	}
+}
+
+func TestValidateInstallerAsset(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry map[string]any
+		field string
+	}{
+		{"asset only", map[string]any{"asset": "*-x64.msi"}, ""},
+		{"url and asset", map[string]any{"asset": "*-x64.msi", "url": "https://example.com/app.msi"}, "installers[0].asset"},
+		{"invalid pattern", map[string]any{"asset": "[-x64.msi"}, "installers[0].asset"},
+		{"neither", map[string]any{}, "installers[0].url"},
+	}
+
+	p := &WinGetPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			installer := map[string]any{"architecture": "x64", "type": "msi"}
+			for k, v := range tt.entry {
+				installer[k] = v
+			}
+			cfg["installers"] = []any{installer}
+
+			resp, err := p.Validate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.field == "" {
+				if !resp.Valid {
+					t.Errorf("expected valid config, got errors: %v", resp.Errors)
+				}
+			} else if !hasValidationError(resp, tt.field) {
+				t.Errorf("expected error for %s, got: %v", tt.field, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestExecuteOptionalInstaller(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "arm64") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		optional      bool
+		minInstallers int
+		success       bool
+	}{
+		{"optional missing", true, 1, true},
+		{"required missing", false, 1, false},
+		{"below minimum", true, 2, false},
+	}
+
+	p := &WinGetPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			cfg["installers"] = []any{
+				map[string]any{"url": server.URL + "/app-x64.msi", "architecture": "x64", "type": "msi"},
+				map[string]any{"url": server.URL + "/app-arm64.msi", "architecture": "arm64", "type": "msi", "optional": tt.optional},
+			}
+			cfg["min_installers"] = tt.minInstallers
+			cfg["output_dir"] = t.TempDir()
+			cfg["pull_request"] = map[string]any{"enabled": false}
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookPostPublish,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Success != tt.success {
+				t.Fatalf("expected success %v, got: %s", tt.success, resp.Message)
+			}
+			if tt.success {
+				skipped, _ := resp.Outputs["skipped_installers"].([]string)
+				if len(skipped) != 1 || !strings.HasSuffix(skipped[0], "/app-arm64.msi") {
+					t.Errorf("expected arm64 installer to be skipped, got %v", resp.Outputs["skipped_installers"])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMinInstallers(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	for _, min := range []int{0, 2} {
+		cfg := validTestConfig()
+		cfg["min_installers"] = min
+
+		resp, err := p.Validate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !hasValidationError(resp, "min_installers") {
+			t.Errorf("expected min_installers error for %d, got: %v", min, resp.Errors)
+		}
+	}
+}
+
+func TestExecuteOnError(t *testing.T) {
+	var deleted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/microsoft/winget-pkgs/pulls":
+			_, _ = w.Write([]byte(`[]`))
+		case r.Method == "DELETE":
+			deleted = r.URL.Path == "/repos/myuser/winget-pkgs/git/refs/heads/winget/MyOrg-MyApp/1.2.3"
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	submitted := submissionRecord{Version: "1.2.3", Outcome: outcomeSubmitted, Branch: "winget/MyOrg-MyApp/1.2.3", RunID: "42"}
+	resumed, otherRun := submitted, submitted
+	resumed.Resumed = true
+	otherRun.RunID = "41"
+
+	tests := []struct {
+		name     string
+		rollback bool
+		record   submissionRecord
+		expected bool
+	}{
+		{"disabled", false, submitted, false},
+		{"submitted by this run", true, submitted, true},
+		{"resumed by this run", true, resumed, false},
+		{"submitted by another run", true, otherRun, false},
+	}
+
+	p := &WinGetPlugin{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted = false
+			statePath := filepath.Join(t.TempDir(), "state.json")
+			state := &submissionState{}
+			state.add("MyOrg.MyApp", tt.record)
+			data, _ := json.Marshal(state)
+			if err := os.WriteFile(statePath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := validTestConfig()
+			cfg["pull_request"] = map[string]any{"fork_owner": "myuser", "rollback_on_error": tt.rollback}
+			cfg["state"] = map[string]any{"path": statePath}
+			cfg["run_id"] = "42"
+
+			resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+				Hook:    plugin.HookOnError,
+				Config:  cfg,
+				Context: plugin.ReleaseContext{Version: "1.2.3"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Errorf("expected success, got: %s", resp.Message)
+			}
+			if deleted != tt.expected {
+				t.Errorf("expected branch deleted %v, got %v", tt.expected, deleted)
+			}
+
+			// A rolled back submission is not rolled back twice
+			if tt.expected {
+				deleted = false
+				if _, err := p.Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookOnError, Config: cfg, Context: plugin.ReleaseContext{Version: "1.2.3"}}); err != nil || deleted {
+					t.Errorf("expected no second rollback, got deleted %v, error %v", deleted, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRollbackOnError(t *testing.T) {
+	p := &WinGetPlugin{}
+	for _, state := range []map[string]any{nil, {"path": "state.json"}} {
+		cfg := validTestConfig()
+		cfg["pull_request"] = map[string]any{"rollback_on_error": true}
+		if state != nil {
+			cfg["state"] = state
+		}
+		resp, err := p.Validate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hasValidationError(resp, "pull_request.rollback_on_error") != (state == nil) {
+			t.Errorf("state %v: unexpected errors %v", state, resp.Errors)
+		}
+	}
+}
+
+func TestValidateBackend(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	cfg := validTestConfig()
+	cfg["backend"] = "ftp"
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasValidationError(resp, "backend") {
+		t.Errorf("expected backend error, got: %v", resp.Errors)
+	}
+
+	cfg = validTestConfig()
+	cfg["backend"] = "wingetcreate"
+	cfg["wingetcreate"] = map[string]any{"mode": "replace"}
+	resp, err = p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasValidationError(resp, "wingetcreate.mode") {
+		t.Errorf("expected wingetcreate.mode error, got: %v", resp.Errors)
+	}
+
+	cfg = validTestConfig()
+	delete(cfg, "github_token")
+	t.Setenv("GITHUB_TOKEN", "")
+	cfg["backend"] = "rest"
+	cfg["rest_source"] = map[string]any{"url": "https://example.com/api", "api_key": "key"}
+	resp, err = p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected REST backend without GitHub token to be valid, got: %v", resp.Errors)
+	}
+}
+
+func TestApplyDefaultSwitches(t *testing.T) {
+	cfg := &Config{
+		DefaultSwitches: map[string]map[string]string{
+			"exe": {"Silent": "/S", "SilentWithProgress": "/S"},
 		},
-		{
-			name:     "missing placeholder",
-			tmpl:     "{{.Name}} {{.Missing}}",
-			data:     map[string]string{"Name": "Test"},
-			expected: "Test {{.Missing}}",
+		Installers: []InstallerConfig{
+			{Type: "exe"},
+			{Type: "EXE", Switches: map[string]string{"Silent": "/quiet", "SilentWithProgress": "", "Log": "/log"}},
+			{Type: "msi"},
 		},
 	}
+	applyDefaultSwitches(cfg)
+
+	expected := []map[string]string{
+		{"Silent": "/S", "SilentWithProgress": "/S"},
+		{"Silent": "/quiet", "Log": "/log"},
+		nil,
+	}
+	for i, switches := range expected {
+		if !reflect.DeepEqual(cfg.Installers[i].Switches, switches) {
+			t.Errorf("installer %d: expected switches %v, got %v", i, switches, cfg.Installers[i].Switches)
+		}
+	}
+	if cfg.DefaultSwitches["exe"]["SilentWithProgress"] != "/S" {
+		t.Error("expected default switches to be left unchanged")
+	}
+}
+
+func TestValidateDefaultSwitches(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	cfg := validTestConfig()
+	cfg["default_switches"] = map[string]any{
+		"exe":   map[string]any{"Silent": "/S"},
+		"setup": map[string]any{"Silent": "/S"},
+		"msi":   map[string]any{"silent": "/qn"},
+	}
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasValidationError(resp, "default_switches.exe") {
+		t.Errorf("unexpected default_switches.exe error: %v", resp.Errors)
+	}
+	for _, field := range []string{"default_switches.setup", "default_switches.msi"} {
+		if !hasValidationError(resp, field) {
+			t.Errorf("expected %s error, got: %v", field, resp.Errors)
+		}
+	}
+}
+
+func TestValidateRepairBehavior(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	for behavior, valid := range map[string]bool{"modify": true, "reinstall": false} {
+		cfg := validTestConfig()
+		installer := cfg["installers"].([]any)[0].(map[string]any)
+		installer["repair_behavior"] = behavior
+		installer["download_command_prohibited"] = true
+		installer["unsupported_arguments"] = []any{"log", "location"}
+		resp, err := p.Validate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hasValidationError(resp, "installers[0].repair_behavior") == valid {
+			t.Errorf("%s: unexpected validation result: %v", behavior, resp.Errors)
+		}
+	}
+}
+
+func TestPRBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       PRConfig
+		expected string
+	}{
+		{"no tracking issue", PRConfig{Body: "Automated update."}, "Automated update."},
+		{"reference", PRConfig{Body: "Automated update.\n", TrackingIssue: "myorg/myapp#123"}, "Automated update.\n\nRefs myorg/myapp#123"},
+		{"closing", PRConfig{Body: "Automated update.", TrackingIssue: "myorg/myapp#123", CloseTrackingIssue: true}, "Automated update.\n\nCloses myorg/myapp#123"},
+	}
+
+	for _, tt := range tests {
+		if result := prBody(tt.pr); result != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestBranchTemplatePrefix(t *testing.T) {
+	tests := []struct {
+		branch   string
+		expected string
+	}{
+		{`winget/{{.PackageId | replace "." "-"}}/{{.PackageVersion}}`, "winget/"},
+		{"releases/winget/{{.PackageVersion}}", "releases/winget/"},
+		{"winget/MyOrg-MyApp/1.2.3", "winget/MyOrg-MyApp/"},
+		{"winget-{{.PackageVersion}}", ""},
+		{"{{.PackageId}}/{{.PackageVersion}}", ""},
+	}
+
+	for _, tt := range tests {
+		if got := branchTemplatePrefix(tt.branch); got != tt.expected {
+			t.Errorf("branchTemplatePrefix(%q) = %q, expected %q", tt.branch, got, tt.expected)
+		}
+	}
+
+	cfg := validTestConfig()
+	cfg["pull_request"] = map[string]any{"branch": "{{.PackageId}}/{{.PackageVersion}}", "cleanup_branches": true}
+	resp, err := (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hasValidationError(resp, "pull_request.cleanup_branches") {
+		t.Errorf("expected a cleanup_branches error without a branch prefix, got %v", resp.Errors)
+	}
+}
+
+func TestPRBranch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("head") {
+		case "myuser:winget/MyOrg-MyApp/1.2.3-2", "myuser:winget/MyOrg-MyApp/1.2.3-3":
+			_, _ = w.Write([]byte(`[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7","state":"open"}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	cfg := &Config{PullRequest: PRConfig{ForkOwner: "myuser", Branch: "winget/MyOrg-MyApp/1.2.3", OnExistingBranch: branchPolicyNew}}
+	ghClient := newGitHubClient(context.Background(), cfg, cfg.PullRequest.ForkOwner, slog.New(slog.DiscardHandler))
+	branch, err := prBranch(context.Background(), ghClient, cfg.PullRequest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if branch != "winget/MyOrg-MyApp/1.2.3-3" {
+		t.Errorf("expected the latest attempt branch, got %s", branch)
+	}
+
+	cfg.PullRequest.OnExistingBranch = branchPolicyReuse
+	if branch, _ := prBranch(context.Background(), ghClient, cfg.PullRequest); branch != "winget/MyOrg-MyApp/1.2.3" {
+		t.Errorf("expected the configured branch, got %s", branch)
+	}
+}
+
+func TestValidatePRTitlePreset(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	for preset, valid := range map[string]bool{"new-version": true, "update": true, "changed": false} {
+		cfg := validTestConfig()
+		cfg["pull_request"] = map[string]any{"title_preset": preset}
+		resp, err := p.Validate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hasValidationError(resp, "pull_request.title_preset") == valid {
+			t.Errorf("%s: unexpected validation result: %v", preset, resp.Errors)
+		}
+	}
+}
+
+func TestValidateOnExistingBranch(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	for policy, valid := range map[string]bool{"reuse": true, "new": true, "overwrite": false} {
+		cfg := validTestConfig()
+		cfg["pull_request"] = map[string]any{"on_existing_branch": policy}
+		resp, err := p.Validate(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if hasValidationError(resp, "pull_request.on_existing_branch") == valid {
+			t.Errorf("%s: unexpected validation result: %v", policy, resp.Errors)
+		}
+	}
+}
+
+func TestValidateCommitStrategy(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	tests := []struct {
+		name      string
+		pr        map[string]any
+		wantField string
+	}{
+		{"api", map[string]any{"commit_strategy": "api"}, ""},
+		{"unknown", map[string]any{"commit_strategy": "svn"}, "pull_request.commit_strategy"},
+		{"signing without git", map[string]any{"signing_key": "3AA5C34371567BD2"}, "pull_request.signing_key"},
+	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := renderTemplate(tt.tmpl, tt.data)
-			if result != tt.expected {
-				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			cfg := validTestConfig()
+			cfg["pull_request"] = tt.pr
+			resp, err := p.Validate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantField == "" {
+				if !resp.Valid {
+					t.Errorf("unexpected errors: %v", resp.Errors)
+				}
+				return
+			}
+			if !hasValidationError(resp, tt.wantField) {
+				t.Errorf("expected an error for %s, got %v", tt.wantField, resp.Errors)
 			}
 		})
 	}
 }
+
+func TestValidateGitHubClientConfig(t *testing.T) {
+	p := &WinGetPlugin{}
+
+	tests := []struct {
+		name      string
+		timeouts  map[string]any
+		retry     map[string]any
+		wantField string
+	}{
+		{"valid", map[string]any{"github_request": "2m"}, map[string]any{"max_retries": float64(3), "backoff": "2s"}, ""},
+		{"negative request timeout", map[string]any{"github_request": "-1s"}, nil, "timeouts.github_request"},
+		{"too many retries", nil, map[string]any{"max_retries": float64(11)}, "github_retry.max_retries"},
+		{"negative retries", nil, map[string]any{"max_retries": float64(-1)}, "github_retry.max_retries"},
+		{"negative backoff", nil, map[string]any{"backoff": "-1s"}, "github_retry.backoff"},
+		{"too many rate limit retries", nil, map[string]any{"rate_limit_retries": float64(20)}, "github_retry.rate_limit_retries"},
+		{"negative rate limit wait", nil, map[string]any{"rate_limit_max_wait": "-1m"}, "github_retry.rate_limit_max_wait"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validTestConfig()
+			if tt.timeouts != nil {
+				cfg["timeouts"] = tt.timeouts
+			}
+			if tt.retry != nil {
+				cfg["github_retry"] = tt.retry
+			}
+			resp, err := p.Validate(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, field := range []string{
+				"timeouts.github_request",
+				"github_retry.max_retries",
+				"github_retry.backoff",
+				"github_retry.rate_limit_retries",
+				"github_retry.rate_limit_max_wait",
+			} {
+				if got := hasValidationError(resp, field); got != (field == tt.wantField) {
+					t.Errorf("%s error = %v, errors: %v", field, got, resp.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteExeProductCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	installer := cfg["installers"].([]any)[0].(map[string]any)
+	installer["url"] = server.URL + "/setup.exe"
+	installer["type"] = "exe"
+	installer["product_code"] = "MyApp"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "AppsAndFeaturesEntries:\n        - ProductCode: MyApp") {
+		t.Errorf("expected the product code in an Apps & Features entry, got:\n%s", content)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Preview renders the manifests of a release and plans their submission
+// without side effects, for UIs showing what a release will submit before
+// it runs. It runs a dry-run post-publish whose manifests are written to a
+// temporary directory, removed again, and whose installers are not scanned.
+// The preview output holds the manifest files with their winget-pkgs paths
+// and contents, the submission plan and the diff against the latest
+// published version.
+func (p *WinGetPlugin) Preview(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+	dir, err := os.MkdirTemp("", "winget-preview-")
+	if err != nil {
+		return failureResponse(categoryUnknown, "Failed to create preview directory: %v", err), nil
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	previewReq := req
+	previewReq.Hook = plugin.HookPostPublish
+	previewReq.DryRun = true
+	previewReq.Config = maps.Clone(req.Config)
+	previewReq.Config["output_dir"] = dir
+	delete(previewReq.Config, "scan")
+	resp, err := p.Execute(ctx, previewReq)
+	if err != nil || !resp.Success {
+		return resp, err
+	}
+
+	manifestPath, _ := resp.Outputs["manifest_path"].(string)
+	manifestDir, _ := resp.Outputs["manifest_dir"].(string)
+	files, err := readManifestDir(manifestDir)
+	if err != nil {
+		return failureResponse(categoryUnknown, "Failed to read previewed manifests: %v", err), nil
+	}
+	manifests := make([]any, 0, len(files))
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		manifests = append(manifests, map[string]any{
+			"path":    path.Join(manifestPath, name),
+			"content": files[name],
+		})
+	}
+
+	preview := map[string]any{
+		"version":         path.Base(manifestPath),
+		"manifest_path":   manifestPath,
+		"manifests":       manifests,
+		"submission":      resp.Outputs["submission_plan"],
+		"hashes_computed": resp.Outputs["hashes_computed"],
+		"install_command": resp.Outputs["install_command"],
+	}
+	for _, key := range []string{"published_version", "manifest_diff", "installer_hashes", "skipped_installers"} {
+		if value, ok := resp.Outputs[key]; ok {
+			preview[key] = value
+		}
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: strings.TrimPrefix(resp.Message, "[DRY-RUN] "),
+		Outputs: map[string]any{"preview": preview},
+	}, nil
+}
+
+// submissionPlan describes where and how a post-publish run submits the
+// manifests with the configured backend.
+func submissionPlan(cfg *Config) map[string]any {
+	plan := map[string]any{"backend": cfg.Backend}
+	switch cfg.Backend {
+	case backendREST:
+		plan["url"] = cfg.RESTSource.URL
+		return plan
+	case backendAzureDevOps:
+		plan["repository"] = fmt.Sprintf("%s/%s/_git/%s", strings.TrimRight(cfg.AzureDevOps.OrganizationURL, "/"), cfg.AzureDevOps.Project, cfg.AzureDevOps.Repository)
+		plan["base_branch"] = cfg.AzureDevOps.TargetBranch
+	case backendGitLab:
+		plan["repository"] = fmt.Sprintf("%s/%s", strings.TrimRight(cfg.GitLab.URL, "/"), cfg.GitLab.Project)
+		plan["base_branch"] = cfg.GitLab.TargetBranch
+	default:
+		plan["repository"] = "microsoft/winget-pkgs"
+		plan["base_branch"] = cfg.PullRequest.BaseBranch
+		plan["pull_request"] = cfg.PullRequest.Enabled
+		plan["fork_owner"] = cfg.PullRequest.ForkOwner
+		plan["labels"] = cfg.PullRequest.Labels
+		plan["reviewers"] = cfg.PullRequest.Reviewers
+		plan["assignees"] = cfg.PullRequest.Assignees
+	}
+	plan["branch"] = cfg.PullRequest.Branch
+	plan["title"] = cfg.PullRequest.Title
+	plan["body"] = prBody(cfg.PullRequest)
+	return plan
+}
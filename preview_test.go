@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestPreview(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"labels": []any{"automated"}}
+	resp, err := (&WinGetPlugin{}).Preview(context.Background(), plugin.ExecuteRequest{
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || strings.HasPrefix(resp.Message, "[DRY-RUN]") {
+		t.Fatalf("expected a preview, got: %s", resp.Message)
+	}
+
+	preview, _ := resp.Outputs["preview"].(map[string]any)
+	manifests, _ := preview["manifests"].([]any)
+	if preview["version"] != "1.2.3" || len(manifests) != 3 {
+		t.Fatalf("expected 3 manifests of 1.2.3, got %v", preview)
+	}
+	first := manifests[0].(map[string]any)
+	if first["path"] != preview["manifest_path"].(string)+"/MyOrg.MyApp.installer.yaml" || !strings.Contains(first["content"].(string), "InstallerType: msi") {
+		t.Errorf("unexpected manifest %v", first)
+	}
+	submission, _ := preview["submission"].(map[string]any)
+	if submission["repository"] != "microsoft/winget-pkgs" || submission["branch"] == "" || submission["title"] == "" {
+		t.Errorf("unexpected submission plan %v", submission)
+	}
+
+	// Nothing is written, even to the configured output directory
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("expected the output directory to stay empty, got %d entries", len(entries))
+	}
+}
+
+func TestSubmissionPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *Config
+		expected map[string]any
+	}{
+		{
+			name:     "rest",
+			cfg:      &Config{Backend: backendREST, RESTSource: RESTSourceConfig{URL: "https://winget.example.com/api"}},
+			expected: map[string]any{"backend": backendREST, "url": "https://winget.example.com/api"},
+		},
+		{
+			name: "gitlab",
+			cfg: &Config{Backend: backendGitLab, GitLab: GitLabConfig{URL: "https://gitlab.com/", Project: "contoso/winget", TargetBranch: "main"},
+				PullRequest: PRConfig{Branch: "myapp-1.2.3", Title: "New version", Body: "Automated"}},
+			expected: map[string]any{"backend": backendGitLab, "repository": "https://gitlab.com/contoso/winget", "base_branch": "main",
+				"branch": "myapp-1.2.3", "title": "New version", "body": "Automated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := submissionPlan(tt.cfg)
+			if len(plan) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, plan)
+			}
+			for key, value := range tt.expected {
+				if plan[key] != value {
+					t.Errorf("%s: expected %v, got %v", key, value, plan[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+)
+
+// configProfile pre-populates the installer settings of a common packaging
+// scenario, which the configuration then overrides.
+type configProfile struct {
+	// Type and Scope fill the installers that set none.
+	Type  string
+	Scope string
+	// Switches are added to the default_switches of Type.
+	Switches map[string]string
+	// Extensions are the file extensions expected of the installers of
+	// Type.
+	Extensions []string
+	// Expect returns advisory findings for an installer of Type.
+	Expect func(field string, installer InstallerConfig) []fieldError
+}
+
+// configProfiles are the profiles selected with the profile setting.
+var configProfiles = map[string]configProfile{
+	"msi-machine": {
+		Type:       "msi",
+		Scope:      "machine",
+		Switches:   map[string]string{"Custom": "ALLUSERS=1"},
+		Extensions: []string{".msi"},
+		Expect: func(field string, installer InstallerConfig) []fieldError {
+			if installer.UpgradeCode == "" {
+				return []fieldError{{field + ".upgrade_code", "UpgradeCode is recommended for msi installers so winget can match upgrades of the installed app"}}
+			}
+			return nil
+		},
+	},
+	"exe-nsis-user": {
+		Type:  "nullsoft",
+		Scope: "user",
+		// The current user install of the NSIS MultiUser plugin
+		Switches:   map[string]string{"Custom": "/CurrentUser"},
+		Extensions: []string{".exe"},
+	},
+	"portable-cli": {
+		Type:       "portable",
+		Extensions: []string{".exe"},
+	},
+	"msix-store": {
+		Type:       "msix",
+		Extensions: []string{".msix", ".msixbundle", ".appx", ".appxbundle"},
+		Expect: func(field string, installer InstallerConfig) []fieldError {
+			if len(installer.Switches) > 0 {
+				return []fieldError{{field + ".switches", "Switches are ignored by msix installers"}}
+			}
+			return nil
+		},
+	},
+}
+
+// validateProfile checks that the profile setting names a known profile.
+func validateProfile(name string) []fieldError {
+	if _, ok := configProfiles[name]; name != "" && !ok {
+		return []fieldError{{"profile", fmt.Sprintf("unknown profile %q, expected one of: %s", name, strings.Join(slices.Sorted(maps.Keys(configProfiles)), ", "))}}
+	}
+	return nil
+}
+
+// applyProfile fills the installer type and scope of the configured profile
+// into installers that set neither, and adds its switches to the default
+// switches of its installer type that the configuration leaves unset.
+func applyProfile(cfg *Config) {
+	profile, ok := configProfiles[cfg.Profile]
+	if !ok {
+		return
+	}
+
+	for i := range cfg.Installers {
+		installer := &cfg.Installers[i]
+		if installer.Type == "" {
+			installer.Type = profile.Type
+		}
+		if installer.Type == profile.Type && installer.Scope == "" && len(installer.Scopes) == 0 {
+			installer.Scope = profile.Scope
+		}
+	}
+
+	if len(profile.Switches) == 0 {
+		return
+	}
+	if cfg.DefaultSwitches == nil {
+		cfg.DefaultSwitches = make(map[string]map[string]string)
+	}
+	switches := maps.Clone(profile.Switches)
+	maps.Copy(switches, cfg.DefaultSwitches[profile.Type])
+	cfg.DefaultSwitches[profile.Type] = switches
+}
+
+// profileFindings returns the advisory findings of the configured profile
+// for the installers of its type: installer files with another extension
+// than expected, and the recommendations of the profile.
+func profileFindings(cfg *Config) []fieldError {
+	profile, ok := configProfiles[cfg.Profile]
+	if !ok {
+		return nil
+	}
+
+	var findings []fieldError
+	for i, installer := range cfg.Installers {
+		if installer.Type != profile.Type {
+			continue
+		}
+		field := fmt.Sprintf("installers[%d]", i)
+		source, sourceField := installer.URL, field+".url"
+		if installer.Asset != "" {
+			source, sourceField = installer.Asset, field+".asset"
+		}
+		ext := strings.ToLower(path.Ext(strings.SplitN(source, "?", 2)[0]))
+		if source != "" && !slices.Contains(profile.Extensions, ext) {
+			findings = append(findings, fieldError{sourceField,
+				fmt.Sprintf("profile %s expects a %s file, not %q", cfg.Profile, strings.Join(profile.Extensions, ", "), path.Base(source))})
+		}
+		if profile.Expect != nil {
+			findings = append(findings, profile.Expect(field, installer)...)
+		}
+	}
+	return findings
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateProfile(t *testing.T) {
+	for name := range configProfiles {
+		if problems := validateProfile(name); len(problems) != 0 {
+			t.Errorf("%s: expected no problems, got %v", name, problems)
+		}
+	}
+	if problems := validateProfile(""); len(problems) != 0 {
+		t.Errorf("expected no problems without a profile, got %v", problems)
+	}
+	if problems := validateProfile("msi-user"); len(problems) != 1 || problems[0].Field != "profile" {
+		t.Errorf("expected a profile problem, got %v", problems)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	raw := validTestConfig()
+	raw["profile"] = "msi-machine"
+	raw["installers"] = []any{
+		map[string]any{"url": "https://example.com/app-x64.msi", "architecture": "x64"},
+		map[string]any{"url": "https://example.com/app-x86.msi", "architecture": "x86", "scope": "user", "switches": map[string]any{"Custom": "ALLUSERS=2"}},
+		map[string]any{"url": "https://example.com/app-arm64.exe", "architecture": "arm64", "type": "exe"},
+	}
+	raw["default_switches"] = map[string]any{"msi": map[string]any{"Log": "/log <LOGPATH>"}}
+	cfg, problems := decodePluginConfig(raw)
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	expected := []struct {
+		typ, scope string
+		switches   map[string]string
+	}{
+		{"msi", "machine", map[string]string{"Custom": "ALLUSERS=1", "Log": "/log <LOGPATH>"}},
+		{"msi", "user", map[string]string{"Custom": "ALLUSERS=2", "Log": "/log <LOGPATH>"}},
+		{"exe", "", nil},
+	}
+	for i, want := range expected {
+		installer := cfg.Installers[i]
+		if installer.Type != want.typ || installer.Scope != want.scope || !reflect.DeepEqual(installer.Switches, want.switches) {
+			t.Errorf("installer %d: expected %+v, got type %q scope %q switches %v", i, want, installer.Type, installer.Scope, installer.Switches)
+		}
+	}
+}
+
+func TestProfileFindings(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   string
+		installer InstallerConfig
+		expected  []string
+	}{
+		{
+			name:      "matching msi",
+			profile:   "msi-machine",
+			installer: InstallerConfig{URL: "https://example.com/app.msi?download=1", Type: "msi", UpgradeCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}"},
+		},
+		{
+			name:      "msi without upgrade code",
+			profile:   "msi-machine",
+			installer: InstallerConfig{URL: "https://example.com/app.exe", Type: "msi"},
+			expected:  []string{"installers[0].url", "installers[0].upgrade_code"},
+		},
+		{
+			name:      "other installer type",
+			profile:   "portable-cli",
+			installer: InstallerConfig{URL: "https://example.com/app.zip", Type: "zip"},
+		},
+		{
+			name:      "msix asset with switches",
+			profile:   "msix-store",
+			installer: InstallerConfig{Asset: "app-*.msixbundle", Type: "msix", Switches: map[string]string{"Silent": "/S"}},
+			expected:  []string{"installers[0].switches"},
+		},
+		{
+			name:      "no profile",
+			installer: InstallerConfig{URL: "https://example.com/app.exe", Type: "msi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields []string
+			for _, finding := range profileFindings(&Config{Profile: tt.profile, Installers: []InstallerConfig{tt.installer}}) {
+				fields = append(fields, finding.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("expected findings %v, got %v", tt.expected, fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// configSuggestion is a configuration change that makes the generated
+// manifests match the published ones. A nil Suggested value suggests
+// removing the setting.
+type configSuggestion struct {
+	// Field is the configuration key, such as metadata.tags or
+	// installers[0].type.
+	Field     string
+	Current   any
+	Suggested any
+}
+
+// Reconcile regenerates the manifests of a merged version like Verify and
+// converts both the regenerated and the published manifests to
+// configuration. The settings that differ, such as tags added or an
+// InstallerType corrected by winget-pkgs moderators, are returned as
+// suggested configuration changes in the config_suggestions output so the
+// next release does not revert them.
+func (p *WinGetPlugin) Reconcile(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+	c, resp, err := p.regeneratePublished(ctx, req, "reconcile")
+	if c == nil {
+		return resp, err
+	}
+
+	generated, err := importManifests(c.regenerated)
+	if err != nil {
+		return failureResponse(categoryValidation, "Failed to read regenerated manifests: %v", err), nil
+	}
+	published, err := importManifests(c.published)
+	if err != nil {
+		return failureResponse(categoryValidation, "Failed to read published manifests of version %s: %v", c.version, err), nil
+	}
+
+	suggestions := suggestConfigChanges("", generated, published)
+	outputs := map[string]any{
+		"manifest_dir":            c.manifestDir,
+		"reconciled_version":      c.version,
+		"config_suggestions":      suggestionOutputs(suggestions),
+		"config_suggestion_count": len(suggestions),
+	}
+	if len(suggestions) == 0 {
+		c.logger.Info("Published manifests match the configuration", "version", c.version)
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("Published manifests of %s version %s match the configuration", c.cfg.PackageID, c.version),
+			Outputs: outputs,
+		}, nil
+	}
+
+	for _, s := range suggestions {
+		c.logger.Info("Published manifests differ from the configuration", "field", s.Field, "current", s.Current, "suggested", s.Suggested)
+	}
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d configuration changes suggested from the published manifests of %s version %s", len(suggestions), c.cfg.PackageID, c.version),
+		Outputs: outputs,
+	}, nil
+}
+
+// suggestConfigChanges compares configuration imported from the generated
+// and the published manifests and returns the published values of the
+// settings that differ, sorted by key. Lists of the same length, such as
+// installers and locales, are compared entry by entry so a suggestion names
+// the single setting that changed.
+func suggestConfigChanges(field string, current, published any) []configSuggestion {
+	currentMap, currentIsMap := current.(map[string]any)
+	publishedMap, publishedIsMap := published.(map[string]any)
+	if currentIsMap && publishedIsMap {
+		keys := slices.AppendSeq(slices.Collect(maps.Keys(currentMap)), maps.Keys(publishedMap))
+		slices.Sort(keys)
+		var suggestions []configSuggestion
+		for _, key := range slices.Compact(keys) {
+			suggestions = append(suggestions, suggestConfigChanges(joinConfigKey(field, key), currentMap[key], publishedMap[key])...)
+		}
+		return suggestions
+	}
+
+	currentList, currentIsList := current.([]any)
+	publishedList, publishedIsList := published.([]any)
+	if currentIsList && publishedIsList && len(currentList) == len(publishedList) && isMapList(currentList) && isMapList(publishedList) {
+		var suggestions []configSuggestion
+		for i := range currentList {
+			suggestions = append(suggestions, suggestConfigChanges(fmt.Sprintf("%s[%d]", field, i), currentList[i], publishedList[i])...)
+		}
+		return suggestions
+	}
+
+	if reflect.DeepEqual(current, published) {
+		return nil
+	}
+	return []configSuggestion{{Field: field, Current: current, Suggested: published}}
+}
+
+// joinConfigKey appends a key to a dotted configuration key.
+func joinConfigKey(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+// isMapList reports whether every entry of a list is a map.
+func isMapList(list []any) bool {
+	for _, entry := range list {
+		if _, ok := entry.(map[string]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// suggestionOutputs converts configuration suggestions to plugin outputs.
+func suggestionOutputs(suggestions []configSuggestion) []any {
+	outputs := make([]any, 0, len(suggestions))
+	for _, s := range suggestions {
+		outputs = append(outputs, map[string]any{
+			"field":     s.Field,
+			"current":   s.Current,
+			"suggested": s.Suggested,
+		})
+	}
+	return outputs
+}
+
+// String formats a suggestion as "field: current -> suggested".
+func (s configSuggestion) String() string {
+	return fmt.Sprintf("%s: %s -> %s", s.Field, formatConfigValue(s.Current), formatConfigValue(s.Suggested))
+}
+
+// formatConfigValue formats a configuration value for display, with lists
+// as "[a, b]" and nil as "(unset)".
+func formatConfigValue(value any) string {
+	if value == nil {
+		return "(unset)"
+	}
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
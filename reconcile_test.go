@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestReconcile(t *testing.T) {
+	published := map[string]string{}
+	server := newPublishedManifestServer(t, "manifests/m/MyOrg/MyApp/1.2.3", published)
+	p := &WinGetPlugin{}
+	req := plugin.ExecuteRequest{Config: publishedTestConfig(server), Context: plugin.ReleaseContext{Version: "1.2.3"}}
+
+	publishGeneratedManifests(t, req, published)
+	resp, err := p.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["config_suggestion_count"] != 0 {
+		t.Fatalf("expected no suggestions, got %s: %v", resp.Message, resp.Outputs["config_suggestions"])
+	}
+
+	// Moderators added tags and corrected the installer type
+	published["MyOrg.MyApp.locale.en-US.yaml"] += "Tags:\n- cli\n- tool\n"
+	published["MyOrg.MyApp.installer.yaml"] = strings.Replace(published["MyOrg.MyApp.installer.yaml"], "InstallerType: msi", "InstallerType: wix", 1)
+	resp, err = p.Reconcile(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["reconciled_version"] != "1.2.3" {
+		t.Fatalf("expected suggestions, got %s", resp.Message)
+	}
+	expected := []any{
+		map[string]any{"field": "installers[0].type", "current": "msi", "suggested": "wix"},
+		map[string]any{"field": "metadata.tags", "current": nil, "suggested": []any{"cli", "tool"}},
+	}
+	if !reflect.DeepEqual(resp.Outputs["config_suggestions"], expected) {
+		t.Errorf("unexpected suggestions %v", resp.Outputs["config_suggestions"])
+	}
+}
+
+func TestSuggestConfigChanges(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   map[string]any
+		published map[string]any
+		expected  []string
+	}{
+		{
+			name:      "identical",
+			current:   map[string]any{"metadata": map[string]any{"license": "MIT"}},
+			published: map[string]any{"metadata": map[string]any{"license": "MIT"}},
+		},
+		{
+			name:      "changed and removed settings",
+			current:   map[string]any{"metadata": map[string]any{"license": "MIT", "moniker": "app"}},
+			published: map[string]any{"metadata": map[string]any{"license": "MIT License"}},
+			expected:  []string{"metadata.license: MIT -> MIT License", "metadata.moniker: app -> (unset)"},
+		},
+		{
+			name: "installer entries",
+			current: map[string]any{"installers": []any{
+				map[string]any{"architecture": "x64", "type": "exe"},
+				map[string]any{"architecture": "arm64", "type": "exe"},
+			}},
+			published: map[string]any{"installers": []any{
+				map[string]any{"architecture": "x64", "type": "exe"},
+				map[string]any{"architecture": "arm64", "type": "inno", "scope": "user"},
+			}},
+			expected: []string{"installers[1].scope: (unset) -> user", "installers[1].type: exe -> inno"},
+		},
+		{
+			name:      "installer added",
+			current:   map[string]any{"installers": []any{map[string]any{"architecture": "x64"}}},
+			published: map[string]any{"installers": []any{map[string]any{"architecture": "x64"}, map[string]any{"architecture": "arm64"}}},
+			expected:  []string{"installers: [map[architecture:x64]] -> [map[architecture:x64], map[architecture:arm64]]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result []string
+			for _, s := range suggestConfigChanges("", tt.current, tt.published) {
+				result = append(result, s.String())
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// redacted replaces secrets in logs and messages.
+const redacted = "[REDACTED]"
+
+// minSecretLength keeps short configured values, which are unlikely to be
+// real credentials, from masking unrelated text.
+const minSecretLength = 4
+
+var (
+	// credentialParam matches credential query parameters, including signed
+	// download URL signatures.
+	credentialParam = regexp.MustCompile(`(?i)([?&](?:access_token|token|api_key|apikey|api-key|key|code|client_secret|password|sig|signature|x-amz-signature|x-amz-credential|x-amz-security-token|x-goog-signature|x-goog-credential)=)[^&#\s"']+`)
+	// authorizationHeader matches Authorization header values.
+	authorizationHeader = regexp.MustCompile(`(?i)(authorization["']?\s*[:=]\s*["']?)(?:(?:bearer|token|basic)\s+)?[^\s"',]+`)
+	// bearerCredential matches bearer credentials outside headers.
+	bearerCredential = regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	// urlUserinfo matches credentials embedded in URLs.
+	urlUserinfo = regexp.MustCompile(`(?i)(\b[a-z][a-z0-9+.-]*://)[^/@\s"']+@`)
+	// githubToken matches GitHub token formats, configured or not.
+	githubToken = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+)
+
+// redactor masks configured secrets and common credential patterns.
+type redactor struct {
+	secrets []string
+}
+
+// newRedactor returns a redactor for the secrets of the configuration.
+func newRedactor(cfg *Config) *redactor {
+	r := &redactor{}
+	for _, secret := range []string{cfg.GitHubToken, cfg.RESTSource.APIKey, cfg.RESTSource.AzureAD.ClientSecret, cfg.AzureDevOps.Token, cfg.GitLab.Token, cfg.Scan.VirusTotalAPIKey} {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	return r
+}
+
+// String returns s with secrets masked.
+func (r *redactor) String(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	s = credentialParam.ReplaceAllString(s, "${1}"+redacted)
+	s = authorizationHeader.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerCredential.ReplaceAllString(s, "${1}"+redacted)
+	s = urlUserinfo.ReplaceAllString(s, "${1}"+redacted+"@")
+	return githubToken.ReplaceAllString(s, redacted)
+}
+
+// error returns err with secrets masked in its message. Errors without
+// secrets are returned unchanged so they can still be matched.
+func (r *redactor) error(err error) error {
+	if message := r.String(err.Error()); message != err.Error() {
+		return errors.New(message)
+	}
+	return err
+}
+
+// executeResponse masks secrets in the message, error and outputs of a
+// response.
+func (r *redactor) executeResponse(resp *plugin.ExecuteResponse) {
+	if resp == nil {
+		return
+	}
+	resp.Message = r.String(resp.Message)
+	resp.Error = r.String(resp.Error)
+	for key, value := range resp.Outputs {
+		resp.Outputs[key] = r.value(value)
+	}
+}
+
+// value masks secrets in strings nested in an output value.
+func (r *redactor) value(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.String(v)
+	case []string:
+		masked := make([]string, len(v))
+		for i, s := range v {
+			masked[i] = r.String(s)
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = r.value(item)
+		}
+		return masked
+	case map[string]string:
+		masked := make(map[string]string, len(v))
+		for key, item := range v {
+			masked[r.String(key)] = r.String(item)
+		}
+		return masked
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			masked[key] = r.value(item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// validateResponse masks secrets in validation messages.
+func (r *redactor) validateResponse(resp *plugin.ValidateResponse) {
+	for i := range resp.Errors {
+		resp.Errors[i].Message = r.String(resp.Errors[i].Message)
+	}
+}
+
+// handler wraps a log handler so secrets are masked in messages and
+// attributes.
+func (r *redactor) handler(h slog.Handler) slog.Handler {
+	return &redactingHandler{handler: h, redactor: r}
+}
+
+// redactingHandler is a slog.Handler that masks secrets before delegating.
+type redactingHandler struct {
+	handler  slog.Handler
+	redactor *redactor
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redactedRecord := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redactedRecord.AddAttrs(h.redactor.attr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redactedRecord)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redactedAttrs[i] = h.redactor.attr(attr)
+	}
+	return &redactingHandler{handler: h.handler.WithAttrs(redactedAttrs), redactor: h.redactor}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{handler: h.handler.WithGroup(name), redactor: h.redactor}
+}
+
+// attr returns a log attribute with secrets masked. Values other than
+// strings and groups are formatted and replaced only if they hold a secret.
+func (r *redactor) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, r.String(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redactedGroup := make([]any, len(group))
+		for i, a := range group {
+			redactedGroup[i] = r.attr(a)
+		}
+		return slog.Group(attr.Key, redactedGroup...)
+	case slog.KindAny:
+		formatted := fmt.Sprint(value.Any())
+		if masked := r.String(formatted); masked != formatted {
+			return slog.String(attr.Key, masked)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
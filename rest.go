@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// azureADAuthority is the Microsoft identity platform endpoint used to obtain
+// REST source access tokens.
+var azureADAuthority = "https://login.microsoftonline.com"
+
+// RESTSourceClient publishes manifests to a private winget REST source
+// implementing the Microsoft winget REST source API.
+type RESTSourceClient struct {
+	baseURL string
+	apiKey  string
+	token   string
+	client  *http.Client
+}
+
+// NewRESTSourceClient creates a REST source client. apiKey is sent as an Azure
+// Functions key and token as an Azure AD bearer token; either may be empty.
+func NewRESTSourceClient(baseURL, apiKey, token string) *RESTSourceClient {
+	return &RESTSourceClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		token:   token,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Publish adds the package if needed and adds or replaces the version
+// described by the manifests.
+func (c *RESTSourceClient) Publish(ctx context.Context, m *manifest.Set) error {
+	packageID := m.Version.PackageIdentifier
+
+	status, err := c.send(ctx, "POST", "/packages", map[string]any{"PackageIdentifier": packageID})
+	if err != nil {
+		return fmt.Errorf("failed to add package: %w", err)
+	}
+	if status != http.StatusCreated && status != http.StatusOK && status != http.StatusConflict {
+		return fmt.Errorf("failed to add package: status %d", status)
+	}
+
+	version, err := restVersion(m)
+	if err != nil {
+		return err
+	}
+
+	versionsPath := "/packages/" + url.PathEscape(packageID) + "/versions"
+	status, err = c.send(ctx, "POST", versionsPath, version)
+	if err != nil {
+		return fmt.Errorf("failed to add version: %w", err)
+	}
+	if status == http.StatusConflict {
+		// The version exists, so replace it
+		status, err = c.send(ctx, "PUT", versionsPath+"/"+url.PathEscape(m.Version.PackageVersion), version)
+		if err != nil {
+			return fmt.Errorf("failed to update version: %w", err)
+		}
+	}
+	if status != http.StatusCreated && status != http.StatusOK {
+		return fmt.Errorf("failed to publish version %s: status %d", m.Version.PackageVersion, status)
+	}
+
+	return nil
+}
+
+// restStatusError is returned for REST source responses with an error status.
+type restStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *restStatusError) Error() string {
+	return fmt.Sprintf("REST source returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// send issues a JSON request and returns the response status. Error statuses
+// other than 409 Conflict are returned as errors including the response body.
+func (c *RESTSourceClient) send(ctx context.Context, method, path string, body any) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("x-functions-key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusConflict {
+		respBody, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, &restStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// restVersion converts a manifest set into a REST source version object.
+func restVersion(m *manifest.Set) (map[string]any, error) {
+	locale, err := restObject(m.Locale)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert locale manifest: %w", err)
+	}
+	delete(locale, "PackageVersion")
+
+	installers := make([]any, len(m.Installer.Installers))
+	for i, installer := range m.Installer.Installers {
+		obj, err := restObject(installer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert installer %d: %w", i, err)
+		}
+		obj["InstallerIdentifier"] = fmt.Sprintf("%s-%s-%s", installer.Architecture, installer.InstallerType, installer.Scope)
+		installers[i] = obj
+	}
+
+	version := map[string]any{
+		"PackageVersion": m.Version.PackageVersion,
+		"DefaultLocale":  locale,
+		"Installers":     installers,
+	}
+	if len(m.Locales) > 0 {
+		locales := make([]any, len(m.Locales))
+		for i, l := range m.Locales {
+			obj, err := restObject(l)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %s locale manifest: %w", l.PackageLocale, err)
+			}
+			delete(obj, "PackageVersion")
+			locales[i] = obj
+		}
+		version["Locales"] = locales
+	}
+	return version, nil
+}
+
+// restObject converts a manifest struct into a JSON object using its YAML
+// field names, without the manifest bookkeeping fields.
+func restObject(v any) (map[string]any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]any
+	if err := yaml.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	delete(obj, "PackageIdentifier")
+	delete(obj, "ManifestType")
+	delete(obj, "ManifestVersion")
+	return obj, nil
+}
+
+// AzureADToken obtains an access token for the REST source using the OAuth 2.0
+// client credentials flow.
+func AzureADToken(ctx context.Context, cfg AzureADConfig) (string, error) {
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureADAuthority, url.PathEscape(cfg.TenantID))
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+		"scope":         {cfg.Scope},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request Azure AD token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode Azure AD token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", fmt.Errorf("Azure AD token request failed with status %d: %s", resp.StatusCode, result.ErrorDescription)
+	}
+
+	return result.AccessToken, nil
+}
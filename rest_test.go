@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+func TestRESTSourceClientPublish(t *testing.T) {
+	tests := []struct {
+		name          string
+		versionExists bool
+		expected      []string
+	}{
+		{"new version", false, []string{"POST /api/packages", "POST /api/packages/MyOrg.MyApp/versions"}},
+		{"existing version", true, []string{"POST /api/packages", "POST /api/packages/MyOrg.MyApp/versions", "PUT /api/packages/MyOrg.MyApp/versions/1.0.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			var version map[string]any
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				if r.Header.Get("x-functions-key") != "key" {
+					t.Errorf("expected functions key header, got %q", r.Header.Get("x-functions-key"))
+				}
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("expected bearer token, got %q", r.Header.Get("Authorization"))
+				}
+
+				switch {
+				case r.URL.Path == "/api/packages":
+					// The package already exists
+					w.WriteHeader(http.StatusConflict)
+				case r.Method == "POST" && tt.versionExists:
+					w.WriteHeader(http.StatusConflict)
+				default:
+					if err := json.NewDecoder(r.Body).Decode(&version); err != nil {
+						t.Errorf("failed to decode version: %v", err)
+					}
+					w.WriteHeader(http.StatusOK)
+				}
+			}))
+			defer server.Close()
+
+			manifests := validTestManifests(t)
+			manifests.Locales = []*manifest.LocaleManifest{{PackageIdentifier: "MyOrg.MyApp", PackageVersion: "1.0.0", PackageLocale: "de-DE", ShortDescription: "Eine App", ManifestType: "locale", ManifestVersion: manifest.SchemaVersion}}
+			client := NewRESTSourceClient(server.URL+"/api/", "key", "token")
+			if err := client.Publish(context.Background(), manifests); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(requests) != len(tt.expected) {
+				t.Fatalf("expected requests %v, got %v", tt.expected, requests)
+			}
+			for i, expected := range tt.expected {
+				if requests[i] != expected {
+					t.Errorf("request %d: expected '%s', got '%s'", i, expected, requests[i])
+				}
+			}
+
+			if version["PackageVersion"] != "1.0.0" {
+				t.Errorf("expected PackageVersion 1.0.0, got %v", version["PackageVersion"])
+			}
+			locale, _ := version["DefaultLocale"].(map[string]any)
+			if locale["PackageLocale"] != "en-US" || locale["ManifestType"] != nil {
+				t.Errorf("unexpected default locale: %v", locale)
+			}
+			locales, _ := version["Locales"].([]any)
+			if len(locales) != 1 || locales[0].(map[string]any)["PackageLocale"] != "de-DE" || locales[0].(map[string]any)["Publisher"] != nil {
+				t.Errorf("unexpected locales: %v", locales)
+			}
+			installers, _ := version["Installers"].([]any)
+			if len(installers) != 1 || installers[0].(map[string]any)["InstallerIdentifier"] == "" {
+				t.Errorf("unexpected installers: %v", installers)
+			}
+		})
+	}
+}
+
+func TestRESTSourceClientPublishError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("unauthorized"))
+	}))
+	defer server.Close()
+
+	client := NewRESTSourceClient(server.URL, "", "")
+	if err := client.Publish(context.Background(), validTestManifests(t)); err == nil {
+		t.Error("expected error for unauthorized response")
+	}
+}
+
+func TestAzureADToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("unexpected form: %v", r.PostForm)
+		}
+		_, _ = w.Write([]byte(`{"access_token":"token"}`))
+	}))
+	defer server.Close()
+
+	originalAuthority := azureADAuthority
+	azureADAuthority = server.URL
+	defer func() { azureADAuthority = originalAuthority }()
+
+	token, err := AzureADToken(context.Background(), AzureADConfig{
+		TenantID:     "tenant",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scope:        "api://restsource/.default",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "token" {
+		t.Errorf("expected token 'token', got '%s'", token)
+	}
+}
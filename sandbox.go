@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const (
+	// sandboxFolder is where the host test directory is mapped inside the sandbox.
+	sandboxFolder = `C:\SandboxTest`
+	// sandboxResultFile is written by the bootstrap script when the test finishes.
+	sandboxResultFile = "result.json"
+	// sandboxLogFile receives the PowerShell transcript of the bootstrap script.
+	sandboxLogFile = "sandbox.log"
+)
+
+var (
+	// sandboxCommand is the Windows Sandbox executable.
+	sandboxCommand = "WindowsSandbox.exe"
+	// sandboxPollInterval is how often the host checks for the result file.
+	sandboxPollInterval = 5 * time.Second
+)
+
+// sandboxBootstrapScript installs winget inside the sandbox, installs the
+// package from the mapped manifests and records the outcome. It mirrors the
+// approach of winget-pkgs' SandboxTest.ps1.
+const sandboxBootstrapScript = `$ErrorActionPreference = 'Continue'
+$ProgressPreference = 'SilentlyContinue'
+$root = '` + sandboxFolder + `'
+Start-Transcript -Path (Join-Path $root '` + sandboxLogFile + `')
+
+$result = [ordered]@{ installExitCode = -1; listExitCode = -1; error = '' }
+
+try {
+    Write-Host 'Installing winget dependencies'
+    $deps = Join-Path $env:TEMP 'winget-deps'
+    New-Item -ItemType Directory -Force -Path $deps | Out-Null
+    Invoke-WebRequest -Uri 'https://aka.ms/Microsoft.VCLibs.x64.14.00.Desktop.appx' -OutFile (Join-Path $deps 'vclibs.appx')
+    Invoke-WebRequest -Uri 'https://www.nuget.org/api/v2/package/Microsoft.UI.Xaml/2.8.6' -OutFile (Join-Path $deps 'xaml.zip')
+    Expand-Archive -Path (Join-Path $deps 'xaml.zip') -DestinationPath (Join-Path $deps 'xaml') -Force
+    Invoke-WebRequest -Uri 'https://aka.ms/getwinget' -OutFile (Join-Path $deps 'winget.msixbundle')
+    Add-AppxPackage -Path (Join-Path $deps 'vclibs.appx')
+    Add-AppxPackage -Path (Join-Path $deps 'xaml\tools\AppX\x64\Release\Microsoft.UI.Xaml.2.8.appx')
+    Add-AppxPackage -Path (Join-Path $deps 'winget.msixbundle')
+
+    winget settings --enable LocalManifestFiles
+    winget install --manifest (Join-Path $root '{{MANIFEST_PATH}}') --silent --accept-package-agreements --accept-source-agreements --disable-interactivity
+    $result.installExitCode = $LASTEXITCODE
+
+    winget list --exact {{ARP_FLAG}} '{{ARP_QUERY}}' --accept-source-agreements --disable-interactivity
+    $result.listExitCode = $LASTEXITCODE
+} catch {
+    $result.error = $_.Exception.Message
+}
+
+Stop-Transcript
+$result | ConvertTo-Json | Out-File -FilePath (Join-Path $root '` + sandboxResultFile + `') -Encoding utf8
+shutdown /s /t 0
+`
+
+// sandboxConfig is the Windows Sandbox .wsb configuration document.
+type sandboxConfig struct {
+	XMLName       xml.Name              `xml:"Configuration"`
+	Networking    string                `xml:"Networking"`
+	MappedFolders []sandboxMappedFolder `xml:"MappedFolders>MappedFolder"`
+	LogonCommand  string                `xml:"LogonCommand>Command"`
+}
+
+// sandboxMappedFolder maps a host folder into the sandbox.
+type sandboxMappedFolder struct {
+	HostFolder    string `xml:"HostFolder"`
+	SandboxFolder string `xml:"SandboxFolder"`
+	ReadOnly      bool   `xml:"ReadOnly"`
+}
+
+// SandboxResult is the outcome reported by the sandbox bootstrap script.
+type SandboxResult struct {
+	InstallExitCode int    `json:"installExitCode"`
+	ListExitCode    int    `json:"listExitCode"`
+	Error           string `json:"error"`
+}
+
+// GenerateSandboxConfig returns a .wsb configuration that maps hostDir into the
+// sandbox and runs the bootstrap script at logon.
+func GenerateSandboxConfig(hostDir string) (string, error) {
+	cfg := sandboxConfig{
+		Networking: "Enable",
+		MappedFolders: []sandboxMappedFolder{
+			{HostFolder: hostDir, SandboxFolder: sandboxFolder},
+		},
+		LogonCommand: fmt.Sprintf(`PowerShell -ExecutionPolicy Bypass -File %s\bootstrap.ps1`, sandboxFolder),
+	}
+
+	data, err := xml.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GenerateSandboxBootstrap returns the bootstrap script for a manifest set.
+// manifestPath is relative to the mapped sandbox folder; arpFlag and arpQuery
+// select the Apps & Features entry to verify, as returned by arpQuery.
+func GenerateSandboxBootstrap(manifestPath, arpFlag, arpQuery string) string {
+	return strings.NewReplacer(
+		"{{MANIFEST_PATH}}", strings.ReplaceAll(manifestPath, "/", `\`),
+		"{{ARP_FLAG}}", arpFlag,
+		"{{ARP_QUERY}}", strings.ReplaceAll(arpQuery, "'", "''"),
+	).Replace(sandboxBootstrapScript)
+}
+
+// SandboxTestInstall installs the package inside Windows Sandbox and waits for
+// the result. It returns the sandbox transcript.
+func SandboxTestInstall(ctx context.Context, m *manifest.Set) (string, error) {
+	hostDir, err := os.MkdirTemp("", "winget-sandbox-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(hostDir) }()
+
+	if _, err := m.WriteTo(hostDir); err != nil {
+		return "", fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	flag, query := arpQuery(m)
+	bootstrap := GenerateSandboxBootstrap(m.Path, flag, query)
+	if err := os.WriteFile(filepath.Join(hostDir, "bootstrap.ps1"), []byte(bootstrap), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write bootstrap script: %w", err)
+	}
+
+	wsb, err := GenerateSandboxConfig(hostDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sandbox configuration: %w", err)
+	}
+	wsbPath := filepath.Join(hostDir, "test.wsb")
+	if err := os.WriteFile(wsbPath, []byte(wsb), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write sandbox configuration: %w", err)
+	}
+
+	if err := exec.CommandContext(ctx, sandboxCommand, wsbPath).Run(); err != nil {
+		return "", fmt.Errorf("failed to launch Windows Sandbox: %w", err)
+	}
+
+	result, err := waitForSandboxResult(ctx, filepath.Join(hostDir, sandboxResultFile))
+	transcript, _ := os.ReadFile(filepath.Join(hostDir, sandboxLogFile))
+	if err != nil {
+		return string(transcript), err
+	}
+
+	switch {
+	case result.Error != "":
+		return string(transcript), fmt.Errorf("sandbox test failed: %s", result.Error)
+	case result.InstallExitCode != 0:
+		return string(transcript), fmt.Errorf("sandbox install failed with exit code %d", result.InstallExitCode)
+	case result.ListExitCode != 0:
+		return string(transcript), fmt.Errorf("installed package %q not found in Apps & Features inside sandbox", query)
+	}
+
+	return string(transcript), nil
+}
+
+// waitForSandboxResult polls for the result file written by the sandbox.
+func waitForSandboxResult(ctx context.Context, path string) (*SandboxResult, error) {
+	ticker := time.NewTicker(sandboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			// PowerShell 5.1 writes UTF-8 with a byte order mark
+			data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+			// A parse failure usually means the file is still being written
+			var result SandboxResult
+			if err := json.Unmarshal(data, &result); err == nil {
+				return &result, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for sandbox result: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
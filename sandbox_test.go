@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSandbox installs a shell script as the Windows Sandbox command for the test.
+func fakeSandbox(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake sandbox script requires a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "WindowsSandbox.exe")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\ndir=$(dirname \"$1\")\n"+script), 0o755); err != nil {
+		t.Fatalf("failed to write fake sandbox: %v", err)
+	}
+
+	originalCommand, originalInterval := sandboxCommand, sandboxPollInterval
+	sandboxCommand, sandboxPollInterval = path, 10*time.Millisecond
+	t.Cleanup(func() {
+		sandboxCommand, sandboxPollInterval = originalCommand, originalInterval
+	})
+}
+
+func TestGenerateSandboxConfig(t *testing.T) {
+	wsb, err := GenerateSandboxConfig(`C:\Temp\winget & test`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"<Configuration>",
+		"<Networking>Enable</Networking>",
+		`<HostFolder>C:\Temp\winget &amp; test</HostFolder>`,
+		`<SandboxFolder>C:\SandboxTest</SandboxFolder>`,
+		`<Command>PowerShell -ExecutionPolicy Bypass -File C:\SandboxTest\bootstrap.ps1</Command>`,
+	} {
+		if !strings.Contains(wsb, expected) {
+			t.Errorf("expected '%s' in configuration:\n%s", expected, wsb)
+		}
+	}
+}
+
+func TestGenerateSandboxBootstrap(t *testing.T) {
+	script := GenerateSandboxBootstrap("manifests/m/MyOrg/MyApp/1.0.0", "--name", "Bob's App")
+
+	if !strings.Contains(script, `'manifests\m\MyOrg\MyApp\1.0.0'`) {
+		t.Error("expected Windows manifest path in bootstrap script")
+	}
+	if !strings.Contains(script, `--name 'Bob''s App'`) {
+		t.Error("expected escaped package name in bootstrap script")
+	}
+	if strings.Contains(script, "{{") {
+		t.Error("bootstrap script contains unrendered placeholders")
+	}
+}
+
+func TestSandboxTestInstall(t *testing.T) {
+	fakeSandbox(t, `echo "transcript" > "$dir/sandbox.log"
+printf '\357\273\277{"installExitCode":0,"listExitCode":0,"error":""}' > "$dir/result.json"
+`)
+
+	output, err := SandboxTestInstall(context.Background(), validTestManifests(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "transcript") {
+		t.Errorf("expected sandbox transcript, got: %s", output)
+	}
+}
+
+func TestSandboxTestInstallFailure(t *testing.T) {
+	fakeSandbox(t, `echo '{"installExitCode":1603,"listExitCode":-1,"error":""}' > "$dir/result.json"`)
+
+	_, err := SandboxTestInstall(context.Background(), validTestManifests(t))
+	if err == nil || !strings.Contains(err.Error(), "1603") {
+		t.Errorf("expected install exit code in error, got: %v", err)
+	}
+}
+
+func TestSandboxTestInstallTimeout(t *testing.T) {
+	fakeSandbox(t, `exit 0`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := SandboxTestInstall(ctx, validTestManifests(t))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Installer scanners.
+const (
+	scannerVirusTotal = "virustotal"
+	scannerDefender   = "defender"
+)
+
+// ScanConfig defines the malware scan of installers before submission.
+type ScanConfig struct {
+	// Scanner is "virustotal" or "defender"; empty disables scanning.
+	Scanner          string `json:"scanner"`
+	VirusTotalAPIKey string `json:"virustotal_api_key"`
+	// MaxDetections is the number of engines that may flag an installer as
+	// malicious before the submission is blocked.
+	MaxDetections int `json:"max_detections"`
+}
+
+// scanReport is the result of scanning one installer. It is returned in the
+// scan_report output.
+type scanReport struct {
+	URL        string   `json:"url"`
+	Scanner    string   `json:"scanner"`
+	Malicious  int      `json:"malicious"`
+	Suspicious int      `json:"suspicious"`
+	Engines    []string `json:"engines,omitempty"`
+	Link       string   `json:"link,omitempty"`
+	Output     string   `json:"output,omitempty"`
+}
+
+// output returns the report as an output value.
+func (r scanReport) output() map[string]any {
+	out := map[string]any{
+		"url":        r.URL,
+		"scanner":    r.Scanner,
+		"malicious":  r.Malicious,
+		"suspicious": r.Suspicious,
+	}
+	if len(r.Engines) > 0 {
+		out["engines"] = r.Engines
+	}
+	if r.Link != "" {
+		out["link"] = r.Link
+	}
+	if r.Output != "" {
+		out["output"] = r.Output
+	}
+	return out
+}
+
+var (
+	// virusTotalAPIBase is the VirusTotal API base URL.
+	virusTotalAPIBase = "https://www.virustotal.com/api/v3"
+	// virusTotalPollInterval is the delay between checks of an uploaded
+	// file's analysis.
+	virusTotalPollInterval = 15 * time.Second
+	// defenderCommand is the Microsoft Defender command line scanner.
+	defenderCommand = filepath.Join(os.Getenv("ProgramFiles"), "Windows Defender", "MpCmdRun.exe")
+)
+
+// virusTotalUploadLimit is the largest file the plain upload endpoint
+// accepts; larger files need an upload URL.
+const virusTotalUploadLimit = 32 << 20
+
+// validateScan checks the scan settings.
+func validateScan(cfg *Config) []fieldError {
+	var problems []fieldError
+	switch cfg.Scan.Scanner {
+	case "":
+		return nil
+	case scannerVirusTotal:
+		if cfg.Scan.VirusTotalAPIKey == "" {
+			problems = append(problems, fieldError{"scan.virustotal_api_key", "virustotal_api_key is required for the virustotal scanner (or set VIRUSTOTAL_API_KEY)"})
+		}
+	case scannerDefender:
+		if cfg.Download.CacheDir == "" {
+			problems = append(problems, fieldError{"scan.scanner", "the defender scanner requires download.cache_dir"})
+		}
+	default:
+		problems = append(problems, fieldError{"scan.scanner", fmt.Sprintf("scanner must be %q or %q", scannerVirusTotal, scannerDefender)})
+	}
+	if cfg.Scan.MaxDetections < 0 {
+		problems = append(problems, fieldError{"scan.max_detections", "max_detections must not be negative"})
+	}
+	return problems
+}
+
+// scanInstaller scans an installer with the configured scanner. file is the
+// cached installer, if any.
+func scanInstaller(ctx context.Context, cfg *Config, url, hash, file string) (scanReport, error) {
+	switch cfg.Scan.Scanner {
+	case scannerVirusTotal:
+		report, err := virusTotalScan(ctx, cfg.Scan.VirusTotalAPIKey, hash, file)
+		report.URL = url
+		return report, err
+	case scannerDefender:
+		report, err := defenderScan(ctx, file)
+		report.URL = url
+		return report, err
+	}
+	return scanReport{}, fmt.Errorf("unknown scanner %q", cfg.Scan.Scanner)
+}
+
+// blocked reports whether a scan report blocks the submission.
+func (r scanReport) blocked(maxDetections int) bool {
+	return r.Malicious > maxDetections
+}
+
+// virusTotalAnalysis holds the parts of VirusTotal file reports and
+// analyses the scan uses.
+type virusTotalAnalysis struct {
+	Status string `json:"status"`
+	Stats  struct {
+		Malicious  int `json:"malicious"`
+		Suspicious int `json:"suspicious"`
+	} `json:"stats"`
+	Results map[string]struct {
+		Category string `json:"category"`
+	} `json:"results"`
+}
+
+// virusTotalScan returns the VirusTotal report of a file hash, uploading the
+// cached file for analysis if VirusTotal does not know it yet.
+func virusTotalScan(ctx context.Context, apiKey, hash, file string) (scanReport, error) {
+	report := scanReport{Scanner: scannerVirusTotal, Link: "https://www.virustotal.com/gui/file/" + strings.ToLower(hash)}
+
+	var fileReport struct {
+		Data struct {
+			Attributes struct {
+				Stats   json.RawMessage `json:"last_analysis_stats"`
+				Results json.RawMessage `json:"last_analysis_results"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	err := virusTotalRequest(ctx, apiKey, http.MethodGet, "/files/"+strings.ToLower(hash), nil, "", &fileReport)
+	var statusErr *virusTotalStatusError
+	switch {
+	case err == nil:
+		var analysis virusTotalAnalysis
+		attrs := fileReport.Data.Attributes
+		if err := json.Unmarshal(attrs.Stats, &analysis.Stats); err != nil {
+			return report, fmt.Errorf("invalid VirusTotal file report: %w", err)
+		}
+		if len(attrs.Results) > 0 {
+			if err := json.Unmarshal(attrs.Results, &analysis.Results); err != nil {
+				return report, fmt.Errorf("invalid VirusTotal file report: %w", err)
+			}
+		}
+		report.apply(analysis)
+		return report, nil
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound:
+	default:
+		return report, err
+	}
+
+	if file == "" {
+		return report, fmt.Errorf("installer is not known to VirusTotal; set download.cache_dir to upload it for analysis")
+	}
+	analysis, err := virusTotalUpload(ctx, apiKey, file)
+	if err != nil {
+		return report, err
+	}
+	report.apply(analysis)
+	return report, nil
+}
+
+// apply copies the detection counts and flagging engines of an analysis.
+func (r *scanReport) apply(analysis virusTotalAnalysis) {
+	r.Malicious = analysis.Stats.Malicious
+	r.Suspicious = analysis.Stats.Suspicious
+	for engine, result := range analysis.Results {
+		if result.Category == "malicious" || result.Category == "suspicious" {
+			r.Engines = append(r.Engines, engine)
+		}
+	}
+	slices.Sort(r.Engines)
+}
+
+// virusTotalUpload uploads a file and waits for its analysis to complete.
+func virusTotalUpload(ctx context.Context, apiKey, file string) (virusTotalAnalysis, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return virusTotalAnalysis{}, fmt.Errorf("failed to read installer: %w", err)
+	}
+
+	uploadURL := virusTotalAPIBase + "/files"
+	if info.Size() > virusTotalUploadLimit {
+		var result struct {
+			Data string `json:"data"`
+		}
+		if err := virusTotalRequest(ctx, apiKey, http.MethodGet, "/files/upload_url", nil, "", &result); err != nil {
+			return virusTotalAnalysis{}, err
+		}
+		uploadURL = result.Data
+	}
+
+	// Stream the multipart body so large installers are not held in memory
+	f, err := os.Open(file)
+	if err != nil {
+		return virusTotalAnalysis{}, fmt.Errorf("failed to read installer: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	body, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile("file", filepath.Base(file))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	var upload struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := virusTotalRequest(ctx, apiKey, http.MethodPost, uploadURL, body, form.FormDataContentType(), &upload); err != nil {
+		return virusTotalAnalysis{}, fmt.Errorf("failed to upload installer to VirusTotal: %w", err)
+	}
+
+	for {
+		var result struct {
+			Data struct {
+				Attributes virusTotalAnalysis `json:"attributes"`
+			} `json:"data"`
+		}
+		if err := virusTotalRequest(ctx, apiKey, http.MethodGet, "/analyses/"+upload.Data.ID, nil, "", &result); err != nil {
+			return virusTotalAnalysis{}, err
+		}
+		if result.Data.Attributes.Status == "completed" {
+			return result.Data.Attributes, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return virusTotalAnalysis{}, fmt.Errorf("VirusTotal analysis did not complete: %w", ctx.Err())
+		case <-time.After(virusTotalPollInterval):
+		}
+	}
+}
+
+// virusTotalStatusError is returned for VirusTotal responses with an error
+// status code.
+type virusTotalStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *virusTotalStatusError) Error() string {
+	return fmt.Sprintf("VirusTotal API error %d: %s", e.StatusCode, e.Body)
+}
+
+// virusTotalRequest sends a VirusTotal API request and decodes the JSON
+// response into result. target is a path below the API base or an absolute
+// upload URL.
+func virusTotalRequest(ctx context.Context, apiKey, method, target string, body io.Reader, contentType string, result any) error {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = virusTotalAPIBase + target
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-apikey", apiKey)
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("VirusTotal request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &virusTotalStatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("invalid VirusTotal response: %w", err)
+	}
+	return nil
+}
+
+// defenderThreatExitCode is the MpCmdRun.exe exit code when a threat is found.
+const defenderThreatExitCode = 2
+
+// defenderScan scans a cached installer with Microsoft Defender.
+func defenderScan(ctx context.Context, file string) (scanReport, error) {
+	report := scanReport{Scanner: scannerDefender}
+	if runtime.GOOS != "windows" {
+		return report, fmt.Errorf("defender scan requires Windows, running on %s", runtime.GOOS)
+	}
+	if file == "" {
+		return report, fmt.Errorf("defender scan requires download.cache_dir")
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, defenderCommand, "-Scan", "-ScanType", "3", "-File", file, "-DisableRemediation")
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	report.Output = strings.TrimSpace(output.String())
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return report, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == defenderThreatExitCode:
+		report.Malicious = 1
+		report.Engines = []string{"Microsoft Defender"}
+		return report, nil
+	default:
+		return report, fmt.Errorf("defender scan failed: %w", err)
+	}
+}
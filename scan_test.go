@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// fakeVirusTotal serves the VirusTotal API for the test. Known maps
+// lowercase hashes to file report attributes.
+func fakeVirusTotal(t *testing.T, known map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-apikey") != "vt-key" {
+			t.Errorf("unexpected API key %q", r.Header.Get("x-apikey"))
+		}
+		switch {
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/files/"):
+			attributes, ok := known[strings.TrimPrefix(r.URL.Path, "/files/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"code":"NotFoundError"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"attributes":` + attributes + `}}`))
+		case r.Method == "POST" && r.URL.Path == "/files":
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("expected an uploaded file: %v", err)
+				return
+			}
+			data, _ := io.ReadAll(file)
+			if string(data) != "installer" {
+				t.Errorf("unexpected upload %q", data)
+			}
+			_, _ = w.Write([]byte(`{"data":{"type":"analysis","id":"analysis-1"}}`))
+		case r.Method == "GET" && r.URL.Path == "/analyses/analysis-1":
+			_, _ = w.Write([]byte(`{"data":{"attributes":{"status":"completed","stats":{"malicious":0,"suspicious":1},"results":{"EngineA":{"category":"suspicious"},"EngineB":{"category":"undetected"}}}}}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalBase, originalInterval := virusTotalAPIBase, virusTotalPollInterval
+	virusTotalAPIBase, virusTotalPollInterval = server.URL, time.Millisecond
+	t.Cleanup(func() { virusTotalAPIBase, virusTotalPollInterval = originalBase, originalInterval })
+	return server
+}
+
+func TestVirusTotalScan(t *testing.T) {
+	flagged := strings.Repeat("a", 64)
+	fakeVirusTotal(t, map[string]string{
+		flagged: `{"last_analysis_stats":{"malicious":2,"suspicious":0},"last_analysis_results":{"EngineB":{"category":"malicious"},"EngineA":{"category":"malicious"},"EngineC":{"category":"harmless"}}}`,
+	})
+
+	report, err := virusTotalScan(context.Background(), "vt-key", strings.ToUpper(flagged), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Malicious != 2 || !reflect.DeepEqual(report.Engines, []string{"EngineA", "EngineB"}) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !report.blocked(0) || report.blocked(2) {
+		t.Errorf("expected 2 detections to block only below max_detections 2")
+	}
+	if report.Link != "https://www.virustotal.com/gui/file/"+flagged {
+		t.Errorf("unexpected link %s", report.Link)
+	}
+}
+
+func TestVirusTotalScanUploadsUnknownFiles(t *testing.T) {
+	fakeVirusTotal(t, nil)
+
+	file := filepath.Join(t.TempDir(), "app.msi")
+	if err := os.WriteFile(file, []byte("installer"), 0o644); err != nil {
+		t.Fatalf("failed to write installer: %v", err)
+	}
+	hash := installerhash.FromBytes([]byte("installer"))
+
+	report, err := virusTotalScan(context.Background(), "vt-key", hash, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Malicious != 0 || report.Suspicious != 1 || !reflect.DeepEqual(report.Engines, []string{"EngineA"}) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	if _, err := virusTotalScan(context.Background(), "vt-key", hash, ""); err == nil || !strings.Contains(err.Error(), "download.cache_dir") {
+		t.Errorf("expected an error asking for download.cache_dir, got %v", err)
+	}
+}
+
+func TestValidateScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		scan      ScanConfig
+		cacheDir  string
+		wantField string
+	}{
+		{"disabled", ScanConfig{}, "", ""},
+		{"virustotal", ScanConfig{Scanner: scannerVirusTotal, VirusTotalAPIKey: "key"}, "", ""},
+		{"virustotal without key", ScanConfig{Scanner: scannerVirusTotal}, "", "scan.virustotal_api_key"},
+		{"defender", ScanConfig{Scanner: scannerDefender}, "/tmp/cache", ""},
+		{"defender without cache", ScanConfig{Scanner: scannerDefender}, "", "scan.scanner"},
+		{"unknown scanner", ScanConfig{Scanner: "clamav"}, "", "scan.scanner"},
+		{"negative max detections", ScanConfig{Scanner: scannerVirusTotal, VirusTotalAPIKey: "key", MaxDetections: -1}, "", "scan.max_detections"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateScan(&Config{Scan: tt.scan, Download: DownloadConfig{CacheDir: tt.cacheDir}})
+			if tt.wantField == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("expected a %s problem, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
+
+func TestDefenderScanRequiresWindows(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("checks the non-Windows error")
+	}
+	if _, err := defenderScan(context.Background(), "/cache/app.msi"); err == nil || !strings.Contains(err.Error(), "requires Windows") {
+		t.Errorf("expected a Windows error, got %v", err)
+	}
+}
+
+func TestExecuteScanBlocksFlaggedInstallers(t *testing.T) {
+	hash := installerhash.FromBytes([]byte("installer"))
+	fakeVirusTotal(t, map[string]string{
+		strings.ToLower(hash): `{"last_analysis_stats":{"malicious":1,"suspicious":0},"last_analysis_results":{"EngineA":{"category":"malicious"}}}`,
+	})
+
+	installers := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer installers.Close()
+
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = installers.URL + "/app.msi"
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["scan"] = map[string]any{"scanner": "virustotal", "virustotal_api_key": "vt-key"}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success {
+		t.Fatal("expected the flagged installer to block the submission")
+	}
+	if !strings.Contains(resp.Message, "EngineA") {
+		t.Errorf("expected the flagging engine in the message, got %s", resp.Message)
+	}
+	reports, _ := resp.Outputs["scan_report"].([]any)
+	if len(reports) != 1 {
+		t.Fatalf("expected one scan report, got %v", resp.Outputs["scan_report"])
+	}
+	if report := reports[0].(map[string]any); report["malicious"] != 1 || report["url"] != installers.URL+"/app.msi" {
+		t.Errorf("unexpected scan report: %v", report)
+	}
+
+	// Allowing one detection lets the release through
+	cfg["scan"].(map[string]any)["max_detections"] = float64(1)
+	resp, err = (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if _, ok := resp.Outputs["scan_report"]; !ok {
+		t.Error("expected the scan report in the outputs")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// ScopeOverride holds the installer settings that differ for one scope of an
+// installer listing several scopes.
+type ScopeOverride struct {
+	URL         string `json:"url"`
+	Asset       string `json:"asset"`
+	ProductCode string `json:"product_code"`
+	UpgradeCode string `json:"upgrade_code"`
+}
+
+// expandInstallerScopes replaces each installer that lists several scopes
+// with one installer per scope, applying its scope_overrides. Indexes in
+// problems refer to the installers passed in.
+func expandInstallerScopes(installers []InstallerConfig) ([]InstallerConfig, []fieldError) {
+	var expanded []InstallerConfig
+	var problems []fieldError
+	for i, installer := range installers {
+		for _, scope := range slices.Sorted(maps.Keys(installer.ScopeOverrides)) {
+			if !slices.Contains(installer.Scopes, scope) {
+				problems = append(problems, fieldError{fmt.Sprintf("installers[%d].scope_overrides", i),
+					fmt.Sprintf("override for scope %q, which is not listed in scopes", scope)})
+			}
+		}
+
+		if len(installer.Scopes) == 0 {
+			expanded = append(expanded, installer)
+			continue
+		}
+		if installer.Scope != "" {
+			problems = append(problems, fieldError{fmt.Sprintf("installers[%d].scopes", i),
+				"Only one of scope and scopes may be set"})
+		}
+		for _, scope := range installer.Scopes {
+			copied := installer
+			copied.Scope = scope
+			copied.Scopes = nil
+			copied.ScopeOverrides = nil
+			override := installer.ScopeOverrides[scope]
+			for _, f := range []struct{ dst, src *string }{
+				{&copied.URL, &override.URL},
+				{&copied.Asset, &override.Asset},
+				{&copied.ProductCode, &override.ProductCode},
+				{&copied.UpgradeCode, &override.UpgradeCode},
+			} {
+				if *f.src != "" {
+					*f.dst = *f.src
+				}
+			}
+			expanded = append(expanded, copied)
+		}
+	}
+	return expanded, problems
+}
+
+// ScopeRule sets the scope of asset installers whose asset file name matches
+// a glob pattern.
+type ScopeRule struct {
+	Pattern string `json:"pattern"`
+	Scope   string `json:"scope"`
+}
+
+// validateScopeRules checks the patterns and scopes of scope_rules.
+func validateScopeRules(rules []ScopeRule) []fieldError {
+	enums, _ := manifest.EnumsFor(manifest.SchemaVersion)
+	var problems []fieldError
+	for i, rule := range rules {
+		field := fmt.Sprintf("scope_rules[%d]", i)
+		if rule.Pattern == "" {
+			problems = append(problems, fieldError{field + ".pattern", "pattern is required"})
+		} else if _, err := path.Match(rule.Pattern, ""); err != nil || strings.Contains(rule.Pattern, "/") {
+			problems = append(problems, fieldError{field + ".pattern", fmt.Sprintf("invalid file name pattern %q", rule.Pattern)})
+		}
+		if !slices.Contains(enums.Scopes, rule.Scope) {
+			problems = append(problems, fieldError{field + ".scope", fmt.Sprintf("scope must be one of: %s", strings.Join(enums.Scopes, ", "))})
+		}
+	}
+	return problems
+}
+
+// inferScope returns the scope of the first rule whose pattern matches an
+// asset file name, or "" if none matches.
+func inferScope(name string, rules []ScopeRule) string {
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.Pattern, name); ok {
+			return rule.Scope
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+func TestExpandInstallerScopes(t *testing.T) {
+	installers, problems := expandInstallerScopes([]InstallerConfig{
+		{URL: "https://example.com/app.exe", Architecture: "x64", Type: "exe"},
+		{
+			URL:          "https://example.com/app-user.msi",
+			Architecture: "x64",
+			Type:         "msi",
+			ProductCode:  "{11111111-1111-1111-1111-111111111111}",
+			Scopes:       []string{"user", "machine"},
+			ScopeOverrides: map[string]ScopeOverride{
+				"machine": {URL: "https://example.com/app-machine.msi", ProductCode: "{22222222-2222-2222-2222-222222222222}"},
+			},
+		},
+	})
+	if len(problems) > 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	expected := []struct{ scope, url, productCode string }{
+		{"", "https://example.com/app.exe", ""},
+		{"user", "https://example.com/app-user.msi", "{11111111-1111-1111-1111-111111111111}"},
+		{"machine", "https://example.com/app-machine.msi", "{22222222-2222-2222-2222-222222222222}"},
+	}
+	if len(installers) != len(expected) {
+		t.Fatalf("expected %d installers, got %d", len(expected), len(installers))
+	}
+	for i, e := range expected {
+		got := installers[i]
+		if got.Scope != e.scope || got.URL != e.url || got.ProductCode != e.productCode {
+			t.Errorf("installer %d: expected %s/%s/%s, got %s/%s/%s", i, e.scope, e.url, e.productCode, got.Scope, got.URL, got.ProductCode)
+		}
+		if got.Scopes != nil || got.ScopeOverrides != nil {
+			t.Errorf("installer %d: expected scopes and overrides to be cleared", i)
+		}
+	}
+}
+
+func TestExpandInstallerScopesProblems(t *testing.T) {
+	_, problems := expandInstallerScopes([]InstallerConfig{
+		{Scope: "user", Scopes: []string{"machine"}},
+		{Scopes: []string{"user"}, ScopeOverrides: map[string]ScopeOverride{"machine": {URL: "https://example.com/app.msi"}}},
+	})
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if problems[0].Field != "installers[0].scopes" || problems[1].Field != "installers[1].scope_overrides" {
+		t.Errorf("unexpected problem fields: %v", problems)
+	}
+}
+
+func TestValidateInstallerScopesAcrossArchitectures(t *testing.T) {
+	cfg := validTestConfig()
+	cfg["installers"] = []any{
+		map[string]any{
+			"url":           "https://example.com/app-{{.Arch}}.msi",
+			"architectures": []any{"x64", "arm64"},
+			"type":          "msi",
+			"scopes":        []any{"user", "machine"},
+		},
+	}
+
+	p := &WinGetPlugin{}
+	cfgParsed := p.parseConfig(cfg)
+	if len(cfgParsed.Installers) != 4 {
+		t.Fatalf("expected 4 installers, got %d", len(cfgParsed.Installers))
+	}
+
+	resp, err := p.Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Valid {
+		t.Errorf("expected valid config, got: %v", resp.Errors)
+	}
+}
+
+func TestInferScope(t *testing.T) {
+	rules := defaultConfig().ScopeRules
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"myapp-1.2.3-user-setup.exe", "user"},
+		{"myapp-1.2.3-machine-setup.exe", "machine"},
+		{"myapp-1.2.3-setup.exe", ""},
+		{"myapp-1.2.3-username.exe", ""},
+	}
+
+	for _, tt := range tests {
+		if scope := inferScope(tt.name, rules); scope != tt.expected {
+			t.Errorf("inferScope(%q) = '%s', expected '%s'", tt.name, scope, tt.expected)
+		}
+	}
+}
+
+func TestValidateScopeRules(t *testing.T) {
+	problems := validateScopeRules([]ScopeRule{
+		{Pattern: "*-per-user.exe", Scope: "user"},
+		{Pattern: "[", Scope: "machine"},
+		{Pattern: "*-system.exe", Scope: "system"},
+		{Scope: "user"},
+	})
+
+	var fields []string
+	for _, problem := range problems {
+		fields = append(fields, problem.Field)
+	}
+	expected := []string{"scope_rules[1].pattern", "scope_rules[2].scope", "scope_rules[3].pattern"}
+	if !slices.Equal(fields, expected) {
+		t.Errorf("expected problems on %v, got %v", expected, problems)
+	}
+}
+
+func TestResolveInstallerAssetsInfersScope(t *testing.T) {
+	assets := []githubclient.ReleaseAsset{
+		{Name: "myapp-1.2.3-user-setup.exe", URL: "https://example.com/myapp-1.2.3-user-setup.exe"},
+		{Name: "myapp-1.2.3-machine-setup.exe", URL: "https://example.com/myapp-1.2.3-machine-setup.exe"},
+	}
+	installers := []InstallerConfig{
+		{Asset: "*-user-setup.exe", Architecture: "x64", Type: "inno"},
+		{Asset: "*-machine-setup.exe", Architecture: "x64", Type: "inno"},
+		{Asset: "*-machine-setup.exe", Architecture: "x86", Type: "inno", Scope: "user"},
+	}
+
+	resolved, err := resolveInstallerAssets(installers, assets, assetRules{ScopeRules: defaultConfig().ScopeRules})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, scope := range []string{"user", "machine", "user"} {
+		if resolved[i].Scope != scope {
+			t.Errorf("installer %d: expected scope '%s', got '%s'", i, scope, resolved[i].Scope)
+		}
+	}
+	if problems := validateInstallerCombinations(installers[:2]); len(problems) > 0 {
+		t.Errorf("expected installers with inferred scopes not to be duplicates, got %v", problems)
+	}
+}
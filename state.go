@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// stateGistFile is the name of the gist file holding the submission state.
+const stateGistFile = "winget-submissions.json"
+
+// maxStateRecords is the number of submissions kept per package.
+const maxStateRecords = 100
+
+// Outcomes of recorded submissions.
+const (
+	outcomeSubmitted = "submitted"
+	outcomePublished = "published"
+	outcomeSkipped   = "skipped"
+	outcomeFailed    = "failed"
+	// outcomeRolledBack records a submission closed by the on-error hook.
+	outcomeRolledBack = "rolled_back"
+)
+
+// StateConfig defines where the history of submissions is recorded, so
+// reruns and audits can tell what was already submitted without querying
+// winget-pkgs.
+type StateConfig struct {
+	// Path is a local JSON file, such as one cached by CI.
+	Path string `json:"path"`
+	// GistID is a GitHub gist, updated with the github_token, which keeps
+	// the state shared across machines.
+	GistID string `json:"gist_id"`
+}
+
+// enabled reports whether submissions are recorded.
+func (c StateConfig) enabled() bool {
+	return c.Path != "" || c.GistID != ""
+}
+
+// validateState checks the state settings.
+func validateState(cfg StateConfig) []fieldError {
+	if cfg.Path != "" && cfg.GistID != "" {
+		return []fieldError{{"state.gist_id", "only one of path and gist_id may be set"}}
+	}
+	return nil
+}
+
+// submissionRecord is one recorded submission of a package version.
+type submissionRecord struct {
+	Version   string `json:"version"`
+	Outcome   string `json:"outcome"`
+	Backend   string `json:"backend"`
+	PRURL     string `json:"pr_url,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	CommitSHA string `json:"commit_sha,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// RunID is the run_id of the run that made the submission, and Resumed
+	// whether it adopted the pull request of an earlier run.
+	RunID     string    `json:"run_id,omitempty"`
+	Resumed   bool      `json:"resumed,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Installers are the hashed installers by URL.
+	Installers map[string]installerRecord `json:"installers,omitempty"`
+}
+
+// installerRecord identifies the exact installer file that was hashed for a
+// submission: its hash and, for downloaded installers, the final URL after
+// redirects and the ETag and Last-Modified validators of the download.
+type installerRecord struct {
+	SHA256       string `json:"sha256"`
+	Size         int64  `json:"size,omitempty"`
+	FinalURL     string `json:"final_url,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// submissionState is the content of the state file: the submissions of
+// each package, oldest first.
+type submissionState struct {
+	Packages map[string][]submissionRecord `json:"packages"`
+}
+
+// add appends a record to the submissions of a package, dropping the oldest
+// beyond maxStateRecords.
+func (s *submissionState) add(packageID string, record submissionRecord) {
+	if s.Packages == nil {
+		s.Packages = make(map[string][]submissionRecord)
+	}
+	records := append(s.Packages[packageID], record)
+	if len(records) > maxStateRecords {
+		records = records[len(records)-maxStateRecords:]
+	}
+	s.Packages[packageID] = records
+}
+
+// submittedByRun returns the latest record of a package version if it is a
+// pull request submitted, not resumed, by the run with runID. Runs without
+// a run ID cannot be told apart and have no submission.
+func (s *submissionState) submittedByRun(packageID, version, runID string) (submissionRecord, bool) {
+	records := s.Packages[packageID]
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if record.Version != version {
+			continue
+		}
+		ok := runID != "" && record.RunID == runID && record.Outcome == outcomeSubmitted && !record.Resumed && record.Branch != ""
+		return record, ok
+	}
+	return submissionRecord{}, false
+}
+
+// newSubmissionRecord returns the record of a post-publish response.
+func newSubmissionRecord(cfg *Config, version string, resp *plugin.ExecuteResponse, now time.Time) submissionRecord {
+	record := submissionRecord{
+		Version:   version,
+		Backend:   cfg.Backend,
+		RunID:     cfg.RunID,
+		Timestamp: now.UTC(),
+	}
+	record.Resumed, _ = resp.Outputs["pr_resumed"].(bool)
+	record.PRURL, _ = resp.Outputs["pr_url"].(string)
+	record.Branch, _ = resp.Outputs["branch_name"].(string)
+	record.CommitSHA, _ = resp.Outputs["commit_sha"].(string)
+	if hashes, ok := resp.Outputs["installer_hashes"].(map[string]any); ok {
+		record.Installers = make(map[string]installerRecord, len(hashes))
+		for url, value := range hashes {
+			entry, _ := value.(map[string]any)
+			var installer installerRecord
+			installer.SHA256, _ = entry["sha256"].(string)
+			installer.Size, _ = entry["size"].(int64)
+			installer.FinalURL, _ = entry["final_url"].(string)
+			installer.ETag, _ = entry["etag"].(string)
+			installer.LastModified, _ = entry["last_modified"].(string)
+			record.Installers[url] = installer
+		}
+	}
+
+	switch {
+	case !resp.Success:
+		record.Outcome = outcomeFailed
+		record.Message = resp.Message
+	case resp.Outputs["skipped"] == true:
+		record.Outcome = outcomeSkipped
+	case record.PRURL != "":
+		record.Outcome = outcomeSubmitted
+	default:
+		record.Outcome = outcomePublished
+	}
+	return record
+}
+
+// loadSubmissionState reads the state from the configured file or gist. A
+// missing state is empty.
+func (p *WinGetPlugin) loadSubmissionState(ctx context.Context, cfg *Config, logger *slog.Logger) (*submissionState, error) {
+	var data []byte
+	if cfg.State.GistID != "" {
+		content, err := newGitHubClient(ctx, cfg, "", logger).GistFile(ctx, cfg.State.GistID, stateGistFile)
+		if err != nil {
+			return nil, err
+		}
+		data = []byte(content)
+	} else {
+		content, err := os.ReadFile(cfg.State.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read state file: %w", err)
+		}
+		data = content
+	}
+
+	state := &submissionState{}
+	if len(data) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return state, nil
+}
+
+// saveSubmissionState writes the state to the configured file or gist.
+func (p *WinGetPlugin) saveSubmissionState(ctx context.Context, cfg *Config, state *submissionState, logger *slog.Logger) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if cfg.State.GistID != "" {
+		return newGitHubClient(ctx, cfg, "", logger).UpdateGistFile(ctx, cfg.State.GistID, stateGistFile, string(data))
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.State.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(cfg.State.Path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// recordSubmission adds the outcome of a post-publish run to the state.
+// Failing to record it does not fail the run.
+func (p *WinGetPlugin) recordSubmission(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, resp *plugin.ExecuteResponse, logger *slog.Logger) {
+	version, err := packageVersionFor(cfg, releaseCtx)
+	if err != nil {
+		return
+	}
+
+	// The state is recorded even if the run used up its deadline
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+
+	state, err := p.loadSubmissionState(ctx, cfg, logger)
+	if err == nil {
+		state.add(cfg.PackageID, newSubmissionRecord(cfg, version, resp, time.Now()))
+		err = p.saveSubmissionState(ctx, cfg, state, logger)
+	}
+	if err != nil {
+		logger.Warn("Failed to record submission state", "error", err)
+	}
+}
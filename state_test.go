@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateState(t *testing.T) {
+	if problems := validateState(StateConfig{Path: "state.json"}); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	if problems := validateState(StateConfig{Path: "state.json", GistID: "abc123"}); len(problems) != 1 || problems[0].Field != "state.gist_id" {
+		t.Errorf("expected a state.gist_id problem, got %v", problems)
+	}
+}
+
+func TestNewSubmissionRecord(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		resp    *plugin.ExecuteResponse
+		outcome string
+	}{
+		{"submitted", &plugin.ExecuteResponse{Success: true, Outputs: map[string]any{"pr_url": "https://github.com/microsoft/winget-pkgs/pull/1", "branch_name": "winget/b", "commit_sha": "abc"}}, outcomeSubmitted},
+		{"published", &plugin.ExecuteResponse{Success: true, Outputs: map[string]any{}}, outcomePublished},
+		{"skipped", &plugin.ExecuteResponse{Success: true, Outputs: map[string]any{"skipped": true}}, outcomeSkipped},
+		{"failed", &plugin.ExecuteResponse{Success: false, Message: "boom"}, outcomeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := newSubmissionRecord(&Config{Backend: backendGitHub}, "1.2.3", tt.resp, now)
+			if record.Outcome != tt.outcome || record.Version != "1.2.3" || !record.Timestamp.Equal(now) {
+				t.Errorf("unexpected record: %+v", record)
+			}
+		})
+	}
+
+	tests[0].resp.Outputs["pr_resumed"] = true
+	record := newSubmissionRecord(&Config{RunID: "42"}, "1.2.3", tests[0].resp, now)
+	if record.PRURL == "" || record.Branch != "winget/b" || record.CommitSHA != "abc" || record.RunID != "42" || !record.Resumed {
+		t.Errorf("expected pull request details, got %+v", record)
+	}
+
+	resp := &plugin.ExecuteResponse{Success: true, Outputs: map[string]any{"installer_hashes": map[string]any{
+		"https://example.com/app.msi": map[string]any{"sha256": "ABC", "size": int64(42), "final_url": "https://cdn.example.com/app.msi", "etag": `"v1"`},
+	}}}
+	record = newSubmissionRecord(&Config{}, "1.2.3", resp, now)
+	expected := installerRecord{SHA256: "ABC", Size: 42, FinalURL: "https://cdn.example.com/app.msi", ETag: `"v1"`}
+	if record.Installers["https://example.com/app.msi"] != expected {
+		t.Errorf("expected installer %+v, got %+v", expected, record.Installers)
+	}
+}
+
+func TestSubmissionStateAdd(t *testing.T) {
+	var state submissionState
+	for i := range maxStateRecords + 5 {
+		state.add("MyOrg.MyApp", submissionRecord{Version: string(rune('a' + i%26))})
+	}
+	if records := state.Packages["MyOrg.MyApp"]; len(records) != maxStateRecords || records[0].Version != "f" {
+		t.Errorf("expected the oldest records to be dropped, got %d starting at %s", len(records), records[0].Version)
+	}
+}
+
+func TestRecordSubmissionFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "winget.json")
+	cfg := &Config{PackageID: "MyOrg.MyApp", Backend: backendGitHub, PackageVersion: "{{.Version}}", State: StateConfig{Path: path}}
+	p := &WinGetPlugin{}
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		resp := &plugin.ExecuteResponse{Success: true, Outputs: map[string]any{"pr_url": "https://example.com/pr/" + version}}
+		p.recordSubmission(context.Background(), &plugin.ReleaseContext{Version: version}, cfg, resp, slog.Default())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected a state file: %v", err)
+	}
+	var state submissionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("invalid state file: %v", err)
+	}
+	records := state.Packages["MyOrg.MyApp"]
+	if len(records) != 2 || records[1].Version != "1.1.0" || records[1].Outcome != outcomeSubmitted || records[1].PRURL != "https://example.com/pr/1.1.0" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestRecordSubmissionGist(t *testing.T) {
+	var saved string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gists/abc123" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"files":{"winget-submissions.json":{"content":"{\"packages\":{\"MyOrg.MyApp\":[{\"version\":\"1.0.0\",\"outcome\":\"submitted\"}]}}"}}}`))
+		case http.MethodPatch:
+			var body struct {
+				Files map[string]struct {
+					Content string `json:"content"`
+				} `json:"files"`
+			}
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+			saved = body.Files[stateGistFile].Content
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	cfg := &Config{PackageID: "MyOrg.MyApp", GitHubToken: "test-token", PackageVersion: "{{.Version}}", State: StateConfig{GistID: "abc123"}}
+	resp := &plugin.ExecuteResponse{Success: false, Message: "Failed to create PR"}
+	(&WinGetPlugin{}).recordSubmission(context.Background(), &plugin.ReleaseContext{Version: "1.1.0"}, cfg, resp, slog.Default())
+
+	var state submissionState
+	if err := json.Unmarshal([]byte(saved), &state); err != nil {
+		t.Fatalf("invalid saved state %q: %v", saved, err)
+	}
+	records := state.Packages["MyOrg.MyApp"]
+	if len(records) != 2 || records[1].Outcome != outcomeFailed || records[1].Message != "Failed to create PR" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
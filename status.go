@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// formatAge formats the age of a pull request in days, hours or minutes.
+func formatAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	}
+}
+
+// Status lists the open winget-pkgs pull requests of the token's user for
+// the configured package, oldest first, with their labels (such as the
+// winget-pkgs validation labels) and ages, in the pull_requests output.
+func (p *WinGetPlugin) Status(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+	cfg := p.parseConfig(req.Config)
+	redactor := newRedactor(cfg)
+	logger := slog.New(redactor.handler(slog.Default().Handler())).With("plugin", "winget", "mode", "status")
+
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+
+	pulls, err := newGitHubClient(ctx, cfg, "", logger).OpenPullRequests(ctx, cfg.PackageID)
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to list open PRs: %v", err)
+		redactor.executeResponse(resp)
+		return resp, nil
+	}
+
+	now := time.Now()
+	results := make([]any, 0, len(pulls))
+	for _, pull := range pulls {
+		labels := make([]any, 0, len(pull.Labels))
+		for _, label := range pull.Labels {
+			labels = append(labels, label)
+		}
+		results = append(results, map[string]any{
+			"number":     pull.Number,
+			"title":      pull.Title,
+			"url":        pull.URL,
+			"labels":     labels,
+			"created_at": pull.CreatedAt.Format(time.RFC3339),
+			"age":        formatAge(now.Sub(pull.CreatedAt)),
+		})
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("%d open PRs for %s", len(pulls), cfg.PackageID),
+		Outputs: map[string]any{"pull_requests": results},
+	}, nil
+}
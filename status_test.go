@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{30 * time.Second, "0m"},
+		{45 * time.Minute, "45m"},
+		{5*time.Hour + 59*time.Minute, "5h"},
+		{50 * time.Hour, "2d"},
+	}
+
+	for _, tt := range tests {
+		if age := formatAge(tt.age); age != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.age, tt.expected, age)
+		}
+	}
+}
+
+func TestRunCLIStatus(t *testing.T) {
+	created := time.Now().Add(-50 * time.Hour).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login":"myuser"}`))
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); !strings.Contains(q, `"MyOrg.MyApp" in:title`) {
+				t.Errorf("unexpected query: %s", q)
+			}
+			_, _ = fmt.Fprintf(w, `{"total_count":1,"items":[{"number":42,"title":"New version: MyOrg.MyApp version 1.2.3","html_url":"https://github.com/microsoft/winget-pkgs/pull/42","labels":[{"name":"Azure-Pipeline-Passed"},{"name":"Validation-Completed"}],"created_at":%q}]}`, created)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	args := []string{"status", "--config", writeCLIConfig(t, cliTestConfig)}
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{"#42 New version: MyOrg.MyApp version 1.2.3 (2d) [Azure-Pipeline-Passed Validation-Completed]", "1 open PRs for MyOrg.MyApp"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in output, got: %s", want, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// dryRunSummaryData is what a dry-run summary reports.
+type dryRunSummaryData struct {
+	// Message is what the run would do, such as creating a PR.
+	Message      string
+	ManifestPath string
+	// Files are the paths of the manifest files to be added.
+	Files          []string
+	Installers     []manifest.Installer
+	HashesComputed bool
+	// PublishedVersion is the latest published version, whose manifests
+	// Changes and Diff compare with, or "" if there is none.
+	PublishedVersion string
+	Changes          []configSuggestion
+	Diff             string
+}
+
+// dryRunSummary renders the summary of a dry-run in GitHub-flavored
+// Markdown, for pipelines to post as a pull request comment or job summary
+// for approval: the manifest files to be added, the installers with their
+// hashes and the metadata changed since the latest published version.
+func dryRunSummary(d dryRunSummaryData) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## winget dry-run\n\n%s.\n\n", d.Message)
+
+	fmt.Fprintf(&b, "### Files to be added\n\nIn `%s`:\n\n", d.ManifestPath)
+	for _, file := range d.Files {
+		fmt.Fprintf(&b, "- `%s`\n", path.Base(file))
+	}
+
+	b.WriteString("\n### Installers\n\n| Architecture | Type | Scope | URL | SHA256 |\n| --- | --- | --- | --- | --- |\n")
+	for _, installer := range d.Installers {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | `%s` |\n",
+			markdownCell(installer.Architecture), markdownCell(installer.InstallerType), markdownCell(installer.Scope),
+			markdownCell(installer.InstallerURL), installer.InstallerSha256)
+	}
+	if !d.HashesComputed {
+		b.WriteString("\nHashes are placeholders; set `dry_run_hash: real` to compute them.\n")
+	}
+
+	b.WriteString("\n### Metadata changes\n\n")
+	switch {
+	case d.PublishedVersion == "":
+		b.WriteString("No published version to compare with.\n")
+	case len(d.Changes) == 0:
+		fmt.Fprintf(&b, "No metadata changes since %s.\n", d.PublishedVersion)
+	default:
+		fmt.Fprintf(&b, "| Field | %s | New |\n| --- | --- | --- |\n", markdownCell(d.PublishedVersion))
+		for _, change := range d.Changes {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", change.Field, markdownCell(formatConfigValue(change.Current)), markdownCell(formatConfigValue(change.Suggested)))
+		}
+	}
+	if d.Diff != "" {
+		fence := "```"
+		for strings.Contains(d.Diff, fence) {
+			fence += "`"
+		}
+		fmt.Fprintf(&b, "\n<details>\n<summary>Manifest diff against %s</summary>\n\n%sdiff\n%s\n%s\n\n</details>\n",
+			d.PublishedVersion, fence, strings.TrimRight(d.Diff, "\n"), fence)
+	}
+	return b.String()
+}
+
+// metadataChanges compares the package and locale metadata of published and
+// generated manifests, keyed by file name, as configuration settings. The
+// Current value of a change is the published one and Suggested the
+// generated one. Manifests that fail to import have no changes.
+func metadataChanges(published, generated map[string]string) []configSuggestion {
+	if len(published) == 0 {
+		return nil
+	}
+	named := make(map[string]string, len(generated))
+	for file, content := range generated {
+		named[path.Base(file)] = content
+	}
+	previous, err := importManifests(published)
+	if err != nil {
+		return nil
+	}
+	current, err := importManifests(named)
+	if err != nil {
+		return nil
+	}
+
+	var changes []configSuggestion
+	for _, key := range []string{"metadata", "locales"} {
+		changes = append(changes, suggestConfigChanges(key, previous[key], current[key])...)
+	}
+	return changes
+}
+
+// markdownCell escapes a value for a Markdown table cell.
+func markdownCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
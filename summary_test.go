@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestDryRunSummary(t *testing.T) {
+	base := dryRunSummaryData{
+		Message:      "Would create PR for MyOrg.MyApp version 1.2.3",
+		ManifestPath: "manifests/m/MyOrg/MyApp/1.2.3",
+		Files: []string{
+			"manifests/m/MyOrg/MyApp/1.2.3/MyOrg.MyApp.installer.yaml",
+			"manifests/m/MyOrg/MyApp/1.2.3/MyOrg.MyApp.yaml",
+		},
+		Installers: []manifest.Installer{{
+			Architecture:    "x64",
+			InstallerType:   "msi",
+			InstallerURL:    "https://example.com/app|1.2.3.msi",
+			InstallerSha256: strings.Repeat("0", 64),
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(d *dryRunSummaryData)
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "unpublished package",
+			contains: []string{
+				"Would create PR for MyOrg.MyApp version 1.2.3.\n",
+				"In `manifests/m/MyOrg/MyApp/1.2.3`:\n\n- `MyOrg.MyApp.installer.yaml`\n- `MyOrg.MyApp.yaml`\n",
+				"| x64 | msi | - | https://example.com/app\\|1.2.3.msi | `" + strings.Repeat("0", 64) + "` |\n",
+				"Hashes are placeholders",
+				"No published version to compare with.\n",
+			},
+			excludes: []string{"<details>"},
+		},
+		{
+			name: "unchanged metadata",
+			modify: func(d *dryRunSummaryData) {
+				d.HashesComputed = true
+				d.PublishedVersion = "1.2.2"
+				d.Diff = "--- a\n+++ b\n"
+			},
+			contains: []string{
+				"No metadata changes since 1.2.2.\n",
+				"<summary>Manifest diff against 1.2.2</summary>\n\n```diff\n--- a\n+++ b\n```\n",
+			},
+			excludes: []string{"Hashes are placeholders"},
+		},
+		{
+			name: "changed metadata",
+			modify: func(d *dryRunSummaryData) {
+				d.PublishedVersion = "1.2.2"
+				d.Changes = []configSuggestion{
+					{Field: "metadata.license", Current: "MIT", Suggested: "Apache-2.0"},
+					{Field: "metadata.tags", Current: nil, Suggested: []any{"cli", "tool"}},
+				}
+			},
+			contains: []string{
+				"| Field | 1.2.2 | New |\n",
+				"| `metadata.license` | MIT | Apache-2.0 |\n",
+				"| `metadata.tags` | (unset) | [cli, tool] |\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := base
+			if tt.modify != nil {
+				tt.modify(&data)
+			}
+			summary := dryRunSummary(data)
+			for _, expected := range tt.contains {
+				if !strings.Contains(summary, expected) {
+					t.Errorf("expected the summary to contain %q, got:\n%s", expected, summary)
+				}
+			}
+			for _, unexpected := range tt.excludes {
+				if strings.Contains(summary, unexpected) {
+					t.Errorf("expected the summary not to contain %q, got:\n%s", unexpected, summary)
+				}
+			}
+		})
+	}
+}
+
+func TestMetadataChanges(t *testing.T) {
+	previous := validTestManifests(t)
+	previous.Locale.License = "MIT License"
+	current := validTestManifests(t)
+	current.Locale.Tags = []string{"cli"}
+	// Installer changes are in the installer table, not the metadata changes
+	current.Installer.Installers[0].InstallerURL = "https://example.com/app-2.0.0.msi"
+
+	generated, err := current.GetFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []string
+	for _, change := range metadataChanges(manifestFiles(t, previous), generated) {
+		result = append(result, change.String())
+	}
+	expected := []string{"metadata.license: MIT License -> MIT", "metadata.tags: [utility] -> [cli]"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+
+	if changes := metadataChanges(nil, generated); changes != nil {
+		t.Errorf("expected no changes without published manifests, got %v", changes)
+	}
+}
+
+func TestExecuteDryRunSummary(t *testing.T) {
+	previous := validTestManifests(t)
+	previous.Locale.License = "MIT License"
+	server := newWingetPkgsServer(t, "MyOrg.MyApp", map[string]map[string]string{"1.0.0": manifestFiles(t, previous)})
+
+	cfg := publishedTestConfig(server)
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+		DryRun:  true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	summary, _ := resp.Outputs["summary_markdown"].(string)
+	for _, expected := range []string{
+		"Would create PR for MyOrg.MyApp version 1.2.3.\n",
+		"- `MyOrg.MyApp.installer.yaml`\n",
+		"| x64 | msi |",
+		"| `metadata.license` | MIT License | MIT |\n",
+		"```diff\n",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected the summary to contain %q, got:\n%s", expected, summary)
+		}
+	}
+}
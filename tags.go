@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Sources of inherited tags.
+const (
+	// tagSourcePublished is the default locale of the latest version
+	// published in winget-pkgs, which keeps tags added by moderators.
+	tagSourcePublished = "published"
+	// tagSourceTopics is the GitHub topics of the released repository.
+	tagSourceTopics = "topics"
+)
+
+// validateInheritTags checks the sources of inherited tags.
+func validateInheritTags(sources []string) []fieldError {
+	var problems []fieldError
+	for i, source := range sources {
+		if source != tagSourcePublished && source != tagSourceTopics {
+			problems = append(problems, fieldError{fmt.Sprintf("metadata.inherit_tags[%d]", i), fmt.Sprintf("tag source must be %q or %q", tagSourcePublished, tagSourceTopics)})
+		}
+	}
+	return problems
+}
+
+// inheritedTags returns the package tags of the configured inherited
+// sources, in source order. A source that cannot be read is logged and
+// skipped, as inherited tags only complement the configured ones.
+func (p *WinGetPlugin) inheritedTags(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) []string {
+	if len(cfg.Metadata.InheritTags) == 0 {
+		return nil
+	}
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+	ghClient := newGitHubClient(ctx, cfg, "", logger)
+
+	var tags []string
+	for _, source := range cfg.Metadata.InheritTags {
+		switch source {
+		case tagSourcePublished:
+			version, files, err := ghClient.PublishedManifests(ctx, cfg.PackageID)
+			if err != nil {
+				logger.Warn("Failed to read tags of the published version", "error", err)
+				continue
+			}
+			if version == "" {
+				continue
+			}
+			published, err := importManifests(files)
+			if err != nil {
+				logger.Warn("Failed to read tags of the published version", "version", version, "error", err)
+				continue
+			}
+			metadata, _ := published["metadata"].(map[string]any)
+			publishedTags, _ := metadata["tags"].([]any)
+			for _, tag := range publishedTags {
+				tags = append(tags, fmt.Sprint(tag))
+			}
+		case tagSourceTopics:
+			if releaseCtx.RepositoryOwner == "" || releaseCtx.RepositoryName == "" {
+				logger.Warn("Release context has no repository to read topics from")
+				continue
+			}
+			topics, err := ghClient.RepositoryTopics(ctx, releaseCtx.RepositoryOwner, releaseCtx.RepositoryName)
+			if err != nil {
+				logger.Warn("Failed to read repository topics", "error", err)
+				continue
+			}
+			tags = append(tags, topics...)
+		}
+	}
+	return tags
+}
+
+// canonicalizeConfigTags merges inherited tags after the configured package
+// tags, so the configured ones are kept first under the cap, and
+// canonicalizes the package and locale tags, kebab-casing them with
+// metadata.normalize_tags. Tags dropped as duplicates or beyond the cap are
+// logged.
+func canonicalizeConfigTags(cfg *Config, inherited []string, logger *slog.Logger) {
+	var dropped []string
+	cfg.Metadata.Tags, dropped = canonicalizeTags(slices.Concat(cfg.Metadata.Tags, inherited), cfg.Metadata.NormalizeTags)
+	if len(dropped) > 0 {
+		logger.Info("Dropped duplicate or excess tags", "tags", dropped)
+	}
+	for i := range cfg.Locales {
+		cfg.Locales[i].Tags, dropped = canonicalizeTags(cfg.Locales[i].Tags, cfg.Metadata.NormalizeTags)
+		if len(dropped) > 0 {
+			logger.Info("Dropped duplicate or excess tags", "locale", cfg.Locales[i].Locale, "tags", dropped)
+		}
+	}
+}
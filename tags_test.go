@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateInheritTags(t *testing.T) {
+	if problems := validateInheritTags([]string{"published", "topics"}); len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	if problems := validateInheritTags([]string{"topics", "readme"}); len(problems) != 1 || problems[0].Field != "metadata.inherit_tags[1]" {
+		t.Errorf("expected a metadata.inherit_tags[1] problem, got %v", problems)
+	}
+}
+
+func TestCanonicalizeConfigTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize bool
+		tags      []string
+		inherited []string
+		expected  string
+	}{
+		{"configured", false, []string{" CLI ", "Developer Tools", "cli"}, nil, "cli,developer tools"},
+		{"normalized", true, []string{"Developer Tools"}, []string{"developer-tools"}, "developer-tools"},
+		{"inherited", false, []string{"cli"}, []string{"utility", "CLI", "windows"}, "cli,utility,windows"},
+		{"configured first under the cap", false, []string{"zeta"}, strings.Split("a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,p", ","), "a,b,c,d,e,f,g,h,i,j,k,l,m,n,o,zeta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Metadata: MetadataConfig{Tags: tt.tags, NormalizeTags: tt.normalize}}
+			canonicalizeConfigTags(cfg, tt.inherited, slog.New(slog.DiscardHandler))
+			if got := strings.Join(cfg.Metadata.Tags, ","); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInheritedTags(t *testing.T) {
+	published := validTestManifests(t)
+	published.Locale.Tags = []string{"utility", "Productivity"}
+	wingetPkgs := wingetPkgsHandler("MyOrg.MyApp", map[string]map[string]string{"1.0.0": manifestFiles(t, published)})
+	newGitHubAPIServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/myorg/myapp/topics" {
+			_, _ = w.Write([]byte(`{"names":["cli","utility"]}`))
+			return
+		}
+		wingetPkgs(w, r)
+	}))
+
+	cfg, _ := decodePluginConfig(validTestConfig())
+	cfg.Metadata.InheritTags = []string{tagSourceTopics, tagSourcePublished}
+	releaseCtx := &plugin.ReleaseContext{RepositoryOwner: "myorg", RepositoryName: "myapp"}
+	tags := (&WinGetPlugin{}).inheritedTags(context.Background(), releaseCtx, cfg, slog.New(slog.DiscardHandler))
+	if got := strings.Join(tags, ","); got != "cli,utility,utility,Productivity" {
+		t.Errorf("expected the topics and then the published tags, got %s", got)
+	}
+
+	// Unreadable sources are skipped
+	tags = (&WinGetPlugin{}).inheritedTags(context.Background(), &plugin.ReleaseContext{}, cfg, slog.New(slog.DiscardHandler))
+	if got := strings.Join(tags, ","); got != "utility,Productivity" {
+		t.Errorf("expected only the published tags, got %s", got)
+	}
+}
+
+func TestExecuteInheritsTags(t *testing.T) {
+	published := validTestManifests(t)
+	published.Locale.Tags = []string{"utility"}
+	wingetPkgs := wingetPkgsHandler("MyOrg.MyApp", map[string]map[string]string{"1.0.0": manifestFiles(t, published)})
+	server := newGitHubAPIServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/app-") {
+			_, _ = w.Write([]byte("installer"))
+			return
+		}
+		wingetPkgs(w, r)
+	}))
+
+	cfg := publishedTestConfig(server)
+	cfg["metadata"].(map[string]any)["tags"] = []any{"CLI"}
+	cfg["metadata"].(map[string]any)["inherit_tags"] = []any{"published"}
+	cfg["output_dir"] = t.TempDir()
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+		DryRun:  true,
+	})
+	if err != nil || !resp.Success {
+		t.Fatalf("expected success, got %v %v", err, resp)
+	}
+
+	content, err := os.ReadFile(filepath.Join(resp.Outputs["manifest_dir"].(string), "MyOrg.MyApp.locale.en-US.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "Tags:\n    - cli\n    - utility\n") {
+		t.Errorf("expected the configured and inherited tags, got:\n%s", content)
+	}
+}
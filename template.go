@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// templateData is the data available to configuration templates. Field names
+// are part of the configuration format, hence PackageId rather than PackageID.
+type templateData struct {
+	// Version is the released version as reported by Relicta.
+	Version string
+	// PackageVersion is the manifest PackageVersion, after normalization and
+	// the package version transform.
+	PackageVersion string
+	// URLVersion and DisplayVersion are Version after the url and display
+	// version transforms.
+	URLVersion     string
+	DisplayVersion string
+	PackageId      string
+	// Arch is the installer architecture, or its arch_aliases alias. It is
+	// only set for installer fields.
+	Arch string
+	// Publisher and PackageName are the configured metadata publisher and
+	// name, before rendering.
+	Publisher   string
+	PackageName string
+	// Channel is the prerelease channel of Version, such as "beta" for
+	// 1.2.3-beta.1, or "" for stable releases.
+	Channel         string
+	PreviousVersion string
+	Tag             string
+	ReleaseType     string
+	Branch          string
+	CommitSHA       string
+	RepositoryURL   string
+	RepositoryOwner string
+	RepositoryName  string
+	ReleaseNotes    string
+	Changelog       string
+	// PluginVersion is the version of this plugin and RunID the run_id of
+	// the pipeline run.
+	PluginVersion string
+	RunID         string
+}
+
+// newTemplateData returns the template data of a release. PackageVersion is
+// left empty if package_version fails to render; packageVersionFor reports
+// the error.
+func newTemplateData(releaseCtx *plugin.ReleaseContext, cfg *Config) templateData {
+	data := releaseTemplateData(releaseCtx, cfg)
+	data.PackageVersion, _ = packageVersionFor(cfg, releaseCtx)
+	return data
+}
+
+// releaseTemplateData returns the template data of a release without the
+// PackageVersion, which is derived from it.
+func releaseTemplateData(releaseCtx *plugin.ReleaseContext, cfg *Config) templateData {
+	return templateData{
+		Version:         releaseCtx.Version,
+		URLVersion:      cfg.VersionTransforms.URL.Apply(releaseCtx.Version),
+		DisplayVersion:  cfg.VersionTransforms.Display.Apply(releaseCtx.Version),
+		PackageId:       cfg.PackageID,
+		Publisher:       cfg.Metadata.Publisher,
+		PackageName:     cfg.Metadata.Name,
+		Channel:         versionChannel(releaseCtx.Version),
+		PreviousVersion: releaseCtx.PreviousVersion,
+		Tag:             releaseCtx.TagName,
+		ReleaseType:     releaseCtx.ReleaseType,
+		Branch:          releaseCtx.Branch,
+		CommitSHA:       releaseCtx.CommitSHA,
+		RepositoryURL:   releaseCtx.RepositoryURL,
+		RepositoryOwner: releaseCtx.RepositoryOwner,
+		RepositoryName:  releaseCtx.RepositoryName,
+		ReleaseNotes:    releaseCtx.ReleaseNotes,
+		Changelog:       releaseCtx.Changelog,
+		PluginVersion:   Version,
+		RunID:           cfg.RunID,
+	}
+}
+
+// defaultUserAgent is the default user_agent template.
+const defaultUserAgent = "Relicta-WinGet-Plugin/{{.PluginVersion}}{{if .RunID}} (run {{.RunID}}){{end}}"
+
+// renderUserAgent renders the user_agent template, which only has the
+// plugin version and run ID as data since clients exist before a release
+// does. An invalid template renders the default user agent.
+func renderUserAgent(cfg *Config) (string, error) {
+	data := templateData{PluginVersion: Version, RunID: cfg.RunID}
+	userAgent, err := renderTemplate(cfg.UserAgent, data)
+	if err == nil && strings.ContainsAny(userAgent, "\r\n") {
+		err = fmt.Errorf("user agent %q must be a single line", userAgent)
+	}
+	if err != nil {
+		userAgent, _ = renderTemplate(defaultUserAgent, data)
+		return userAgent, err
+	}
+	return strings.TrimSpace(userAgent), nil
+}
+
+// versionChannel returns the first prerelease identifier of a semantic
+// version with its numeric suffix removed, so 1.2.3-beta.1 and 1.2.3-beta1
+// are on the beta channel.
+func versionChannel(version string) string {
+	version, _, _ = strings.Cut(version, "+")
+	_, prerelease, ok := strings.Cut(version, "-")
+	if !ok {
+		return ""
+	}
+	channel, _, _ := strings.Cut(prerelease, ".")
+	return strings.ToLower(strings.TrimRight(channel, "0123456789"))
+}
+
+// sampleTemplateData returns template data used to check templates during
+// config validation, before a release exists.
+func sampleTemplateData(cfg *Config) templateData {
+	return newTemplateData(sampleReleaseContext(), cfg)
+}
+
+// sampleReleaseContext returns the release used to check templates during
+// config validation.
+func sampleReleaseContext() *plugin.ReleaseContext {
+	return &plugin.ReleaseContext{
+		Version:         "1.2.3",
+		PreviousVersion: "1.2.2",
+		TagName:         "v1.2.3",
+		RepositoryOwner: "owner",
+		RepositoryName:  "repo",
+	}
+}
+
+// defaultReleaseNotesURL returns the release page of the release tag in the
+// released repository, or "" if the release has no tag or repository. The
+// page is on GitHub unless the repository URL is another https URL, such as
+// a GitHub Enterprise one.
+func defaultReleaseNotesURL(releaseCtx *plugin.ReleaseContext) string {
+	if releaseCtx.TagName == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(releaseCtx.RepositoryURL, "/"), ".git")
+	if !strings.HasPrefix(base, "https://") {
+		if releaseCtx.RepositoryOwner == "" || releaseCtx.RepositoryName == "" {
+			return ""
+		}
+		base = "https://github.com/" + releaseCtx.RepositoryOwner + "/" + releaseCtx.RepositoryName
+	}
+	return base + "/releases/tag/" + url.PathEscape(releaseCtx.TagName)
+}
+
+// templateFuncs are the helper functions available to configuration
+// templates, in addition to the text/template builtins such as urlquery.
+// Arguments are ordered so the value can be piped in, as in
+// {{.Version | replace "." "_"}}.
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"trimSuffix": func(suffix, s string) string {
+		return strings.TrimSuffix(s, suffix)
+	},
+	"major": func(version string) string { return versionField(version, 0) },
+	"minor": func(version string) string { return versionField(version, 1) },
+	"patch": func(version string) string { return versionField(version, 2) },
+}
+
+// versionField returns a numeric field of a semantic version, ignoring a
+// leading "v" and any pre-release or build suffix. Missing fields are "0".
+func versionField(version string, index int) string {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if index >= len(fields) || fields[index] == "" {
+		return "0"
+	}
+	return fields[index]
+}
+
+// renderTemplate renders a configuration template. Strings without template
+// actions are returned unchanged.
+func renderTemplate(tmpl string, data templateData) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+
+	t, err := template.New("").Funcs(templateFuncs).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", tmpl, err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template %q: %w", tmpl, err)
+	}
+	return b.String(), nil
+}
+
+// unrenderedAction matches a template action left in generated text.
+var unrenderedAction = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// checkUnrendered reports the first template action left in a templated
+// configuration value after rendering, so literal braces are never
+// submitted. It runs before description files are read, whose content may
+// contain braces of its own.
+func checkUnrendered(cfg *Config) error {
+	for _, f := range configTemplates(cfg) {
+		if action := unrenderedAction.FindString(*f.Value); action != "" {
+			return fmt.Errorf("%s contains the unrendered template %s", f.Field, action)
+		}
+	}
+	return nil
+}
+
+// templateField is a templated configuration value. Arch is the {{.Arch}}
+// value of installer fields.
+type templateField struct {
+	Field string
+	Value *string
+	Arch  string
+}
+
+// configTemplates returns the configuration values that are rendered as
+// templates for each release.
+func configTemplates(cfg *Config) []templateField {
+	fields := []templateField{
+		{Field: "pull_request.title", Value: &cfg.PullRequest.Title},
+		{Field: "pull_request.body", Value: &cfg.PullRequest.Body},
+		{Field: "pull_request.branch", Value: &cfg.PullRequest.Branch},
+		{Field: "issue.title", Value: &cfg.Issue.Title},
+		{Field: "checksums.file", Value: &cfg.Checksums.File},
+		{Field: "metadata.publisher", Value: &cfg.Metadata.Publisher},
+		{Field: "metadata.publisher_url", Value: &cfg.Metadata.PublisherURL},
+		{Field: "metadata.publisher_support_url", Value: &cfg.Metadata.PublisherSupportURL},
+		{Field: "metadata.name", Value: &cfg.Metadata.Name},
+		{Field: "metadata.short_description", Value: &cfg.Metadata.ShortDescription},
+		{Field: "metadata.license", Value: &cfg.Metadata.License},
+		{Field: "metadata.license_url", Value: &cfg.Metadata.LicenseURL},
+		{Field: "metadata.copyright", Value: &cfg.Metadata.Copyright},
+		{Field: "metadata.package_url", Value: &cfg.Metadata.PackageURL},
+		{Field: "metadata.moniker", Value: &cfg.Metadata.Moniker},
+		{Field: "metadata.release_notes", Value: &cfg.Metadata.ReleaseNotes},
+		{Field: "metadata.release_notes_url", Value: &cfg.Metadata.ReleaseNotesURL},
+		{Field: "metadata.installation_notes", Value: &cfg.Metadata.InstallationNotes},
+	}
+	for i := range cfg.Installers {
+		arch := cfg.Installers[i].templateArch()
+		fields = append(fields,
+			templateField{fmt.Sprintf("installers[%d].url", i), &cfg.Installers[i].URL, arch},
+			templateField{fmt.Sprintf("installers[%d].asset", i), &cfg.Installers[i].Asset, arch},
+		)
+	}
+	for i := range cfg.PatchAssets {
+		fields = append(fields, templateField{Field: fmt.Sprintf("patch_assets[%d]", i), Value: &cfg.PatchAssets[i]})
+	}
+	for i := range cfg.Locales {
+		fields = append(fields,
+			templateField{Field: fmt.Sprintf("locales[%d].short_description", i), Value: &cfg.Locales[i].ShortDescription},
+			templateField{Field: fmt.Sprintf("locales[%d].description", i), Value: &cfg.Locales[i].Description},
+			templateField{Field: fmt.Sprintf("locales[%d].release_notes", i), Value: &cfg.Locales[i].ReleaseNotes},
+			templateField{Field: fmt.Sprintf("locales[%d].release_notes_url", i), Value: &cfg.Locales[i].ReleaseNotesURL},
+			templateField{Field: fmt.Sprintf("locales[%d].installation_notes", i), Value: &cfg.Locales[i].InstallationNotes},
+		)
+	}
+	return fields
+}
+
+// renderConfigTemplates renders every templated configuration value in place.
+func renderConfigTemplates(cfg *Config, data templateData) error {
+	for _, f := range configTemplates(cfg) {
+		data.Arch = f.Arch
+		rendered, err := renderTemplate(*f.Value, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Field, err)
+		}
+		*f.Value = rendered
+	}
+	return nil
+}
+
+// validateConfigTemplates renders a copy of the configuration with sample
+// data and returns one problem per template that fails to parse or render.
+func validateConfigTemplates(cfg *Config) []fieldError {
+	copied := *cfg
+	copied.Installers = append([]InstallerConfig(nil), cfg.Installers...)
+	copied.Locales = append([]LocaleConfig(nil), cfg.Locales...)
+	copied.PatchAssets = append([]string(nil), cfg.PatchAssets...)
+
+	data := sampleTemplateData(cfg)
+	var problems []fieldError
+	if version, err := packageVersionFor(cfg, sampleReleaseContext()); err != nil {
+		problems = append(problems, fieldError{"package_version", strings.TrimPrefix(err.Error(), "package_version: ")})
+	} else if err := validatePackageVersion(version); err != nil {
+		problems = append(problems, fieldError{"package_version", fmt.Sprintf("renders an invalid version for release 1.2.3: %v", err)})
+	}
+	for _, f := range configTemplates(&copied) {
+		data.Arch = f.Arch
+		if _, err := renderTemplate(*f.Value, data); err != nil {
+			problems = append(problems, fieldError{f.Field, err.Error()})
+		}
+	}
+	return problems
+}
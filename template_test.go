@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := newTemplateData(&plugin.ReleaseContext{
+		Version:         "v2.10.3-beta.1",
+		TagName:         "v2.10.3-beta.1",
+		RepositoryOwner: "myorg",
+		RepositoryName:  "myapp",
+	}, &Config{PackageID: "MyOrg.MyApp", NormalizeVersion: true, Metadata: MetadataConfig{Publisher: "My Org", Name: "My App"}})
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		expected string
+	}{
+		{"simple version", "https://example.com/app-{{.PackageVersion}}.msi", "https://example.com/app-2.10.3-beta.1.msi"},
+		{"multiple placeholders", "{{.PackageId}} version {{.PackageVersion}}", "MyOrg.MyApp version 2.10.3-beta.1"},
+		{"no placeholders", "https://example.com/app.msi", "https://example.com/app.msi"},
+		{"release context", "https://github.com/{{.RepositoryOwner}}/{{.RepositoryName}}/releases/tag/{{.Tag}}", "https://github.com/myorg/myapp/releases/tag/v2.10.3-beta.1"},
+		{"package metadata", "{{.Publisher}} {{.PackageName}} ({{.Channel}})", "My Org My App (beta)"},
+		{"lower", "{{.PackageId | lower}}", "myorg.myapp"},
+		{"upper", "{{upper .RepositoryName}}", "MYAPP"},
+		{"replace", `{{.PackageVersion | replace "." "_"}}`, "2_10_3-beta_1"},
+		{"trimPrefix", `{{.Version | trimPrefix "v"}}`, "2.10.3-beta.1"},
+		{"trimSuffix", `{{.PackageVersion | trimSuffix "-beta.1"}}`, "2.10.3"},
+		{"semver fields", "{{major .Version}}.{{minor .Version}}.{{patch .Version}}", "2.10.3"},
+		{"urlquery", "https://example.com/?v={{urlquery .Version}}", "https://example.com/?v=v2.10.3-beta.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := renderTemplate(tt.tmpl, data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     string
+		contains string
+	}{
+		{"unknown field", "{{.Missing}}", "can't evaluate field Missing"},
+		{"unknown function", "{{.Version | shout}}", `function "shout" not defined`},
+		{"unclosed action", "{{.Version", "invalid template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := renderTemplate(tt.tmpl, sampleTemplateData(&Config{PackageID: "MyOrg.MyApp"}))
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing '%s', got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestVersionField(t *testing.T) {
+	tests := []struct {
+		version  string
+		index    int
+		expected string
+	}{
+		{"1.2.3", 0, "1"},
+		{"v1.2.3", 1, "2"},
+		{"1.2.3+build", 2, "3"},
+		{"1.2", 2, "0"},
+		{"2024", 1, "0"},
+	}
+
+	for _, tt := range tests {
+		if result := versionField(tt.version, tt.index); result != tt.expected {
+			t.Errorf("versionField(%q, %d) = '%s', expected '%s'", tt.version, tt.index, result, tt.expected)
+		}
+	}
+}
+
+func TestVersionChannel(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", ""},
+		{"1.2.3+build.5", ""},
+		{"1.2.3-beta.1", "beta"},
+		{"v1.2.3-RC2", "rc"},
+		{"1.2.3-nightly+build-5", "nightly"},
+	}
+
+	for _, tt := range tests {
+		if result := versionChannel(tt.version); result != tt.expected {
+			t.Errorf("versionChannel(%q) = '%s', expected '%s'", tt.version, result, tt.expected)
+		}
+	}
+}
+
+func TestPRTitlePreset(t *testing.T) {
+	tests := []struct {
+		name     string
+		pr       map[string]any
+		expected string
+	}{
+		{"default", map[string]any{}, "New version: MyOrg.MyApp version 1.2.3"},
+		{"new package", map[string]any{"title_preset": "new-package"}, "New package: MyOrg.MyApp version 1.2.3"},
+		{"update", map[string]any{"title_preset": "update"}, "Update MyOrg.MyApp to 1.2.3"},
+		{"title wins", map[string]any{"title_preset": "update", "title": "{{.PackageName}} {{.PackageVersion}}"}, "My App 1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := validTestConfig()
+			raw["pull_request"] = tt.pr
+			cfg, _ := decodePluginConfig(raw)
+			if err := renderConfigTemplates(cfg, newTemplateData(&plugin.ReleaseContext{Version: "1.2.3"}, cfg)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.PullRequest.Title != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, cfg.PullRequest.Title)
+			}
+		})
+	}
+}
+
+func TestRenderConfigTemplates(t *testing.T) {
+	cfg, _ := decodePluginConfig(validTestConfig())
+	cfg.Metadata.ReleaseNotesURL = "https://github.com/{{.RepositoryOwner}}/{{.RepositoryName}}/releases/tag/{{.Tag}}"
+	cfg.Locales = []LocaleConfig{{Locale: "en-US", Description: "{{.PackageId}} {{.Version}}"}}
+
+	data := newTemplateData(&plugin.ReleaseContext{Version: "1.2.3", TagName: "v1.2.3", RepositoryOwner: "myorg", RepositoryName: "myapp"}, cfg)
+	if err := renderConfigTemplates(cfg, data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Installers[0].URL != "https://example.com/app-1.2.3.msi" {
+		t.Errorf("unexpected URL: %s", cfg.Installers[0].URL)
+	}
+	if cfg.Metadata.ReleaseNotesURL != "https://github.com/myorg/myapp/releases/tag/v1.2.3" {
+		t.Errorf("unexpected release notes URL: %s", cfg.Metadata.ReleaseNotesURL)
+	}
+	if cfg.Locales[0].Description != "MyOrg.MyApp 1.2.3" {
+		t.Errorf("unexpected description: %s", cfg.Locales[0].Description)
+	}
+	if cfg.PullRequest.Title != "New version: MyOrg.MyApp version 1.2.3" {
+		t.Errorf("unexpected title: %s", cfg.PullRequest.Title)
+	}
+	if cfg.PullRequest.Branch != "winget/MyOrg-MyApp/1.2.3" {
+		t.Errorf("unexpected branch: %s", cfg.PullRequest.Branch)
+	}
+}
+
+func TestDefaultReleaseNotesURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		releaseCtx plugin.ReleaseContext
+		expected   string
+	}{
+		{"owner and name", plugin.ReleaseContext{TagName: "v1.2.3", RepositoryOwner: "myorg", RepositoryName: "myapp"}, "https://github.com/myorg/myapp/releases/tag/v1.2.3"},
+		{"repository URL", plugin.ReleaseContext{TagName: "v1.2.3", RepositoryURL: "https://github.example.com/myorg/myapp.git", RepositoryOwner: "myorg", RepositoryName: "myapp"}, "https://github.example.com/myorg/myapp/releases/tag/v1.2.3"},
+		{"ssh repository URL", plugin.ReleaseContext{TagName: "app/v1.2.3", RepositoryURL: "git@github.com:myorg/myapp.git", RepositoryOwner: "myorg", RepositoryName: "myapp"}, "https://github.com/myorg/myapp/releases/tag/app%2Fv1.2.3"},
+		{"no tag", plugin.ReleaseContext{RepositoryOwner: "myorg", RepositoryName: "myapp"}, ""},
+		{"no repository", plugin.ReleaseContext{TagName: "v1.2.3"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := defaultReleaseNotesURL(&tt.releaseCtx); result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateConfigTemplates(t *testing.T) {
+	cfg, _ := decodePluginConfig(validTestConfig())
+	cfg.PullRequest.Title = "{{.Bogus}}"
+	cfg.Installers[0].URL = "https://example.com/{{.Version"
+
+	problems := validateConfigTemplates(cfg)
+	if len(problems) != 2 || problems[0].Field != "pull_request.title" || problems[1].Field != "installers[0].url" {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+	if cfg.PullRequest.Title != "{{.Bogus}}" {
+		t.Error("expected validation to leave the config unchanged")
+	}
+
+	cfg, _ = decodePluginConfig(validTestConfig())
+	cfg.PackageVersion = "{{.PackageVersion}}"
+	problems = validateConfigTemplates(cfg)
+	if len(problems) != 1 || problems[0].Field != "package_version" {
+		t.Errorf("expected a package_version problem, got %v", problems)
+	}
+}
+
+func TestPackageVersionFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      map[string]any
+		version  string
+		expected string
+	}{
+		{"default", nil, "2024.10.1+build5", "2024.10.1+build5"},
+		{"template", map[string]any{"package_version": `{{.Version | trimSuffix "+build5"}}`}, "2024.10.1+build5", "2024.10.1"},
+		{"semver fields", map[string]any{"package_version": "{{major .Version}}.{{minor .Version}}"}, "2024.10.1+build5", "2024.10"},
+		{"then normalized", map[string]any{"package_version": "{{.Tag}}", "normalize_version": true}, "2024.10.1+build5", "2024.10.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := validTestConfig()
+			for k, v := range tt.cfg {
+				raw[k] = v
+			}
+			cfg, problems := decodePluginConfig(raw)
+			if len(problems) > 0 {
+				t.Fatalf("unexpected problems: %v", problems)
+			}
+			version, err := packageVersionFor(cfg, &plugin.ReleaseContext{Version: tt.version, TagName: "v" + tt.version})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, version)
+			}
+			if data := newTemplateData(&plugin.ReleaseContext{Version: tt.version, TagName: "v" + tt.version}, cfg); data.PackageVersion != tt.expected {
+				t.Errorf("expected template PackageVersion %q, got %q", tt.expected, data.PackageVersion)
+			}
+		})
+	}
+}
+
+func TestCheckUnrendered(t *testing.T) {
+	cfg := &Config{
+		Metadata:   MetadataConfig{Name: "My App"},
+		Installers: []InstallerConfig{{URL: "https://example.com/app.msi"}},
+	}
+	if err := checkUnrendered(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Installers[0].URL = "https://example.com/app-{{ .version }}.msi"
+	err := checkUnrendered(cfg)
+	if err == nil || !strings.Contains(err.Error(), "installers[0].url") || !strings.Contains(err.Error(), "{{ .version }}") {
+		t.Errorf("expected the installer URL to be reported, got %v", err)
+	}
+}
+
+func TestExecuteAllowsBracesInDescriptionFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "short-description.txt")
+	if err := os.WriteFile(file, []byte("Renders {{ mustache }} templates"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app.msi"
+	cfg["output_dir"] = t.TempDir()
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	metadata := cfg["metadata"].(map[string]any)
+	delete(metadata, "short_description")
+	metadata["short_description_file"] = file
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected braces in a description file to be submitted as written, got: %s", resp.Message)
+	}
+}
+
+func TestRenderUserAgent(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		runID     string
+		expected  string
+		wantErr   bool
+	}{
+		{"default", defaultUserAgent, "", "Relicta-WinGet-Plugin/" + Version, false},
+		{"default with run ID", defaultUserAgent, "42", "Relicta-WinGet-Plugin/" + Version + " (run 42)", false},
+		{"custom", "MyOrg-Release/{{.PluginVersion}} run={{.RunID}}", "7", "MyOrg-Release/" + Version + " run=7", false},
+		{"multiline", "MyOrg\nInjected: header", "", "Relicta-WinGet-Plugin/" + Version, true},
+		{"invalid template", "{{.Missing}}", "", "Relicta-WinGet-Plugin/" + Version, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userAgent, err := renderUserAgent(&Config{UserAgent: tt.userAgent, RunID: tt.runID})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderUserAgent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if userAgent != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, userAgent)
+			}
+		})
+	}
+}
+
+func TestDecodeRunID(t *testing.T) {
+	t.Setenv("GITHUB_RUN_ID", "")
+	t.Setenv("CI_PIPELINE_ID", "")
+	t.Setenv("BUILD_BUILDID", "1234")
+
+	cfg, _ := decodePluginConfig(map[string]any{"package_id": "MyOrg.MyApp"})
+	if cfg.RunID != "1234" || cfg.UserAgent != "Relicta-WinGet-Plugin/"+Version+" (run 1234)" {
+		t.Errorf("expected the Azure Pipelines run ID, got %q in %q", cfg.RunID, cfg.UserAgent)
+	}
+}
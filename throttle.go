@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// errSubmissionQueueFull is returned when a submission waited throttle.max_wait
+// without the number of open pull requests dropping below
+// throttle.max_open_prs.
+var errSubmissionQueueFull = errors.New("too many open winget-pkgs pull requests")
+
+// ThrottleConfig paces the pull requests submitted to winget-pkgs, so that
+// submitting several versions or packages in one run does not flood the
+// winget-pkgs moderators.
+type ThrottleConfig struct {
+	// MinInterval is the minimum delay between pull requests submitted by
+	// this process, across packages.
+	MinInterval time.Duration `json:"min_interval"`
+	// MaxOpenPRs queues submissions while the token's user has this many open
+	// winget-pkgs pull requests. Zero disables the limit.
+	MaxOpenPRs int `json:"max_open_prs"`
+	// PollInterval is the delay between checks of the open pull requests.
+	PollInterval time.Duration `json:"poll_interval"`
+	// MaxWait is how long a submission waits in the queue before failing.
+	MaxWait time.Duration `json:"max_wait"`
+}
+
+// validateThrottle checks the throttle settings.
+func validateThrottle(cfg ThrottleConfig) []fieldError {
+	var problems []fieldError
+	if cfg.MinInterval < 0 {
+		problems = append(problems, fieldError{"throttle.min_interval", "min_interval must not be negative"})
+	}
+	if cfg.MaxOpenPRs < 0 {
+		problems = append(problems, fieldError{"throttle.max_open_prs", "max_open_prs must not be negative"})
+	}
+	if cfg.MaxOpenPRs > 0 && cfg.PollInterval <= 0 {
+		problems = append(problems, fieldError{"throttle.poll_interval", "poll_interval must be positive"})
+	}
+	if cfg.MaxWait < 0 {
+		problems = append(problems, fieldError{"throttle.max_wait", "max_wait must not be negative"})
+	}
+	return problems
+}
+
+// submissionQueue orders the pull request submissions of this process, so
+// the throttle applies across all packages submitted by it.
+var submissionQueue = newSubmissionThrottle()
+
+// acquireSubmission waits in queue for the turn of a submission. The wait
+// itself is bounded only by throttle.max_wait and the deadline of ctx, while
+// timeouts.github bounds each check of the open pull requests.
+func acquireSubmission(ctx context.Context, queue *submissionThrottle, cfg *Config, openPRs func(context.Context) (int, error), logger *slog.Logger) (func(submitted bool), error) {
+	return queue.acquire(ctx, cfg.Throttle, func(ctx context.Context) (int, error) {
+		ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+		defer cancel()
+		return openPRs(ctx)
+	}, logger)
+}
+
+// submissionThrottle lets one submission through at a time, once
+// throttle.min_interval has passed since the previous one and the number of
+// open pull requests is below throttle.max_open_prs.
+type submissionThrottle struct {
+	// turn holds a token while a submission is in progress.
+	turn chan struct{}
+	// last is when the previous pull request was submitted. It is only
+	// accessed while holding turn.
+	last time.Time
+}
+
+func newSubmissionThrottle() *submissionThrottle {
+	return &submissionThrottle{turn: make(chan struct{}, 1)}
+}
+
+// acquire waits for the turn of a submission. The returned function must be
+// called once the submission is done, reporting whether a pull request was
+// submitted.
+func (s *submissionThrottle) acquire(ctx context.Context, cfg ThrottleConfig, openPRs func(context.Context) (int, error), logger *slog.Logger) (func(submitted bool), error) {
+	var deadline <-chan time.Time
+	if cfg.MaxWait > 0 {
+		timer := time.NewTimer(cfg.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case s.turn <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-deadline:
+		return nil, fmt.Errorf("%w: timed out waiting for earlier submissions", errSubmissionQueueFull)
+	}
+	release := func(submitted bool) {
+		if submitted {
+			s.last = time.Now()
+		}
+		<-s.turn
+	}
+
+	if err := s.wait(ctx, cfg, openPRs, deadline, logger); err != nil {
+		release(false)
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait waits out throttle.min_interval and throttle.max_open_prs.
+func (s *submissionThrottle) wait(ctx context.Context, cfg ThrottleConfig, openPRs func(context.Context) (int, error), deadline <-chan time.Time, logger *slog.Logger) error {
+	if delay := time.Until(s.last.Add(cfg.MinInterval)); !s.last.IsZero() && delay > 0 {
+		logger.Info("Waiting before submitting the next pull request", "delay", delay.Round(time.Second))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("%w: timed out waiting for throttle.min_interval", errSubmissionQueueFull)
+		}
+	}
+
+	if cfg.MaxOpenPRs == 0 {
+		return nil
+	}
+	for {
+		open, err := openPRs(ctx)
+		if err != nil {
+			return err
+		}
+		if open < cfg.MaxOpenPRs {
+			return nil
+		}
+		logger.Info("Waiting for open pull requests to be merged or closed", "open", open, "max_open_prs", cfg.MaxOpenPRs)
+		select {
+		case <-time.After(cfg.PollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("%w: %d open", errSubmissionQueueFull, open)
+		}
+	}
+}
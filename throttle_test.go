@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestValidateThrottle(t *testing.T) {
+	tests := []struct {
+		name     string
+		throttle ThrottleConfig
+		fields   []string
+	}{
+		{"empty", ThrottleConfig{}, nil},
+		{"limits", ThrottleConfig{MinInterval: time.Minute, MaxOpenPRs: 5, PollInterval: time.Minute, MaxWait: time.Hour}, nil},
+		{"negative", ThrottleConfig{MinInterval: -time.Second, MaxOpenPRs: -1, MaxWait: -time.Second}, []string{"throttle.min_interval", "throttle.max_open_prs", "throttle.max_wait"}},
+		{"no poll interval", ThrottleConfig{MaxOpenPRs: 5}, []string{"throttle.poll_interval"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateThrottle(tt.throttle)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestSubmissionThrottleMinInterval(t *testing.T) {
+	throttle := newSubmissionThrottle()
+	cfg := ThrottleConfig{MinInterval: 50 * time.Millisecond}
+
+	start := time.Now()
+	for range 3 {
+		release, err := throttle.acquire(context.Background(), cfg, nil, slog.Default())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		release(true)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected submissions to be spaced by min_interval, took %s", elapsed)
+	}
+}
+
+func TestSubmissionThrottleMaxOpenPRs(t *testing.T) {
+	throttle := newSubmissionThrottle()
+	cfg := ThrottleConfig{MaxOpenPRs: 2, PollInterval: time.Millisecond}
+
+	open := []int{3, 2, 1}
+	checks := 0
+	openPRs := func(context.Context) (int, error) {
+		checks++
+		return open[min(checks, len(open))-1], nil
+	}
+
+	release, err := throttle.acquire(context.Background(), cfg, openPRs, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release(true)
+	if checks != 3 {
+		t.Errorf("expected to wait until fewer than 2 pull requests are open, checked %d times", checks)
+	}
+
+	// A full queue fails once max_wait is over
+	cfg.MaxWait = 20 * time.Millisecond
+	_, err = throttle.acquire(context.Background(), cfg, func(context.Context) (int, error) { return 2, nil }, slog.Default())
+	if !errors.Is(err, errSubmissionQueueFull) {
+		t.Fatalf("expected a full queue, got %v", err)
+	}
+	if category := classifyError(err); category != categoryRateLimit {
+		t.Errorf("expected a rate limit failure, got %s", category)
+	}
+
+	// A failed wait gives up the turn
+	release, err = throttle.acquire(context.Background(), ThrottleConfig{}, nil, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release(false)
+}
+
+func TestAcquireSubmissionOutlastsGitHubTimeout(t *testing.T) {
+	cfg := &Config{
+		Throttle: ThrottleConfig{MaxOpenPRs: 1, PollInterval: 10 * time.Millisecond, MaxWait: time.Second},
+		Timeouts: TimeoutConfig{GitHub: 50 * time.Millisecond},
+	}
+
+	start := time.Now()
+	openPRs := func(ctx context.Context) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if time.Since(start) < 150*time.Millisecond {
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	release, err := acquireSubmission(context.Background(), newSubmissionThrottle(), cfg, openPRs, slog.Default())
+	if err != nil {
+		t.Fatalf("expected the queue to wait past timeouts.github, got %v", err)
+	}
+	release(true)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected to wait until the pull requests were closed, took %s", elapsed)
+	}
+}
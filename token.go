@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+)
+
+// TokenCheckConfig defines the GitHub token check run during Validate.
+type TokenCheckConfig struct {
+	Enabled bool `json:"enabled"`
+	// MinValidity is how long the token must remain valid, so it does not
+	// expire mid-run or before the next release.
+	MinValidity time.Duration `json:"min_validity"`
+}
+
+// fineGrainedTokenPrefix starts fine-grained personal access tokens.
+const fineGrainedTokenPrefix = "github_pat_"
+
+// validateTokenCheck checks the token check settings.
+func validateTokenCheck(cfg TokenCheckConfig) []fieldError {
+	if cfg.MinValidity < 0 {
+		return []fieldError{{"token_check.min_validity", "min_validity must not be negative"}}
+	}
+	return nil
+}
+
+// checkGitHubToken verifies that the GitHub token is valid, is not about to
+// expire, has the permissions needed to submit to winget-pkgs and has rate
+// limit left, returning an actionable problem for each failure.
+func checkGitHubToken(ctx context.Context, cfg *Config, now time.Time) []fieldError {
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, slog.New(slog.DiscardHandler))
+	info, err := ghClient.CheckToken(ctx)
+	if err != nil {
+		var apiErr *githubclient.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			return []fieldError{{"github_token", "GitHub rejected the token (401): it is invalid, revoked or expired; create a new token and update github_token"}}
+		}
+		return []fieldError{{"github_token", fmt.Sprintf("failed to check the token: %v", err)}}
+	}
+
+	var problems []fieldError
+	switch {
+	case info.ExpiresAt.IsZero():
+	case !info.ExpiresAt.After(now):
+		problems = append(problems, fieldError{"github_token", fmt.Sprintf("the token expired at %s; regenerate it", info.ExpiresAt.Format(time.RFC3339))})
+	case info.ExpiresAt.Before(now.Add(cfg.TokenCheck.MinValidity)):
+		problems = append(problems, fieldError{"github_token", fmt.Sprintf("the token expires at %s, within token_check.min_validity (%s); regenerate it", info.ExpiresAt.Format(time.RFC3339), cfg.TokenCheck.MinValidity)})
+	}
+
+	// Classic tokens report their scopes; fine-grained tokens have
+	// per-repository permissions, checked on the fork
+	if info.Scopes != nil && !slices.Contains(info.Scopes, "public_repo") && !slices.Contains(info.Scopes, "repo") {
+		problems = append(problems, fieldError{"github_token", fmt.Sprintf("the token has scopes %q but needs public_repo (or repo) to fork winget-pkgs and open pull requests", strings.Join(info.Scopes, ", "))})
+	}
+	if info.ForkExists && !info.ForkWritable {
+		message := fmt.Sprintf("the token cannot push to the winget-pkgs fork of %s", info.User)
+		if strings.HasPrefix(cfg.GitHubToken, fineGrainedTokenPrefix) {
+			message += "; grant the fine-grained token Contents and Pull requests read and write access to the fork"
+		}
+		problems = append(problems, fieldError{"github_token", message})
+	}
+
+	if info.RateLimitRemaining == 0 {
+		problems = append(problems, fieldError{"github_token", fmt.Sprintf("the token's GitHub API rate limit is exhausted until %s", info.RateLimitReset.UTC().Format(time.RFC3339))})
+	}
+	return problems
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckGitHubToken(t *testing.T) {
+	now := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		token    string
+		status   int
+		headers  map[string]string
+		fork     string
+		rate     string
+		contains string
+	}{
+		{"valid", "ghp_token", http.StatusOK, map[string]string{"X-OAuth-Scopes": "public_repo"}, `{"permissions":{"push":true}}`, "4999", ""},
+		{"unauthorized", "ghp_token", http.StatusUnauthorized, nil, "", "4999", "invalid, revoked or expired"},
+		{"expiring", "github_pat_token", http.StatusOK, map[string]string{"GitHub-Authentication-Token-Expiration": "2030-06-01 12:00:00 UTC"}, "", "4999", "within token_check.min_validity"},
+		{"expired", "github_pat_token", http.StatusOK, map[string]string{"GitHub-Authentication-Token-Expiration": "2030-05-01 12:00:00 UTC"}, "", "4999", "expired at 2030-05-01T12:00:00Z"},
+		{"missing scope", "ghp_token", http.StatusOK, map[string]string{"X-OAuth-Scopes": "read:user"}, "", "4999", "needs public_repo"},
+		{"read-only fork", "github_pat_token", http.StatusOK, nil, `{"permissions":{"push":false}}`, "4999", "grant the fine-grained token Contents and Pull requests"},
+		{"rate limited", "ghp_token", http.StatusOK, map[string]string{"X-OAuth-Scopes": "repo"}, "", "0", "rate limit is exhausted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/user":
+					for key, value := range tt.headers {
+						w.Header().Set(key, value)
+					}
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(`{"login":"myuser"}`))
+				case "/rate_limit":
+					_, _ = w.Write([]byte(`{"resources":{"core":{"remaining":` + tt.rate + `,"reset":1906000000}}}`))
+				case "/repos/myuser/winget-pkgs":
+					if tt.fork == "" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write([]byte(tt.fork))
+				}
+			}))
+			defer server.Close()
+
+			originalBase := githubAPIBase
+			githubAPIBase = server.URL
+			defer func() { githubAPIBase = originalBase }()
+
+			cfg, _ := decodePluginConfig(validTestConfig())
+			cfg.GitHubToken = tt.token
+			problems := checkGitHubToken(context.Background(), cfg, now)
+			if tt.contains == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != "github_token" || !strings.Contains(problems[0].Message, tt.contains) {
+				t.Errorf("expected a github_token problem containing %q, got %v", tt.contains, problems)
+			}
+		})
+	}
+}
+
+func TestValidateTokenCheck(t *testing.T) {
+	if problems := validateTokenCheck(TokenCheckConfig{MinValidity: -time.Hour}); len(problems) != 1 {
+		t.Errorf("expected a problem for a negative min_validity, got %v", problems)
+	}
+	if problems := validateTokenCheck(TokenCheckConfig{Enabled: true, MinValidity: 24 * time.Hour}); len(problems) != 0 {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
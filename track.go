@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/githubclient"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Pull request states reported by tracking.
+const (
+	prStateOpen     = "open"
+	prStateMerged   = "merged"
+	prStateClosed   = "closed"
+	prStateNotFound = "not_found"
+)
+
+// trackEventType is the type of the events posted to track.webhook_url.
+const trackEventType = "winget.pull_request.completed"
+
+// TrackConfig defines how the winget-pkgs pull request is followed until it
+// is merged or closed.
+type TrackConfig struct {
+	Enabled bool `json:"enabled"`
+	// PollInterval is the delay between checks of the pull request.
+	PollInterval time.Duration `json:"poll_interval"`
+	// Timeout is how long to wait for the pull request to be merged or
+	// closed. Zero checks it once.
+	Timeout time.Duration `json:"timeout"`
+	// WebhookURL receives a JSON event once the pull request is merged or
+	// closed.
+	WebhookURL string `json:"webhook_url"`
+}
+
+// trackEvent is the final status of a tracked pull request.
+type trackEvent struct {
+	Type      string     `json:"type"`
+	PackageID string     `json:"package_id"`
+	Version   string     `json:"version"`
+	State     string     `json:"state"`
+	PRURL     string     `json:"pr_url,omitempty"`
+	MergedAt  *time.Time `json:"merged_at,omitempty"`
+}
+
+// final reports whether the pull request will not change state anymore.
+func (e trackEvent) final() bool {
+	return e.State == prStateMerged || e.State == prStateClosed
+}
+
+// validateTrack checks the tracking settings.
+func validateTrack(cfg *Config) []fieldError {
+	track := cfg.Track
+	if !track.Enabled {
+		return nil
+	}
+
+	var problems []fieldError
+	if cfg.Backend != backendGitHub {
+		problems = append(problems, fieldError{"track.enabled", "tracking requires the github backend"})
+	}
+	if track.PollInterval <= 0 {
+		problems = append(problems, fieldError{"track.poll_interval", "poll_interval must be positive"})
+	}
+	if track.Timeout < 0 {
+		problems = append(problems, fieldError{"track.timeout", "timeout must not be negative"})
+	}
+	if track.WebhookURL != "" {
+		u, err := url.Parse(track.WebhookURL)
+		switch {
+		case err != nil || u.Host == "":
+			problems = append(problems, fieldError{"track.webhook_url", "webhook_url must be an absolute URL"})
+		case u.Scheme == "http" && !cfg.AllowInsecureURLs:
+			problems = append(problems, fieldError{"track.webhook_url", "webhook_url must use https (set allow_insecure_urls to override)"})
+		case u.Scheme != "https" && u.Scheme != "http":
+			problems = append(problems, fieldError{"track.webhook_url", "webhook_url must use https"})
+		}
+	}
+	return problems
+}
+
+// executeOnSuccess follows the pull request of the release until it is
+// merged or closed, within track.timeout, and posts the final state to
+// track.webhook_url.
+func (p *WinGetPlugin) executeOnSuccess(ctx context.Context, releaseCtx *plugin.ReleaseContext, cfg *Config, logger *slog.Logger) (*plugin.ExecuteResponse, error) {
+	if !cfg.Track.Enabled || cfg.Backend != backendGitHub || !cfg.PullRequest.Enabled {
+		return &plugin.ExecuteResponse{Success: true, Message: "Pull request tracking disabled"}, nil
+	}
+
+	packageVersion, err := packageVersionFor(cfg, releaseCtx)
+	if err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+	logger = logger.With("version", packageVersion, "package_id", cfg.PackageID)
+
+	if err := renderConfigTemplates(cfg, newTemplateData(releaseCtx, cfg)); err != nil {
+		return failureResponse(categoryValidation, "Failed to render configuration: %v", err), nil
+	}
+
+	if cfg.DryRun {
+		logger.Info("[DRY-RUN] Would track pull request", "branch", cfg.PullRequest.Branch)
+		return &plugin.ExecuteResponse{
+			Success: true,
+			Message: fmt.Sprintf("[DRY-RUN] Would track the PR of %s version %s", cfg.PackageID, packageVersion),
+		}, nil
+	}
+
+	if cfg.Track.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Track.Timeout)
+		defer cancel()
+	}
+
+	ghClient := newGitHubClient(ctx, cfg, cfg.PullRequest.ForkOwner, logger)
+	branch, err := prBranch(ctx, ghClient, cfg.PullRequest)
+	if err != nil {
+		return failureResponse(classifyError(err), "Failed to track PR: %v", err), nil
+	}
+	event := trackEvent{Type: trackEventType, PackageID: cfg.PackageID, Version: packageVersion}
+	var labels []string
+	for {
+		status, err := ghClient.BranchPullRequest(ctx, branch)
+		if err != nil && ctx.Err() == nil {
+			return failureResponse(classifyError(err), "Failed to track PR: %v", err), nil
+		}
+		if err == nil {
+			event.update(status)
+			if status != nil {
+				labels = status.Labels
+			}
+			logger.Info("Pull request state", "state", event.State, "url", event.PRURL)
+		}
+		if event.final() || cfg.Track.Timeout == 0 || ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(cfg.Track.PollInterval):
+		}
+	}
+
+	outputs := map[string]any{"pr_state": event.State}
+	if event.PRURL != "" {
+		outputs["pr_url"] = event.PRURL
+	}
+	if event.MergedAt != nil {
+		outputs["merged_at"] = event.MergedAt.Format(time.RFC3339)
+	}
+
+	if cfg.Issue.Enabled && event.State != "" {
+		status := issueStatus(event.State, labels)
+		issueCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		issueURL, err := upsertIssue(issueCtx, ghClient, cfg, releaseCtx, func(existing *githubclient.Issue) string {
+			if existing != nil {
+				return withIssueStatus(existing.Body, status)
+			}
+			return issueBody(event.PRURL, status, "", "")
+		})
+		cancel()
+		if err != nil {
+			logger.Warn("Failed to update tracking issue", "error", err)
+		} else {
+			outputs["issue_url"] = issueURL
+		}
+	}
+
+	if event.final() && cfg.Track.WebhookURL != "" {
+		// The webhook is notified even if tracking used up its deadline
+		notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if err := postTrackEvent(notifyCtx, cfg.Track.WebhookURL, cfg.UserAgent, event); err != nil {
+			resp := failureResponse(classifyError(err), "Failed to send PR status notification: %v", err)
+			maps.Copy(resp.Outputs, outputs)
+			return resp, nil
+		}
+		logger.Info("Sent PR status notification", "state", event.State)
+	}
+
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("PR of %s version %s is %s", cfg.PackageID, packageVersion, strings.ReplaceAll(event.State, "_", " ")),
+		Outputs: outputs,
+	}, nil
+}
+
+// update records the status of the pull request.
+func (e *trackEvent) update(status *githubclient.PullRequestStatus) {
+	switch {
+	case status == nil:
+		e.State = prStateNotFound
+		return
+	case status.Merged:
+		e.State = prStateMerged
+		mergedAt := status.MergedAt
+		e.MergedAt = &mergedAt
+	case status.State == "closed":
+		e.State = prStateClosed
+	default:
+		e.State = prStateOpen
+	}
+	e.PRURL = status.URL
+}
+
+// webhookStatusError is returned when the tracking webhook rejects an
+// event.
+type webhookStatusError struct {
+	StatusCode int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
+
+// postTrackEvent posts an event as JSON to a webhook.
+func postTrackEvent(ctx context.Context, webhookURL, userAgent string, event trackEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return &webhookStatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
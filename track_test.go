@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestValidateTrack(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   string
+		track     TrackConfig
+		insecure  bool
+		wantField string
+	}{
+		{"disabled", backendREST, TrackConfig{}, false, ""},
+		{"enabled", backendGitHub, TrackConfig{Enabled: true, PollInterval: time.Minute, WebhookURL: "https://hooks.example.com/winget"}, false, ""},
+		{"other backend", backendREST, TrackConfig{Enabled: true, PollInterval: time.Minute}, false, "track.enabled"},
+		{"no poll interval", backendGitHub, TrackConfig{Enabled: true}, false, "track.poll_interval"},
+		{"negative timeout", backendGitHub, TrackConfig{Enabled: true, PollInterval: time.Minute, Timeout: -time.Second}, false, "track.timeout"},
+		{"http webhook", backendGitHub, TrackConfig{Enabled: true, PollInterval: time.Minute, WebhookURL: "http://hooks.example.com"}, false, "track.webhook_url"},
+		{"allowed http webhook", backendGitHub, TrackConfig{Enabled: true, PollInterval: time.Minute, WebhookURL: "http://hooks.example.com"}, true, ""},
+		{"relative webhook", backendGitHub, TrackConfig{Enabled: true, PollInterval: time.Minute, WebhookURL: "/hooks"}, false, "track.webhook_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateTrack(&Config{Backend: tt.backend, Track: tt.track, AllowInsecureURLs: tt.insecure})
+			if tt.wantField == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %v", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || problems[0].Field != tt.wantField {
+				t.Errorf("expected a %s problem, got %v", tt.wantField, problems)
+			}
+		})
+	}
+}
+
+func TestExecuteOnSuccessTracksUntilMerged(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/microsoft/winget-pkgs/pulls" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("head") != "myuser:winget/MyOrg-MyApp/1.2.3" {
+			t.Errorf("unexpected head: %s", r.URL.Query().Get("head"))
+		}
+		polls++
+		if polls < 3 {
+			_, _ = w.Write([]byte(`[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7","state":"open"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7","state":"closed","merged_at":"2024-05-01T10:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	var event trackEvent
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("invalid event: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	cfg := validTestConfig()
+	cfg["pull_request"] = map[string]any{"fork_owner": "myuser"}
+	cfg["allow_insecure_urls"] = true
+	cfg["track"] = map[string]any{"enabled": true, "poll_interval": "1ms", "timeout": "10s", "webhook_url": webhook.URL}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookOnSuccess,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if polls != 3 {
+		t.Errorf("expected 3 polls, got %d", polls)
+	}
+	if resp.Outputs["pr_state"] != prStateMerged || resp.Outputs["merged_at"] != "2024-05-01T10:00:00Z" || resp.Outputs["pr_url"] != "https://github.com/microsoft/winget-pkgs/pull/7" {
+		t.Errorf("unexpected outputs: %v", resp.Outputs)
+	}
+	if event.Type != trackEventType || event.State != prStateMerged || event.PackageID != "MyOrg.MyApp" || event.Version != "1.2.3" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestExecuteOnSuccessChecksOnce(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		_, _ = w.Write([]byte(`[{"number":7,"html_url":"https://github.com/microsoft/winget-pkgs/pull/7","state":"open"}]`))
+	}))
+	defer server.Close()
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected no notification for an open PR")
+	}))
+	defer webhook.Close()
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	defer func() { githubAPIBase = originalBase }()
+
+	cfg := validTestConfig()
+	cfg["pull_request"] = map[string]any{"fork_owner": "myuser"}
+	cfg["allow_insecure_urls"] = true
+	cfg["track"] = map[string]any{"enabled": true, "webhook_url": webhook.URL}
+
+	resp, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookOnSuccess,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["pr_state"] != prStateOpen {
+		t.Errorf("expected an open PR, got %v: %s", resp.Outputs, resp.Message)
+	}
+	if polls != 1 {
+		t.Errorf("expected one check, got %d", polls)
+	}
+
+	// Tracking is opt-in
+	cfg["track"] = map[string]any{"enabled": false}
+	resp, err = (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookOnSuccess,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "1.2.3"},
+	})
+	if err != nil || !resp.Success || polls != 1 {
+		t.Errorf("expected tracking to be skipped, got %v polls %d", resp, polls)
+	}
+}
+
+func TestPostTrackEventRejected(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer webhook.Close()
+
+	err := postTrackEvent(context.Background(), webhook.URL, defaultUserAgent, trackEvent{Type: trackEventType, State: prStateClosed})
+	if err == nil || err.Error() != "webhook returned status 502" {
+		t.Errorf("expected a status error, got %v", err)
+	}
+}
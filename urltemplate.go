@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// versionForms are the forms in which installer URLs embed a version, with
+// the template producing each form from the version. They are substituted
+// in order, so longer forms go first.
+var versionForms = []struct {
+	template string
+	render   func(version string) string
+}{
+	{"{{.Version}}", func(v string) string { return v }},
+	{`{{.Version | replace "." "_"}}`, func(v string) string { return strings.ReplaceAll(v, ".", "_") }},
+	{`{{.Version | replace "." "-"}}`, func(v string) string { return strings.ReplaceAll(v, ".", "-") }},
+	{`{{.Version | replace "." ""}}`, func(v string) string { return strings.ReplaceAll(v, ".", "") }},
+	{"{{major .Version}}.{{minor .Version}}", func(v string) string { return versionField(v, 0) + "." + versionField(v, 1) }},
+}
+
+// inferURLTemplate derives an installer URL template from the URLs of the
+// same installer in two versions: the forms of the latest version are
+// replaced with placeholders, one more form at a time, until the template
+// renders the URL of the previous version. It returns false if no template
+// does, such as when the URLs differ in a build number or a hash.
+func inferURLTemplate(latestURL, latestVersion, previousURL, previousVersion string) (string, bool) {
+	tmpl := latestURL
+	for _, form := range versionForms {
+		if value := form.render(latestVersion); len(value) > 1 && form.render(previousVersion) != value {
+			tmpl = strings.ReplaceAll(tmpl, value, form.template)
+		}
+		rendered, err := renderTemplate(tmpl, templateData{Version: previousVersion})
+		if err == nil && rendered == previousURL {
+			return tmpl, true
+		}
+	}
+	return "", false
+}
+
+// inferURLTemplates replaces the installer URLs of configuration imported
+// from the latest version of a package with templates inferred from the
+// installers of the previous version, matched by architecture, type and
+// scope. URLs without an inferred template keep the literal URL of the
+// latest version and are returned as warnings asking for manual input.
+func inferURLTemplates(latest, previous map[string]any, latestVersion, previousVersion string) []string {
+	key := func(entry map[string]any) string {
+		return fmt.Sprint(entry["architecture"], "/", entry["type"], "/", entry["scope"])
+	}
+	// Imported URLs have the version of their manifest replaced
+	url := func(entry map[string]any, version string) string {
+		tmpl, _ := entry["url"].(string)
+		return strings.ReplaceAll(tmpl, "{{.Version}}", version)
+	}
+
+	previousURLs := make(map[string]string)
+	previousInstallers, _ := previous["installers"].([]any)
+	for _, value := range previousInstallers {
+		if entry, ok := value.(map[string]any); ok {
+			previousURLs[key(entry)] = url(entry, previousVersion)
+		}
+	}
+
+	var warnings []string
+	latestInstallers, _ := latest["installers"].([]any)
+	for i, value := range latestInstallers {
+		entry, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		latestURL := url(entry, latestVersion)
+		previousURL, ok := previousURLs[key(entry)]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("installers[%d].url: version %s has no %s installer to infer the URL template from; check %s", i, previousVersion, key(entry), entry["url"]))
+			continue
+		}
+		if tmpl, ok := inferURLTemplate(latestURL, latestVersion, previousURL, previousVersion); ok {
+			entry["url"] = tmpl
+			continue
+		}
+		entry["url"] = latestURL
+		warnings = append(warnings, fmt.Sprintf("installers[%d].url: %s and %s differ in more than the version; set the URL template manually", i, previousURL, latestURL))
+	}
+	return warnings
+}
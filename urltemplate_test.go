@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cmp"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInferURLTemplate(t *testing.T) {
+	tests := []struct {
+		name            string
+		latest          string
+		previous        string
+		previousVersion string
+		expected        string
+		wantFound       bool
+	}{
+		{
+			name:      "version",
+			latest:    "https://github.com/myorg/myapp/releases/download/v1.2.3/myapp-1.2.3-x64.msi",
+			previous:  "https://github.com/myorg/myapp/releases/download/v1.2.2/myapp-1.2.2-x64.msi",
+			expected:  "https://github.com/myorg/myapp/releases/download/v{{.Version}}/myapp-{{.Version}}-x64.msi",
+			wantFound: true,
+		},
+		{
+			name:      "version without dots",
+			latest:    "https://example.com/1.2.3/myapp123.exe",
+			previous:  "https://example.com/1.2.2/myapp122.exe",
+			expected:  `https://example.com/{{.Version}}/myapp{{.Version | replace "." ""}}.exe`,
+			wantFound: true,
+		},
+		{
+			name:            "major and minor",
+			latest:          "https://example.com/1.2/myapp-1.2.3.msi",
+			previous:        "https://example.com/1.1/myapp-1.1.9.msi",
+			previousVersion: "1.1.9",
+			expected:        "https://example.com/{{major .Version}}.{{minor .Version}}/myapp-{{.Version}}.msi",
+			wantFound:       true,
+		},
+		{
+			name:      "unversioned",
+			latest:    "https://example.com/latest/myapp.msi",
+			previous:  "https://example.com/latest/myapp.msi",
+			expected:  "https://example.com/latest/myapp.msi",
+			wantFound: true,
+		},
+		{
+			name:     "build number",
+			latest:   "https://example.com/1.2.3/myapp-1.2.3.4567.msi",
+			previous: "https://example.com/1.2.2/myapp-1.2.2.4501.msi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousVersion := cmp.Or(tt.previousVersion, "1.2.2")
+			tmpl, found := inferURLTemplate(tt.latest, "1.2.3", tt.previous, previousVersion)
+			if found != tt.wantFound || tmpl != tt.expected {
+				t.Errorf("expected %q (%v), got %q (%v)", tt.expected, tt.wantFound, tmpl, found)
+			}
+		})
+	}
+}
+
+func TestInferURLTemplates(t *testing.T) {
+	installer := func(arch, url string) any {
+		return map[string]any{"architecture": arch, "type": "exe", "url": url}
+	}
+	latest := map[string]any{"installers": []any{
+		installer("x64", "https://example.com/{{.Version}}/myapp_1_2_3-x64.exe"),
+		installer("x86", "https://example.com/{{.Version}}/myapp-{{.Version}}-build7-x86.exe"),
+		installer("arm64", "https://example.com/{{.Version}}/myapp-arm64.exe"),
+	}}
+	previous := map[string]any{"installers": []any{
+		installer("x64", "https://example.com/{{.Version}}/myapp_1_2_2-x64.exe"),
+		installer("x86", "https://example.com/{{.Version}}/myapp-{{.Version}}-build5-x86.exe"),
+	}}
+
+	warnings := inferURLTemplates(latest, previous, "1.2.3", "1.2.2")
+	var urls []any
+	for _, entry := range latest["installers"].([]any) {
+		urls = append(urls, entry.(map[string]any)["url"])
+	}
+	expected := []any{
+		`https://example.com/{{.Version}}/myapp_{{.Version | replace "." "_"}}-x64.exe`,
+		"https://example.com/1.2.3/myapp-1.2.3-build7-x86.exe",
+		"https://example.com/{{.Version}}/myapp-arm64.exe",
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected URLs %q, got %q", expected, urls)
+	}
+	if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "installers[1].url:") || !strings.HasPrefix(warnings[1], "installers[2].url:") {
+		t.Errorf("expected warnings for installers 1 and 2, got %q", warnings)
+	}
+}
@@ -0,0 +1,675 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const (
+	// maxPackageIDLength is the maximum length of a PackageIdentifier.
+	maxPackageIDLength = 128
+	// maxPackageIDSegments is the maximum number of dot-separated segments.
+	maxPackageIDSegments = 8
+	// maxPackageIDSegmentLength is the maximum length of a single segment.
+	maxPackageIDSegmentLength = 32
+)
+
+// maxPackageVersionLength is the maximum length of a PackageVersion.
+const maxPackageVersionLength = 128
+
+const (
+	// maxTags is the maximum number of tags in a locale manifest.
+	maxTags = 16
+	// maxTagLength is the maximum length of a single tag or moniker.
+	maxTagLength = 40
+)
+
+const (
+	// validationCodeWarning marks advisory findings that do not fail validation.
+	validationCodeWarning = "warning"
+	// validationCodeStrict marks advisory findings promoted to errors by strict mode.
+	validationCodeStrict = "strict"
+)
+
+// packageIDForbiddenChars are characters not allowed in PackageIdentifier
+// segments, in addition to whitespace and control characters.
+const packageIDForbiddenChars = `\/:*?"<>|`
+
+// isValidPackageID checks if a package ID is in valid format.
+func isValidPackageID(id string) bool {
+	return validatePackageID(id) == nil
+}
+
+// validatePackageID checks a package ID against the winget PackageIdentifier
+// rules and reports the first rule that is violated.
+func validatePackageID(id string) error {
+	if id == "" {
+		return fmt.Errorf("package ID is required and must be in format Publisher.PackageName")
+	}
+	if len(id) > maxPackageIDLength {
+		return fmt.Errorf("package ID must be at most %d characters, got %d", maxPackageIDLength, len(id))
+	}
+
+	segments := strings.Split(id, ".")
+	if len(segments) < 2 {
+		return fmt.Errorf("package ID must be in format Publisher.PackageName")
+	}
+	if len(segments) > maxPackageIDSegments {
+		return fmt.Errorf("package ID must have at most %d segments, got %d", maxPackageIDSegments, len(segments))
+	}
+
+	for i, segment := range segments {
+		switch {
+		case segment == "" && i == len(segments)-1:
+			return fmt.Errorf("package ID must not end with a dot")
+		case segment == "" && i == 0:
+			return fmt.Errorf("package ID must not start with a dot")
+		case segment == "":
+			return fmt.Errorf("package ID must not contain empty segments")
+		case len(segment) > maxPackageIDSegmentLength:
+			return fmt.Errorf("package ID segment %q must be at most %d characters", segment, maxPackageIDSegmentLength)
+		}
+
+		for _, r := range segment {
+			if r <= 0x1f || r == ' ' || r == '\t' || strings.ContainsRune(packageIDForbiddenChars, r) {
+				return fmt.Errorf("package ID segment %q contains invalid character %q", segment, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePackageVersion checks a version against the winget PackageVersion
+// schema pattern and winget-pkgs submission rules.
+func validatePackageVersion(version string) error {
+	if version == "" {
+		return fmt.Errorf("package version is required")
+	}
+	if len(version) > maxPackageVersionLength {
+		return fmt.Errorf("package version must be at most %d characters, got %d", maxPackageVersionLength, len(version))
+	}
+	for _, r := range version {
+		if r <= 0x1f || strings.ContainsRune(packageIDForbiddenChars, r) {
+			return fmt.Errorf("package version %q contains invalid character %q", version, r)
+		}
+	}
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && version[1] >= '0' && version[1] <= '9' {
+		return fmt.Errorf("package version %q must not start with %q (enable normalize_version to strip it)", version, version[0])
+	}
+	return nil
+}
+
+// normalizePackageVersion strips a leading "v" and any semver build metadata
+// suffix from a release version.
+func normalizePackageVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	if i := strings.Index(version, "+"); i >= 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// validateTags checks tags against the winget Tags constraints and returns one
+// error message per violation.
+func validateTags(tags []string) []string {
+	var problems []string
+	if len(tags) > maxTags {
+		problems = append(problems, fmt.Sprintf("at most %d tags are allowed, got %d", maxTags, len(tags)))
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		switch {
+		case tag == "":
+			problems = append(problems, "tags must not be empty")
+		case len(tag) > maxTagLength:
+			problems = append(problems, fmt.Sprintf("tag %q must be at most %d characters", tag, maxTagLength))
+		case seen[tag]:
+			problems = append(problems, fmt.Sprintf("tag %q is duplicated", tag))
+		}
+		seen[tag] = true
+	}
+	return problems
+}
+
+// normalizeTags canonicalizes tags like canonicalizeTags and also kebab-cases
+// them: whitespace and underscores become hyphens.
+func normalizeTags(tags []string) (normalized, dropped []string) {
+	return canonicalizeTags(tags, true)
+}
+
+// canonicalizeTags canonicalizes tags from the configuration and inherited
+// sources before manifest generation: tags are lowercased and trimmed, empty
+// and duplicate tags are removed, the list is capped at maxTags (keeping the
+// first tags) and sorted. With kebab, whitespace and underscores become
+// hyphens first. The duplicate tags and the tags beyond the cap are also
+// returned as dropped.
+func canonicalizeTags(tags []string, kebab bool) (normalized, dropped []string) {
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag := strings.ToLower(strings.TrimSpace(tag))
+		if kebab {
+			tag = strings.Join(strings.FieldsFunc(tag, func(r rune) bool {
+				return r == ' ' || r == '\t' || r == '_' || r == '-'
+			}), "-")
+		}
+
+		switch {
+		case tag == "":
+		case seen[tag] || len(normalized) == maxTags:
+			dropped = append(dropped, tag)
+		default:
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, dropped
+}
+
+// localePattern is the winget PackageLocale pattern, a BCP 47 language tag.
+var localePattern = regexp.MustCompile(`^([a-zA-Z]{2,3}|[iI]-[a-zA-Z]+|[xX]-[a-zA-Z]{1,8})(-[a-zA-Z]{1,8})*$`)
+
+const (
+	// maxAgreementLabelLength is the maximum length of an AgreementLabel.
+	maxAgreementLabelLength = 100
+	// maxAgreementLength is the maximum length of an Agreement text.
+	maxAgreementLength = 10000
+)
+
+// validateLocales checks the locale names, tags and agreements of the
+// configured locales. Tags are checked once normalized if normalize is set.
+func validateLocales(locales []LocaleConfig, normalize bool) []fieldError {
+	var problems []fieldError
+	seen := make(map[string]bool)
+	for i, locale := range locales {
+		field := fmt.Sprintf("locales[%d]", i)
+		switch {
+		case !localePattern.MatchString(locale.Locale):
+			problems = append(problems, fieldError{field + ".locale", fmt.Sprintf("locale %q is not a valid BCP 47 language tag", locale.Locale)})
+		case seen[strings.ToLower(locale.Locale)]:
+			problems = append(problems, fieldError{field + ".locale", fmt.Sprintf("locale %q is duplicated", locale.Locale)})
+		}
+		seen[strings.ToLower(locale.Locale)] = true
+
+		tags := locale.Tags
+		if normalize {
+			tags, _ = normalizeTags(tags)
+		}
+		for _, problem := range validateTags(tags) {
+			problems = append(problems, fieldError{field + ".tags", problem})
+		}
+		for j, agreement := range locale.Agreements {
+			agreementField := fmt.Sprintf("%s.agreements[%d]", field, j)
+			if agreement.Text == "" && agreement.URL == "" {
+				problems = append(problems, fieldError{agreementField, "text or url is required"})
+			}
+			if len(agreement.Label) > maxAgreementLabelLength {
+				problems = append(problems, fieldError{agreementField + ".label", fmt.Sprintf("label must be at most %d characters", maxAgreementLabelLength)})
+			}
+			if len(agreement.Text) > maxAgreementLength {
+				problems = append(problems, fieldError{agreementField + ".text", fmt.Sprintf("text must be at most %d characters", maxAgreementLength)})
+			}
+		}
+	}
+	return problems
+}
+
+// validateLengths checks the metadata and locale values against the length
+// bounds of the manifest schema, and their URLs. Values rendered from
+// templates are checked once the manifests are generated.
+func validateLengths(cfg *Config) []fieldError {
+	type bounded struct {
+		field, manifestField, value string
+	}
+	fields := []bounded{
+		{"metadata.publisher", "Publisher", cfg.Metadata.Publisher},
+		{"metadata.name", "PackageName", cfg.Metadata.Name},
+		{"metadata.license", "License", cfg.Metadata.License},
+		{"metadata.copyright", "Copyright", cfg.Metadata.Copyright},
+		{"metadata.short_description", "ShortDescription", cfg.Metadata.ShortDescription},
+		{"metadata.moniker", "Moniker", cfg.Metadata.Moniker},
+		{"metadata.release_notes", "ReleaseNotes", cfg.Metadata.ReleaseNotes},
+		{"metadata.installation_notes", "InstallationNotes", cfg.Metadata.InstallationNotes},
+	}
+	urls := []bounded{
+		{"metadata.publisher_url", "PublisherUrl", cfg.Metadata.PublisherURL},
+		{"metadata.publisher_support_url", "PublisherSupportUrl", cfg.Metadata.PublisherSupportURL},
+		{"metadata.license_url", "LicenseUrl", cfg.Metadata.LicenseURL},
+		{"metadata.package_url", "PackageUrl", cfg.Metadata.PackageURL},
+		{"metadata.release_notes_url", "ReleaseNotesUrl", cfg.Metadata.ReleaseNotesURL},
+	}
+	for i, locale := range cfg.Locales {
+		field := fmt.Sprintf("locales[%d]", i)
+		fields = append(fields,
+			bounded{field + ".publisher", "Publisher", locale.Publisher},
+			bounded{field + ".name", "PackageName", locale.Name},
+			bounded{field + ".short_description", "ShortDescription", locale.ShortDescription},
+			bounded{field + ".description", "Description", locale.Description},
+			bounded{field + ".release_notes", "ReleaseNotes", locale.ReleaseNotes},
+			bounded{field + ".installation_notes", "InstallationNotes", locale.InstallationNotes},
+		)
+		urls = append(urls, bounded{field + ".release_notes_url", "ReleaseNotesUrl", locale.ReleaseNotesURL})
+		for j, agreement := range locale.Agreements {
+			urls = append(urls, bounded{fmt.Sprintf("%s.agreements[%d].url", field, j), "AgreementUrl", agreement.URL})
+		}
+	}
+
+	var problems []fieldError
+	for _, f := range fields {
+		if strings.Contains(f.value, "{{") {
+			continue
+		}
+		if message := manifest.CheckLength(f.manifestField, f.value); message != "" {
+			problems = append(problems, fieldError{f.field, message})
+		}
+	}
+	for _, f := range urls {
+		if strings.Contains(f.value, "{{") {
+			continue
+		}
+		if message := manifest.CheckURL(f.manifestField, f.value); message != "" {
+			problems = append(problems, fieldError{f.field, message})
+		}
+	}
+	return problems
+}
+
+// fieldError is a validation problem tied to a configuration field.
+type fieldError struct {
+	Field   string
+	Message string
+}
+
+// validateInstallerCombinations reports installers that would produce an
+// ambiguous or nonsensical installer manifest.
+func validateInstallerCombinations(installers []InstallerConfig) []fieldError {
+	var problems []fieldError
+	seen := make(map[string]int)
+
+	for i, installer := range installers {
+		field := fmt.Sprintf("installers[%d]", i)
+
+		// winget selects installers by architecture, type and scope, so each
+		// combination may only appear once
+		key := strings.ToLower(installer.Architecture + "|" + installer.Type + "|" + installer.Scope)
+		// Asset installers without an architecture or scope take them from
+		// the asset name once it is resolved
+		if installer.Asset != "" && (installer.Architecture == "" || installer.Scope == "") {
+			key += "|" + installer.Asset
+		}
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fieldError{
+				Field: field,
+				Message: fmt.Sprintf("Installer duplicates installers[%d] (architecture %q, type %q, scope %q)",
+					first, installer.Architecture, installer.Type, installer.Scope),
+			})
+		} else {
+			seen[key] = i
+		}
+
+		switch strings.ToLower(installer.Type) {
+		case "msix", "appx":
+			if installer.ProductCode != "" {
+				problems = append(problems, fieldError{field + ".product_code",
+					"ProductCode is not used by msix/appx installers; their PackageFamilyName is read from the package"})
+			}
+			if len(installer.Switches) > 0 {
+				problems = append(problems, fieldError{field + ".switches",
+					"Installer switches are not supported by msix/appx installers"})
+			}
+		case "zip", "portable", "pwa":
+			if installer.ProductCode != "" {
+				problems = append(problems, fieldError{field + ".product_code",
+					fmt.Sprintf("ProductCode is not used by %s installers", installer.Type)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// installerSwitchKeys are the valid InstallerSwitches keys with their maximum
+// value lengths.
+var installerSwitchKeys = map[string]int{
+	"Silent":             512,
+	"SilentWithProgress": 512,
+	"Interactive":        512,
+	"InstallLocation":    512,
+	"Log":                512,
+	"Upgrade":            512,
+	"Repair":             512,
+	"Custom":             2048,
+}
+
+// validateInstallerSwitches checks switch keys against the schema and returns
+// one message per problem, suggesting the correct casing for near misses.
+func validateInstallerSwitches(switches map[string]string) []string {
+	keys := make([]string, 0, len(switches))
+	for key := range switches {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		maxLength, ok := installerSwitchKeys[key]
+		if !ok {
+			if valid := matchSwitchKey(key); valid != "" {
+				problems = append(problems, fmt.Sprintf("switch %q is not valid, did you mean %q?", key, valid))
+			} else {
+				problems = append(problems, fmt.Sprintf("switch %q is not valid, expected one of: %s", key, strings.Join(validSwitchKeys(), ", ")))
+			}
+			continue
+		}
+
+		value := switches[key]
+		if value == "" || len(value) > maxLength {
+			problems = append(problems, fmt.Sprintf("switch %q must be 1-%d characters", key, maxLength))
+		}
+	}
+	return problems
+}
+
+// matchSwitchKey returns the valid switch key matching key case-insensitively.
+func matchSwitchKey(key string) string {
+	for valid := range installerSwitchKeys {
+		if strings.EqualFold(valid, key) {
+			return valid
+		}
+	}
+	return ""
+}
+
+// validSwitchKeys returns the sorted list of valid switch keys.
+func validSwitchKeys() []string {
+	keys := make([]string, 0, len(installerSwitchKeys))
+	for key := range installerSwitchKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// unpinnedURLTokens are URL tokens that indicate a download that changes with
+// every release, which winget-pkgs moderators reject.
+var unpinnedURLTokens = []string{"latest", "current", "nightly"}
+
+// validateInstallerURL checks that an installer URL is an absolute https URL
+// pointing at a version-pinned download.
+func validateInstallerURL(rawURL string, allowInsecure bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("installer URL %q is not a valid absolute URL", rawURL)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+	case "http":
+		if !allowInsecure {
+			return fmt.Errorf("installer URL %q must use https (set allow_insecure_urls to override)", rawURL)
+		}
+	default:
+		return fmt.Errorf("installer URL %q must use https", rawURL)
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(u.Path+"?"+u.RawQuery), func(r rune) bool {
+		return strings.ContainsRune("/?&=-_.+", r)
+	})
+	for _, token := range tokens {
+		for _, unpinned := range unpinnedURLTokens {
+			if token == unpinned {
+				return fmt.Errorf("installer URL %q is not version-pinned (contains %q)", rawURL, unpinned)
+			}
+		}
+	}
+
+	return nil
+}
+
+// advisoryFindings returns issues winget-pkgs moderators commonly comment on
+// but which do not make the manifests invalid.
+func advisoryFindings(cfg *Config) []fieldError {
+	var findings []fieldError
+	meta := cfg.Metadata
+
+	if meta.PublisherURL == "" {
+		findings = append(findings, fieldError{"metadata.publisher_url", "PublisherUrl is recommended"})
+	}
+	if meta.LicenseURL == "" {
+		findings = append(findings, fieldError{"metadata.license_url", "LicenseUrl is recommended"})
+	}
+	if meta.PackageURL == "" {
+		findings = append(findings, fieldError{"metadata.package_url", "PackageUrl is recommended"})
+	}
+	if len(meta.Tags) == 0 {
+		findings = append(findings, fieldError{"metadata.tags", "Tags are recommended to make the package discoverable"})
+	}
+	if strings.HasSuffix(strings.TrimSpace(meta.ShortDescription), ".") {
+		findings = append(findings, fieldError{"metadata.short_description", "Short description should not end with a period"})
+	}
+	if meta.Name != "" && strings.EqualFold(meta.ShortDescription, meta.Name) {
+		findings = append(findings, fieldError{"metadata.short_description", "Short description should describe the package, not repeat its name"})
+	}
+
+	findings = append(findings, installerFindings(cfg)...)
+	findings = append(findings, profileFindings(cfg)...)
+
+	return findings
+}
+
+// installerFindings returns the advisory findings of installers that winget
+// cannot correlate with their Apps & Features entry. Installers that are read
+// once downloaded are only flagged after that when nothing could be derived
+// from them.
+func installerFindings(cfg *Config) []fieldError {
+	var findings []fieldError
+	for i, installer := range cfg.Installers {
+		if !productCodeTypes[installer.Type] || hasProductCode(installer) {
+			continue
+		}
+		if installer.inspected && installer.correlated || !installer.inspected && readsInstallerFile(installer) {
+			continue
+		}
+		findings = append(findings, fieldError{
+			fmt.Sprintf("installers[%d].product_code", i),
+			fmt.Sprintf("ProductCode or AppsAndFeaturesEntries are recommended for %s installers so winget can correlate the installed app for upgrades", installer.Type),
+		})
+	}
+	return findings
+}
+
+// findingOutputs formats advisory findings for step outputs.
+func findingOutputs(findings []fieldError) []map[string]any {
+	outputs := make([]map[string]any, len(findings))
+	for i, finding := range findings {
+		outputs[i] = map[string]any{"field": finding.Field, "message": finding.Message}
+	}
+	return outputs
+}
+
+// productCodeTypes are the installer types winget-pkgs expects a ProductCode
+// for, since winget cannot read it from the installer itself.
+var productCodeTypes = map[string]bool{
+	"exe":      true,
+	"inno":     true,
+	"nullsoft": true,
+}
+
+// hasProductCode reports whether an installer sets a ProductCode or an
+// UpgradeCode winget can correlate the installed app by, directly or in the
+// overrides of each of its scopes.
+func hasProductCode(installer InstallerConfig) bool {
+	if installer.ProductCode != "" || installer.UpgradeCode != "" {
+		return true
+	}
+	if len(installer.Scopes) == 0 {
+		return false
+	}
+	for _, scope := range installer.Scopes {
+		override := installer.ScopeOverrides[scope]
+		if override.ProductCode == "" && override.UpgradeCode == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// trackingIssuePattern matches an issue reference such as myorg/myapp#123.
+var trackingIssuePattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+#[1-9][0-9]*$`)
+
+// validatePullRequest checks the pull request annotations and tracking
+// issue reference.
+func validatePullRequest(pr PRConfig) []fieldError {
+	var problems []fieldError
+	for _, list := range []struct {
+		field  string
+		values []string
+	}{{"labels", pr.Labels}, {"reviewers", pr.Reviewers}, {"assignees", pr.Assignees}} {
+		for i, value := range list.values {
+			if strings.TrimSpace(value) == "" {
+				problems = append(problems, fieldError{fmt.Sprintf("pull_request.%s[%d]", list.field, i), "must not be empty"})
+			}
+		}
+	}
+	if pr.TrackingIssue != "" && !trackingIssuePattern.MatchString(pr.TrackingIssue) {
+		problems = append(problems, fieldError{"pull_request.tracking_issue", fmt.Sprintf("%q is not an issue reference such as myorg/myapp#123", pr.TrackingIssue)})
+	}
+	if pr.CloseTrackingIssue && pr.TrackingIssue == "" {
+		problems = append(problems, fieldError{"pull_request.close_tracking_issue", "close_tracking_issue requires tracking_issue"})
+	}
+	return problems
+}
+
+// guidPattern matches a hyphenated GUID without braces.
+var guidPattern = regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`)
+
+// guidProductCodeTypes are the installer types whose ProductCode is a GUID.
+// Other types, such as Inno Setup's "<AppId>_is1", use free-form keys.
+var guidProductCodeTypes = map[string]bool{
+	"msi":  true,
+	"wix":  true,
+	"burn": true,
+}
+
+// normalizeGUID validates a GUID given with or without braces and returns it
+// in the braced, uppercase form used by winget-pkgs.
+func normalizeGUID(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	hasOpen, hasClose := strings.HasPrefix(s, "{"), strings.HasSuffix(s, "}")
+	if hasOpen != hasClose {
+		return "", fmt.Errorf("GUID %q has mismatched braces", s)
+	}
+
+	inner := strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if !guidPattern.MatchString(inner) {
+		return "", fmt.Errorf("%q is not a GUID, expected a form such as {01234567-89AB-CDEF-0123-456789ABCDEF}", s)
+	}
+	return "{" + strings.ToUpper(inner) + "}", nil
+}
+
+// validateInstallerGUIDs checks the GUID syntax of an installer's product and
+// upgrade codes.
+func validateInstallerGUIDs(installer InstallerConfig) []fieldError {
+	var problems []fieldError
+	if installer.ProductCode != "" && guidProductCodeTypes[strings.ToLower(installer.Type)] {
+		if _, err := normalizeGUID(installer.ProductCode); err != nil {
+			problems = append(problems, fieldError{"product_code", err.Error()})
+		}
+	}
+	if installer.UpgradeCode != "" {
+		if _, err := normalizeGUID(installer.UpgradeCode); err != nil {
+			problems = append(problems, fieldError{"upgrade_code", err.Error()})
+		}
+	}
+	return problems
+}
+
+// validateRESTSource checks the REST source backend configuration.
+func validateRESTSource(cfg RESTSourceConfig, allowInsecure bool) []fieldError {
+	var problems []fieldError
+
+	if cfg.URL == "" {
+		problems = append(problems, fieldError{"rest_source.url", "REST source URL is required"})
+	} else if u, err := url.Parse(cfg.URL); err != nil || u.Host == "" {
+		problems = append(problems, fieldError{"rest_source.url", fmt.Sprintf("invalid REST source URL %q", cfg.URL)})
+	} else if u.Scheme != "https" && !(allowInsecure && u.Scheme == "http") {
+		problems = append(problems, fieldError{"rest_source.url", "REST source URL must use https"})
+	}
+
+	ad := cfg.AzureAD
+	adSet := ad.TenantID != "" || ad.ClientID != "" || ad.ClientSecret != "" || ad.Scope != ""
+	switch {
+	case adSet:
+		for _, f := range []struct{ name, value string }{
+			{"tenant_id", ad.TenantID},
+			{"client_id", ad.ClientID},
+			{"client_secret", ad.ClientSecret},
+			{"scope", ad.Scope},
+		} {
+			if f.value == "" {
+				problems = append(problems, fieldError{"rest_source.azure_ad." + f.name, f.name + " is required for Azure AD authentication"})
+			}
+		}
+	case cfg.APIKey == "":
+		problems = append(problems, fieldError{"rest_source", "api_key or azure_ad credentials are required"})
+	}
+
+	return problems
+}
+
+// maxGitHubRetries bounds github_retry.max_retries and
+// github_retry.rate_limit_retries.
+const maxGitHubRetries = 10
+
+// validateGitHubClientConfig checks the GitHub request timeout and retry
+// policy.
+func validateGitHubClientConfig(cfg *Config) []fieldError {
+	var problems []fieldError
+	if cfg.Timeouts.GitHubRequest < 0 {
+		problems = append(problems, fieldError{"timeouts.github_request", "github_request must not be negative"})
+	}
+	if cfg.GitHubRetry.MaxRetries < 0 || cfg.GitHubRetry.MaxRetries > maxGitHubRetries {
+		problems = append(problems, fieldError{"github_retry.max_retries", fmt.Sprintf("max_retries must be between 0 and %d", maxGitHubRetries)})
+	}
+	if cfg.GitHubRetry.Backoff < 0 {
+		problems = append(problems, fieldError{"github_retry.backoff", "backoff must not be negative"})
+	}
+	if cfg.GitHubRetry.RateLimitRetries < 0 || cfg.GitHubRetry.RateLimitRetries > maxGitHubRetries {
+		problems = append(problems, fieldError{"github_retry.rate_limit_retries", fmt.Sprintf("rate_limit_retries must be between 0 and %d", maxGitHubRetries)})
+	}
+	if cfg.GitHubRetry.RateLimitMaxWait < 0 {
+		problems = append(problems, fieldError{"github_retry.rate_limit_max_wait", "rate_limit_max_wait must not be negative"})
+	}
+	return problems
+}
+
+// maxDownloadBufferSize bounds download.buffer_size.
+const maxDownloadBufferSize = 16 << 20
+
+// validateDownloadConfig checks the installer download settings.
+func validateDownloadConfig(cfg DownloadConfig) []fieldError {
+	var problems []fieldError
+	if cfg.BufferSize < 1 || cfg.BufferSize > maxDownloadBufferSize {
+		problems = append(problems, fieldError{"download.buffer_size", fmt.Sprintf("buffer_size must be between 1 and %d bytes", maxDownloadBufferSize)})
+	}
+	if cfg.MinSize < 0 {
+		problems = append(problems, fieldError{"download.min_size", "min_size must not be negative"})
+	}
+	for i, contentType := range cfg.RejectContentTypes {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !strings.Contains(mediaType, "/") {
+			problems = append(problems, fieldError{fmt.Sprintf("download.reject_content_types[%d]", i), fmt.Sprintf("%q is not a media type", contentType)})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,647 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/installerhash"
+)
+
+func TestValidatePackageID(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		contains string
+	}{
+		{"valid", "MyOrg.MyApp", ""},
+		{"valid many segments", "A.B.C.D.E.F.G.H", ""},
+		{"empty", "", "required"},
+		{"single segment", "MyApp", "Publisher.PackageName"},
+		{"too long", "MyOrg." + strings.Repeat("a", 32) + "." + strings.Repeat("b", 32) + "." + strings.Repeat("c", 32) + "." + strings.Repeat("d", 32), "at most 128 characters"},
+		{"too many segments", "A.B.C.D.E.F.G.H.I", "at most 8 segments"},
+		{"trailing dot", "MyOrg.MyApp.", "must not end with a dot"},
+		{"leading dot", ".MyApp", "must not start with a dot"},
+		{"empty segment", "MyOrg..MyApp", "empty segments"},
+		{"long segment", "MyOrg." + strings.Repeat("a", 33), "at most 32 characters"},
+		{"space", "My Org.MyApp", "invalid character ' '"},
+		{"slash", "MyOrg.My/App", "invalid character '/'"},
+		{"control character", "MyOrg.My\x01App", "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePackageID(tt.id)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error containing '%s'", tt.contains)
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing '%s', got '%v'", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestValidatePackageVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		contains string
+	}{
+		{"1.2.3", ""},
+		{"2024.10.1", ""},
+		{"1.0.0-beta.1", ""},
+		{"1.0.0+build5", ""},
+		{"version-1", ""},
+		{"", "required"},
+		{"v1.2.3", "must not start with"},
+		{"V2", "must not start with"},
+		{"1.2/3", "invalid character"},
+		{"1.2:3", "invalid character"},
+		{strings.Repeat("1", 129), "at most 128 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			err := validatePackageVersion(tt.version)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing '%s', got '%v'", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestNormalizePackageVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"V1.2.3", "1.2.3"},
+		{"2024.10.1+build5", "2024.10.1"},
+		{"v1.0.0-rc.1+sha.abc", "1.0.0-rc.1"},
+		{"version", "version"},
+		{" 1.0.0 ", "1.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if result := normalizePackageVersion(tt.version); result != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tooMany := make([]string, 17)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		problems int
+		contains string
+	}{
+		{"valid", []string{"utility", "cli"}, 0, ""},
+		{"none", nil, 0, ""},
+		{"too many", tooMany, 1, "at most 16 tags"},
+		{"too long", []string{strings.Repeat("a", 41)}, 1, "at most 40 characters"},
+		{"empty", []string{""}, 1, "must not be empty"},
+		{"duplicate", []string{"cli", "cli"}, 1, "duplicated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateTags(tt.tags)
+			if len(problems) != tt.problems {
+				t.Fatalf("expected %d problems, got %v", tt.problems, problems)
+			}
+			if tt.contains != "" && !strings.Contains(problems[0], tt.contains) {
+				t.Errorf("expected problem containing '%s', got '%s'", tt.contains, problems[0])
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	many := make([]string, 20)
+	capped := make([]string, maxTags)
+	for i := range many {
+		many[i] = fmt.Sprintf("Tag %02d", i)
+		if i < maxTags {
+			capped[i] = fmt.Sprintf("tag-%02d", i)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		expected []string
+		dropped  []string
+	}{
+		{"lowercase", []string{"Utility", "CLI"}, []string{"cli", "utility"}, nil},
+		{"kebab-case", []string{"Developer Tools", "package_manager", " spaced  out "}, []string{"developer-tools", "package-manager", "spaced-out"}, nil},
+		{"dedupe", []string{"cli", "CLI", "c l i", "c-l-i"}, []string{"c-l-i", "cli"}, []string{"cli", "c-l-i"}},
+		{"drop empty", []string{"", "  ", "ok"}, []string{"ok"}, nil},
+		{"cap list", many, capped, []string{"tag-16", "tag-17", "tag-18", "tag-19"}},
+		{"cap before sort", append([]string{"zeta"}, many[:maxTags]...), append(capped[:maxTags-1:maxTags-1], "zeta"), []string{"tag-15"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, dropped := normalizeTags(tt.tags)
+			if strings.Join(result, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+			if strings.Join(dropped, ",") != strings.Join(tt.dropped, ",") {
+				t.Errorf("expected dropped %v, got %v", tt.dropped, dropped)
+			}
+		})
+	}
+}
+
+func TestValidateNormalizedTags(t *testing.T) {
+	cfg := validTestConfig()
+	cfg["metadata"].(map[string]any)["tags"] = []any{"cli", "cli"}
+	cfg["locales"] = []any{map[string]any{"locale": "de-DE", "tags": []any{"werkzeug", "werkzeug"}}}
+
+	resp, err := (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Valid {
+		t.Fatal("expected duplicate tags to be invalid")
+	}
+
+	cfg["metadata"].(map[string]any)["normalize_tags"] = true
+	resp, err = (&WinGetPlugin{}).Validate(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hasValidationError(resp, "metadata.tags") || hasValidationError(resp, "locales[0].tags") {
+		t.Errorf("expected duplicate tags to be normalized, got: %+v", resp.Errors)
+	}
+}
+
+func TestValidateInstallerCombinations(t *testing.T) {
+	tests := []struct {
+		name       string
+		installers []InstallerConfig
+		fields     []string
+	}{
+		{
+			name: "distinct installers",
+			installers: []InstallerConfig{
+				{Architecture: "x64", Type: "msi", Scope: "machine"},
+				{Architecture: "x64", Type: "msi", Scope: "user"},
+				{Architecture: "arm64", Type: "msi", Scope: "machine"},
+			},
+		},
+		{
+			name: "duplicate combination",
+			installers: []InstallerConfig{
+				{Architecture: "x64", Type: "msi", Scope: "machine"},
+				{Architecture: "x64", Type: "MSI", Scope: "machine"},
+			},
+			fields: []string{"installers[1]"},
+		},
+		{
+			name: "product code on exe",
+			installers: []InstallerConfig{
+				{Architecture: "x64", Type: "exe", ProductCode: "MyApp"},
+			},
+		},
+		{
+			name: "msix with product code and switches",
+			installers: []InstallerConfig{
+				{Architecture: "x64", Type: "msix", ProductCode: "{GUID}", Switches: map[string]string{"Silent": "/S"}},
+			},
+			fields: []string{"installers[0].product_code", "installers[0].switches"},
+		},
+		{
+			name: "product code on zip",
+			installers: []InstallerConfig{
+				{Architecture: "x64", Type: "zip", ProductCode: "MyApp"},
+			},
+			fields: []string{"installers[0].product_code"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateInstallerCombinations(tt.installers)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLengths(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		fields []string
+	}{
+		{"valid", func(cfg *Config) {}, nil},
+		{"short copyright", func(cfg *Config) { cfg.Metadata.Copyright = "c" }, []string{"metadata.copyright"}},
+		{"long short description", func(cfg *Config) { cfg.Metadata.ShortDescription = strings.Repeat("x", 257) }, []string{"metadata.short_description"}},
+		{"long moniker", func(cfg *Config) { cfg.Metadata.Moniker = strings.Repeat("m", 41) }, []string{"metadata.moniker"}},
+		{"invalid URL", func(cfg *Config) { cfg.Metadata.PackageURL = "myorg.com" }, []string{"metadata.package_url"}},
+		{"long installation notes", func(cfg *Config) { cfg.Metadata.InstallationNotes = strings.Repeat("n", 10001) }, []string{"metadata.installation_notes"}},
+		{"template", func(cfg *Config) { cfg.Metadata.ReleaseNotesURL = "{{.ReleaseURL}}" }, nil},
+		{"locale", func(cfg *Config) {
+			cfg.Locales = []LocaleConfig{{
+				Locale:      "de-DE",
+				Description: "ab",
+				Agreements:  []AgreementConfig{{Label: "EULA", URL: "/eula"}},
+			}}
+		}, []string{"locales[0].description", "locales[0].agreements[0].url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := decodePluginConfig(validTestConfig())
+			tt.modify(cfg)
+			problems := validateLengths(cfg)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLocales(t *testing.T) {
+	tests := []struct {
+		name    string
+		locales []LocaleConfig
+		fields  []string
+	}{
+		{
+			name: "valid",
+			locales: []LocaleConfig{
+				{Locale: "en-US", Tags: []string{"tool"}},
+				{Locale: "zh-Hans-CN", Agreements: []AgreementConfig{{Label: "EULA", URL: "https://example.com/eula"}}},
+			},
+		},
+		{
+			name:    "invalid locale",
+			locales: []LocaleConfig{{Locale: "german"}},
+			fields:  []string{"locales[0].locale"},
+		},
+		{
+			name:    "duplicate locale",
+			locales: []LocaleConfig{{Locale: "de-DE"}, {Locale: "de-de"}},
+			fields:  []string{"locales[1].locale"},
+		},
+		{
+			name:    "duplicate tags",
+			locales: []LocaleConfig{{Locale: "de-DE", Tags: []string{"a", "a"}}},
+			fields:  []string{"locales[0].tags"},
+		},
+		{
+			name:    "agreement without text or url",
+			locales: []LocaleConfig{{Locale: "de-DE", Agreements: []AgreementConfig{{Label: strings.Repeat("x", 101)}}}},
+			fields:  []string{"locales[0].agreements[0]", "locales[0].agreements[0].label"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateLocales(tt.locales, false)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInstallerURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		allowInsecure bool
+		contains      string
+	}{
+		{"https", "https://example.com/app-{{.Version}}.msi", false, ""},
+		{"http rejected", "http://example.com/app-1.0.0.msi", false, "must use https"},
+		{"http allowed", "http://example.com/app-1.0.0.msi", true, ""},
+		{"ftp rejected", "ftp://example.com/app.msi", true, "must use https"},
+		{"relative", "/app.msi", false, "not a valid absolute URL"},
+		{"latest release", "https://github.com/org/app/releases/latest/download/app.msi", false, "not version-pinned"},
+		{"latest file name", "https://example.com/app-latest.exe", false, "not version-pinned"},
+		{"latest query", "https://example.com/download?version=latest", false, "not version-pinned"},
+		{"nightly", "https://example.com/nightly/app.exe", false, "not version-pinned"},
+		{"word containing latest", "https://example.com/greatest-app-1.0.msi", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateInstallerURL(tt.url, tt.allowInsecure)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing '%s', got '%v'", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestValidateInstallerSwitches(t *testing.T) {
+	tests := []struct {
+		name     string
+		switches map[string]string
+		problems []string
+	}{
+		{"none", nil, nil},
+		{"valid", map[string]string{"Silent": "/S", "SilentWithProgress": "/S", "Custom": "/norestart"}, nil},
+		{"wrong case", map[string]string{"silent": "/S"}, []string{`did you mean "Silent"?`}},
+		{"wrong case compound", map[string]string{"SILENTWITHPROGRESS": "/S"}, []string{`did you mean "SilentWithProgress"?`}},
+		{"unknown", map[string]string{"Quiet": "/q"}, []string{"expected one of: Custom, InstallLocation, Interactive, Log, Repair, Silent, SilentWithProgress, Upgrade"}},
+		{"empty value", map[string]string{"Silent": ""}, []string{"must be 1-512 characters"}},
+		{"long custom", map[string]string{"Custom": strings.Repeat("x", 2049)}, []string{"must be 1-2048 characters"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateInstallerSwitches(tt.switches)
+			if len(problems) != len(tt.problems) {
+				t.Fatalf("expected %d problems, got %v", len(tt.problems), problems)
+			}
+			for i, expected := range tt.problems {
+				if !strings.Contains(problems[i], expected) {
+					t.Errorf("expected problem containing '%s', got '%s'", expected, problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAdvisoryFindings(t *testing.T) {
+	complete := MetadataConfig{
+		Publisher:        "My Org",
+		PublisherURL:     "https://example.com",
+		Name:             "My App",
+		ShortDescription: "A test app",
+		License:          "MIT",
+		LicenseURL:       "https://example.com/license",
+		PackageURL:       "https://example.com/app",
+		Tags:             []string{"cli"},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(m *MetadataConfig)
+		fields []string
+	}{
+		{"complete", func(m *MetadataConfig) {}, nil},
+		{"missing publisher url", func(m *MetadataConfig) { m.PublisherURL = "" }, []string{"metadata.publisher_url"}},
+		{"missing tags", func(m *MetadataConfig) { m.Tags = nil }, []string{"metadata.tags"}},
+		{"trailing period", func(m *MetadataConfig) { m.ShortDescription = "A test app." }, []string{"metadata.short_description"}},
+		{"description repeats name", func(m *MetadataConfig) { m.ShortDescription = "my app" }, []string{"metadata.short_description"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := complete
+			tt.modify(&meta)
+			findings := advisoryFindings(&Config{Metadata: meta})
+			if len(findings) != len(tt.fields) {
+				t.Fatalf("expected %d findings, got %v", len(tt.fields), findings)
+			}
+			for i, field := range tt.fields {
+				if findings[i].Field != field {
+					t.Errorf("expected finding for '%s', got '%s'", field, findings[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestAdvisoryFindingsProductCode(t *testing.T) {
+	complete := MetadataConfig{
+		PublisherURL: "https://example.com",
+		LicenseURL:   "https://example.com/license",
+		PackageURL:   "https://example.com/app",
+		Tags:         []string{"cli"},
+	}
+	installers := []InstallerConfig{
+		{Type: "msi"},
+		{Type: "exe"},
+		{Type: "exe", inspected: true},
+		{Type: "inno", inspected: true, correlated: true},
+		{Type: "nullsoft", inspected: true, UpgradeCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}"},
+		{Type: "nullsoft", inspected: true, Scopes: []string{"user", "machine"}, ScopeOverrides: map[string]ScopeOverride{"user": {ProductCode: "MyApp"}}},
+	}
+
+	// Installers that are read once downloaded are only flagged once nothing
+	// could be derived from them
+	findings := advisoryFindings(&Config{Metadata: complete, Installers: installers})
+	expected := []string{"installers[2].product_code", "installers[5].product_code"}
+	if len(findings) != len(expected) {
+		t.Fatalf("expected %d findings, got %v", len(expected), findings)
+	}
+	for i, field := range expected {
+		if findings[i].Field != field {
+			t.Errorf("expected finding for '%s', got '%s'", field, findings[i].Field)
+		}
+	}
+}
+
+func TestHasProductCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		installer InstallerConfig
+		expected  bool
+	}{
+		{"none", InstallerConfig{Type: "exe"}, false},
+		{"product code", InstallerConfig{Type: "inno", ProductCode: "MyApp_is1"}, true},
+		{"upgrade code", InstallerConfig{Type: "exe", UpgradeCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}"}, true},
+		{"every scope", InstallerConfig{Type: "nullsoft", Scopes: []string{"user", "machine"}, ScopeOverrides: map[string]ScopeOverride{"user": {ProductCode: "MyApp"}, "machine": {UpgradeCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}"}}}, true},
+		{"missing scope", InstallerConfig{Type: "nullsoft", Scopes: []string{"user", "machine"}, ScopeOverrides: map[string]ScopeOverride{"user": {ProductCode: "MyApp"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasProductCode(tt.installer); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeGUID(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"{01234567-89AB-CDEF-0123-456789ABCDEF}", "{01234567-89AB-CDEF-0123-456789ABCDEF}", false},
+		{"01234567-89ab-cdef-0123-456789abcdef", "{01234567-89AB-CDEF-0123-456789ABCDEF}", false},
+		{" {01234567-89ab-cdef-0123-456789abcdef} ", "{01234567-89AB-CDEF-0123-456789ABCDEF}", false},
+		{"{01234567-89AB-CDEF-0123-456789ABCDEF", "", true},
+		{"01234567-89AB-CDEF-0123-456789ABCDEF}", "", true},
+		{"{0123456-89AB-CDEF-0123-456789ABCDEF}", "", true},
+		{"{0123456789ABCDEF0123456789ABCDEF}", "", true},
+		{"{GGGGGGGG-89AB-CDEF-0123-456789ABCDEF}", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := normalizeGUID(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("normalizeGUID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if result != tt.expected {
+				t.Errorf("expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateInstallerGUIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		installer InstallerConfig
+		fields    []string
+	}{
+		{"valid msi", InstallerConfig{Type: "msi", ProductCode: "{01234567-89AB-CDEF-0123-456789ABCDEF}"}, nil},
+		{"malformed msi", InstallerConfig{Type: "msi", ProductCode: "{01234567-89AB}"}, []string{"product_code"}},
+		{"free-form inno", InstallerConfig{Type: "inno", ProductCode: "MyApp_is1"}, nil},
+		{"malformed upgrade code", InstallerConfig{Type: "wix", UpgradeCode: "not-a-guid"}, []string{"upgrade_code"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateInstallerGUIDs(tt.installer)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRESTSource(t *testing.T) {
+	ad := AzureADConfig{TenantID: "t", ClientID: "c", ClientSecret: "s", Scope: "api://x/.default"}
+
+	tests := []struct {
+		name   string
+		cfg    RESTSourceConfig
+		fields []string
+	}{
+		{"api key", RESTSourceConfig{URL: "https://example.com/api", APIKey: "key"}, nil},
+		{"azure ad", RESTSourceConfig{URL: "https://example.com/api", AzureAD: ad}, nil},
+		{"missing url", RESTSourceConfig{APIKey: "key"}, []string{"rest_source.url"}},
+		{"http url", RESTSourceConfig{URL: "http://example.com/api", APIKey: "key"}, []string{"rest_source.url"}},
+		{"no credentials", RESTSourceConfig{URL: "https://example.com/api"}, []string{"rest_source"}},
+		{"partial azure ad", RESTSourceConfig{URL: "https://example.com/api", AzureAD: AzureADConfig{TenantID: "t"}},
+			[]string{"rest_source.azure_ad.client_id", "rest_source.azure_ad.client_secret", "rest_source.azure_ad.scope"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validateRESTSource(tt.cfg, false)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDownloadConfig(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantErr bool
+	}{
+		{installerhash.DefaultBufferSize, false},
+		{1, false},
+		{0, true},
+		{-1, true},
+		{maxDownloadBufferSize + 1, true},
+	}
+
+	for _, tt := range tests {
+		problems := validateDownloadConfig(DownloadConfig{BufferSize: tt.size})
+		if (len(problems) > 0) != tt.wantErr {
+			t.Errorf("buffer_size %d: expected error %v, got %v", tt.size, tt.wantErr, problems)
+		}
+	}
+
+	problems := validateDownloadConfig(DownloadConfig{BufferSize: 1, MinSize: -1, RejectContentTypes: []string{"text/html", "html"}})
+	if len(problems) != 2 || problems[0].Field != "download.min_size" || problems[1].Field != "download.reject_content_types[1]" {
+		t.Errorf("expected min_size and reject_content_types problems, got %v", problems)
+	}
+}
+
+func TestValidatePullRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		pr     PRConfig
+		fields []string
+	}{
+		{"annotations", PRConfig{Labels: []string{"New-Package"}, Reviewers: []string{"octocat"}, Assignees: []string{"octocat"}}, nil},
+		{"empty label", PRConfig{Labels: []string{"New-Package", " "}}, []string{"pull_request.labels[1]"}},
+		{"tracking issue", PRConfig{TrackingIssue: "myorg/myapp#123", CloseTrackingIssue: true}, nil},
+		{"tracking issue URL", PRConfig{TrackingIssue: "https://github.com/myorg/myapp/issues/123"}, []string{"pull_request.tracking_issue"}},
+		{"close without issue", PRConfig{CloseTrackingIssue: true}, []string{"pull_request.close_tracking_issue"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := validatePullRequest(tt.pr)
+			if len(problems) != len(tt.fields) {
+				t.Fatalf("expected %d problems, got %v", len(tt.fields), problems)
+			}
+			for i, field := range tt.fields {
+				if problems[i].Field != field {
+					t.Errorf("expected problem for '%s', got '%s'", field, problems[i].Field)
+				}
+			}
+		})
+	}
+}
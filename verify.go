@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+// Verify regenerates the manifests of a version already published in
+// winget-pkgs, hashing the real installers, and compares them byte for byte
+// with the published ones. Drift, from configuration changes or from edits
+// made by winget-pkgs moderators, fails the verification and is returned as
+// a diff from the published to the regenerated manifests in the
+// manifest_drift output.
+func (p *WinGetPlugin) Verify(ctx context.Context, req plugin.ExecuteRequest) (*plugin.ExecuteResponse, error) {
+	c, resp, err := p.regeneratePublished(ctx, req, "verify")
+	if c == nil {
+		return resp, err
+	}
+
+	outputs := map[string]any{
+		"manifest_dir":     c.manifestDir,
+		"verified_version": c.version,
+	}
+	drift := manifest.DiffFiles(c.version, c.published, c.version, c.regenerated)
+	if drift != "" {
+		c.logger.Warn("Regenerated manifests differ from winget-pkgs", "version", c.version, "drift", drift)
+		resp := failureResponse(categoryValidation, "Regenerated manifests of %s version %s differ from winget-pkgs", c.cfg.PackageID, c.version)
+		maps.Copy(resp.Outputs, outputs)
+		resp.Outputs["manifest_drift"] = drift
+		return resp, nil
+	}
+
+	c.logger.Info("Regenerated manifests match winget-pkgs", "version", c.version)
+	return &plugin.ExecuteResponse{
+		Success: true,
+		Message: fmt.Sprintf("Regenerated manifests of %s version %s match winget-pkgs byte for byte", c.cfg.PackageID, c.version),
+		Outputs: outputs,
+	}, nil
+}
+
+// publishedComparison holds the regenerated and published manifests of a
+// version, keyed by file name.
+type publishedComparison struct {
+	cfg         *Config
+	logger      *slog.Logger
+	version     string
+	manifestDir string
+	regenerated map[string]string
+	published   map[string]string
+}
+
+// regeneratePublished regenerates the manifests of a version, hashing the
+// real installers, and fetches the published manifests of the version from
+// winget-pkgs. It returns a nil comparison and the response to return when
+// either fails or the version is not published.
+func (p *WinGetPlugin) regeneratePublished(ctx context.Context, req plugin.ExecuteRequest, mode string) (*publishedComparison, *plugin.ExecuteResponse, error) {
+	cfg := p.parseConfig(req.Config)
+	redactor := newRedactor(cfg)
+	logger := slog.New(redactor.handler(slog.Default().Handler())).With("plugin", "winget", "mode", mode)
+
+	// Placeholder hashes would always differ
+	regenerateReq := req
+	regenerateReq.Hook = plugin.HookPostPublish
+	regenerateReq.DryRun = true
+	regenerateReq.Config = maps.Clone(req.Config)
+	regenerateReq.Config["dry_run_hash"] = dryRunHashReal
+	resp, err := p.Execute(ctx, regenerateReq)
+	if err != nil || !resp.Success {
+		return nil, resp, err
+	}
+
+	manifestPath, _ := resp.Outputs["manifest_path"].(string)
+	manifestDir, _ := resp.Outputs["manifest_dir"].(string)
+	version := path.Base(manifestPath)
+	regenerated, err := readManifestDir(manifestDir)
+	if err != nil {
+		return nil, failureResponse(categoryUnknown, "Failed to read regenerated manifests: %v", err), nil
+	}
+
+	ctx, cancel := withOptionalTimeout(ctx, cfg.Timeouts.GitHub)
+	defer cancel()
+	published, err := newGitHubClient(ctx, cfg, "", logger).VersionManifests(ctx, cfg.PackageID, version)
+	if err != nil {
+		resp := failureResponse(classifyError(err), "Failed to fetch published manifests: %v", err)
+		redactor.executeResponse(resp)
+		return nil, resp, nil
+	}
+	if published == nil {
+		return nil, failureResponse(categoryValidation, "%s version %s is not published in winget-pkgs", cfg.PackageID, version), nil
+	}
+
+	return &publishedComparison{
+		cfg:         cfg,
+		logger:      logger,
+		version:     version,
+		manifestDir: manifestDir,
+		regenerated: regenerated,
+		published:   published,
+	}, nil, nil
+}
+
+// readManifestDir reads the manifest files of a version directory, keyed by
+// file name.
+func readManifestDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files, nil
+}
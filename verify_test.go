@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestVerify(t *testing.T) {
+	published := map[string]string{}
+	server := newPublishedManifestServer(t, "manifests/m/MyOrg/MyApp/1.2.3", published)
+	p := &WinGetPlugin{}
+	req := plugin.ExecuteRequest{Config: publishedTestConfig(server), Context: plugin.ReleaseContext{Version: "1.2.3"}}
+
+	resp, err := p.Verify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Message, "is not published") {
+		t.Fatalf("expected an unpublished version to fail, got %s", resp.Message)
+	}
+
+	publishGeneratedManifests(t, req, published)
+	resp, err = p.Verify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success || resp.Outputs["verified_version"] != "1.2.3" {
+		t.Fatalf("expected identical manifests, got %s: %v", resp.Message, resp.Outputs["manifest_drift"])
+	}
+
+	// A moderator edit shows as drift
+	published["MyOrg.MyApp.locale.en-US.yaml"] = strings.Replace(published["MyOrg.MyApp.locale.en-US.yaml"], "License: MIT", "License: MIT License", 1)
+	resp, err = p.Verify(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	drift, _ := resp.Outputs["manifest_drift"].(string)
+	if resp.Success || !strings.Contains(drift, "-License: MIT License\n+License: MIT\n") {
+		t.Errorf("expected the license edit as drift, got %s:\n%s", resp.Message, drift)
+	}
+}
+
+// newPublishedManifestServer serves an installer at /app-<version>.msi and,
+// once published has entries, the published manifests of a winget-pkgs
+// version directory through the GitHub API.
+func newPublishedManifestServer(t *testing.T, versionDir string, published map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contents := strings.TrimPrefix(r.URL.Path, "/repos/microsoft/winget-pkgs/contents/")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/app-"):
+			_, _ = w.Write([]byte("installer"))
+		case contents == versionDir && len(published) > 0:
+			var entries []map[string]string
+			for name := range published {
+				entries = append(entries, map[string]string{"name": name, "path": versionDir + "/" + name, "type": "file"})
+			}
+			_ = json.NewEncoder(w).Encode(entries)
+		case path.Dir(contents) == versionDir && len(published) > 0:
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"content":  base64.StdEncoding.EncodeToString([]byte(published[path.Base(contents)])),
+				"encoding": "base64",
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	originalBase := githubAPIBase
+	githubAPIBase = server.URL
+	t.Cleanup(func() { githubAPIBase = originalBase })
+	return server
+}
+
+// publishedTestConfig returns validTestConfig with the installer served by a
+// newPublishedManifestServer.
+func publishedTestConfig(server *httptest.Server) map[string]any {
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.Version}}.msi"
+	cfg["allow_insecure_urls"] = true
+	return cfg
+}
+
+// publishGeneratedManifests generates the manifests of a request with real
+// hashes and stores them in published.
+func publishGeneratedManifests(t *testing.T, req plugin.ExecuteRequest, published map[string]string) {
+	t.Helper()
+
+	genCfg := maps.Clone(req.Config)
+	genCfg["dry_run_hash"] = dryRunHashReal
+	genCfg["output_dir"] = t.TempDir()
+	gen, err := (&WinGetPlugin{}).Execute(context.Background(), plugin.ExecuteRequest{Hook: plugin.HookPostPublish, Config: genCfg, Context: req.Context, DryRun: true})
+	if err != nil || !gen.Success {
+		t.Fatalf("failed to generate manifests: %v %v", err, gen)
+	}
+	files, err := readManifestDir(gen.Outputs["manifest_dir"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	maps.Copy(published, files)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxZeroPad bounds the zero_pad width of version transforms.
+const maxZeroPad = 10
+
+// VersionTransform rewrites a release version. Steps run in field order: the
+// prefix is stripped, numeric fields are zero-padded and the "." separators
+// are replaced.
+type VersionTransform struct {
+	StripPrefix string `json:"strip_prefix"`
+	ZeroPad     int    `json:"zero_pad"`
+	Separator   string `json:"separator"`
+}
+
+// VersionTransformsConfig defines independent version transforms for
+// installer URLs, the manifest PackageVersion and the ARP DisplayVersion.
+type VersionTransformsConfig struct {
+	URL     VersionTransform `json:"url"`
+	Package VersionTransform `json:"package"`
+	Display VersionTransform `json:"display"`
+}
+
+// IsZero reports whether the transform leaves versions unchanged.
+func (t VersionTransform) IsZero() bool {
+	return t == VersionTransform{}
+}
+
+// Apply returns the transformed version.
+func (t VersionTransform) Apply(version string) string {
+	version = strings.TrimPrefix(version, t.StripPrefix)
+
+	if t.ZeroPad > 0 {
+		// Only pad the dot-separated fields before any pre-release or build
+		// suffix, so 1.2.3-rc.1 keeps its rc.1
+		core, suffix := version, ""
+		if i := strings.IndexAny(version, "-+"); i >= 0 {
+			core, suffix = version[:i], version[i:]
+		}
+		fields := strings.Split(core, ".")
+		for i, field := range fields {
+			if isDigits(field) && len(field) < t.ZeroPad {
+				fields[i] = strings.Repeat("0", t.ZeroPad-len(field)) + field
+			}
+		}
+		version = strings.Join(fields, ".") + suffix
+	}
+
+	if t.Separator != "" {
+		version = strings.ReplaceAll(version, ".", t.Separator)
+	}
+	return version
+}
+
+// validateVersionTransforms checks the bounds of each version transform.
+func validateVersionTransforms(cfg VersionTransformsConfig) []fieldError {
+	var problems []fieldError
+	for _, t := range []struct {
+		name      string
+		transform VersionTransform
+	}{
+		{"url", cfg.URL},
+		{"package", cfg.Package},
+		{"display", cfg.Display},
+	} {
+		if t.transform.ZeroPad < 0 || t.transform.ZeroPad > maxZeroPad {
+			problems = append(problems, fieldError{"version_transforms." + t.name + ".zero_pad",
+				fmt.Sprintf("zero_pad must be between 0 and %d", maxZeroPad)})
+		}
+	}
+	return problems
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
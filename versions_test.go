@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/relicta-plugin-sdk/plugin"
+)
+
+func TestVersionTransformApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform VersionTransform
+		version   string
+		expected  string
+	}{
+		{"zero transform", VersionTransform{}, "v1.2.3", "v1.2.3"},
+		{"strip prefix", VersionTransform{StripPrefix: "v"}, "v1.2.3", "1.2.3"},
+		{"separator", VersionTransform{Separator: "_"}, "1.2.3", "1_2_3"},
+		{"zero pad", VersionTransform{ZeroPad: 2}, "1.2.13", "01.02.13"},
+		{"zero pad keeps suffix", VersionTransform{ZeroPad: 2}, "1.2.3-rc.1+build.5", "01.02.03-rc.1+build.5"},
+		{"zero pad skips non-numeric fields", VersionTransform{ZeroPad: 3}, "1.x.3", "001.x.003"},
+		{"all steps", VersionTransform{StripPrefix: "release-", ZeroPad: 2, Separator: "_"}, "release-1.2.3", "01_02_03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := tt.transform.Apply(tt.version); result != tt.expected {
+				t.Errorf("Apply(%q) = %q, expected %q", tt.version, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidateVersionTransforms(t *testing.T) {
+	problems := validateVersionTransforms(VersionTransformsConfig{
+		URL:     VersionTransform{ZeroPad: -1},
+		Package: VersionTransform{ZeroPad: 4},
+		Display: VersionTransform{ZeroPad: maxZeroPad + 1},
+	})
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	if problems[0].Field != "version_transforms.url.zero_pad" || problems[1].Field != "version_transforms.display.zero_pad" {
+		t.Errorf("unexpected fields: %v", problems)
+	}
+}
+
+func TestExecuteVersionTransforms(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		_, _ = w.Write([]byte("installer"))
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	cfg := validTestConfig()
+	cfg["installers"].([]any)[0].(map[string]any)["url"] = server.URL + "/app-{{.URLVersion}}.msi"
+	cfg["output_dir"] = outputDir
+	cfg["pull_request"] = map[string]any{"enabled": false}
+	cfg["version_transforms"] = map[string]any{
+		"url":     map[string]any{"strip_prefix": "v", "separator": "_"},
+		"package": map[string]any{"strip_prefix": "v"},
+		"display": map[string]any{"strip_prefix": "v", "zero_pad": 2},
+	}
+
+	p := &WinGetPlugin{}
+	resp, err := p.Execute(context.Background(), plugin.ExecuteRequest{
+		Hook:    plugin.HookPostPublish,
+		Config:  cfg,
+		Context: plugin.ReleaseContext{Version: "v1.2.3"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected success, got: %s", resp.Message)
+	}
+	if requested != "/app-1_2_3.msi" {
+		t.Errorf("expected installer URL path '/app-1_2_3.msi', got '%s'", requested)
+	}
+
+	installer, err := os.ReadFile(filepath.Join(outputDir, "manifests", "m", "MyOrg", "MyApp", "1.2.3", "MyOrg.MyApp.installer.yaml"))
+	if err != nil {
+		t.Fatalf("expected manifests for PackageVersion 1.2.3: %v", err)
+	}
+	if !strings.Contains(string(installer), "DisplayVersion: 01.02.03") {
+		t.Errorf("expected ARP DisplayVersion 01.02.03, got:\n%s", installer)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// wingetCommand is the winget executable used for local validation.
+var wingetCommand = "winget"
+
+// WingetAvailable reports whether the winget CLI can be found in PATH.
+func WingetAvailable() bool {
+	_, err := exec.LookPath(wingetCommand)
+	return err == nil
+}
+
+// InstallCommand returns the winget command that installs a package
+// version, for release notes and install instructions.
+func InstallCommand(packageID, version string) string {
+	return fmt.Sprintf("winget install --id %s -v %s", packageID, version)
+}
+
+// ValidateWithWinget writes the manifests to a temporary directory and runs
+// `winget validate` against them. It returns the combined command output.
+func ValidateWithWinget(ctx context.Context, m *manifest.Set) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "winget-manifests-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	manifestDir, err := m.WriteTo(tmpDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	return runWinget(ctx, "validate", "--manifest", manifestDir)
+}
+
+// TestInstall installs the package from locally written manifests, verifies the
+// installation is registered, and uninstalls it again. It returns the combined
+// output of all winget invocations.
+func TestInstall(ctx context.Context, m *manifest.Set) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "winget-test-install-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	manifestDir, err := m.WriteTo(tmpDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	var log strings.Builder
+	run := func(args ...string) error {
+		output, err := runWinget(ctx, args...)
+		fmt.Fprintf(&log, "$ winget %s\n%s\n", strings.Join(args, " "), output)
+		return err
+	}
+
+	// Installing from local manifests requires the LocalManifestFiles setting
+	if err := run("settings", "--enable", "LocalManifestFiles"); err != nil {
+		return log.String(), fmt.Errorf("failed to enable local manifests: %w", err)
+	}
+
+	if err := run("install", "--manifest", manifestDir, "--silent",
+		"--accept-package-agreements", "--accept-source-agreements", "--disable-interactivity"); err != nil {
+		return log.String(), fmt.Errorf("test install failed: %w", err)
+	}
+
+	// Verify the installer registered an Apps & Features entry
+	flag, query := arpQuery(m)
+	verifyErr := run("list", "--exact", flag, query,
+		"--accept-source-agreements", "--disable-interactivity")
+
+	uninstallErr := run("uninstall", "--manifest", manifestDir, "--silent",
+		"--accept-source-agreements", "--disable-interactivity")
+
+	if verifyErr != nil {
+		return log.String(), fmt.Errorf("installed package %q not found in Apps & Features: %w", query, verifyErr)
+	}
+	if uninstallErr != nil {
+		return log.String(), fmt.Errorf("test uninstall failed: %w", uninstallErr)
+	}
+
+	return log.String(), nil
+}
+
+// arpQuery returns the `winget list` flag and value that find the Apps &
+// Features entry of an installed package: the first ProductCode of the
+// installers, else the first ARP DisplayName, else the PackageName.
+func arpQuery(m *manifest.Set) (string, string) {
+	var displayName string
+	for _, installer := range m.Installer.Installers {
+		for _, entry := range installer.AppsAndFeaturesEntries {
+			if entry.ProductCode != "" {
+				return "--product-code", entry.ProductCode
+			}
+			if displayName == "" {
+				displayName = entry.DisplayName
+			}
+		}
+		if installer.ProductCode != "" {
+			return "--product-code", installer.ProductCode
+		}
+	}
+	if displayName != "" {
+		return "--name", displayName
+	}
+	return "--name", m.Locale.PackageName
+}
+
+// runWinget runs a winget command and returns its combined output.
+func runWinget(ctx context.Context, args ...string) (string, error) {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, wingetCommand, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if err != nil {
+		return out, fmt.Errorf("winget %s failed: %w", args[0], err)
+	}
+	return out, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// fakeCommand installs a shell script as the name command for the test,
+// pointing cmd at it until the test ends.
+func fakeCommand(t *testing.T, name string, cmd *string, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skipf("fake %s script requires a POSIX shell", name)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+
+	original := *cmd
+	*cmd = path
+	t.Cleanup(func() { *cmd = original })
+}
+
+// fakeWinget installs a shell script as the winget command for the test.
+func fakeWinget(t *testing.T, script string) {
+	t.Helper()
+	fakeCommand(t, "winget", &wingetCommand, script)
+}
+
+func TestValidateWithWinget(t *testing.T) {
+	fakeWinget(t, `echo "args: $@"
+ls "$3"
+echo "Manifest validation succeeded."
+`)
+
+	output, err := ValidateWithWinget(context.Background(), validTestManifests(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(output, "args: validate --manifest") {
+		t.Errorf("unexpected arguments in output: %s", output)
+	}
+	if !strings.Contains(output, "MyOrg.MyApp.installer.yaml") {
+		t.Errorf("expected manifests in validated directory, got: %s", output)
+	}
+	if !strings.Contains(output, "Manifest validation succeeded.") {
+		t.Errorf("expected winget output, got: %s", output)
+	}
+}
+
+func TestValidateWithWingetFailure(t *testing.T) {
+	fakeWinget(t, `echo "Manifest Error: Field value is not supported."
+exit 1
+`)
+
+	output, err := ValidateWithWinget(context.Background(), validTestManifests(t))
+	if err == nil {
+		t.Fatal("expected error for failing winget")
+	}
+	if !strings.Contains(output, "Field value is not supported") {
+		t.Errorf("expected winget output on failure, got: %s", output)
+	}
+}
+
+func TestWingetAvailable(t *testing.T) {
+	original := wingetCommand
+	defer func() { wingetCommand = original }()
+
+	wingetCommand = "winget-does-not-exist"
+	if WingetAvailable() {
+		t.Error("expected missing winget to be unavailable")
+	}
+}
+
+func TestTestInstall(t *testing.T) {
+	fakeWinget(t, `echo "$1"`)
+
+	output, err := TestInstall(context.Background(), validTestManifests(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, step := range []string{"winget settings --enable LocalManifestFiles", "winget install --manifest", "winget list --exact --name My Application", "winget uninstall --manifest"} {
+		if !strings.Contains(output, step) {
+			t.Errorf("expected step '%s' in output:\n%s", step, output)
+		}
+	}
+}
+
+func TestArpQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []manifest.AppsAndFeaturesEntry
+		code    string
+		flag    string
+		query   string
+	}{
+		{"package name", nil, "", "--name", "My Application"},
+		{"display name", []manifest.AppsAndFeaturesEntry{{DisplayName: "My App 1.0"}}, "", "--name", "My App 1.0"},
+		{"installer product code", []manifest.AppsAndFeaturesEntry{{DisplayName: "My App 1.0"}}, "{ABC}", "--product-code", "{ABC}"},
+		{"entry product code", []manifest.AppsAndFeaturesEntry{{DisplayName: "My App 1.0", ProductCode: "MyApp_is1"}}, "", "--product-code", "MyApp_is1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := validTestManifests(t)
+			m.Installer.Installers[0].AppsAndFeaturesEntries = tt.entries
+			m.Installer.Installers[0].ProductCode = tt.code
+
+			flag, query := arpQuery(m)
+			if flag != tt.flag || query != tt.query {
+				t.Errorf("expected %s '%s', got %s '%s'", tt.flag, tt.query, flag, query)
+			}
+		})
+	}
+}
+
+func TestTestInstallNotRegistered(t *testing.T) {
+	fakeWinget(t, `if [ "$1" = "list" ]; then echo "No installed package found"; exit 1; fi`)
+
+	output, err := TestInstall(context.Background(), validTestManifests(t))
+	if err == nil {
+		t.Fatal("expected error when package is not registered")
+	}
+	if !strings.Contains(err.Error(), "not found in Apps & Features") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !strings.Contains(output, "winget uninstall") {
+		t.Errorf("expected uninstall to run after failed verification:\n%s", output)
+	}
+}
+
+func TestInstallCommand(t *testing.T) {
+	if got := InstallCommand("MyOrg.MyApp", "1.2.3"); got != "winget install --id MyOrg.MyApp -v 1.2.3" {
+		t.Errorf("unexpected install command %q", got)
+	}
+}
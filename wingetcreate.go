@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+const (
+	// wingetcreateModeSubmit submits the plugin's generated manifests.
+	wingetcreateModeSubmit = "submit"
+	// wingetcreateModeUpdate lets wingetcreate update the published manifests
+	// with the new installer URLs.
+	wingetcreateModeUpdate = "update"
+)
+
+// wingetcreateCommand is the wingetcreate executable.
+var wingetcreateCommand = "wingetcreate"
+
+// WingetcreateAvailable reports whether the wingetcreate CLI can be found in
+// PATH.
+func WingetcreateAvailable() bool {
+	_, err := exec.LookPath(wingetcreateCommand)
+	return err == nil
+}
+
+// pullRequestURLPattern matches winget-pkgs pull request URLs in CLI output.
+var pullRequestURLPattern = regexp.MustCompile(`https://github\.com/[^/\s]+/winget-pkgs/pull/\d+`)
+
+// WingetcreateSubmit writes the manifests to a temporary directory and
+// submits them with `wingetcreate submit`. It returns the pull request URL and
+// the command output.
+func WingetcreateSubmit(ctx context.Context, m *manifest.Set, token, prTitle string) (string, string, error) {
+	tmpDir, err := os.MkdirTemp("", "wingetcreate-")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	manifestDir, err := m.WriteTo(tmpDir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	return runWingetcreate(ctx, token, "submit", "--prtitle", prTitle, manifestDir)
+}
+
+// ValidateWithWingetcreate writes the manifests to a temporary directory and
+// runs `wingetcreate validate` against them, for agents without winget. It
+// returns the combined command output.
+func ValidateWithWingetcreate(ctx context.Context, m *manifest.Set) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "wingetcreate-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	manifestDir, err := m.WriteTo(tmpDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to write manifests: %w", err)
+	}
+
+	return execWingetcreate(ctx, nil, "validate", manifestDir)
+}
+
+// WingetcreateUpdate updates the published manifests of a package with new
+// installer URLs and submits them with `wingetcreate update --submit`. It
+// returns the pull request URL and the command output.
+func WingetcreateUpdate(ctx context.Context, packageID, version string, installers []manifest.Installer, token, prTitle string) (string, string, error) {
+	args := []string{"update", packageID, "--version", version, "--submit", "--prtitle", prTitle, "--urls"}
+	for _, installer := range installers {
+		// Override the detected architecture with the configured one
+		args = append(args, installer.InstallerURL+"|"+installer.Architecture)
+	}
+
+	return runWingetcreate(ctx, token, args...)
+}
+
+// runWingetcreate runs a wingetcreate command and returns the pull request URL
+// found in its output and the combined output. The token is passed through
+// the environment so it does not appear in process listings.
+func runWingetcreate(ctx context.Context, token string, args ...string) (string, string, error) {
+	out, err := execWingetcreate(ctx, []string{"WINGET_CREATE_GITHUB_TOKEN=" + token}, args...)
+	if err != nil {
+		return "", out, err
+	}
+
+	prURL := pullRequestURLPattern.FindString(out)
+	if prURL == "" {
+		return "", out, fmt.Errorf("wingetcreate %s did not report a pull request URL", args[0])
+	}
+	return prURL, out, nil
+}
+
+// execWingetcreate runs a wingetcreate command with env added to the
+// environment and returns its combined output.
+func execWingetcreate(ctx context.Context, env []string, args ...string) (string, error) {
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, wingetcreateCommand, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	if err != nil {
+		return out, fmt.Errorf("wingetcreate %s failed: %w", args[0], err)
+	}
+	return out, nil
+}
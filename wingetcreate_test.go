@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/relicta-tech/plugin-winget/manifest"
+)
+
+// fakeWingetcreate installs a shell script as the wingetcreate command for the test.
+func fakeWingetcreate(t *testing.T, script string) {
+	t.Helper()
+	fakeCommand(t, "wingetcreate", &wingetcreateCommand, script)
+}
+
+func TestValidateWithWingetcreate(t *testing.T) {
+	fakeWingetcreate(t, `echo "args: $@"
+ls "$2"
+echo "Manifest validation succeeded: True"
+`)
+
+	output, err := ValidateWithWingetcreate(context.Background(), validTestManifests(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+	if !strings.Contains(output, "args: validate ") {
+		t.Errorf("unexpected arguments in output: %s", output)
+	}
+	if !strings.Contains(output, "MyOrg.MyApp.installer.yaml") {
+		t.Errorf("expected manifests in validated directory, got: %s", output)
+	}
+}
+
+func TestWingetcreateSubmit(t *testing.T) {
+	fakeWingetcreate(t, `echo "args: $@"
+echo "token: $WINGET_CREATE_GITHUB_TOKEN"
+ls "$4"
+echo "Pull request can be found here: https://github.com/microsoft/winget-pkgs/pull/12345"
+`)
+
+	prURL, output, err := WingetcreateSubmit(context.Background(), validTestManifests(t), "secret", "New version")
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, output)
+	}
+
+	if prURL != "https://github.com/microsoft/winget-pkgs/pull/12345" {
+		t.Errorf("unexpected PR URL '%s'", prURL)
+	}
+	if !strings.Contains(output, "args: submit --prtitle New version") {
+		t.Errorf("unexpected arguments in output: %s", output)
+	}
+	if !strings.Contains(output, "token: secret") {
+		t.Errorf("expected token in environment, got: %s", output)
+	}
+	if !strings.Contains(output, "MyOrg.MyApp.installer.yaml") {
+		t.Errorf("expected manifests in submitted directory, got: %s", output)
+	}
+}
+
+func TestWingetcreateUpdate(t *testing.T) {
+	fakeWingetcreate(t, `echo "args: $@"
+echo "https://github.com/microsoft/winget-pkgs/pull/42"
+`)
+
+	installers := []manifest.Installer{
+		{Architecture: "x64", InstallerURL: "https://example.com/app-x64.msi"},
+		{Architecture: "arm64", InstallerURL: "https://example.com/app-arm64.msi"},
+	}
+	_, output, err := WingetcreateUpdate(context.Background(), "MyOrg.MyApp", "1.2.3", installers, "secret", "title")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "args: update MyOrg.MyApp --version 1.2.3 --submit --prtitle title --urls https://example.com/app-x64.msi|x64 https://example.com/app-arm64.msi|arm64"
+	if !strings.Contains(output, expected) {
+		t.Errorf("expected '%s' in output, got: %s", expected, output)
+	}
+}
+
+func TestWingetcreateFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		message string
+	}{
+		{"exit code", "echo 'Token is invalid'\nexit 1\n", "wingetcreate submit failed"},
+		{"no PR URL", "echo 'Submitted'\n", "did not report a pull request URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeWingetcreate(t, tt.script)
+
+			_, _, err := WingetcreateSubmit(context.Background(), validTestManifests(t), "secret", "title")
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error containing '%s', got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestSubmitWingetcreateOutlastsGitHubTimeout(t *testing.T) {
+	fakeWingetcreate(t, `sleep 0.2
+echo "https://github.com/microsoft/winget-pkgs/pull/12345"
+`)
+
+	cfg := defaultConfig()
+	cfg.Wingetcreate.Mode = wingetcreateModeUpdate
+	cfg.Timeouts.GitHub = 50 * time.Millisecond
+	resp, err := (&WinGetPlugin{}).submitWingetcreate(context.Background(), cfg, validTestManifests(t), map[string]any{}, slog.Default())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Errorf("expected wingetcreate to run under timeouts.cli, got: %s", resp.Message)
+	}
+}